@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	kafkapkg "github.com/tanint/go-eda/internal/kafka"
+)
+
+// replayFilter selects the dead-lettered messages to replay by the headers
+// the consumer added when it dead-lettered them. Zero fields match everything.
+type replayFilter struct {
+	ErrorContains string    // case-insensitive substring of the handler error
+	Since         time.Time // dead-lettered at or after
+	Until         time.Time // dead-lettered before
+}
+
+// parseFilter validates the filter flags; since and until are RFC3339 times
+func parseFilter(errorContains, since, until string) (replayFilter, error) {
+	filter := replayFilter{ErrorContains: errorContains}
+
+	var err error
+	if since != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return replayFilter{}, fmt.Errorf("invalid -since %q (expected RFC3339): %w", since, err)
+		}
+	}
+	if until != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return replayFilter{}, fmt.Errorf("invalid -until %q (expected RFC3339): %w", until, err)
+		}
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Until.After(filter.Since) {
+		return replayFilter{}, fmt.Errorf("-until %s must be after -since %s", until, since)
+	}
+	return filter, nil
+}
+
+// matches reports whether a dead-lettered message with these headers is
+// selected. With a time range, messages without a readable dead-letter
+// timestamp never match.
+func (f replayFilter) matches(headers []kafka.Header) bool {
+	if f.ErrorContains != "" {
+		cause, _ := headerValue(headers, kafkapkg.HeaderDLQError)
+		if !strings.Contains(strings.ToLower(cause), strings.ToLower(f.ErrorContains)) {
+			return false
+		}
+	}
+
+	if f.Since.IsZero() && f.Until.IsZero() {
+		return true
+	}
+	value, ok := headerValue(headers, kafkapkg.HeaderDLQTimestamp)
+	if !ok {
+		return false
+	}
+	at, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return false
+	}
+	if !f.Since.IsZero() && at.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !at.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// describe renders the filter for the run summary
+func (f replayFilter) describe() string {
+	var parts []string
+	if f.ErrorContains != "" {
+		parts = append(parts, fmt.Sprintf("error contains %q", f.ErrorContains))
+	}
+	if !f.Since.IsZero() {
+		parts = append(parts, "since "+f.Since.Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		parts = append(parts, "until "+f.Until.Format(time.RFC3339))
+	}
+	if len(parts) == 0 {
+		return "all messages"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// headerValue returns the value of the last header with the key
+func headerValue(headers []kafka.Header, key string) (string, bool) {
+	for i := len(headers) - 1; i >= 0; i-- {
+		if headers[i].Key == key {
+			return string(headers[i].Value), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	kafkapkg "github.com/tanint/go-eda/internal/kafka"
+)
+
+// dlqHeaders returns the headers the consumer stamps on a dead letter
+func dlqHeaders(cause, at string) []kafka.Header {
+	var headers []kafka.Header
+	if cause != "" {
+		headers = append(headers, kafka.Header{Key: kafkapkg.HeaderDLQError, Value: []byte(cause)})
+	}
+	if at != "" {
+		headers = append(headers, kafka.Header{Key: kafkapkg.HeaderDLQTimestamp, Value: []byte(at)})
+	}
+	return headers
+}
+
+func TestReplayFilterMatches(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(time.Hour)
+	tests := []struct {
+		name    string
+		filter  replayFilter
+		headers []kafka.Header
+		want    bool
+	}{
+		{name: "no filter", headers: nil, want: true},
+		{name: "error substring", filter: replayFilter{ErrorContains: "timeout"}, headers: dlqHeaders("inventory: request timeout", ""), want: true},
+		{name: "error substring ignores case", filter: replayFilter{ErrorContains: "TimeOut"}, headers: dlqHeaders("request timeout", ""), want: true},
+		{name: "other error", filter: replayFilter{ErrorContains: "timeout"}, headers: dlqHeaders("invalid payload", ""), want: false},
+		{name: "no error header", filter: replayFilter{ErrorContains: "timeout"}, headers: nil, want: false},
+		{name: "last error header wins", filter: replayFilter{ErrorContains: "timeout"}, headers: append(dlqHeaders("timeout", ""), dlqHeaders("invalid payload", "")...), want: false},
+		{name: "at since", filter: replayFilter{Since: since}, headers: dlqHeaders("", "2026-01-01T00:00:00Z"), want: true},
+		{name: "before since", filter: replayFilter{Since: since}, headers: dlqHeaders("", "2025-12-31T23:59:59.999Z"), want: false},
+		{name: "inside range", filter: replayFilter{Since: since, Until: until}, headers: dlqHeaders("", "2026-01-01T00:30:00.5Z"), want: true},
+		{name: "at until", filter: replayFilter{Since: since, Until: until}, headers: dlqHeaders("", "2026-01-01T01:00:00Z"), want: false},
+		{name: "other time zone", filter: replayFilter{Until: until}, headers: dlqHeaders("", "2026-01-01T01:30:00+01:00"), want: true},
+		{name: "range without timestamp", filter: replayFilter{Since: since}, headers: dlqHeaders("timeout", ""), want: false},
+		{name: "unreadable timestamp", filter: replayFilter{Since: since}, headers: dlqHeaders("", "yesterday"), want: false},
+		{name: "error and range", filter: replayFilter{ErrorContains: "timeout", Since: since, Until: until}, headers: dlqHeaders("timeout", "2026-01-01T00:10:00Z"), want: true},
+		{name: "error outside range", filter: replayFilter{ErrorContains: "timeout", Since: since, Until: until}, headers: dlqHeaders("timeout", "2026-01-02T00:00:00Z"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(tt.headers); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name                        string
+		errorContains, since, until string
+		want                        string
+		wantErr                     bool
+	}{
+		{name: "empty", want: "all messages"},
+		{name: "all flags", errorContains: "timeout", since: "2026-01-01T00:00:00Z", until: "2026-01-02T00:00:00Z",
+			want: `error contains "timeout", since 2026-01-01T00:00:00Z, until 2026-01-02T00:00:00Z`},
+		{name: "invalid since", since: "2026-01-01", wantErr: true},
+		{name: "invalid until", until: "tomorrow", wantErr: true},
+		{name: "until before since", since: "2026-01-02T00:00:00Z", until: "2026-01-01T00:00:00Z", wantErr: true},
+		{name: "empty range", since: "2026-01-01T00:00:00Z", until: "2026-01-01T00:00:00Z", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := parseFilter(tt.errorContains, tt.since, tt.until)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFilter() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && filter.describe() != tt.want {
+				t.Errorf("describe() = %q, want %q", filter.describe(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	kafkapkg "github.com/tanint/go-eda/internal/kafka"
+)
+
+// queryTimeoutMs bounds each metadata/watermark lookup against the brokers
+const queryTimeoutMs = 10000
+
+// dlqHeaderPrefix marks the headers added when a message was dead-lettered
+const dlqHeaderPrefix = "dlq-"
+
+func main() {
+	configPath := flag.String("config", "", "Path to the config file (defaults to ./config.yaml or ./configs/config.yaml)")
+	topic := flag.String("topic", "", "Dead-letter topic to replay from (required)")
+	to := flag.String("to", "", "Topic to replay to (defaults to each message's original topic)")
+	errorContains := flag.String("error-contains", "", "Only replay messages whose error contains this text (case-insensitive)")
+	since := flag.String("since", "", "Only replay messages dead-lettered at or after this RFC3339 time")
+	until := flag.String("until", "", "Only replay messages dead-lettered before this RFC3339 time")
+	dryRun := flag.Bool("dry-run", false, "List the matching messages without replaying them")
+	yes := flag.Bool("yes", false, "Replay without asking for confirmation")
+	flag.Parse()
+
+	if *topic == "" {
+		fmt.Println("-topic is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	filter, err := parseFilter(*errorContains, *since, *until)
+	if err != nil {
+		fmt.Printf("Invalid filter: %v\n", err)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Printf("Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := run(cfg.Kafka, *topic, *to, filter, *dryRun, *yes); err != nil {
+		fmt.Printf("Replay failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(cfg config.KafkaConfig, topic, to string, filter replayFilter, dryRun, yes bool) error {
+	matched, err := readMatching(cfg, topic, filter)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Topic %q, %s: %d matching messages\n", topic, filter.describe(), len(matched))
+	for _, msg := range matched {
+		cause, _ := headerValue(msg.Headers, kafkapkg.HeaderDLQError)
+		at, _ := headerValue(msg.Headers, kafkapkg.HeaderDLQTimestamp)
+		fmt.Printf("  partition %d offset %s -> %s: %s (%s)\n",
+			msg.TopicPartition.Partition, msg.TopicPartition.Offset, replayTopic(msg, to), cause, at)
+	}
+
+	if len(matched) == 0 {
+		return nil
+	}
+	if dryRun {
+		fmt.Println("Dry run: nothing was replayed")
+		return nil
+	}
+
+	if !yes && !confirm("Replay these messages?") {
+		fmt.Println("Aborted: nothing was replayed")
+		return nil
+	}
+
+	producer, err := kafkapkg.NewProducer(cfg, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create producer: %w", err)
+	}
+	defer producer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	replayed := 0
+	for _, msg := range matched {
+		target := replayTopic(msg, to)
+		if target == "" {
+			fmt.Printf("  skipping partition %d offset %s: no original topic, use -to\n",
+				msg.TopicPartition.Partition, msg.TopicPartition.Offset)
+			continue
+		}
+		if err := producer.PublishWithHeaders(ctx, target, msg.Key, msg.Value, originalHeaders(msg.Headers)); err != nil {
+			return fmt.Errorf("replayed %d messages, then failed on partition %d offset %s: %w",
+				replayed, msg.TopicPartition.Partition, msg.TopicPartition.Offset, err)
+		}
+		replayed++
+	}
+
+	fmt.Printf("Replayed %d messages\n", replayed)
+	return nil
+}
+
+// readMatching reads the dead-letter topic from the beginning up to its
+// current end and returns the messages the filter selects. It assigns the
+// partitions itself, so no group joins and no offsets are committed.
+func readMatching(cfg config.KafkaConfig, topic string, filter replayFilter) ([]*kafka.Message, error) {
+	configMap := &kafka.ConfigMap{
+		"bootstrap.servers":  strings.Join(cfg.Brokers, ","),
+		"group.id":           "dlq-replay",
+		"enable.auto.commit": false,
+	}
+
+	kafkapkg.ApplySecurityConfig(configMap, cfg)
+
+	consumer, err := kafka.NewConsumer(configMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	metadata, err := consumer.GetMetadata(&topic, false, queryTimeoutMs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch metadata: %w", err)
+	}
+	topicMetadata, ok := metadata.Topics[topic]
+	if !ok || topicMetadata.Error.Code() != kafka.ErrNoError {
+		return nil, fmt.Errorf("topic %q not found", topic)
+	}
+
+	// Read each partition up to the end it has now
+	end := make(map[int32]int64)
+	var assignment []kafka.TopicPartition
+	for _, p := range topicMetadata.Partitions {
+		low, high, err := consumer.QueryWatermarkOffsets(topic, p.ID, queryTimeoutMs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query watermarks for %s[%d]: %w", topic, p.ID, err)
+		}
+		if high <= low {
+			continue
+		}
+		end[p.ID] = high
+		assignment = append(assignment, kafka.TopicPartition{Topic: &topic, Partition: p.ID, Offset: kafka.Offset(low)})
+	}
+	if len(assignment) == 0 {
+		return nil, nil
+	}
+	if err := consumer.Assign(assignment); err != nil {
+		return nil, fmt.Errorf("failed to assign partitions: %w", err)
+	}
+
+	var matched []*kafka.Message
+	for len(end) > 0 {
+		msg, err := consumer.ReadMessage(time.Duration(queryTimeoutMs) * time.Millisecond)
+		if err != nil {
+			// The end offset of a transactional partition may be a commit
+			// marker, which is never delivered
+			if err.(kafka.Error).Code() == kafka.ErrTimedOut {
+				break
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", topic, err)
+		}
+
+		partition := msg.TopicPartition.Partition
+		if high, ok := end[partition]; ok && int64(msg.TopicPartition.Offset) >= high-1 {
+			delete(end, partition)
+		}
+		if filter.matches(msg.Headers) {
+			matched = append(matched, msg)
+		}
+	}
+	return matched, nil
+}
+
+// replayTopic is where the message is replayed to: the -to topic, or the
+// topic it was dead-lettered from
+func replayTopic(msg *kafka.Message, to string) string {
+	if to != "" {
+		return to
+	}
+	topic, _ := headerValue(msg.Headers, kafkapkg.HeaderDLQOriginalTopic)
+	return topic
+}
+
+// originalHeaders drops the headers added when the message was
+// dead-lettered; a replayed message that fails again gets fresh ones
+func originalHeaders(headers []kafka.Header) []kafka.Header {
+	original := make([]kafka.Header, 0, len(headers))
+	for _, h := range headers {
+		if !strings.HasPrefix(h.Key, dlqHeaderPrefix) {
+			original = append(original, h)
+		}
+	}
+	return original
+}
+
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N]: ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -2,55 +2,101 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/tanint/go-eda/internal/config"
 	"github.com/tanint/go-eda/internal/handlers"
+	"github.com/tanint/go-eda/internal/heartbeat"
 	"github.com/tanint/go-eda/internal/kafka"
 	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/metrics"
+	"github.com/tanint/go-eda/internal/version"
+	"github.com/tanint/go-eda/pkg/events"
 	"go.uber.org/zap"
+
+	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
 	// Load configuration
 	cfg, err := config.Load("")
+	if err == nil {
+		cfg, err = cfg.ForService("inventory")
+	}
 	if err != nil {
 		fmt.Printf("Failed to load config: %v\n", err)
 		os.Exit(1)
 	}
 
+	version.Set(cfg.Version)
+
 	// Initialize logger
 	if err := logger.Initialize(cfg.Logger); err != nil {
 		fmt.Printf("Failed to initialize logger: %v\n", err)
 		os.Exit(1)
 	}
 	defer logger.Sync()
+	logger.FollowLevel(cfg)
+
+	if err := metrics.Initialize(cfg.Metrics); err != nil {
+		logger.Fatal("Failed to initialize metrics", zap.Error(err))
+	}
+	defer metrics.Close()
 
 	logger.Info("Starting Inventory Service...")
 
-	// Initialize Kafka producer (for publishing events)
-	producer, err := kafka.NewProducer(cfg.Kafka)
+	// Initialize Kafka producer (for publishing events). A transactional
+	// producer publishes only inside transactions, so heartbeats, quarantined
+	// and dead-lettered messages go through a plain one.
+	plainCfg := cfg.Kafka
+	plainCfg.Producer.TransactionalID = ""
+	producer, err := kafka.NewProducer(plainCfg, logger.Global())
 	if err != nil {
 		logger.Fatal("Failed to create Kafka producer", zap.Error(err))
 	}
-	defer producer.Close()
+	producers := []*kafka.Producer{producer}
 
 	// Initialize Kafka consumer
-	consumer, err := kafka.NewConsumer(cfg.Kafka, "inventory-service-group")
+	consumer, err := kafka.NewConsumer(cfg.Kafka, cfg.Kafka.GroupID, logger.Global())
 	if err != nil {
 		logger.Fatal("Failed to create Kafka consumer", zap.Error(err))
 	}
-	defer consumer.Close()
+	consumer.SetQuarantine(producer, cfg.Kafka.Topics["quarantine"])
+	consumer.SetDeadLetterQueue(producer)
 
 	// Register message handlers
+	inventory := handlers.NewInventory()
+	chunks, chunkDB := newChunkReassembler(cfg.Orders)
+	if chunkDB != nil {
+		defer chunkDB.Close()
+	}
 	orderCreatedTopic := cfg.Kafka.Topics["order_created"]
-	consumer.RegisterHandler(orderCreatedTopic, handlers.HandleOrderCreated(context.Background(), producer, cfg.Kafka.Topics))
+	if cfg.Kafka.Producer.TransactionalID != "" {
+		// Consume-transform-produce atomically: the published events and the
+		// order's offset commit together or not at all
+		txProducer, err := kafka.NewProducer(cfg.Kafka, logger.Global())
+		if err != nil {
+			logger.Fatal("Failed to create transactional Kafka producer", zap.Error(err))
+		}
+		producers = append(producers, txProducer)
+		consumer.RegisterHandler(orderCreatedTopic, kafka.TransactionalHandler(txProducer, consumer,
+			handlers.HandleOrderCreated(context.Background(), txProducer, cfg.Kafka.Topics, cfg.Orders.PartitionBy, inventory, chunks)))
+	} else {
+		consumer.RegisterHandler(orderCreatedTopic, handlers.HandleOrderCreated(context.Background(), producer, cfg.Kafka.Topics, cfg.Orders.PartitionBy, inventory, chunks))
+	}
+
+	// Release the reservations of cancelled and failed orders
+	statusChangedTopic := cfg.Kafka.Topics["order_status_changed"]
+	kafka.RegisterHandlerT(consumer, statusChangedTopic, events.EventTypeOrderStatusChanged,
+		handlers.HandleOrderStatusChanged(producer, cfg.Kafka.Topics, cfg.Orders.PartitionBy, inventory))
 
 	// Subscribe to topics
-	if err := consumer.Subscribe([]string{orderCreatedTopic}); err != nil {
+	if err := consumer.Subscribe([]string{orderCreatedTopic, statusChangedTopic}); err != nil {
 		logger.Fatal("Failed to subscribe to topics", zap.Error(err))
 	}
 
@@ -58,10 +104,29 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	errChan := make(chan error, 1)
+	consumerDone := make(chan error, 1)
+	go func() {
+		consumerDone <- consumer.Start(ctx)
+	}()
+
+	var adminServer *http.Server
+	if cfg.Admin.Enabled {
+		adminServer = handlers.NewAdminServer(cfg.Admin, consumer, logger.Global())
+		go func() {
+			logger.Info("Admin server starting",
+				zap.String("address", adminServer.Addr),
+			)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("Admin server failed", zap.Error(err))
+			}
+		}()
+	}
+
+	heartbeatDone := make(chan struct{})
 	go func() {
-		if err := consumer.Start(ctx); err != nil && err != context.Canceled {
-			errChan <- err
+		defer close(heartbeatDone)
+		if cfg.Heartbeat.Interval > 0 {
+			heartbeat.NewEmitter(producer, cfg.Kafka.Topics["service_heartbeat"], "inventory-service", cfg.Heartbeat.Interval).Run(ctx)
 		}
 	}()
 
@@ -75,10 +140,57 @@ func main() {
 	case <-quit:
 		logger.Info("Shutting down Inventory Service...")
 		cancel()
-	case err := <-errChan:
-		logger.Error("Consumer error", zap.Error(err))
+		<-consumerDone
+	case err := <-consumerDone:
+		if err != nil && err != context.Canceled {
+			logger.Error("Consumer error", zap.Error(err))
+		}
 		cancel()
 	}
 
+	shutdown(adminServer, consumer, heartbeatDone, producers...)
 	logger.Info("Inventory Service stopped")
 }
+
+// newChunkReassembler keeps the chunks of large orders in the configured
+// SQLite database, or in memory when none is configured
+func newChunkReassembler(cfg config.OrdersConfig) (*handlers.OrderChunkReassembler, *sql.DB) {
+	if cfg.ChunkDatabase == "" {
+		return handlers.NewOrderChunkReassembler(handlers.NewMemoryChunkStore(), cfg.ChunkTTL), nil
+	}
+
+	db, err := sql.Open("sqlite3", cfg.ChunkDatabase)
+	if err != nil {
+		logger.Fatal("Failed to open order chunk database", zap.Error(err))
+	}
+	db.SetMaxOpenConns(1)
+	store, err := handlers.NewSQLChunkStore(context.Background(), db)
+	if err != nil {
+		logger.Fatal("Failed to initialize order chunk store", zap.Error(err))
+	}
+	logger.Info("Order chunks kept in database", zap.String("database", cfg.ChunkDatabase))
+	return handlers.NewOrderChunkReassembler(store, cfg.ChunkTTL), db
+}
+
+// shutdown releases resources in dependency order once consumption has
+// stopped: the consume loop has returned, so in-flight handlers (which may
+// publish) have finished and offsets are committed. The consumer is closed
+// next, and the producers last so everything published is flushed.
+func shutdown(adminServer *http.Server, consumer *kafka.Consumer, heartbeatDone <-chan struct{}, producers ...*kafka.Producer) {
+	if adminServer != nil {
+		if err := adminServer.Close(); err != nil {
+			logger.Error("Error closing admin server", zap.Error(err))
+		}
+	}
+
+	if err := consumer.Close(); err != nil {
+		logger.Error("Error closing consumer", zap.Error(err))
+	}
+
+	<-heartbeatDone
+	for _, producer := range producers {
+		if err := producer.Close(); err != nil {
+			logger.Error("Error closing producer", zap.Error(err))
+		}
+	}
+}
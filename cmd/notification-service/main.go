@@ -2,16 +2,18 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
-	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/handlers"
 	kafkapkg "github.com/tanint/go-eda/internal/kafka"
 	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/metrics"
+	"github.com/tanint/go-eda/internal/version"
 	"github.com/tanint/go-eda/pkg/events"
 	"go.uber.org/zap"
 )
@@ -19,22 +21,33 @@ import (
 func main() {
 	// Load configuration
 	cfg, err := config.Load("")
+	if err == nil {
+		cfg, err = cfg.ForService("notification")
+	}
 	if err != nil {
 		fmt.Printf("Failed to load config: %v\n", err)
 		os.Exit(1)
 	}
 
+	version.Set(cfg.Version)
+
 	// Initialize logger
 	if err := logger.Initialize(cfg.Logger); err != nil {
 		fmt.Printf("Failed to initialize logger: %v\n", err)
 		os.Exit(1)
 	}
 	defer logger.Sync()
+	logger.FollowLevel(cfg)
+
+	if err := metrics.Initialize(cfg.Metrics); err != nil {
+		logger.Fatal("Failed to initialize metrics", zap.Error(err))
+	}
+	defer metrics.Close()
 
 	logger.Info("Starting Notification Service...")
 
 	// Initialize Kafka consumer
-	consumer, err := kafkapkg.NewConsumer(cfg.Kafka, "notification-service-group")
+	consumer, err := kafkapkg.NewConsumer(cfg.Kafka, cfg.Kafka.GroupID, logger.Global())
 	if err != nil {
 		logger.Fatal("Failed to create Kafka consumer", zap.Error(err))
 	}
@@ -42,10 +55,12 @@ func main() {
 
 	// Register message handlers
 	inventoryReservedTopic := cfg.Kafka.Topics["inventory_reserved"]
-	consumer.RegisterHandler(inventoryReservedTopic, handleInventoryReserved)
+	orderRejectedTopic := cfg.Kafka.Topics["order_rejected"]
+	kafkapkg.RegisterHandlerT(consumer, inventoryReservedTopic, events.EventTypeInventoryReserved, handleInventoryReserved)
+	kafkapkg.RegisterHandlerT(consumer, orderRejectedTopic, events.EventTypeOrderRejected, handleOrderRejected)
 
 	// Subscribe to topics
-	if err := consumer.Subscribe([]string{inventoryReservedTopic}); err != nil {
+	if err := consumer.Subscribe([]string{inventoryReservedTopic, orderRejectedTopic}); err != nil {
 		logger.Fatal("Failed to subscribe to topics", zap.Error(err))
 	}
 
@@ -60,6 +75,19 @@ func main() {
 		}
 	}()
 
+	if cfg.Admin.Enabled {
+		adminServer := handlers.NewAdminServer(cfg.Admin, consumer, logger.Global())
+		go func() {
+			logger.Info("Admin server starting",
+				zap.String("address", adminServer.Addr),
+			)
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Error("Admin server failed", zap.Error(err))
+			}
+		}()
+		defer adminServer.Close()
+	}
+
 	logger.Info("Notification Service is running and consuming messages...")
 
 	// Wait for interrupt signal for graceful shutdown
@@ -78,47 +106,46 @@ func main() {
 	logger.Info("Notification Service stopped")
 }
 
-func handleInventoryReserved(ctx context.Context, msg *kafka.Message) error {
-	var event events.Event
-	if err := json.Unmarshal(msg.Value, &event); err != nil {
-		logger.Error("Failed to unmarshal event",
-			zap.Error(err),
-		)
-		return err
-	}
-
-	// Parse the event data
-	eventDataJSON, err := json.Marshal(event.Data)
-	if err != nil {
-		logger.Error("Failed to marshal event data",
-			zap.Error(err),
-		)
-		return err
-	}
-
-	var inventoryReserved events.InventoryReservedEvent
-	if err := json.Unmarshal(eventDataJSON, &inventoryReserved); err != nil {
-		logger.Error("Failed to unmarshal inventory reserved event",
-			zap.Error(err),
-		)
-		return err
-	}
-
-	logger.Info("Processing inventory reserved event",
+func handleInventoryReserved(ctx context.Context, event *events.Event, inventoryReserved events.InventoryReservedEvent) error {
+	logger.FromContext(ctx).Info("Processing inventory reserved event",
 		zap.String("order_id", inventoryReserved.OrderID),
 		zap.Int("items_count", len(inventoryReserved.Items)),
 	)
 
 	// Send notification (mock implementation)
-	sendNotification(inventoryReserved.OrderID)
+	sendNotification(ctx, inventoryReserved.OrderID)
+
+	return nil
+}
+
+func handleOrderRejected(ctx context.Context, event *events.Event, orderRejected events.OrderRejectedEvent) error {
+	logger.FromContext(ctx).Info("Processing order rejected event",
+		zap.String("order_id", orderRejected.OrderID),
+		zap.String("customer_id", orderRejected.CustomerID),
+		zap.String("reason", orderRejected.Reason),
+	)
+
+	// Inform the customer (mock implementation)
+	sendRejectionNotification(ctx, orderRejected)
 
 	return nil
 }
 
-func sendNotification(orderID string) {
+func sendRejectionNotification(ctx context.Context, rejected events.OrderRejectedEvent) {
+	// This is a mock implementation
+	// In production, you would integrate with email/SMS/push notification services
+	logger.FromContext(ctx).Info("Notification sent",
+		zap.String("order_id", rejected.OrderID),
+		zap.String("customer_id", rejected.CustomerID),
+		zap.String("type", "order_rejected"),
+		zap.String("message", "Your order could not be accepted: "+rejected.Reason),
+	)
+}
+
+func sendNotification(ctx context.Context, orderID string) {
 	// This is a mock implementation
 	// In production, you would integrate with email/SMS/push notification services
-	logger.Info("Notification sent",
+	logger.FromContext(ctx).Info("Notification sent",
 		zap.String("order_id", orderID),
 		zap.String("type", "order_confirmed"),
 		zap.String("message", "Your order has been confirmed and inventory has been reserved"),
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	kafkapkg "github.com/tanint/go-eda/internal/kafka"
+)
+
+func main() {
+	configPath := flag.String("config", "", "Path to the config file (defaults to ./config.yaml or ./configs/config.yaml)")
+	group := flag.String("group", "", "Consumer group whose offsets are reset (required)")
+	topic := flag.String("topic", "", "Topic to reset offsets for (required)")
+	to := flag.String("to", targetLatest, "Reset target: earliest, latest, timestamp or offset")
+	timestamp := flag.String("timestamp", "", "RFC3339 time to reset to when -to=timestamp")
+	offset := flag.String("offset", "", "Offset to reset every partition to when -to=offset")
+	dryRun := flag.Bool("dry-run", false, "Print the resolved offsets without applying them")
+	yes := flag.Bool("yes", false, "Apply without asking for confirmation")
+	flag.Parse()
+
+	if *group == "" || *topic == "" {
+		fmt.Println("Both -group and -topic are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	target, err := parseTarget(*to, *timestamp, *offset)
+	if err != nil {
+		fmt.Printf("Invalid reset target: %v\n", err)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Printf("Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := run(cfg.Kafka, *group, *topic, target, *dryRun, *yes); err != nil {
+		fmt.Printf("Offset reset failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(cfg config.KafkaConfig, group, topic string, target resetTarget, dryRun, yes bool) error {
+	configMap := &kafka.ConfigMap{
+		"bootstrap.servers":  strings.Join(cfg.Brokers, ","),
+		"group.id":           group,
+		"enable.auto.commit": false,
+	}
+
+	kafkapkg.ApplySecurityConfig(configMap, cfg)
+
+	// The consumer never subscribes, so it does not join the group; it is
+	// only used for metadata, watermark and committed-offset lookups
+	consumer, err := kafka.NewConsumer(configMap)
+	if err != nil {
+		return fmt.Errorf("failed to create consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	partitions, err := topicPartitions(consumer, topic)
+	if err != nil {
+		return err
+	}
+
+	resolved, err := resolveOffsets(consumer, topic, partitions, target)
+	if err != nil {
+		return err
+	}
+
+	committed, err := consumer.Committed(resolved, queryTimeoutMs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch committed offsets: %w", err)
+	}
+
+	fmt.Printf("Group %q, topic %q, target %s\n", group, topic, describeTarget(target))
+	for i, tp := range resolved {
+		fmt.Printf("  partition %d: %s -> %s\n", tp.Partition, committed[i].Offset, tp.Offset)
+	}
+
+	if dryRun {
+		fmt.Println("Dry run: no offsets were changed")
+		return nil
+	}
+
+	if !yes && !confirm("Apply these offsets?") {
+		fmt.Println("Aborted: no offsets were changed")
+		return nil
+	}
+
+	admin, err := kafka.NewAdminClientFromConsumer(consumer)
+	if err != nil {
+		return fmt.Errorf("failed to create admin client: %w", err)
+	}
+	defer admin.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := admin.AlterConsumerGroupOffsets(ctx, []kafka.ConsumerGroupTopicPartitions{{
+		Group:      group,
+		Partitions: resolved,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to alter offsets (the group must have no active members): %w", err)
+	}
+
+	for _, gp := range result.ConsumerGroupsTopicPartitions {
+		for _, tp := range gp.Partitions {
+			if tp.Error != nil {
+				return fmt.Errorf("failed to alter offset for partition %d: %w", tp.Partition, tp.Error)
+			}
+		}
+	}
+
+	fmt.Println("Offsets reset successfully")
+	return nil
+}
+
+// topicPartitions returns the partition IDs of the topic
+func topicPartitions(consumer *kafka.Consumer, topic string) ([]int32, error) {
+	metadata, err := consumer.GetMetadata(&topic, false, queryTimeoutMs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch metadata: %w", err)
+	}
+
+	topicMetadata, ok := metadata.Topics[topic]
+	if !ok || topicMetadata.Error.Code() != kafka.ErrNoError {
+		return nil, fmt.Errorf("topic %q not found", topic)
+	}
+
+	partitions := make([]int32, 0, len(topicMetadata.Partitions))
+	for _, p := range topicMetadata.Partitions {
+		partitions = append(partitions, p.ID)
+	}
+	return partitions, nil
+}
+
+func describeTarget(target resetTarget) string {
+	switch target.Mode {
+	case targetTimestamp:
+		return fmt.Sprintf("%s (%s)", target.Mode, target.Timestamp.Format(time.RFC3339))
+	case targetOffset:
+		return fmt.Sprintf("%s (%d)", target.Mode, target.Offset)
+	}
+	return target.Mode
+}
+
+func confirm(prompt string) bool {
+	fmt.Printf("%s [y/N]: ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
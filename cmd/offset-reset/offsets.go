@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Reset targets supported by the tool
+const (
+	targetEarliest  = "earliest"
+	targetLatest    = "latest"
+	targetTimestamp = "timestamp"
+	targetOffset    = "offset"
+)
+
+// queryTimeoutMs bounds each watermark/timestamp lookup against the brokers
+const queryTimeoutMs = 10000
+
+// offsetQuerier is the subset of the Kafka client used to resolve reset targets
+type offsetQuerier interface {
+	QueryWatermarkOffsets(topic string, partition int32, timeoutMs int) (low, high int64, err error)
+	OffsetsForTimes(times []kafka.TopicPartition, timeoutMs int) (offsets []kafka.TopicPartition, err error)
+}
+
+// resetTarget describes where the group's offsets should be moved to
+type resetTarget struct {
+	Mode      string
+	Timestamp time.Time
+	Offset    int64 // the offset of every partition when Mode is offset
+}
+
+// parseTarget validates the requested target mode and its timestamp or offset
+func parseTarget(mode, timestamp, offset string) (resetTarget, error) {
+	switch mode {
+	case targetEarliest, targetLatest:
+		return resetTarget{Mode: mode}, nil
+	case targetTimestamp:
+		ts, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return resetTarget{}, fmt.Errorf("invalid timestamp %q (expected RFC3339): %w", timestamp, err)
+		}
+		return resetTarget{Mode: mode, Timestamp: ts}, nil
+	case targetOffset:
+		n, err := strconv.ParseInt(offset, 10, 64)
+		if err != nil || n < 0 {
+			return resetTarget{}, fmt.Errorf("invalid offset %q (expected a non-negative integer)", offset)
+		}
+		return resetTarget{Mode: mode, Offset: n}, nil
+	default:
+		return resetTarget{}, fmt.Errorf("unknown reset target %q (expected earliest, latest, timestamp or offset)", mode)
+	}
+}
+
+// resolveOffsets turns the reset target into a concrete offset for each partition
+func resolveOffsets(q offsetQuerier, topic string, partitions []int32, target resetTarget) ([]kafka.TopicPartition, error) {
+	resolved := make([]kafka.TopicPartition, 0, len(partitions))
+
+	for _, partition := range partitions {
+		low, high, err := q.QueryWatermarkOffsets(topic, partition, queryTimeoutMs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query watermarks for %s[%d]: %w", topic, partition, err)
+		}
+
+		var offset int64
+		switch target.Mode {
+		case targetEarliest:
+			offset = low
+		case targetLatest:
+			offset = high
+		case targetTimestamp:
+			offset, err = offsetForTime(q, topic, partition, target.Timestamp, high)
+			if err != nil {
+				return nil, err
+			}
+		case targetOffset:
+			if target.Offset < low || target.Offset > high {
+				return nil, fmt.Errorf("offset %d is outside %s[%d], which holds offsets %d to %d", target.Offset, topic, partition, low, high)
+			}
+			offset = target.Offset
+		default:
+			return nil, fmt.Errorf("unknown reset target %q", target.Mode)
+		}
+
+		resolved = append(resolved, kafka.TopicPartition{
+			Topic:     &topic,
+			Partition: partition,
+			Offset:    kafka.Offset(offset),
+		})
+	}
+
+	return resolved, nil
+}
+
+// offsetForTime returns the earliest offset whose timestamp is at or after ts,
+// falling back to the high watermark when no such message exists yet
+func offsetForTime(q offsetQuerier, topic string, partition int32, ts time.Time, high int64) (int64, error) {
+	offsets, err := q.OffsetsForTimes([]kafka.TopicPartition{{
+		Topic:     &topic,
+		Partition: partition,
+		Offset:    kafka.Offset(ts.UnixMilli()),
+	}}, queryTimeoutMs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up offsets for time on %s[%d]: %w", topic, partition, err)
+	}
+	if len(offsets) != 1 {
+		return 0, fmt.Errorf("unexpected offsets-for-times result for %s[%d]", topic, partition)
+	}
+	if offsets[0].Error != nil {
+		return 0, fmt.Errorf("failed to look up offsets for time on %s[%d]: %w", topic, partition, offsets[0].Error)
+	}
+
+	if offsets[0].Offset < 0 {
+		return high, nil
+	}
+	return int64(offsets[0].Offset), nil
+}
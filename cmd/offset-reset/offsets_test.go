@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// watermarks are the low and high watermark of a partition
+type watermarks struct{ low, high int64 }
+
+// fakeQuerier answers offset lookups from fixed watermarks and timestamp
+// lookups from a fixed offset per partition, or -1 when it has none
+type fakeQuerier struct {
+	watermarks map[int32]watermarks
+	byTime     map[int32]kafka.Offset
+	err        error // returned by every lookup when set
+	times      []kafka.TopicPartition
+}
+
+func (q *fakeQuerier) QueryWatermarkOffsets(topic string, partition int32, timeoutMs int) (int64, int64, error) {
+	if q.err != nil {
+		return 0, 0, q.err
+	}
+	w := q.watermarks[partition]
+	return w.low, w.high, nil
+}
+
+func (q *fakeQuerier) OffsetsForTimes(times []kafka.TopicPartition, timeoutMs int) ([]kafka.TopicPartition, error) {
+	q.times = append(q.times, times...)
+	offsets := make([]kafka.TopicPartition, len(times))
+	for i, tp := range times {
+		offset, ok := q.byTime[tp.Partition]
+		if !ok {
+			offset = kafka.OffsetEnd
+		}
+		offsets[i] = kafka.TopicPartition{Topic: tp.Topic, Partition: tp.Partition, Offset: offset}
+	}
+	return offsets, nil
+}
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		timestamp string
+		offset    string
+		want      resetTarget
+		wantErr   bool
+	}{
+		{name: "earliest", mode: targetEarliest, want: resetTarget{Mode: targetEarliest}},
+		{name: "latest", mode: targetLatest, want: resetTarget{Mode: targetLatest}},
+		{name: "timestamp", mode: targetTimestamp, timestamp: "2024-01-01T00:00:00Z", want: resetTarget{Mode: targetTimestamp, Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{name: "malformed timestamp", mode: targetTimestamp, timestamp: "yesterday", wantErr: true},
+		{name: "offset", mode: targetOffset, offset: "1200", want: resetTarget{Mode: targetOffset, Offset: 1200}},
+		{name: "negative offset", mode: targetOffset, offset: "-1", wantErr: true},
+		{name: "missing offset", mode: targetOffset, wantErr: true},
+		{name: "unknown mode", mode: "middle", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTarget(tt.mode, tt.timestamp, tt.offset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTarget error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got.Mode != tt.want.Mode || !got.Timestamp.Equal(tt.want.Timestamp) || got.Offset != tt.want.Offset) {
+				t.Errorf("parseTarget = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveOffsets(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		target  resetTarget
+		want    []kafka.Offset
+		wantErr bool
+	}{
+		{name: "earliest", target: resetTarget{Mode: targetEarliest}, want: []kafka.Offset{10, 0}},
+		{name: "latest", target: resetTarget{Mode: targetLatest}, want: []kafka.Offset{100, 50}},
+		// Partition 1 has no message at or after ts, so it moves to its end
+		{name: "timestamp", target: resetTarget{Mode: targetTimestamp, Timestamp: ts}, want: []kafka.Offset{42, 50}},
+		{name: "explicit offset", target: resetTarget{Mode: targetOffset, Offset: 30}, want: []kafka.Offset{30, 30}},
+		{name: "explicit offset at the end", target: resetTarget{Mode: targetOffset, Offset: 50}, want: []kafka.Offset{50, 50}},
+		{name: "explicit offset before the earliest", target: resetTarget{Mode: targetOffset, Offset: 5}, wantErr: true},
+		{name: "explicit offset past the end", target: resetTarget{Mode: targetOffset, Offset: 60}, wantErr: true},
+		{name: "unknown mode", target: resetTarget{Mode: "middle"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &fakeQuerier{
+				watermarks: map[int32]watermarks{0: {low: 10, high: 100}, 1: {low: 0, high: 50}},
+				byTime:     map[int32]kafka.Offset{0: 42},
+			}
+			got, err := resolveOffsets(q, "orders", []int32{0, 1}, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveOffsets error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("resolved %d partitions, want %d", len(got), len(tt.want))
+			}
+			for i, tp := range got {
+				if *tp.Topic != "orders" || tp.Partition != int32(i) || tp.Offset != tt.want[i] {
+					t.Errorf("partition %d resolved to %s[%d]@%d, want orders[%d]@%d", i, *tp.Topic, tp.Partition, tp.Offset, i, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestResolveOffsetsLooksUpTimestampInMilliseconds(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := &fakeQuerier{watermarks: map[int32]watermarks{0: {low: 0, high: 10}}}
+
+	if _, err := resolveOffsets(q, "orders", []int32{0}, resetTarget{Mode: targetTimestamp, Timestamp: ts}); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.times) != 1 || int64(q.times[0].Offset) != ts.UnixMilli() {
+		t.Errorf("looked up %v, want the timestamp %d in milliseconds", q.times, ts.UnixMilli())
+	}
+}
+
+func TestResolveOffsetsFailsOnQueryError(t *testing.T) {
+	queryErr := errors.New("broker unavailable")
+	q := &fakeQuerier{err: queryErr}
+
+	_, err := resolveOffsets(q, "orders", []int32{0}, resetTarget{Mode: targetEarliest})
+	if !errors.Is(err, queryErr) {
+		t.Errorf("resolveOffsets error = %v, want the watermark query error", err)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"os"
@@ -12,49 +13,84 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/tanint/go-eda/internal/config"
 	"github.com/tanint/go-eda/internal/handlers"
+	"github.com/tanint/go-eda/internal/heartbeat"
 	"github.com/tanint/go-eda/internal/kafka"
 	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/metrics"
+	"github.com/tanint/go-eda/internal/outbox"
+	"github.com/tanint/go-eda/internal/version"
 	"go.uber.org/zap"
+
+	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
 	// Load configuration
 	cfg, err := config.Load("")
+	if err == nil {
+		cfg, err = cfg.ForService("order")
+	}
 	if err != nil {
 		fmt.Printf("Failed to load config: %v\n", err)
 		os.Exit(1)
 	}
 
+	version.Set(cfg.Version)
+
 	// Initialize logger
 	if err := logger.Initialize(cfg.Logger); err != nil {
 		fmt.Printf("Failed to initialize logger: %v\n", err)
 		os.Exit(1)
 	}
 	defer logger.Sync()
+	logger.FollowLevel(cfg)
+
+	if err := metrics.Initialize(cfg.Metrics); err != nil {
+		logger.Fatal("Failed to initialize metrics", zap.Error(err))
+	}
+	defer metrics.Close()
 
 	logger.Info("Starting Order Service...")
 
 	// Initialize Kafka producer
-	producer, err := kafka.NewProducer(cfg.Kafka)
+	producer, err := kafka.NewProducer(cfg.Kafka, logger.Global())
 	if err != nil {
 		logger.Fatal("Failed to create Kafka producer", zap.Error(err))
 	}
 	defer producer.Close()
 
 	// Initialize handlers
-	orderHandler := handlers.NewOrderHandler(producer, cfg.Kafka.Topics)
+	orderHandler := handlers.NewOrderHandler(producer, cfg.Kafka.Topics, logger.Global())
+	orderHandler.SetChunkSize(cfg.Orders.ChunkSize)
+	orderHandler.SetBatchPublisher(func() handlers.BatchPublisher {
+		return kafka.NewBatchPublisher(producer, cfg.Kafka.Batch)
+	})
+	if err := orderHandler.SetPartitionBy(cfg.Orders.PartitionBy); err != nil {
+		logger.Fatal("Invalid order partitioning", zap.Error(err))
+	}
+	if cfg.Orders.IdempotencyTTL > 0 {
+		orderHandler.SetIdempotencyStore(handlers.NewMemoryIdempotencyStore(cfg.Orders.IdempotencyTTL))
+	}
+
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	defer stopRelay()
+	relayDone := make(chan struct{})
+	if cfg.Outbox.Enabled {
+		db, box := openOutbox(cfg.Outbox, orderHandler)
+		defer db.Close()
+		go func() {
+			defer close(relayDone)
+			outbox.NewRelay(box, producer, cfg.Outbox.PollInterval, cfg.Outbox.BatchSize).Run(relayCtx)
+		}()
+	} else {
+		close(relayDone)
+	}
 
 	// Setup HTTP router
-	router := setupRouter(orderHandler)
+	router := setupRouter(orderHandler, producer, cfg.Server, cfg.Admin)
 
 	// Create HTTP server
-	server := &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
-	}
+	server := newServer(cfg.Server, router)
 
 	// Start server in a goroutine
 	go func() {
@@ -66,12 +102,19 @@ func main() {
 		}
 	}()
 
+	heartbeatCtx, stopHeartbeat := context.WithCancel(context.Background())
+	defer stopHeartbeat()
+	if cfg.Heartbeat.Interval > 0 {
+		go heartbeat.NewEmitter(producer, cfg.Kafka.Topics["service_heartbeat"], "order-service", cfg.Heartbeat.Interval).Run(heartbeatCtx)
+	}
+
 	// Wait for interrupt signal for graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	logger.Info("Shutting down server...")
+	stopHeartbeat()
 
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -81,29 +124,88 @@ func main() {
 		logger.Error("Server forced to shutdown", zap.Error(err))
 	}
 
+	// Stop the relay before the producer closes; unsent events stay in the outbox
+	stopRelay()
+	<-relayDone
+
 	logger.Info("Order Service stopped")
 }
 
-func setupRouter(orderHandler *handlers.OrderHandler) *gin.Engine {
+// openOutbox opens the outbox database and makes the order handler write
+// orders and their events to it, and read orders back from it
+func openOutbox(cfg config.OutboxConfig, orderHandler *handlers.OrderHandler) (*sql.DB, *outbox.SQLOutbox) {
+	db, err := sql.Open("sqlite3", cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to open outbox database", zap.Error(err))
+	}
+	// SQLite allows one writer; serialize handler and relay writes
+	db.SetMaxOpenConns(1)
+
+	box, err := outbox.NewSQLOutbox(context.Background(), db)
+	if err != nil {
+		logger.Fatal("Failed to initialize outbox", zap.Error(err))
+	}
+	store, err := handlers.NewSQLOrderStore(context.Background(), db)
+	if err != nil {
+		logger.Fatal("Failed to initialize order store", zap.Error(err))
+	}
+	orderHandler.SetOutbox(box, store)
+	orderHandler.SetOrderRepository(store)
+
+	logger.Info("Transactional outbox enabled", zap.String("database", cfg.Database))
+	return db, box
+}
+
+// newServer creates the HTTP server with the configured timeouts and limits
+func newServer(cfg config.ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+func setupRouter(orderHandler *handlers.OrderHandler, producer handlers.HealthChecker, cfg config.ServerConfig, admin config.AdminConfig) *gin.Engine {
 	router := gin.New()
 
 	// Middleware
 	router.Use(gin.Recovery())
-	router.Use(loggingMiddleware())
+	router.Use(loggingMiddleware(cfg.LogSkipPaths))
+	// Must run after any middleware setting tenant/principal/trace_id on the gin context
+	router.Use(handlers.PropagateContext())
 
 	// Routes
 	router.GET("/health", orderHandler.HealthCheck)
+	handlers.RegisterHealthRoutes(router, map[string]handlers.HealthChecker{"kafka_producer": producer}, logger.Global())
+	handlers.RegisterMetricsRoute(router)
 
 	api := router.Group("/api/v1")
 	{
 		api.POST("/orders", orderHandler.CreateOrder)
+		api.POST("/orders/bulk", orderHandler.BulkCreateOrders)
+		api.GET("/orders", orderHandler.ListOrders)
 		api.GET("/orders/:id", orderHandler.GetOrderStatus)
 	}
 
+	if admin.Enabled {
+		handlers.RegisterLogLevelRoutes(router)
+	}
+
 	return router
 }
 
-func loggingMiddleware() gin.HandlerFunc {
+// loggingMiddleware logs every request at info level, except requests to
+// skipPaths (health probes, metrics scrapes) which are logged at debug level
+func loggingMiddleware(skipPaths []string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, p := range skipPaths {
+		skip[p] = true
+	}
+
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
@@ -114,7 +216,12 @@ func loggingMiddleware() gin.HandlerFunc {
 		latency := time.Since(start)
 		statusCode := c.Writer.Status()
 
-		logger.Info("HTTP Request",
+		log := logger.Info
+		if skip[path] {
+			log = logger.Debug
+		}
+
+		log("HTTP Request",
 			zap.String("method", method),
 			zap.String("path", path),
 			zap.Int("status", statusCode),
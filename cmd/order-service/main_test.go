@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/logger"
+)
+
+// serverLimits are the address, timeouts and limits of an http.Server
+type serverLimits struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	MaxHeaderBytes    int
+}
+
+func TestNewServerAppliesConfiguredLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want serverLimits
+	}{
+		{
+			name: "defaults",
+			want: serverLimits{
+				Addr:              "0.0.0.0:8080",
+				ReadTimeout:       15 * time.Second,
+				WriteTimeout:      15 * time.Second,
+				IdleTimeout:       60 * time.Second,
+				ReadHeaderTimeout: 5 * time.Second,
+				MaxHeaderBytes:    1 << 20,
+			},
+		},
+		{
+			name: "configured",
+			yaml: "server:\n  host: 127.0.0.1\n  port: 9000\n  read_timeout: 2s\n  write_timeout: 3s\n  idle_timeout: 4s\n  read_header_timeout: 1s\n  max_header_bytes: 4096\n",
+			want: serverLimits{
+				Addr:              "127.0.0.1:9000",
+				ReadTimeout:       2 * time.Second,
+				WriteTimeout:      3 * time.Second,
+				IdleTimeout:       4 * time.Second,
+				ReadHeaderTimeout: time.Second,
+				MaxHeaderBytes:    4096,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("APP_ENV", "")
+			os.Unsetenv("APP_ENV")
+			cfg, err := config.Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			server := newServer(cfg.Server, http.NotFoundHandler())
+			got := serverLimits{
+				Addr:              server.Addr,
+				ReadTimeout:       server.ReadTimeout,
+				WriteTimeout:      server.WriteTimeout,
+				IdleTimeout:       server.IdleTimeout,
+				ReadHeaderTimeout: server.ReadHeaderTimeout,
+				MaxHeaderBytes:    server.MaxHeaderBytes,
+			}
+			if got != tt.want {
+				t.Errorf("server limits = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoggingMiddlewareSkipsProbesAtInfoLevel(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "requests.log")
+	if err := logger.Initialize(config.LoggerConfig{Level: "info", OutputPath: output}); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(loggingMiddleware([]string{"/health", "/metrics"}))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/health", ok)
+	router.GET("/api/v1/orders", ok)
+	for _, path := range []string{"/health", "/api/v1/orders"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	logger.Sync()
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logged []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry struct {
+			Msg  string `json:"msg"`
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line %q: %v", line, err)
+		}
+		if entry.Msg == "HTTP Request" {
+			logged = append(logged, entry.Path)
+		}
+	}
+	if want := []string{"/api/v1/orders"}; !slices.Equal(logged, want) {
+		t.Errorf("logged requests to %v at info level, want %v", logged, want)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/handlers"
+	"github.com/tanint/go-eda/internal/kafka"
+	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/metrics"
+	"github.com/tanint/go-eda/internal/version"
+	"go.uber.org/zap"
+)
+
+func main() {
+	configPath := flag.String("config", "", "Path to the config file (defaults to ./config.yaml or ./configs/config.yaml)")
+	topic := flag.String("topic", "", "Topic to verify (required)")
+	group := flag.String("group", "ordering-verifier-group", "Consumer group used by the verifier; keep it separate from real consumers")
+	flag.Parse()
+
+	if *topic == "" {
+		fmt.Println("-topic is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load configuration
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Printf("Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	version.Set(cfg.Version)
+
+	// Initialize logger
+	if err := logger.Initialize(cfg.Logger); err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	if err := metrics.Initialize(cfg.Metrics); err != nil {
+		logger.Fatal("Failed to initialize metrics", zap.Error(err))
+	}
+	defer metrics.Close()
+
+	logger.Info("Starting Ordering Verifier...",
+		zap.String("topic", *topic),
+		zap.String("group_id", *group),
+	)
+
+	// Initialize Kafka consumer in its own group so real processing is unaffected
+	consumer, err := kafka.NewConsumer(cfg.Kafka, *group, logger.Global())
+	if err != nil {
+		logger.Fatal("Failed to create Kafka consumer", zap.Error(err))
+	}
+	defer consumer.Close()
+
+	verifier := handlers.NewOrderingVerifier(logger.Global())
+	consumer.RegisterHandler(*topic, verifier.Handle)
+
+	if err := consumer.Subscribe([]string{*topic}); err != nil {
+		logger.Fatal("Failed to subscribe to topics", zap.Error(err))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errChan := make(chan error, 1)
+	go func() {
+		if err := consumer.Start(ctx); err != nil && err != context.Canceled {
+			errChan <- err
+		}
+	}()
+
+	// Wait for interrupt signal for graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case <-quit:
+		logger.Info("Shutting down Ordering Verifier...")
+		cancel()
+	case err := <-errChan:
+		logger.Error("Consumer error", zap.Error(err))
+		cancel()
+	}
+
+	logger.Info("Ordering Verifier stopped")
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/handlers"
+	"github.com/tanint/go-eda/internal/kafka"
+	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/metrics"
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/internal/projection"
+	"github.com/tanint/go-eda/internal/snapshot"
+	"github.com/tanint/go-eda/internal/version"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+func main() {
+	dbPath := flag.String("db", "orders.db", "Path to the SQLite database file")
+	addr := flag.String("addr", ":8081", "Address of the query API")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.Load("")
+	if err == nil {
+		cfg, err = cfg.ForService("projection")
+	}
+	if err != nil {
+		fmt.Printf("Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	version.Set(cfg.Version)
+
+	// Initialize logger
+	if err := logger.Initialize(cfg.Logger); err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+	logger.FollowLevel(cfg)
+
+	if err := metrics.Initialize(cfg.Metrics); err != nil {
+		logger.Fatal("Failed to initialize metrics", zap.Error(err))
+	}
+	defer metrics.Close()
+
+	logger.Info("Starting Projection Service...")
+
+	db, err := sql.Open("sqlite3", *dbPath)
+	if err != nil {
+		logger.Fatal("Failed to open SQLite database", zap.Error(err))
+	}
+	defer db.Close()
+
+	orders, err := projection.NewOrderProjection(context.Background(), db)
+	if err != nil {
+		logger.Fatal("Failed to initialize order projection", zap.Error(err))
+	}
+
+	audit, err := projection.NewAuditProjection(context.Background(), db)
+	if err != nil {
+		logger.Fatal("Failed to initialize order status audit", zap.Error(err))
+	}
+
+	// Initialize Kafka consumer
+	consumer, err := kafka.NewConsumer(cfg.Kafka, cfg.Kafka.GroupID, logger.Global())
+	if err != nil {
+		logger.Fatal("Failed to create Kafka consumer", zap.Error(err))
+	}
+	defer consumer.Close()
+
+	// Register message handlers
+	topics := []string{
+		cfg.Kafka.Topics["order_created"],
+		cfg.Kafka.Topics["order_confirmed"],
+		cfg.Kafka.Topics["order_rejected"],
+	}
+	latency := handlers.NewOrderLatencyTracker(cfg.Orders.LatencyTTL)
+	for _, topic := range topics {
+		consumer.RegisterHandler(topic, func(ctx context.Context, msg *kafka.Message) error {
+			if err := latency.Handle(ctx, msg); err != nil {
+				logger.Warn("Failed to track order latency", zap.Error(err))
+			}
+			return orders.Handle(ctx, msg)
+		})
+	}
+	statusChangedTopic := cfg.Kafka.Topics["order_status_changed"]
+	kafka.RegisterHandlerT(consumer, statusChangedTopic, events.EventTypeOrderStatusChanged, audit.Handle)
+
+	// Subscribe to topics
+	if err := consumer.Subscribe(append(topics, statusChangedTopic)); err != nil {
+		logger.Fatal("Failed to subscribe to topics", zap.Error(err))
+	}
+
+	// Start consuming in a goroutine
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errChan := make(chan error, 1)
+	go func() {
+		if err := consumer.Start(ctx); err != nil && err != context.Canceled {
+			errChan <- err
+		}
+	}()
+
+	if cfg.Orders.SnapshotInterval > 0 {
+		producer, err := kafka.NewProducer(cfg.Kafka, logger.Global())
+		if err != nil {
+			logger.Fatal("Failed to create Kafka producer", zap.Error(err))
+		}
+		defer producer.Close()
+
+		go snapshot.NewSnapshotter(orders, producer, cfg.Kafka.Topics["order_state"],
+			cfg.Orders.SnapshotInterval, cfg.Orders.SnapshotRetention).Run(ctx)
+	}
+
+	// Serve the query API
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           setupRouter(orders, audit),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		logger.Info("Query API starting", zap.String("address", server.Addr))
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errChan <- err
+		}
+	}()
+
+	// Wait for interrupt signal for graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case <-quit:
+		logger.Info("Shutting down Projection Service...")
+	case err := <-errChan:
+		logger.Error("Projection Service error", zap.Error(err))
+	}
+	cancel()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Server forced to shutdown", zap.Error(err))
+	}
+
+	logger.Info("Projection Service stopped")
+}
+
+func setupRouter(orders *projection.OrderProjection, audit *projection.AuditProjection) *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Recovery())
+	handlers.RegisterMetricsRoute(router)
+
+	router.GET("/orders", func(c *gin.Context) {
+		rows, err := orders.List(c.Request.Context(), c.Query("customer_id"))
+		if err != nil {
+			logger.Error("Failed to list projected orders", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list orders"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"orders": rows})
+	})
+
+	router.GET("/orders/:id", func(c *gin.Context) {
+		row, err := orders.Get(c.Request.Context(), c.Param("id"))
+		if errors.Is(err, models.ErrOrderNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			logger.Error("Failed to get projected order", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+			return
+		}
+		c.JSON(http.StatusOK, row)
+	})
+
+	router.GET("/orders/:id/history", func(c *gin.Context) {
+		history, err := audit.History(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			logger.Error("Failed to get order status history", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order history"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"history": history})
+	})
+
+	return router
+}
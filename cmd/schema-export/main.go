@@ -0,0 +1,25 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+func main() {
+	out := flag.String("out", "schemas", "Directory the JSON Schema documents are written to")
+	flag.Parse()
+
+	paths, err := events.DefaultRegistry.WriteJSONSchemas(*out)
+	if err != nil {
+		fmt.Printf("Schema export failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+	fmt.Printf("Exported %d event schemas to %s\n", len(paths), *out)
+}
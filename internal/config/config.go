@@ -1,21 +1,55 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server ServerConfig `mapstructure:"server"`
-	Kafka  KafkaConfig  `mapstructure:"kafka"`
-	Logger LoggerConfig `mapstructure:"logger"`
+	Env string `mapstructure:"env"` // active profile: dev, staging or prod
+	// Version overrides the build version set via -ldflags
+	Version string `mapstructure:"version"`
+	// EmptyEnv is "ignore" to treat empty or whitespace-only APP_* variables as
+	// unset, or "error" to fail loading when one is found
+	EmptyEnv  string          `mapstructure:"empty_env"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Kafka     KafkaConfig     `mapstructure:"kafka"`
+	Logger    LoggerConfig    `mapstructure:"logger"`
+	Metrics   MetricsConfig   `mapstructure:"metrics"`
+	Admin     AdminConfig     `mapstructure:"admin"`
+	Orders    OrdersConfig    `mapstructure:"orders"`
+	Heartbeat HeartbeatConfig `mapstructure:"heartbeat"`
+	Outbox    OutboxConfig    `mapstructure:"outbox"`
+	// Reload re-reads the configuration when the base or profile config file
+	// changes and notifies the OnChange callbacks
+	Reload bool `mapstructure:"reload"`
+	// Services holds per-service overrides of the settings above, keyed by
+	// service name; see ForService
+	Services map[string]map[string]interface{} `mapstructure:"services"`
+
+	settings map[string]interface{} // effective shared settings, for ForService
+
+	watcher *watcher // nil unless Reload is set and a config file was read
+	service string   // set by ForService, so OnChange delivers the service's config
 }
 
 type ServerConfig struct {
-	Port int    `mapstructure:"port"`
-	Host string `mapstructure:"host"`
+	Port              int           `mapstructure:"port"`
+	Host              string        `mapstructure:"host"`
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+	MaxHeaderBytes    int           `mapstructure:"max_header_bytes"`
+	// LogSkipPaths are request paths logged at debug instead of info level
+	LogSkipPaths []string `mapstructure:"log_skip_paths"`
 }
 
 type KafkaConfig struct {
@@ -26,16 +60,239 @@ type KafkaConfig struct {
 	SASLPassword     string            `mapstructure:"sasl_password"`
 	GroupID          string            `mapstructure:"group_id"`
 	Topics           map[string]string `mapstructure:"topics"`
+	Consumer         ConsumerConfig    `mapstructure:"consumer"`
+	// TLS settings of the SSL and SASL_SSL protocols; empty locations use
+	// the system CA store and no client certificate
+	SSLCALocation   string `mapstructure:"ssl_ca_location"`
+	SSLCertLocation string `mapstructure:"ssl_cert_location"` // client certificate, for mutual TLS
+	SSLKeyLocation  string `mapstructure:"ssl_key_location"`
+	SSLKeyPassword  string `mapstructure:"ssl_key_password"`
+	// SSLSkipVerify disables broker certificate verification; for testing only
+	SSLSkipVerify bool `mapstructure:"ssl_skip_verify"`
+	// Batch sets the flush thresholds of BatchPublisher
+	Batch BatchConfig `mapstructure:"batch"`
+	// StartupRetry retries producer and consumer creation at startup
+	StartupRetry RetryConfig `mapstructure:"startup_retry"`
+	// Serializer is the event wire format: "json", "protobuf", "cloudevents" or "avro"
+	Serializer string `mapstructure:"serializer"`
+	// CloudEventsSource is the CloudEvents source attribute of published
+	// events, a URI reference identifying the service
+	CloudEventsSource string `mapstructure:"cloudevents_source"`
+	// SchemaRegistry is where the avro serializer registers and fetches schemas
+	SchemaRegistry SchemaRegistryConfig `mapstructure:"schema_registry"`
+	// Producer tunes the durability and batching of published messages
+	Producer ProducerConfig `mapstructure:"producer"`
+	// DLQ moves messages whose handler failed to a dead-letter topic
+	DLQ DLQConfig `mapstructure:"dlq"`
+}
+
+// SchemaRegistryConfig holds Confluent Schema Registry connection settings
+type SchemaRegistryConfig struct {
+	URL      string `mapstructure:"url"`
+	Username string `mapstructure:"username"` // API key on Confluent Cloud
+	Password string `mapstructure:"password"` // API secret on Confluent Cloud
+}
+
+// ProducerConfig holds producer durability and batching settings
+type ProducerConfig struct {
+	Acks            string        `mapstructure:"acks"`             // 0, 1 or all
+	Retries         int           `mapstructure:"retries"`          // 0 disables retries
+	CompressionType string        `mapstructure:"compression_type"` // none, gzip, snappy, lz4 or zstd
+	Linger          time.Duration `mapstructure:"linger"`           // how long to wait to fill a batch
+	BatchSize       int           `mapstructure:"batch_size"`       // bytes per partition batch
+	Idempotent      bool          `mapstructure:"idempotent"`       // exactly-once per partition, no duplicates on retry
+	TransactionalID string        `mapstructure:"transactional_id"` // enables transactions; implies idempotent
+}
+
+// DLQConfig holds dead-letter queue settings
+type DLQConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Suffix  string `mapstructure:"suffix"` // appended to the failing topic's name
+}
+
+// ConsumerConfig holds consumer-specific settings
+type ConsumerConfig struct {
+	IsolationLevel string        `mapstructure:"isolation_level"` // read_committed or read_uncommitted
+	HandlerTimeout time.Duration `mapstructure:"handler_timeout"`
+	// DrainTimeout bounds how long in-flight handlers may keep running once
+	// shutdown begins before they are cancelled; 0 lets them run until the
+	// handler timeout
+	DrainTimeout time.Duration `mapstructure:"drain_timeout"`
+	// SlowHandlerThreshold reports handlers still running after this long,
+	// without cancelling them; 0 disables it. Must be below HandlerTimeout.
+	SlowHandlerThreshold time.Duration `mapstructure:"slow_handler_threshold"`
+	// ShutdownCommitPolicy is "commit" to commit the last processed offsets on
+	// shutdown or "discard" to leave them for reprocessing
+	ShutdownCommitPolicy string `mapstructure:"shutdown_commit_policy"`
+	// StrictDecoding rejects events carrying JSON fields unknown to the payload type
+	StrictDecoding bool `mapstructure:"strict_decoding"`
+	// MaxMessagesPerSecond caps handler dispatch; 0 means unlimited
+	MaxMessagesPerSecond float64 `mapstructure:"max_messages_per_second"`
+	// MissingTopics is "warn" or "fail" when subscribed topics don't exist
+	MissingTopics string `mapstructure:"missing_topics"`
+	// DeliveryMode is "at_least_once" (commit after handling) or
+	// "at_most_once" (commit before handling; failed messages are lost)
+	DeliveryMode string `mapstructure:"delivery_mode"`
+	// CommitMode is "sync" (commit after every message), "async" (commit
+	// processed offsets every CommitInterval and on shutdown) or "batch"
+	// (commit every CommitBatchSize processed messages and on shutdown)
+	CommitMode      string        `mapstructure:"commit_mode"`
+	CommitInterval  time.Duration `mapstructure:"commit_interval"`
+	CommitBatchSize int           `mapstructure:"commit_batch_size"`
+	// FutureSkewTolerance is how far in the future an event timestamp may be
+	// before the event is quarantined; 0 disables the check
+	FutureSkewTolerance time.Duration `mapstructure:"future_skew_tolerance"`
+	// StartFromTime (RFC3339) is where partitions the group has never
+	// committed start from, e.g. for backfills; empty uses auto.offset.reset
+	StartFromTime string `mapstructure:"start_from_time"`
+	// AutoOffsetReset is where partitions without a committed offset start
+	// when no start time applies: "earliest", "latest" or "error"
+	AutoOffsetReset string `mapstructure:"auto_offset_reset"`
+	// Shard restricts processing to a subset of partitions
+	Shard ShardConfig `mapstructure:"shard"`
+	// Retry is the default handler retry policy
+	Retry RetryConfig `mapstructure:"retry"`
+	// Workers handles messages on this many goroutines, partition by
+	// partition; 0 or 1 handles them on the read loop
+	Workers int `mapstructure:"workers"`
+	// WorkerQueueSize bounds each worker's queue; the read loop blocks when full
+	WorkerQueueSize int `mapstructure:"worker_queue_size"`
+	// LagInterval is how often the lag of assigned partitions is recorded as
+	// the consumer_lag gauge; 0 disables it
+	LagInterval time.Duration `mapstructure:"lag_interval"`
+	// CoordinatorTimeout is how long Start waits for an unavailable group
+	// coordinator before it fails; 0 waits indefinitely
+	CoordinatorTimeout time.Duration `mapstructure:"coordinator_timeout"`
+}
+
+// ShardConfig splits partitions across workers without group rebalancing:
+// worker Index processes partitions where partition % Total == Index
+type ShardConfig struct {
+	Index int `mapstructure:"index"`
+	Total int `mapstructure:"total"` // 0 or 1 disables sharding
+}
+
+// BatchConfig holds the flush thresholds for batched publishing
+type BatchConfig struct {
+	MaxSize  int           `mapstructure:"max_size"`  // flush once this many events are buffered
+	MaxDelay time.Duration `mapstructure:"max_delay"` // flush this long after the first buffered event
+}
+
+// RetryConfig holds retry settings: the wait starts at Backoff and grows by
+// Multiplier after every failed attempt, up to MaxBackoff
+type RetryConfig struct {
+	MaxAttempts int           `mapstructure:"max_attempts"` // 1 disables retries
+	Backoff     time.Duration `mapstructure:"backoff"`
+	Multiplier  float64       `mapstructure:"multiplier"`  // 1 or less keeps the wait constant
+	MaxBackoff  time.Duration `mapstructure:"max_backoff"` // 0 means uncapped
 }
 
 type LoggerConfig struct {
 	Level      string `mapstructure:"level"`
 	Encoding   string `mapstructure:"encoding"` // json or console
 	OutputPath string `mapstructure:"output_path"`
+	// Sampling limits repeated log lines; zero keeps the encoding's default
+	Sampling SamplingConfig `mapstructure:"sampling"`
+}
+
+// SamplingConfig logs the first Initial entries with the same level and
+// message each second, then every Thereafter-th one
+type SamplingConfig struct {
+	Initial    int `mapstructure:"initial"`
+	Thereafter int `mapstructure:"thereafter"`
+}
+
+// OrdersConfig holds order-service settings
+type OrdersConfig struct {
+	// ChunkSize splits orders with more items into order.created.chunk events; 0 disables
+	ChunkSize int `mapstructure:"chunk_size"`
+	// ChunkTTL is how long the inventory service waits for the missing chunks
+	// of an order, and ignores redelivered chunks of a completed one
+	ChunkTTL time.Duration `mapstructure:"chunk_ttl"`
+	// ChunkDatabase is the SQLite file the inventory service keeps received
+	// chunks in, so they survive restarts; empty keeps them in memory
+	ChunkDatabase string `mapstructure:"chunk_database"`
+	// PartitionBy keys order events by "order" (the order ID) or "customer"
+	// (one partition per customer)
+	PartitionBy string `mapstructure:"partition_by"`
+	// LatencyTTL is how long an order is awaited for confirmation when
+	// measuring created-to-confirmed latency
+	LatencyTTL time.Duration `mapstructure:"latency_ttl"`
+	// SnapshotInterval is how often the projection service republishes order
+	// states to the compacted order_state topic; 0 disables snapshots
+	SnapshotInterval time.Duration `mapstructure:"snapshot_interval"`
+	// SnapshotRetention is how long failed or cancelled orders keep being snapshotted
+	SnapshotRetention time.Duration `mapstructure:"snapshot_retention"`
+	// IdempotencyTTL is how long an Idempotency-Key of POST /orders is
+	// remembered; 0 ignores the header
+	IdempotencyTTL time.Duration `mapstructure:"idempotency_ttl"`
 }
 
-// Load loads configuration from file and environment variables
+// HeartbeatConfig controls the periodic service.heartbeat event
+type HeartbeatConfig struct {
+	Interval time.Duration `mapstructure:"interval"` // 0 disables heartbeats
+}
+
+// OutboxConfig configures the order service's transactional outbox
+type OutboxConfig struct {
+	// Enabled stores orders and their events in one SQLite transaction and
+	// publishes the events from a relay, instead of publishing directly
+	Enabled      bool          `mapstructure:"enabled"`
+	Database     string        `mapstructure:"database"` // SQLite file path
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size"` // messages per relay query
+}
+
+// AdminConfig configures the operational HTTP endpoint of consumer services
+type AdminConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Host    string `mapstructure:"host"`
+	Port    int    `mapstructure:"port"`
+}
+
+// MetricsConfig selects where metrics are emitted besides the in-memory counters
+type MetricsConfig struct {
+	Sink   string       `mapstructure:"sink"` // "", statsd or dogstatsd
+	StatsD StatsDConfig `mapstructure:"statsd"`
+}
+
+// StatsDConfig holds StatsD/DogStatsD agent settings
+type StatsDConfig struct {
+	Address string `mapstructure:"address"` // host:port of the agent (UDP)
+	Prefix  string `mapstructure:"prefix"`
+}
+
+// Profiles selectable through APP_ENV
+var profiles = map[string]bool{
+	"dev":     true,
+	"staging": true,
+	"prod":    true,
+}
+
+// Load loads configuration from file and environment variables.
+//
+// Precedence, from lowest to highest: defaults, the base config file
+// (config.yaml), the profile file selected by APP_ENV (config.<env>.yaml,
+// next to the base file), then APP_* environment variables.
+//
+// With reload set, changes to the base config file or to the profile file
+// (including creating it) are loaded the same way and passed to the OnChange
+// callbacks.
 func Load(configPath string) (*Config, error) {
+	cfg, files, err := load(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Reload && len(files) > 0 {
+		cfg.watcher = watchFiles(configPath, files)
+	}
+	return cfg, nil
+}
+
+// load reads the configuration and returns it with the config files it
+// depends on: the base file read, if any, and the profile file, whether or
+// not it exists yet
+func load(configPath string) (*Config, []string, error) {
 	v := viper.New()
 
 	// Set default values
@@ -55,38 +312,246 @@ func Load(configPath string) (*Config, error) {
 	if err := v.ReadInConfig(); err != nil {
 		// It's okay if config file doesn't exist, we'll use defaults and env vars
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("error reading config file: %w", err)
+			return nil, nil, fmt.Errorf("error reading config file: %w", err)
+		}
+	}
+	var files []string
+	if file := v.ConfigFileUsed(); file != "" {
+		files = append(files, file)
+	}
+
+	// Profile overrides
+	if env := os.Getenv("APP_ENV"); env != "" {
+		if !profiles[env] {
+			return nil, nil, fmt.Errorf("unknown APP_ENV %q: must be dev, staging or prod", env)
+		}
+		profile, err := mergeProfile(v, configPath, env)
+		if err != nil {
+			return nil, nil, err
+		}
+		if profile != "" {
+			files = append(files, profile)
 		}
 	}
 
 	// Environment variables
+	blank, err := blankEnvOverrides(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	v.SetEnvPrefix("APP")
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	v.AutomaticEnv()
 
+	// Blank overrides fall back to the file/default values
+	for key, value := range blank {
+		v.Set(key, value)
+	}
+
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("unable to decode config: %w", err)
+		return nil, nil, fmt.Errorf("unable to decode config: %w", err)
+	}
+	cfg.settings = v.AllSettings()
+	delete(cfg.settings, "services")
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return &cfg, files, nil
+}
+
+// Policies for empty or whitespace-only environment overrides
+const (
+	EmptyEnvIgnore = "ignore"
+	EmptyEnvError  = "error"
+)
+
+// envVarName returns the environment variable overriding a config key
+func envVarName(key string) string {
+	return "APP_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// blankEnvOverrides finds config keys overridden by empty or whitespace-only
+// environment variables. Under the ignore policy it returns their file/default
+// values so they can be restored; under the error policy it fails.
+func blankEnvOverrides(v *viper.Viper) (map[string]interface{}, error) {
+	policy := v.GetString("empty_env")
+	if value := strings.TrimSpace(os.Getenv(envVarName("empty_env"))); value != "" {
+		policy = value
+	}
+	if policy != EmptyEnvIgnore && policy != EmptyEnvError {
+		return nil, fmt.Errorf("invalid empty_env policy %q: must be %s or %s", policy, EmptyEnvIgnore, EmptyEnvError)
+	}
+
+	blank := make(map[string]interface{})
+	var names []string
+	for _, key := range v.AllKeys() {
+		name := envVarName(key)
+		value, ok := os.LookupEnv(name)
+		if !ok || strings.TrimSpace(value) != "" {
+			continue
+		}
+		blank[key] = v.Get(key)
+		names = append(names, name)
 	}
 
-	return &cfg, nil
+	if policy == EmptyEnvError && len(names) > 0 {
+		sort.Strings(names)
+		return nil, fmt.Errorf("environment variables set to empty values: %s", strings.Join(names, ", "))
+	}
+	return blank, nil
+}
+
+// mergeProfile layers config.<env>.yaml over the already loaded base config
+// and returns the profile file. A missing profile file is not an error; its
+// expected path next to the base file is returned, or "" without a base file.
+func mergeProfile(v *viper.Viper, configPath, env string) (string, error) {
+	base := v.ConfigFileUsed()
+	if configPath != "" {
+		ext := filepath.Ext(configPath)
+		profilePath := strings.TrimSuffix(configPath, ext) + "." + env + ext
+		if _, err := os.Stat(profilePath); errors.Is(err, os.ErrNotExist) {
+			return profilePath, nil
+		}
+		v.SetConfigFile(profilePath)
+	} else {
+		v.SetConfigName("config." + env)
+	}
+
+	if err := v.MergeInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return "", fmt.Errorf("error reading %s profile config: %w", env, err)
+		}
+		if base == "" {
+			return "", nil
+		}
+		return filepath.Join(filepath.Dir(base), "config."+env+filepath.Ext(base)), nil
+	}
+	return v.ConfigFileUsed(), nil
 }
 
 func setDefaults(v *viper.Viper) {
+	v.SetDefault("env", "")
+	v.SetDefault("version", "")
+	v.SetDefault("empty_env", EmptyEnvIgnore)
+	v.SetDefault("reload", false)
+
 	// Server defaults
 	v.SetDefault("server.port", 8080)
 	v.SetDefault("server.host", "0.0.0.0")
+	v.SetDefault("server.read_timeout", 15*time.Second)
+	v.SetDefault("server.write_timeout", 15*time.Second)
+	v.SetDefault("server.idle_timeout", 60*time.Second)
+	v.SetDefault("server.read_header_timeout", 5*time.Second)
+	v.SetDefault("server.max_header_bytes", 1<<20)
+	v.SetDefault("server.log_skip_paths", []string{"/health", "/health/live", "/health/ready", "/healthz", "/readyz", "/metrics"})
 
 	// Kafka defaults for local development
 	v.SetDefault("kafka.brokers", []string{"localhost:9092"})
 	v.SetDefault("kafka.security_protocol", "PLAINTEXT")
+	v.SetDefault("kafka.ssl_ca_location", "")
+	v.SetDefault("kafka.ssl_cert_location", "")
+	v.SetDefault("kafka.ssl_key_location", "")
+	v.SetDefault("kafka.ssl_key_password", "")
+	v.SetDefault("kafka.ssl_skip_verify", false)
 	v.SetDefault("kafka.group_id", "default-group")
+	v.SetDefault("kafka.serializer", "json")
+	v.SetDefault("kafka.cloudevents_source", "/go-eda")
+	v.SetDefault("kafka.schema_registry.url", "")
+	v.SetDefault("kafka.schema_registry.username", "")
+	v.SetDefault("kafka.schema_registry.password", "")
+	v.SetDefault("services.order.kafka.cloudevents_source", "/go-eda/order-service")
+	v.SetDefault("services.inventory.kafka.group_id", "inventory-service-group")
+	v.SetDefault("services.inventory.kafka.cloudevents_source", "/go-eda/inventory-service")
+	v.SetDefault("services.notification.kafka.group_id", "notification-service-group")
+	v.SetDefault("services.notification.kafka.cloudevents_source", "/go-eda/notification-service")
+	v.SetDefault("services.projection.kafka.group_id", "projection-service-group")
+	v.SetDefault("services.projection.kafka.cloudevents_source", "/go-eda/projection-service")
 	v.SetDefault("kafka.topics.order_created", "order.created")
 	v.SetDefault("kafka.topics.order_confirmed", "order.confirmed")
+	v.SetDefault("kafka.topics.order_rejected", "order.rejected")
+	v.SetDefault("kafka.topics.order_status_changed", "order.status_changed")
+	v.SetDefault("kafka.topics.order_state", "order.state")
 	v.SetDefault("kafka.topics.inventory_reserved", "inventory.reserved")
+	v.SetDefault("kafka.topics.inventory_released", "inventory.released")
+	v.SetDefault("kafka.topics.service_heartbeat", "service.heartbeat")
+	v.SetDefault("kafka.topics.quarantine", "events.quarantine")
+	v.SetDefault("kafka.batch.max_size", 500)
+	v.SetDefault("kafka.batch.max_delay", 100*time.Millisecond)
+	v.SetDefault("kafka.producer.acks", "all")
+	v.SetDefault("kafka.producer.retries", 3)
+	v.SetDefault("kafka.producer.compression_type", "snappy")
+	v.SetDefault("kafka.producer.linger", 5*time.Millisecond)
+	v.SetDefault("kafka.producer.batch_size", 16384)
+	v.SetDefault("kafka.producer.idempotent", false)
+	v.SetDefault("kafka.producer.transactional_id", "")
+	v.SetDefault("kafka.dlq.enabled", false)
+	v.SetDefault("kafka.dlq.suffix", ".dlq")
+	v.SetDefault("kafka.startup_retry.max_attempts", 1)
+	v.SetDefault("kafka.startup_retry.backoff", 2*time.Second)
+	v.SetDefault("kafka.startup_retry.multiplier", 1)
+	v.SetDefault("kafka.startup_retry.max_backoff", 0)
+	v.SetDefault("kafka.consumer.isolation_level", "read_committed")
+	v.SetDefault("kafka.consumer.handler_timeout", 30*time.Second)
+	v.SetDefault("kafka.consumer.slow_handler_threshold", 0)
+	v.SetDefault("kafka.consumer.drain_timeout", 0)
+	v.SetDefault("kafka.consumer.shutdown_commit_policy", "commit")
+	v.SetDefault("kafka.consumer.strict_decoding", false)
+	v.SetDefault("kafka.consumer.max_messages_per_second", 0)
+	v.SetDefault("kafka.consumer.missing_topics", "warn")
+	v.SetDefault("kafka.consumer.delivery_mode", "at_least_once")
+	v.SetDefault("kafka.consumer.commit_mode", "sync")
+	v.SetDefault("kafka.consumer.commit_interval", 5*time.Second)
+	v.SetDefault("kafka.consumer.commit_batch_size", 100)
+	v.SetDefault("kafka.consumer.future_skew_tolerance", 0)
+	v.SetDefault("kafka.consumer.start_from_time", "")
+	v.SetDefault("kafka.consumer.auto_offset_reset", "earliest")
+	v.SetDefault("kafka.consumer.shard.index", 0)
+	v.SetDefault("kafka.consumer.shard.total", 0)
+	v.SetDefault("kafka.consumer.retry.max_attempts", 1)
+	v.SetDefault("kafka.consumer.retry.backoff", time.Second)
+	v.SetDefault("kafka.consumer.retry.multiplier", 2)
+	v.SetDefault("kafka.consumer.retry.max_backoff", 30*time.Second)
+	v.SetDefault("kafka.consumer.workers", 0)
+	v.SetDefault("kafka.consumer.worker_queue_size", 100)
+	v.SetDefault("kafka.consumer.coordinator_timeout", time.Minute)
+	v.SetDefault("kafka.consumer.lag_interval", 0)
 
 	// Logger defaults
 	v.SetDefault("logger.level", "info")
 	v.SetDefault("logger.encoding", "json")
 	v.SetDefault("logger.output_path", "stdout")
+	v.SetDefault("logger.sampling.initial", 0)
+	v.SetDefault("logger.sampling.thereafter", 0)
+
+	// Orders defaults
+	v.SetDefault("orders.chunk_size", 0)
+	v.SetDefault("orders.chunk_ttl", 24*time.Hour)
+	v.SetDefault("orders.chunk_database", "")
+	v.SetDefault("orders.partition_by", "order")
+	v.SetDefault("orders.latency_ttl", time.Hour)
+	v.SetDefault("orders.snapshot_interval", 0)
+	v.SetDefault("orders.snapshot_retention", 7*24*time.Hour)
+	v.SetDefault("orders.idempotency_ttl", 24*time.Hour)
+
+	// Heartbeat defaults
+	v.SetDefault("heartbeat.interval", 0)
+
+	// Outbox defaults
+	v.SetDefault("outbox.enabled", false)
+	v.SetDefault("outbox.database", "order-service.db")
+	v.SetDefault("outbox.poll_interval", time.Second)
+	v.SetDefault("outbox.batch_size", 100)
+
+	// Admin defaults
+	v.SetDefault("admin.enabled", false)
+	v.SetDefault("admin.host", "127.0.0.1")
+	v.SetDefault("admin.port", 9091)
+
+	// Metrics defaults
+	v.SetDefault("metrics.sink", "")
+	v.SetDefault("metrics.statsd.address", "localhost:8125")
+	v.SetDefault("metrics.statsd.prefix", "go_eda")
 }
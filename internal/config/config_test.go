@@ -0,0 +1,263 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes a config file named name to dir and returns its path
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// unsetEnv unsets the environment variable name for the test; a variable set
+// to an empty value would count as a blank override
+func unsetEnv(t *testing.T, name string) {
+	t.Helper()
+	t.Setenv(name, "")
+	os.Unsetenv(name)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name        string
+		base        string
+		profile     string // config.dev.yaml, not written when empty
+		env         map[string]string
+		wantGroup   string
+		wantBrokers string
+	}{
+		{
+			name:        "defaults",
+			wantGroup:   "default-group",
+			wantBrokers: "localhost:9092",
+		},
+		{
+			name:        "base over defaults",
+			base:        "kafka:\n  group_id: base-group\n  brokers: [base:9092]\n",
+			wantGroup:   "base-group",
+			wantBrokers: "base:9092",
+		},
+		{
+			name:        "profile over base",
+			base:        "kafka:\n  group_id: base-group\n  brokers: [base:9092]\n",
+			profile:     "kafka:\n  group_id: dev-group\n",
+			env:         map[string]string{"APP_ENV": "dev"},
+			wantGroup:   "dev-group",
+			wantBrokers: "base:9092",
+		},
+		{
+			name:        "profile ignored without APP_ENV",
+			base:        "kafka:\n  group_id: base-group\n",
+			profile:     "kafka:\n  group_id: dev-group\n",
+			wantGroup:   "base-group",
+			wantBrokers: "localhost:9092",
+		},
+		{
+			name:        "missing profile file",
+			base:        "kafka:\n  group_id: base-group\n",
+			env:         map[string]string{"APP_ENV": "dev"},
+			wantGroup:   "base-group",
+			wantBrokers: "localhost:9092",
+		},
+		{
+			name:        "env over profile",
+			base:        "kafka:\n  group_id: base-group\n",
+			profile:     "kafka:\n  group_id: dev-group\n  brokers: [dev:9092]\n",
+			env:         map[string]string{"APP_ENV": "dev", "APP_KAFKA_GROUP_ID": "env-group"},
+			wantGroup:   "env-group",
+			wantBrokers: "dev:9092",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writeConfig(t, dir, "config.yaml", tt.base)
+			if tt.profile != "" {
+				writeConfig(t, dir, "config.dev.yaml", tt.profile)
+			}
+			unsetEnv(t, "APP_ENV")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Kafka.GroupID != tt.wantGroup {
+				t.Errorf("group ID %q, want %q", cfg.Kafka.GroupID, tt.wantGroup)
+			}
+			if brokers := strings.Join(cfg.Kafka.Brokers, ","); brokers != tt.wantBrokers {
+				t.Errorf("brokers %q, want %q", brokers, tt.wantBrokers)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsUnknownProfile(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config.yaml", "")
+	t.Setenv("APP_ENV", "qa")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "unknown APP_ENV") {
+		t.Errorf("got %v, want an unknown APP_ENV error", err)
+	}
+}
+
+// awaitChange returns the next configuration delivered to changes
+func awaitChange(t *testing.T, changes <-chan *Config) *Config {
+	t.Helper()
+	select {
+	case cfg := <-changes:
+		return cfg
+	case <-time.After(5 * time.Second):
+		t.Fatal("configuration was not reloaded")
+		return nil
+	}
+}
+
+// watchChanges loads path, which enables reload, and returns the
+// configuration and the valid configurations reloaded afterwards
+func watchChanges(t *testing.T, path string) (*Config, <-chan *Config) {
+	t.Helper()
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := make(chan *Config, 16)
+	cfg.OnChange(func(cfg *Config, err error) {
+		if err == nil {
+			changes <- cfg
+		}
+	})
+	return cfg, changes
+}
+
+// awaitGroup waits for a reloaded configuration with groupID; a single edit
+// may be reported more than once
+func awaitGroup(t *testing.T, changes <-chan *Config, groupID string) {
+	t.Helper()
+	for {
+		if cfg := awaitChange(t, changes); cfg.Kafka.GroupID == groupID {
+			return
+		}
+	}
+}
+
+func TestReloadWatchesTheProfileFile(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "config.yaml", "reload: true\nkafka:\n  group_id: base-group\n")
+	t.Setenv("APP_ENV", "dev")
+	_, changes := watchChanges(t, path)
+
+	// Creating the profile file, then changing it, reloads the configuration
+	writeConfig(t, dir, "config.dev.yaml", "kafka:\n  group_id: dev-group\n")
+	awaitGroup(t, changes, "dev-group")
+	writeConfig(t, dir, "config.dev.yaml", "kafka:\n  group_id: dev-group-2\n")
+	awaitGroup(t, changes, "dev-group-2")
+}
+
+func TestEmptyEnvPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		base        string
+		env         map[string]string
+		wantErr     string
+		wantBrokers string
+		wantTopic   string
+	}{
+		{
+			name:        "empty brokers fall back to the file",
+			base:        "kafka:\n  brokers: [base:9092]\n",
+			env:         map[string]string{"APP_KAFKA_BROKERS": ""},
+			wantBrokers: "base:9092",
+			wantTopic:   "order.created",
+		},
+		{
+			name:        "whitespace topic falls back to the default",
+			env:         map[string]string{"APP_KAFKA_TOPICS_ORDER_CREATED": "  "},
+			wantBrokers: "localhost:9092",
+			wantTopic:   "order.created",
+		},
+		{
+			name:    "error policy from the file",
+			base:    "empty_env: error\n",
+			env:     map[string]string{"APP_KAFKA_BROKERS": "", "APP_KAFKA_TOPICS_ORDER_CREATED": " "},
+			wantErr: "APP_KAFKA_BROKERS, APP_KAFKA_TOPICS_ORDER_CREATED",
+		},
+		{
+			name:    "error policy from the environment",
+			env:     map[string]string{"APP_EMPTY_ENV": "error", "APP_KAFKA_BROKERS": ""},
+			wantErr: "APP_KAFKA_BROKERS",
+		},
+		{
+			name:        "error policy without blank variables",
+			base:        "empty_env: error\n",
+			env:         map[string]string{"APP_KAFKA_BROKERS": "env:9092"},
+			wantBrokers: "env:9092",
+			wantTopic:   "order.created",
+		},
+		{
+			name:    "invalid policy",
+			env:     map[string]string{"APP_EMPTY_ENV": "drop"},
+			wantErr: `invalid empty_env policy "drop"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, t.TempDir(), "config.yaml", tt.base)
+			unsetEnv(t, "APP_ENV")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := Load(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %v, want an error naming %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if brokers := strings.Join(cfg.Kafka.Brokers, ","); brokers != tt.wantBrokers {
+				t.Errorf("brokers %q, want %q", brokers, tt.wantBrokers)
+			}
+			if topic := cfg.Kafka.Topics["order_created"]; topic != tt.wantTopic {
+				t.Errorf("order_created topic %q, want %q", topic, tt.wantTopic)
+			}
+		})
+	}
+}
+
+func TestProducerConfigDefaultsAndOverrides(t *testing.T) {
+	unsetEnv(t, "APP_ENV")
+	dir := t.TempDir()
+
+	cfg, err := Load(writeConfig(t, dir, "config.yaml", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ProducerConfig{Acks: "all", Retries: 3, CompressionType: "snappy", Linger: 5 * time.Millisecond, BatchSize: 16384}
+	if cfg.Kafka.Producer != want {
+		t.Errorf("default producer config %+v, want today's hardcoded values %+v", cfg.Kafka.Producer, want)
+	}
+
+	t.Setenv("APP_KAFKA_PRODUCER_ACKS", "1")
+	cfg, err = Load(writeConfig(t, dir, "config.yaml", "kafka:\n  producer:\n    retries: 0\n    compression_type: lz4\n    linger: 20ms\n    batch_size: 65536\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = ProducerConfig{Acks: "1", Retries: 0, CompressionType: "lz4", Linger: 20 * time.Millisecond, BatchSize: 65536}
+	if cfg.Kafka.Producer != want {
+		t.Errorf("producer config %+v, want %+v", cfg.Kafka.Producer, want)
+	}
+}
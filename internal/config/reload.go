@@ -0,0 +1,73 @@
+package config
+
+import (
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// ChangeFunc receives the configuration reloaded after the config file
+// changed, or the error that made it invalid; the previous configuration
+// then stays in effect
+type ChangeFunc func(cfg *Config, err error)
+
+type subscriber struct {
+	service string // "" for the shared configuration
+	fn      ChangeFunc
+}
+
+// watcher reloads the configuration when one of its config files changes
+type watcher struct {
+	configPath string
+
+	mu          sync.Mutex
+	subscribers []subscriber
+}
+
+// watchFiles starts watching the base and profile config files of
+// Load(configPath). A profile file that does not exist yet is reloaded from
+// once it is created.
+func watchFiles(configPath string, files []string) *watcher {
+	w := &watcher{configPath: configPath}
+
+	for _, file := range files {
+		v := viper.New()
+		v.SetConfigFile(file)
+		v.OnConfigChange(func(fsnotify.Event) { w.reload() })
+		v.WatchConfig()
+	}
+	return w
+}
+
+// OnChange registers fn to be called with the new configuration whenever the
+// base or profile config file changes. A configuration returned by
+// ForService delivers that service's configuration. It is a no-op unless
+// reload is enabled.
+func (c *Config) OnChange(fn ChangeFunc) {
+	if c.watcher == nil {
+		return
+	}
+	c.watcher.mu.Lock()
+	defer c.watcher.mu.Unlock()
+	c.watcher.subscribers = append(c.watcher.subscribers, subscriber{service: c.service, fn: fn})
+}
+
+// reload loads the configuration again, with the same precedence as Load,
+// and notifies the subscribers
+func (w *watcher) reload() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	cfg, _, err := load(w.configPath)
+	if cfg != nil {
+		cfg.watcher = w
+	}
+	for _, sub := range w.subscribers {
+		if err != nil || sub.service == "" {
+			sub.fn(cfg, err)
+			continue
+		}
+		sub.fn(cfg.ForService(sub.service))
+	}
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReloadNotifiesSubscribers(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "config.yaml", `
+reload: true
+logger:
+  level: info
+services:
+  inventory:
+    kafka:
+      group_id: inventory-group
+`)
+	unsetEnv(t, "APP_ENV")
+	shared, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inventory, err := shared.ForService("inventory")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type change struct {
+		cfg *Config
+		err error
+	}
+	sharedChanges := make(chan change, 16)
+	shared.OnChange(func(cfg *Config, err error) { sharedChanges <- change{cfg, err} })
+	inventoryChanges := make(chan change, 16)
+	inventory.OnChange(func(cfg *Config, err error) { inventoryChanges <- change{cfg, err} })
+
+	await := func(changes <-chan change, match func(change) bool) {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case c := <-changes:
+				if match(c) {
+					return
+				}
+			case <-timeout:
+				t.Fatal("subscriber was not notified")
+			}
+		}
+	}
+
+	writeConfig(t, dir, "config.yaml", `
+reload: true
+logger:
+  level: debug
+services:
+  inventory:
+    kafka:
+      group_id: inventory-group-2
+`)
+	await(sharedChanges, func(c change) bool {
+		return c.err == nil && c.cfg.Logger.Level == "debug" && c.cfg.Kafka.GroupID == "default-group"
+	})
+	await(inventoryChanges, func(c change) bool {
+		return c.err == nil && c.cfg.Logger.Level == "debug" && c.cfg.Kafka.GroupID == "inventory-group-2"
+	})
+
+	// An invalid change is reported; the subscriber keeps its configuration
+	writeConfig(t, dir, "config.yaml", "reload: true\nkafka:\n  brokers: []\n")
+	await(sharedChanges, func(c change) bool { return c.err != nil })
+	await(inventoryChanges, func(c change) bool { return c.err != nil })
+}
+
+func TestOnChangeIsANoOpWithoutReload(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "config.yaml", "logger:\n  level: info\n")
+	unsetEnv(t, "APP_ENV")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	called := make(chan struct{}, 1)
+	cfg.OnChange(func(*Config, error) { called <- struct{}{} })
+
+	writeConfig(t, dir, "config.yaml", "logger:\n  level: debug\n")
+	select {
+	case <-called:
+		t.Error("callback called although reload is disabled")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ForService returns the configuration of the named service: its section
+// under services (e.g. services.inventory) merged over the shared settings.
+// Nested keys are merged individually, so a section only lists what differs,
+// such as kafka.group_id. A service without a section gets the shared settings.
+func (c *Config) ForService(name string) (*Config, error) {
+	v := viper.New()
+	// MergeConfigMap merges into nested maps in place, so work on copies
+	if err := v.MergeConfigMap(copySettings(c.settings)); err != nil {
+		return nil, fmt.Errorf("unable to merge shared config: %w", err)
+	}
+	if overrides, ok := c.Services[strings.ToLower(name)]; ok {
+		if err := v.MergeConfigMap(copySettings(overrides)); err != nil {
+			return nil, fmt.Errorf("unable to merge %s service config: %w", name, err)
+		}
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("unable to decode %s service config: %w", name, err)
+	}
+	cfg.Services = c.Services
+	cfg.settings = c.settings
+	cfg.watcher = c.watcher
+	cfg.service = name
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s service: %w", name, err)
+	}
+	return &cfg, nil
+}
+
+// copySettings deep-copies the nested maps of a settings tree
+func copySettings(settings map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		if nested, ok := value.(map[string]interface{}); ok {
+			value = copySettings(nested)
+		}
+		copied[key] = value
+	}
+	return copied
+}
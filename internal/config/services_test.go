@@ -0,0 +1,89 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestForService(t *testing.T) {
+	base := `
+server:
+  port: 8080
+kafka:
+  brokers: [shared:9092]
+  group_id: shared-group
+  consumer:
+    commit_mode: async
+    handler_timeout: 30s
+services:
+  inventory:
+    kafka:
+      group_id: inventory-group
+      consumer:
+        commit_mode: batch
+  order:
+    server:
+      port: 9090
+  broken:
+    kafka:
+      brokers: []
+`
+	tests := []struct {
+		service        string
+		wantErr        bool
+		wantGroup      string
+		wantPort       int
+		wantCommitMode string
+	}{
+		// Nested keys merge individually: the handler timeout stays shared
+		{service: "inventory", wantGroup: "inventory-group", wantPort: 8080, wantCommitMode: "batch"},
+		{service: "Inventory", wantGroup: "inventory-group", wantPort: 8080, wantCommitMode: "batch"},
+		{service: "order", wantGroup: "shared-group", wantPort: 9090, wantCommitMode: "async"},
+		// Services without a section get the shared settings, including
+		// the default group of a service known to the defaults
+		{service: "billing", wantGroup: "shared-group", wantPort: 8080, wantCommitMode: "async"},
+		{service: "notification", wantGroup: "notification-service-group", wantPort: 8080, wantCommitMode: "async"},
+		{service: "broken", wantErr: true},
+	}
+
+	path := writeConfig(t, t.TempDir(), "config.yaml", base)
+	unsetEnv(t, "APP_ENV")
+	shared, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			cfg, err := shared.ForService(tt.service)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.service+" service") {
+					t.Fatalf("got %v, want an invalid %s service error", err, tt.service)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Kafka.GroupID != tt.wantGroup {
+				t.Errorf("group ID %q, want %q", cfg.Kafka.GroupID, tt.wantGroup)
+			}
+			if cfg.Server.Port != tt.wantPort {
+				t.Errorf("port %d, want %d", cfg.Server.Port, tt.wantPort)
+			}
+			if cfg.Kafka.Consumer.CommitMode != tt.wantCommitMode {
+				t.Errorf("commit mode %q, want %q", cfg.Kafka.Consumer.CommitMode, tt.wantCommitMode)
+			}
+			if cfg.Kafka.Consumer.HandlerTimeout.String() != "30s" {
+				t.Errorf("handler timeout %s, want the shared 30s", cfg.Kafka.Consumer.HandlerTimeout)
+			}
+			if strings.Join(cfg.Kafka.Brokers, ",") != "shared:9092" {
+				t.Errorf("brokers %v, want the shared ones", cfg.Kafka.Brokers)
+			}
+		})
+	}
+
+	// Merging works on copies, so the shared settings are left untouched
+	if again, err := shared.ForService("billing"); err != nil || again.Kafka.Consumer.CommitMode != "async" {
+		t.Errorf("shared settings changed by merging service sections: %v", err)
+	}
+}
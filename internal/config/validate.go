@@ -0,0 +1,90 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// securityProtocols are the Kafka security protocols librdkafka accepts
+var securityProtocols = []string{"PLAINTEXT", "SSL", "SASL_PLAINTEXT", "SASL_SSL"}
+
+// saslMechanisms are the SASL mechanisms librdkafka accepts
+var saslMechanisms = []string{"PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512", "OAUTHBEARER", "GSSAPI"}
+
+// requiredTopics are the kafka.topics keys the services look up
+var requiredTopics = []string{
+	"order_created",
+	"order_confirmed",
+	"order_rejected",
+	"order_status_changed",
+	"order_state",
+	"inventory_reserved",
+	"inventory_released",
+	"service_heartbeat",
+	"quarantine",
+}
+
+// Validate checks the Kafka connection settings and topics, so mistakes
+// fail at startup instead of surfacing later as Kafka errors. All problems
+// are reported together.
+func (c *Config) Validate() error {
+	var errs []error
+	k := c.Kafka
+
+	if len(k.Brokers) == 0 {
+		errs = append(errs, errors.New("kafka.brokers is empty"))
+	}
+	for _, broker := range k.Brokers {
+		if strings.TrimSpace(broker) == "" {
+			errs = append(errs, errors.New("kafka.brokers contains an empty address"))
+			break
+		}
+	}
+
+	protocol := strings.ToUpper(k.SecurityProtocol)
+	if !contains(securityProtocols, protocol) {
+		errs = append(errs, fmt.Errorf("invalid kafka.security_protocol %q: must be %s", k.SecurityProtocol, strings.Join(securityProtocols, ", ")))
+	}
+	if strings.HasPrefix(protocol, "SASL_") {
+		mechanism := strings.ToUpper(k.SASLMechanism)
+		switch {
+		case mechanism == "":
+			errs = append(errs, fmt.Errorf("kafka.sasl_mechanism is required with security protocol %s", k.SecurityProtocol))
+		case !contains(saslMechanisms, mechanism):
+			errs = append(errs, fmt.Errorf("invalid kafka.sasl_mechanism %q: must be %s", k.SASLMechanism, strings.Join(saslMechanisms, ", ")))
+		case mechanism == "PLAIN" || strings.HasPrefix(mechanism, "SCRAM-"):
+			if k.SASLUsername == "" || k.SASLPassword == "" {
+				errs = append(errs, fmt.Errorf("kafka.sasl_username and kafka.sasl_password are required with SASL mechanism %s", k.SASLMechanism))
+			}
+		}
+	}
+
+	if (k.SSLCertLocation == "") != (k.SSLKeyLocation == "") {
+		errs = append(errs, errors.New("kafka.ssl_cert_location and kafka.ssl_key_location must be set together"))
+	}
+
+	var missing []string
+	for _, key := range requiredTopics {
+		if strings.TrimSpace(k.Topics[key]) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		errs = append(errs, fmt.Errorf("kafka.topics missing %s", strings.Join(missing, ", ")))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
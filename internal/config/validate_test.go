@@ -0,0 +1,149 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// validConfig returns a configuration that passes Validate
+func validConfig() *Config {
+	topics := make(map[string]string)
+	for _, key := range requiredTopics {
+		topics[key] = strings.ReplaceAll(key, "_", ".")
+	}
+	return &Config{Kafka: KafkaConfig{
+		Brokers:          []string{"localhost:9092"},
+		SecurityProtocol: "PLAINTEXT",
+		Topics:           topics,
+	}}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(k *KafkaConfig)
+		wantErr []string // substrings of the error; none for a valid config
+	}{
+		{name: "valid", modify: func(k *KafkaConfig) {}},
+		{
+			name:    "no brokers",
+			modify:  func(k *KafkaConfig) { k.Brokers = nil },
+			wantErr: []string{"kafka.brokers is empty"},
+		},
+		{
+			name:    "blank broker",
+			modify:  func(k *KafkaConfig) { k.Brokers = []string{"localhost:9092", " "} },
+			wantErr: []string{"kafka.brokers contains an empty address"},
+		},
+		{
+			name:    "unknown security protocol",
+			modify:  func(k *KafkaConfig) { k.SecurityProtocol = "TLS" },
+			wantErr: []string{`invalid kafka.security_protocol "TLS"`},
+		},
+		{
+			name:   "lower case security protocol",
+			modify: func(k *KafkaConfig) { k.SecurityProtocol = "ssl" },
+		},
+		{
+			name:    "SASL without mechanism",
+			modify:  func(k *KafkaConfig) { k.SecurityProtocol = "SASL_SSL" },
+			wantErr: []string{"kafka.sasl_mechanism is required with security protocol SASL_SSL"},
+		},
+		{
+			name: "unknown SASL mechanism",
+			modify: func(k *KafkaConfig) {
+				k.SecurityProtocol = "SASL_PLAINTEXT"
+				k.SASLMechanism = "DIGEST-MD5"
+			},
+			wantErr: []string{`invalid kafka.sasl_mechanism "DIGEST-MD5"`},
+		},
+		{
+			name: "PLAIN without credentials",
+			modify: func(k *KafkaConfig) {
+				k.SecurityProtocol = "SASL_SSL"
+				k.SASLMechanism = "PLAIN"
+				k.SASLUsername = "user"
+			},
+			wantErr: []string{"kafka.sasl_username and kafka.sasl_password are required with SASL mechanism PLAIN"},
+		},
+		{
+			name: "SCRAM without credentials",
+			modify: func(k *KafkaConfig) {
+				k.SecurityProtocol = "SASL_SSL"
+				k.SASLMechanism = "SCRAM-SHA-512"
+			},
+			wantErr: []string{"required with SASL mechanism SCRAM-SHA-512"},
+		},
+		{
+			name: "SCRAM with credentials",
+			modify: func(k *KafkaConfig) {
+				k.SecurityProtocol = "SASL_SSL"
+				k.SASLMechanism = "SCRAM-SHA-256"
+				k.SASLUsername = "user"
+				k.SASLPassword = "secret"
+			},
+		},
+		{
+			name: "OAUTHBEARER needs no password",
+			modify: func(k *KafkaConfig) {
+				k.SecurityProtocol = "SASL_SSL"
+				k.SASLMechanism = "OAUTHBEARER"
+			},
+		},
+		{
+			name:    "certificate without key",
+			modify:  func(k *KafkaConfig) { k.SSLCertLocation = "client.pem" },
+			wantErr: []string{"kafka.ssl_cert_location and kafka.ssl_key_location must be set together"},
+		},
+		{
+			name: "missing topics",
+			modify: func(k *KafkaConfig) {
+				delete(k.Topics, "order_created")
+				k.Topics["quarantine"] = "  "
+			},
+			wantErr: []string{"kafka.topics missing order_created, quarantine"},
+		},
+		{
+			name: "all problems together",
+			modify: func(k *KafkaConfig) {
+				k.Brokers = nil
+				k.SecurityProtocol = "TLS"
+				delete(k.Topics, "order_state")
+			},
+			wantErr: []string{"kafka.brokers is empty", "invalid kafka.security_protocol", "kafka.topics missing order_state"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(&cfg.Kafka)
+
+			err := cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("valid configuration rejected: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("invalid configuration accepted")
+			}
+			if !strings.HasPrefix(err.Error(), "invalid configuration: ") {
+				t.Errorf("error %q does not say the configuration is invalid", err)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadValidates(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "config.yaml", "kafka:\n  security_protocol: TLS\n")
+	unsetEnv(t, "APP_ENV")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "invalid kafka.security_protocol") {
+		t.Errorf("got %v, want the validation error", err)
+	}
+}
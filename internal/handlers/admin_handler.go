@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// resubscribeTimeout bounds how long an admin request waits for in-flight work to drain
+const resubscribeTimeout = 2 * time.Minute
+
+// Resubscriber is a consumer that can leave and rejoin its group
+type Resubscriber interface {
+	Resubscribe(ctx context.Context) error
+}
+
+// Pauser is a consumer that can stop reading messages without leaving its group
+type Pauser interface {
+	Pause()
+	Resume()
+}
+
+// AdminConsumer is the consumer an admin server operates on
+type AdminConsumer interface {
+	Resubscriber
+	Pauser
+	HealthChecker
+}
+
+// AdminHandler serves operational endpoints of consumer services
+type AdminHandler struct {
+	consumer AdminConsumer
+	log      logger.Logger
+}
+
+// NewAdminHandler creates a new admin handler logging to log, or to the
+// global logger when log is nil
+func NewAdminHandler(consumer AdminConsumer, log logger.Logger) *AdminHandler {
+	if log == nil {
+		log = logger.Global()
+	}
+	return &AdminHandler{consumer: consumer, log: log}
+}
+
+// ResubscribeConsumer makes the consumer rejoin its group after draining in-flight work
+func (h *AdminHandler) ResubscribeConsumer(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), resubscribeTimeout)
+	defer cancel()
+
+	if err := h.consumer.Resubscribe(ctx); err != nil {
+		h.log.Error("Failed to resubscribe consumer",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "resubscribed",
+	})
+}
+
+// PauseConsumer stops the consumer reading messages, keeping its assignment
+func (h *AdminHandler) PauseConsumer(c *gin.Context) {
+	h.consumer.Pause()
+	c.JSON(http.StatusOK, gin.H{
+		"status": "paused",
+	})
+}
+
+// ResumeConsumer undoes PauseConsumer
+func (h *AdminHandler) ResumeConsumer(c *gin.Context) {
+	h.consumer.Resume()
+	c.JSON(http.StatusOK, gin.H{
+		"status": "resumed",
+	})
+}
+
+// logLevelRequest is the body of a log level change
+type logLevelRequest struct {
+	Level string `json:"level" binding:"required"`
+}
+
+// GetLogLevel returns the current log level
+func GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"level": logger.Level(),
+	})
+}
+
+// SetLogLevel changes the log level until the service restarts
+func SetLogLevel(c *gin.Context) {
+	var req logLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := logger.SetLevel(req.Level); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	logger.Info("Log level changed",
+		zap.String("level", logger.Level()),
+		zap.String("remote_addr", c.ClientIP()),
+	)
+	c.JSON(http.StatusOK, gin.H{
+		"level": logger.Level(),
+	})
+}
+
+// RegisterLogLevelRoutes mounts GET and PUT /admin/loglevel on router
+func RegisterLogLevelRoutes(router gin.IRouter) {
+	router.GET("/admin/loglevel", GetLogLevel)
+	router.PUT("/admin/loglevel", SetLogLevel)
+}
+
+// RegisterMetricsRoute serves GET /metrics for Prometheus when the prometheus
+// metrics sink is configured
+func RegisterMetricsRoute(router gin.IRouter) {
+	if handler := metrics.Handler(); handler != nil {
+		router.GET("/metrics", gin.WrapH(handler))
+	}
+}
+
+// NewAdminServer creates the admin HTTP server for a consumer service, whose
+// readiness is the consumer's, logging to log or to the global logger when
+// log is nil
+func NewAdminServer(cfg config.AdminConfig, consumer AdminConsumer, log logger.Logger) *http.Server {
+	h := NewAdminHandler(consumer, log)
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.POST("/admin/consumer/resubscribe", h.ResubscribeConsumer)
+	router.POST("/admin/consumer/pause", h.PauseConsumer)
+	router.POST("/admin/consumer/resume", h.ResumeConsumer)
+	RegisterLogLevelRoutes(router)
+	RegisterMetricsRoute(router)
+	RegisterHealthRoutes(router, map[string]HealthChecker{"kafka_consumer": consumer}, h.log)
+
+	return &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Handler:           router,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
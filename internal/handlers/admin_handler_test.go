@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// adminConsumerStub records the admin operations applied to it
+type adminConsumerStub struct {
+	resubscribeErr error
+	resubscribes   int
+	paused         bool
+}
+
+func (s *adminConsumerStub) Resubscribe(ctx context.Context) error {
+	s.resubscribes++
+	return s.resubscribeErr
+}
+
+func (s *adminConsumerStub) Pause()         { s.paused = true }
+func (s *adminConsumerStub) Resume()        { s.paused = false }
+func (s *adminConsumerStub) Healthy() error { return nil }
+
+func TestAdminServerResubscribesTheConsumer(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantLogged int
+	}{
+		{name: "rejoined", wantStatus: http.StatusOK},
+		{name: "failed", err: errors.New("failed to unsubscribe"), wantStatus: http.StatusInternalServerError, wantLogged: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumer := &adminConsumerStub{resubscribeErr: tt.err}
+			core, logs := observer.New(zap.InfoLevel)
+			server := NewAdminServer(config.AdminConfig{}, consumer, zap.New(core))
+
+			w := httptest.NewRecorder()
+			server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/consumer/resubscribe", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if consumer.resubscribes != 1 {
+				t.Errorf("consumer resubscribed %d times, want 1", consumer.resubscribes)
+			}
+			if n := logs.FilterMessage("Failed to resubscribe consumer").Len(); n != tt.wantLogged {
+				t.Errorf("logged %d failures to the injected logger, want %d", n, tt.wantLogged)
+			}
+		})
+	}
+}
+
+func TestLogLevelEndpoint(t *testing.T) {
+	if err := logger.SetLevel("info"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { logger.SetLevel("info") })
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterLogLevelRoutes(router)
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantLevel  string
+	}{
+		{name: "read", method: http.MethodGet, wantStatus: http.StatusOK, wantLevel: "info"},
+		{name: "change", method: http.MethodPut, body: `{"level": "debug"}`, wantStatus: http.StatusOK, wantLevel: "debug"},
+		{name: "read the change", method: http.MethodGet, wantStatus: http.StatusOK, wantLevel: "debug"},
+		{name: "unknown level", method: http.MethodPut, body: `{"level": "verbose"}`, wantStatus: http.StatusBadRequest, wantLevel: "debug"},
+		{name: "missing level", method: http.MethodPut, body: `{}`, wantStatus: http.StatusBadRequest, wantLevel: "debug"},
+		{name: "malformed body", method: http.MethodPut, body: `level=warn`, wantStatus: http.StatusBadRequest, wantLevel: "debug"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/admin/loglevel", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if logger.Level() != tt.wantLevel {
+				t.Errorf("level %s, want %s", logger.Level(), tt.wantLevel)
+			}
+			var body struct {
+				Level string `json:"level"`
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantStatus == http.StatusOK && body.Level != tt.wantLevel {
+				t.Errorf("responded with level %q, want %q", body.Level, tt.wantLevel)
+			}
+			if tt.wantStatus != http.StatusOK && body.Error == "" {
+				t.Errorf("responded %s, want an error message", w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/tanint/go-eda/internal/kafka"
+)
+
+// PropagateContext copies request values set on the gin context by earlier
+// middleware (c.Set) into the request context, so events published while
+// handling the request carry them as metadata. Without keys, the tenant,
+// principal and trace ID are propagated.
+func PropagateContext(keys ...kafka.ContextKey) gin.HandlerFunc {
+	if len(keys) == 0 {
+		keys = []kafka.ContextKey{kafka.ContextTenant, kafka.ContextPrincipal, kafka.ContextTrace}
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		for _, key := range keys {
+			if value := c.GetString(string(key)); value != "" {
+				ctx = kafka.WithContextValue(ctx, key, value)
+			}
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanint/go-eda/internal/kafka"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+func TestPropagateContextStampsMiddlewareValuesOnEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("tenant", "acme")
+		c.Set("principal", "user-1")
+		c.Set("region", "eu") // not propagated unless registered
+	})
+	router.Use(PropagateContext())
+
+	var published *events.Event
+	router.POST("/orders", func(c *gin.Context) {
+		published = events.NewEvent(events.EventTypeOrderCreated, nil)
+		kafka.StampContext(c.Request.Context(), published)
+		c.Status(http.StatusCreated)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", nil))
+
+	if published == nil {
+		t.Fatal("handler did not run")
+	}
+	want := map[string]string{"tenant": "acme", "principal": "user-1"}
+	for key, value := range want {
+		if got := published.Metadata[key]; got != value {
+			t.Errorf("metadata %s = %q, want %q", key, got, value)
+		}
+	}
+	for _, key := range []string{"region", string(kafka.ContextTrace)} {
+		if got, ok := published.Metadata[key]; ok {
+			t.Errorf("metadata %s = %q, want it unset", key, got)
+		}
+	}
+}
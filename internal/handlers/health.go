@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanint/go-eda/internal/logger"
+	"go.uber.org/zap"
+)
+
+// HealthChecker reports whether a dependency is usable, such as a Kafka
+// producer or consumer
+type HealthChecker interface {
+	Healthy() error
+}
+
+// Liveness reports that the process is up; it checks no dependencies, so an
+// unreachable broker does not get the service restarted
+func Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "alive",
+	})
+}
+
+// Readiness returns a handler reporting whether every check passes, with 503
+// Service Unavailable and the failures per check name otherwise. Failures are
+// logged to log, or to the global logger when log is nil.
+func Readiness(checks map[string]HealthChecker, log logger.Logger) gin.HandlerFunc {
+	if log == nil {
+		log = logger.Global()
+	}
+	return func(c *gin.Context) {
+		failures := make(map[string]string)
+		for name, check := range checks {
+			if err := check.Healthy(); err != nil {
+				failures[name] = err.Error()
+			}
+		}
+
+		if len(failures) > 0 {
+			log.Warn("Readiness check failed",
+				zap.Any("checks", failures),
+			)
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status": "unavailable",
+				"checks": failures,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status": "ready",
+		})
+	}
+}
+
+// RegisterHealthRoutes serves GET /health/live and GET /health/ready, logging
+// failed readiness checks to log
+func RegisterHealthRoutes(router gin.IRouter, checks map[string]HealthChecker, log logger.Logger) {
+	router.GET("/health/live", Liveness)
+	router.GET("/health/ready", Readiness(checks, log))
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// checkerStub is a dependency whose health is toggled by err
+type checkerStub struct {
+	err error
+}
+
+func (s *checkerStub) Healthy() error { return s.err }
+
+func TestReadinessReportsFailingChecks(t *testing.T) {
+	broker := &checkerStub{}
+	database := &checkerStub{}
+	core, logs := observer.New(zap.WarnLevel)
+	router := gin.New()
+	RegisterHealthRoutes(router, map[string]HealthChecker{"kafka_producer": broker, "database": database}, zap.New(core))
+
+	ready := func() (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, body
+	}
+
+	if code, body := ready(); code != http.StatusOK || body["status"] != "ready" {
+		t.Errorf("reachable broker: %d %v, want 200 ready", code, body)
+	}
+
+	broker.err = errors.New("failed to fetch cluster metadata: broker down")
+	code, body := ready()
+	checks, _ := body["checks"].(map[string]interface{})
+	if code != http.StatusServiceUnavailable || body["status"] != "unavailable" {
+		t.Errorf("unreachable broker: %d %v, want 503 unavailable", code, body)
+	}
+	if len(checks) != 1 || checks["kafka_producer"] != broker.err.Error() {
+		t.Errorf("checks %v, want only kafka_producer with its error", checks)
+	}
+	if entries := logs.FilterMessage("Readiness check failed").All(); len(entries) != 1 {
+		t.Errorf("logged %d readiness failures to the injected logger, want 1", len(entries))
+	}
+
+	broker.err = nil
+	if code, _ := ready(); code != http.StatusOK {
+		t.Errorf("broker back: %d, want 200", code)
+	}
+}
+
+func TestLivenessIgnoresDependencies(t *testing.T) {
+	router := gin.New()
+	RegisterHealthRoutes(router, map[string]HealthChecker{"kafka_producer": &checkerStub{err: errors.New("broker down")}}, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 while a dependency is down", w.Code)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader carries the client-chosen key that makes retries of
+// POST /orders return the original order instead of creating another one
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// scopedIdempotencyKey scopes key to a customer. The customer ID is length
+// prefixed, so IDs containing the separator cannot collide.
+func scopedIdempotencyKey(customerID, key string) string {
+	return strconv.Itoa(len(customerID)) + ":" + customerID + "/" + key
+}
+
+// IdempotencyStore maps idempotency keys to the IDs of the orders they
+// created. Implementations must be safe for concurrent use.
+type IdempotencyStore interface {
+	// Get returns the order ID stored for key, unless it expired
+	Get(ctx context.Context, key string) (orderID string, ok bool, err error)
+	// PutIfAbsent stores orderID for key unless the key is already taken, in
+	// which case it returns the stored order ID and false
+	PutIfAbsent(ctx context.Context, key, orderID string) (existing string, stored bool, err error)
+	// Delete releases key, so a retry of a request that failed can succeed
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryIdempotencyStore keeps keys in memory for a fixed TTL; they are lost
+// on restart
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	keys      map[string]idempotencyEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type idempotencyEntry struct {
+	orderID   string
+	expiresAt time.Time
+}
+
+// NewMemoryIdempotencyStore creates a store keeping keys for ttl
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		ttl:  ttl,
+		keys: make(map[string]idempotencyEntry),
+		now:  time.Now,
+	}
+}
+
+// Get returns the order ID stored for key unless it has expired
+func (s *MemoryIdempotencyStore) Get(ctx context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.keys[key]
+	if !ok || !s.now().Before(entry.expiresAt) {
+		return "", false, nil
+	}
+	return entry.orderID, true, nil
+}
+
+// PutIfAbsent stores orderID for key for the store's TTL unless an unexpired
+// entry exists
+func (s *MemoryIdempotencyStore) PutIfAbsent(ctx context.Context, key, orderID string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if entry, ok := s.keys[key]; ok && now.Before(entry.expiresAt) {
+		return entry.orderID, false, nil
+	}
+	s.keys[key] = idempotencyEntry{orderID: orderID, expiresAt: now.Add(s.ttl)}
+
+	// Drop expired keys at most once per TTL so the map doesn't grow unbounded
+	if now.Sub(s.lastSweep) >= s.ttl {
+		s.lastSweep = now
+		for k, entry := range s.keys {
+			if !now.Before(entry.expiresAt) {
+				delete(s.keys, k)
+			}
+		}
+	}
+	return orderID, true, nil
+}
+
+// Delete removes key
+func (s *MemoryIdempotencyStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, key)
+	return nil
+}
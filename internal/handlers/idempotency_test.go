@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/tanint/go-eda/internal/models"
+	"go.uber.org/zap"
+)
+
+// postIdempotentOrder creates validOrder for customerID under the idempotency
+// key and returns the response status and order ID
+func postIdempotentOrder(t *testing.T, h *OrderHandler, customerID, key string) (int, string) {
+	t.Helper()
+	body := `{"customer_id": "` + customerID + `", "items": [{"product_id": "p1", "quantity": 2, "price": 10}]}`
+	w := postOrder(h, body, map[string]string{IdempotencyKeyHeader: key})
+	var order models.Order
+	if w.Code == http.StatusOK || w.Code == http.StatusCreated {
+		if err := json.Unmarshal(w.Body.Bytes(), &order); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code, order.ID
+}
+
+func TestIdempotencyKeyReplaysTheOriginalOrder(t *testing.T) {
+	producer := &publishRecorder{}
+	h := NewOrderHandler(producer, testTopics, zap.NewNop())
+	h.SetIdempotencyStore(NewMemoryIdempotencyStore(time.Hour))
+
+	status, first := postIdempotentOrder(t, h, "customer-1", "key-1")
+	if status != http.StatusCreated || first == "" {
+		t.Fatalf("first request: status %d, order %q; want a created order", status, first)
+	}
+
+	status, replayed := postIdempotentOrder(t, h, "customer-1", "key-1")
+	if status != http.StatusOK || replayed != first {
+		t.Errorf("retry: status %d, order %q; want 200 with the original order %s", status, replayed, first)
+	}
+	if n := len(producer.events()); n != 1 {
+		t.Errorf("published %d events, want only the first request's order.created", n)
+	}
+
+	status, second := postIdempotentOrder(t, h, "customer-1", "key-2")
+	if status != http.StatusCreated || second == first {
+		t.Errorf("other key: status %d, order %q; want a new order", status, second)
+	}
+
+	// Keys are scoped to the customer, so customers cannot see each other's orders
+	status, other := postIdempotentOrder(t, h, "customer-2", "key-1")
+	if status != http.StatusCreated || other == first {
+		t.Errorf("other customer: status %d, order %q; want a new order", status, other)
+	}
+}
+
+func TestIdempotencyKeysExpire(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryIdempotencyStore(time.Hour)
+	store.now = func() time.Time { return now }
+	h := NewOrderHandler(&publishRecorder{}, testTopics, zap.NewNop())
+	h.SetIdempotencyStore(store)
+
+	_, first := postIdempotentOrder(t, h, "customer-1", "key-1")
+	now = now.Add(59 * time.Minute)
+	if _, replayed := postIdempotentOrder(t, h, "customer-1", "key-1"); replayed != first {
+		t.Errorf("got order %q within the TTL, want the original %s", replayed, first)
+	}
+
+	now = now.Add(time.Minute)
+	status, renewed := postIdempotentOrder(t, h, "customer-1", "key-1")
+	if status != http.StatusCreated || renewed == first {
+		t.Errorf("after the TTL: status %d, order %q; want a new order", status, renewed)
+	}
+}
+
+func TestFailedOrderReleasesItsIdempotencyKey(t *testing.T) {
+	producer := &publishRecorder{err: errors.New("broker unavailable")}
+	h := NewOrderHandler(producer, testTopics, zap.NewNop())
+	h.SetIdempotencyStore(NewMemoryIdempotencyStore(time.Hour))
+
+	if status, _ := postIdempotentOrder(t, h, "customer-1", "key-1"); status != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500 while the broker is down", status)
+	}
+	producer.err = nil
+	if status, order := postIdempotentOrder(t, h, "customer-1", "key-1"); status != http.StatusCreated || order == "" {
+		t.Errorf("retry: status %d, order %q; want the order created", status, order)
+	}
+}
+
+func TestScopedIdempotencyKeysDoNotCollide(t *testing.T) {
+	if scopedIdempotencyKey("a/b", "c") == scopedIdempotencyKey("a", "b/c") {
+		t.Error("customer a/b's key c collides with customer a's key b/c")
+	}
+}
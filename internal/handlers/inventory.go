@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/tanint/go-eda/internal/kafka"
+	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+// Inventory tracks the stock reserved for each order so it can be released
+// again (mock implementation: stock levels are not modelled)
+type Inventory struct {
+	mu           sync.Mutex
+	reservations map[string]reservation // by order ID
+}
+
+type reservation struct {
+	customerID string
+	items      []events.InventoryReservation
+}
+
+// NewInventory creates an inventory without reservations
+func NewInventory() *Inventory {
+	return &Inventory{reservations: make(map[string]reservation)}
+}
+
+// Reserve records the items reserved for the order, replacing any earlier reservation
+func (i *Inventory) Reserve(orderID, customerID string, items []events.InventoryReservation) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.reservations[orderID] = reservation{customerID: customerID, items: items}
+}
+
+// release removes and returns the order's reservation
+func (i *Inventory) release(orderID string) (reservation, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	r, ok := i.reservations[orderID]
+	delete(i.reservations, orderID)
+	return r, ok
+}
+
+// HandleOrderStatusChanged releases the inventory reserved for an order that
+// was cancelled or failed and publishes inventory.released, compensating the
+// earlier inventory.reserved, partitioned like the order's events by
+// partitionBy. Orders without a reservation are ignored.
+func HandleOrderStatusChanged(producer kafka.Publisher, topics map[string]string, partitionBy string, inventory *Inventory) kafka.TypedHandler[events.OrderStatusChangedEvent] {
+	return func(ctx context.Context, event *events.Event, changed events.OrderStatusChangedEvent) error {
+		if changed.To != models.OrderStatusCancelled && changed.To != models.OrderStatusFailed {
+			return nil
+		}
+
+		reserved, ok := inventory.release(changed.OrderID)
+		if !ok {
+			logger.FromContext(ctx).Debug("No inventory reserved for order",
+				zap.String("order_id", changed.OrderID),
+				zap.String("status", string(changed.To)),
+			)
+			return nil
+		}
+
+		// Return the items to stock (mock logic), then announce it
+		releasedEvent := events.NewChildEvent(event, events.EventTypeInventoryReleased, events.InventoryReleasedEvent{
+			OrderID:    changed.OrderID,
+			Items:      reserved.items,
+			Reason:     changed.To,
+			ReleasedAt: time.Now(),
+		})
+
+		topic := topics["inventory_released"]
+		if err := producer.PublishEventWithPartitionKey(ctx, topic, orderPartitionKey(partitionBy, reserved.customerID), []byte(changed.OrderID), releasedEvent); err != nil {
+			logger.FromContext(ctx).Error("Failed to publish inventory released event",
+				zap.Error(err),
+				zap.String("order_id", changed.OrderID),
+			)
+			// Keep the reservation so the retried event releases it
+			inventory.Reserve(changed.OrderID, reserved.customerID, reserved.items)
+			return err
+		}
+
+		logger.FromContext(ctx).Info("Inventory released",
+			zap.String("order_id", changed.OrderID),
+			zap.String("reason", string(changed.To)),
+		)
+		return nil
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// reservedOrder has inventory reserve a new two-item order and returns the
+// order.created event
+func reservedOrder(t *testing.T, producer *publishRecorder, inventory *Inventory) *events.Event {
+	t.Helper()
+	order := models.Order{
+		ID:         "order-1",
+		CustomerID: "customer-1",
+		Status:     models.OrderStatusPending,
+		Items: []models.OrderItem{
+			{ProductID: "p1", Quantity: 2, Price: 10},
+			{ProductID: "p2", Quantity: 1, Price: 5},
+		},
+		TotalPrice: 25,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	created := events.NewEvent(events.EventTypeOrderCreated, events.OrderCreatedEvent{Order: order})
+	handle := HandleOrderCreated(context.Background(), producer, testTopics, PartitionByOrder, inventory, nil)
+	if err := handle(context.Background(), eventMessage(t, created)); err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
+
+// changeStatus delivers the order's move to status to the inventory's
+// order.status_changed handler
+func changeStatus(t *testing.T, producer *publishRecorder, inventory *Inventory, created *events.Event, status models.OrderStatus) error {
+	t.Helper()
+	order, err := events.DecodeData[events.OrderCreatedEvent](created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transition, err := order.Order.TransitionTo(status, actorOrderService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := events.NewOrderStatusChangedEvent(created, transition)
+	data, err := events.DecodeData[events.OrderStatusChangedEvent](changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return HandleOrderStatusChanged(producer, testTopics, PartitionByOrder, inventory)(context.Background(), changed, data)
+}
+
+// released returns the inventory.released events published so far
+func released(t *testing.T, producer *publishRecorder) []events.InventoryReleasedEvent {
+	t.Helper()
+	var payloads []events.InventoryReleasedEvent
+	for _, p := range producer.events() {
+		if p.event.Type != events.EventTypeInventoryReleased {
+			continue
+		}
+		if p.topic != "inventory_released" || p.key != "order-1" {
+			t.Errorf("inventory.released published to %s with key %q, want inventory_released keyed by the order ID", p.topic, p.key)
+		}
+		payload, err := events.DecodeData[events.InventoryReleasedEvent](p.event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		payloads = append(payloads, payload)
+	}
+	return payloads
+}
+
+func TestOrderFailureReleasesItsInventory(t *testing.T) {
+	wantItems := []events.InventoryReservation{{ProductID: "p1", Quantity: 2}, {ProductID: "p2", Quantity: 1}}
+	for _, status := range []models.OrderStatus{models.OrderStatusCancelled, models.OrderStatusFailed} {
+		t.Run(string(status), func(t *testing.T) {
+			producer := &publishRecorder{}
+			inventory := NewInventory()
+			created := reservedOrder(t, producer, inventory)
+
+			if err := changeStatus(t, producer, inventory, created, status); err != nil {
+				t.Fatal(err)
+			}
+			payloads := released(t, producer)
+			if len(payloads) != 1 {
+				t.Fatalf("published %d inventory.released events, want 1", len(payloads))
+			}
+			if p := payloads[0]; p.OrderID != "order-1" || p.Reason != status || !slices.Equal(p.Items, wantItems) {
+				t.Errorf("released %s %v for %s, want order-1's %v for %s", p.OrderID, p.Items, p.Reason, wantItems, status)
+			}
+
+			// A redelivered status change finds nothing left to release
+			if err := changeStatus(t, producer, inventory, created, status); err != nil {
+				t.Fatal(err)
+			}
+			if n := len(released(t, producer)); n != 1 {
+				t.Errorf("released %d times, want once", n)
+			}
+		})
+	}
+}
+
+func TestConfirmedOrdersKeepTheirInventory(t *testing.T) {
+	producer := &publishRecorder{}
+	inventory := NewInventory()
+	created := reservedOrder(t, producer, inventory)
+
+	if err := changeStatus(t, producer, inventory, created, models.OrderStatusConfirmed); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(released(t, producer)); n != 0 {
+		t.Errorf("released %d times for a confirmed order, want none", n)
+	}
+}
+
+func TestFailedReleaseKeepsTheReservationForTheRetry(t *testing.T) {
+	producer := &publishRecorder{}
+	inventory := NewInventory()
+	created := reservedOrder(t, producer, inventory)
+
+	producer.err = errors.New("broker unavailable")
+	if err := changeStatus(t, producer, inventory, created, models.OrderStatusCancelled); err == nil {
+		t.Fatal("handled the cancellation without publishing inventory.released")
+	}
+	producer.err = nil
+	if err := changeStatus(t, producer, inventory, created, models.OrderStatusCancelled); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(released(t, producer)); n != 1 {
+		t.Errorf("released %d times after the retry, want once", n)
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+// eventPublisher is the part of kafka.Publisher a bulk import publishes with
+type eventPublisher interface {
+	PublishEventWithPartitionKey(ctx context.Context, topic, partitionKey string, key []byte, event *events.Event) error
+}
+
+// BatchPublisher buffers the events of a bulk import and publishes them in
+// batches; kafka.BatchPublisher implements it. Close flushes what is still
+// buffered and reports every event that could not be published.
+type BatchPublisher interface {
+	eventPublisher
+	Close(ctx context.Context) error
+}
+
+// SetBatchPublisher makes BulkCreateOrders publish through a batch publisher
+// from newBatch, one per import, instead of publishing events one by one
+func (h *OrderHandler) SetBatchPublisher(newBatch func() BatchPublisher) {
+	h.newBatch = newBatch
+}
+
+// bulkOrderError is the rejection of one order of a bulk import
+type bulkOrderError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// BulkCreateOrders imports many orders at once. Every order is validated
+// like CreateOrder before any is saved: if one is rejected, none is created
+// and the response lists the rejections by index. Idempotency keys are not
+// supported.
+func (h *OrderHandler) BulkCreateOrders(c *gin.Context) {
+	var req models.BulkCreateOrderRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error("Invalid request body",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	orders := make([]*models.Order, 0, len(req.Orders))
+	var rejected []bulkOrderError
+	for i, orderReq := range req.Orders {
+		order, status, err := h.prepareOrder(orderReq)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, gin.H{
+				"error": rejection(status, err),
+			})
+			return
+		}
+		if err != nil {
+			rejected = append(rejected, bulkOrderError{Index: i, Error: err.Error()})
+			continue
+		}
+		orders = append(orders, order)
+	}
+	if len(rejected) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Orders rejected",
+			"orders": rejected,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	topic := h.topics["order_created"]
+	var err error
+	if h.outbox != nil {
+		err = h.saveAllWithEvents(ctx, orders, topic)
+	} else {
+		err = h.saveAllAndPublish(ctx, orders, topic)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to process orders",
+		})
+		return
+	}
+
+	h.log.Info("Orders imported successfully",
+		zap.Int("orders", len(orders)),
+	)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"orders": orders,
+	})
+}
+
+// saveAllWithEvents writes each order and its events to the outbox, stopping
+// at the first failure. Orders saved before it stay saved; the relay
+// publishes their events.
+func (h *OrderHandler) saveAllWithEvents(ctx context.Context, orders []*models.Order, topic string) error {
+	for _, order := range orders {
+		if err := h.saveWithEvents(ctx, order, topic, h.orderCreatedEvents(order)); err != nil {
+			h.log.Error("Failed to save order",
+				zap.Error(err),
+				zap.String("order_id", order.ID),
+			)
+			return err
+		}
+	}
+	return nil
+}
+
+// saveAllAndPublish saves the orders and then publishes their events through
+// a batch publisher, if one is set. Delivery failures are not reported per
+// event, so if any fails every order of the import is marked failed.
+func (h *OrderHandler) saveAllAndPublish(ctx context.Context, orders []*models.Order, topic string) error {
+	for i, order := range orders {
+		if err := h.repo.Save(ctx, order); err != nil {
+			h.log.Error("Failed to save order",
+				zap.Error(err),
+				zap.String("order_id", order.ID),
+			)
+			for _, saved := range orders[:i] {
+				h.markUnpublished(ctx, saved)
+			}
+			return err
+		}
+	}
+
+	var publisher eventPublisher = h.producer
+	var batch BatchPublisher
+	if h.newBatch != nil {
+		batch = h.newBatch()
+		publisher = batch
+	}
+
+	err := func() error {
+		for _, order := range orders {
+			for _, event := range h.orderCreatedEvents(order) {
+				if err := publisher.PublishEventWithPartitionKey(ctx, topic, h.partitionKey(order), []byte(order.ID), event); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}()
+	if batch != nil {
+		if closeErr := batch.Close(ctx); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		h.log.Error("Failed to publish events",
+			zap.Error(err),
+			zap.String("topic", topic),
+		)
+		for _, order := range orders {
+			h.markUnpublished(ctx, order)
+		}
+		return err
+	}
+	return nil
+}
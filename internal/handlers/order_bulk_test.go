@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+// batchRecorder records the events of a bulk import instead of publishing them
+type batchRecorder struct {
+	keys   []string
+	events []*events.Event
+	closed bool
+}
+
+func (r *batchRecorder) PublishEventWithPartitionKey(ctx context.Context, topic, partitionKey string, key []byte, event *events.Event) error {
+	r.keys = append(r.keys, string(key))
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *batchRecorder) Close(ctx context.Context) error {
+	r.closed = true
+	return nil
+}
+
+func postBulk(h *OrderHandler, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/orders/bulk", h.BulkCreateOrders)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/orders/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestBulkCreateOrdersPublishesThroughBatch(t *testing.T) {
+	h := NewOrderHandler(nil, map[string]string{"order_created": "order.created"}, zap.NewNop())
+	batch := &batchRecorder{}
+	h.SetBatchPublisher(func() BatchPublisher { return batch })
+
+	w := postBulk(h, `{"orders": [
+		{"customer_id": "customer-1", "items": [{"product_id": "p1", "quantity": 1, "price": 10}]},
+		{"customer_id": "customer-2", "items": [{"product_id": "p2", "quantity": 2, "price": 5}]}
+	]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body)
+	}
+
+	var resp struct {
+		Orders []models.Order `json:"orders"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Orders) != 2 || len(batch.events) != 2 {
+		t.Fatalf("created %d orders and published %d events, want 2 of each", len(resp.Orders), len(batch.events))
+	}
+	for i, order := range resp.Orders {
+		if batch.keys[i] != order.ID {
+			t.Errorf("event %d keyed %q, want order ID %q", i, batch.keys[i], order.ID)
+		}
+		if _, err := h.repo.FindByID(context.Background(), order.ID); err != nil {
+			t.Errorf("order %s was not saved: %v", order.ID, err)
+		}
+	}
+	if !batch.closed {
+		t.Error("batch publisher was not closed, so buffered events may never be flushed")
+	}
+}
+
+func TestBulkCreateOrdersRejectsAllOnInvalidOrder(t *testing.T) {
+	h := NewOrderHandler(nil, map[string]string{"order_created": "order.created"}, zap.NewNop())
+	batch := &batchRecorder{}
+	h.SetBatchPublisher(func() BatchPublisher { return batch })
+
+	w := postBulk(h, `{"orders": [
+		{"customer_id": "customer-1", "items": [{"product_id": "p1", "quantity": 1, "price": 10}]},
+		{"customer_id": "customer-2", "items": [{"product_id": "p2", "quantity": 0, "price": 5}]}
+	]}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", w.Code, w.Body)
+	}
+
+	var resp struct {
+		Orders []bulkOrderError `json:"orders"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Orders) != 1 || resp.Orders[0].Index != 1 {
+		t.Errorf("rejections %+v, want only order 1", resp.Orders)
+	}
+	if len(batch.events) != 0 {
+		t.Errorf("published %d events for a rejected import, want none", len(batch.events))
+	}
+}
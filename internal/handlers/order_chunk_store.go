@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// Times are Unix milliseconds, so they compare as numbers
+const chunkStoreSchema = `
+CREATE TABLE IF NOT EXISTS order_chunks (
+	order_id    TEXT NOT NULL,
+	chunk_index INTEGER NOT NULL,
+	chunk_json  BLOB NOT NULL,
+	received_at INTEGER NOT NULL,
+	PRIMARY KEY (order_id, chunk_index)
+);
+CREATE TABLE IF NOT EXISTS reassembled_orders (
+	order_id     TEXT PRIMARY KEY,
+	completed_at INTEGER NOT NULL
+)`
+
+// SQLChunkStore keeps order chunks in a SQLite database, so orders being
+// reassembled survive restarts and rebalances
+type SQLChunkStore struct {
+	db *sql.DB
+}
+
+var _ ChunkStore = (*SQLChunkStore)(nil)
+
+// NewSQLChunkStore creates the chunk tables if needed. The caller opens db
+// with a SQLite driver.
+func NewSQLChunkStore(ctx context.Context, db *sql.DB) (*SQLChunkStore, error) {
+	if _, err := db.ExecContext(ctx, chunkStoreSchema); err != nil {
+		return nil, fmt.Errorf("failed to create order chunk tables: %w", err)
+	}
+	return &SQLChunkStore{db: db}, nil
+}
+
+// Chunks returns the order's chunks in index order
+func (s *SQLChunkStore) Chunks(ctx context.Context, orderID string) ([]events.OrderCreatedChunkEvent, bool, error) {
+	var completed int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM reassembled_orders WHERE order_id = ?`, orderID).Scan(&completed); err != nil {
+		return nil, false, fmt.Errorf("failed to query reassembled order: %w", err)
+	}
+	if completed > 0 {
+		return nil, true, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT chunk_json FROM order_chunks WHERE order_id = ? ORDER BY chunk_index`, orderID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query order chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []events.OrderCreatedChunkEvent
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, false, fmt.Errorf("failed to scan order chunk: %w", err)
+		}
+		var chunk events.OrderCreatedChunkEvent
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return nil, false, fmt.Errorf("failed to decode order chunk: %w", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("failed to query order chunks: %w", err)
+	}
+	return chunks, false, nil
+}
+
+// SaveChunk inserts the chunk unless its index is stored already
+func (s *SQLChunkStore) SaveChunk(ctx context.Context, chunk events.OrderCreatedChunkEvent, receivedAt time.Time) error {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order chunk: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO order_chunks (order_id, chunk_index, chunk_json, received_at)
+		VALUES (?, ?, ?, ?)`,
+		chunk.Order.ID, chunk.ChunkIndex, data, receivedAt.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save order chunk: %w", err)
+	}
+	return nil
+}
+
+// Complete replaces the order's chunks with a tombstone in one transaction
+func (s *SQLChunkStore) Complete(ctx context.Context, orderID string, completedAt time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM order_chunks WHERE order_id = ?`, orderID); err != nil {
+		return fmt.Errorf("failed to delete order chunks: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO reassembled_orders (order_id, completed_at) VALUES (?, ?)`,
+		orderID, completedAt.UnixMilli(),
+	); err != nil {
+		return fmt.Errorf("failed to save reassembled order: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Expire drops orders started, and tombstones left, before cutoff
+func (s *SQLChunkStore) Expire(ctx context.Context, cutoff time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM order_chunks WHERE order_id IN (
+			SELECT order_id FROM order_chunks GROUP BY order_id HAVING MIN(received_at) < ?
+		)`, cutoff.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to expire order chunks: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM reassembled_orders WHERE completed_at < ?`, cutoff.UnixMilli()); err != nil {
+		return fmt.Errorf("failed to expire reassembled orders: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// ErrOrderReassembled reports a chunk of an order that was already
+// reassembled and processed, e.g. one redelivered after a restart
+var ErrOrderReassembled = errors.New("order already reassembled")
+
+// ChunkStore keeps the chunks of orders still being reassembled, and a
+// tombstone of each completed order. Chunk offsets are committed as soon as
+// the chunk is stored, so the store must outlive the process for orders to
+// survive a restart or rebalance. Implementations must be safe for
+// concurrent use.
+type ChunkStore interface {
+	// Chunks returns the chunks stored for the order in index order, and
+	// whether it was completed
+	Chunks(ctx context.Context, orderID string) (chunks []events.OrderCreatedChunkEvent, completed bool, err error)
+	// SaveChunk stores a chunk received at receivedAt, ignoring duplicates
+	SaveChunk(ctx context.Context, chunk events.OrderCreatedChunkEvent, receivedAt time.Time) error
+	// Complete drops the order's chunks and keeps its tombstone
+	Complete(ctx context.Context, orderID string, completedAt time.Time) error
+	// Expire drops incomplete orders whose first chunk was received, and
+	// tombstones of orders completed, before cutoff
+	Expire(ctx context.Context, cutoff time.Time) error
+}
+
+// OrderChunkReassembler rebuilds orders published as order.created.chunk
+// events. Orders still missing chunks after the TTL are dropped, and so are
+// the tombstones that make redelivered chunks of completed orders ignored.
+type OrderChunkReassembler struct {
+	store ChunkStore
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewOrderChunkReassembler creates a reassembler keeping its chunks in store
+// for ttl; 0 keeps them until the order completes, and its tombstone forever
+func NewOrderChunkReassembler(store ChunkStore, ttl time.Duration) *OrderChunkReassembler {
+	return &OrderChunkReassembler{
+		store: store,
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+// Add records a chunk and returns the complete order once all of its chunks
+// have arrived, or nil while chunks are still missing. Redelivered chunks are
+// ignored; those of a completed order fail with ErrOrderReassembled. Call
+// Complete once the returned order has been processed.
+func (r *OrderChunkReassembler) Add(ctx context.Context, chunk events.OrderCreatedChunkEvent) (*models.Order, error) {
+	id := chunk.Order.ID
+	if chunk.ChunkTotal < 1 || chunk.ChunkIndex < 0 || chunk.ChunkIndex >= chunk.ChunkTotal {
+		return nil, fmt.Errorf("order %s: invalid chunk %d of %d", id, chunk.ChunkIndex, chunk.ChunkTotal)
+	}
+	if err := r.sweep(ctx); err != nil {
+		return nil, err
+	}
+
+	chunks, completed, err := r.store.Chunks(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("order %s: failed to load chunks: %w", id, err)
+	}
+	if completed {
+		return nil, fmt.Errorf("order %s: %w", id, ErrOrderReassembled)
+	}
+	if len(chunks) > 0 && chunks[0].ChunkTotal != chunk.ChunkTotal {
+		return nil, fmt.Errorf("order %s: chunk total changed from %d to %d", id, chunks[0].ChunkTotal, chunk.ChunkTotal)
+	}
+
+	if !hasChunk(chunks, chunk.ChunkIndex) {
+		if err := r.store.SaveChunk(ctx, chunk, r.now()); err != nil {
+			return nil, fmt.Errorf("order %s: failed to save chunk %d: %w", id, chunk.ChunkIndex, err)
+		}
+		chunks = append(chunks, chunk)
+		sort.Slice(chunks, func(i, j int) bool { return chunks[i].ChunkIndex < chunks[j].ChunkIndex })
+	}
+	if len(chunks) < chunk.ChunkTotal {
+		return nil, nil
+	}
+
+	order := chunks[0].Order
+	order.Items = nil
+	for _, c := range chunks {
+		order.Items = append(order.Items, c.Order.Items...)
+	}
+	return &order, nil
+}
+
+// Complete drops the chunks of a reassembled order, so its redelivered
+// chunks are ignored until the TTL passes
+func (r *OrderChunkReassembler) Complete(ctx context.Context, orderID string) error {
+	if err := r.store.Complete(ctx, orderID, r.now()); err != nil {
+		return fmt.Errorf("order %s: failed to complete chunks: %w", orderID, err)
+	}
+	return nil
+}
+
+// sweep expires old orders from the store at most once per TTL
+func (r *OrderChunkReassembler) sweep(ctx context.Context) error {
+	if r.ttl <= 0 {
+		return nil
+	}
+	now := r.now()
+	r.mu.Lock()
+	if now.Sub(r.lastSweep) < r.ttl {
+		r.mu.Unlock()
+		return nil
+	}
+	r.lastSweep = now
+	r.mu.Unlock()
+
+	if err := r.store.Expire(ctx, now.Add(-r.ttl)); err != nil {
+		return fmt.Errorf("failed to expire order chunks: %w", err)
+	}
+	return nil
+}
+
+func hasChunk(chunks []events.OrderCreatedChunkEvent, index int) bool {
+	for _, c := range chunks {
+		if c.ChunkIndex == index {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryChunkStore keeps chunks in memory; they are lost on restart
+type MemoryChunkStore struct {
+	mu     sync.Mutex
+	orders map[string]*chunkedOrder
+}
+
+type chunkedOrder struct {
+	chunks      map[int]events.OrderCreatedChunkEvent
+	receivedAt  time.Time // of the first chunk
+	completedAt time.Time // zero until completed
+}
+
+// NewMemoryChunkStore creates an empty store
+func NewMemoryChunkStore() *MemoryChunkStore {
+	return &MemoryChunkStore{
+		orders: make(map[string]*chunkedOrder),
+	}
+}
+
+// Chunks returns the order's chunks in index order
+func (s *MemoryChunkStore) Chunks(ctx context.Context, orderID string) ([]events.OrderCreatedChunkEvent, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[orderID]
+	if !ok {
+		return nil, false, nil
+	}
+	chunks := make([]events.OrderCreatedChunkEvent, 0, len(order.chunks))
+	for _, c := range order.chunks {
+		chunks = append(chunks, c)
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].ChunkIndex < chunks[j].ChunkIndex })
+	return chunks, !order.completedAt.IsZero(), nil
+}
+
+// SaveChunk stores the chunk unless its index is stored already or its
+// order was completed
+func (s *MemoryChunkStore) SaveChunk(ctx context.Context, chunk events.OrderCreatedChunkEvent, receivedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[chunk.Order.ID]
+	if !ok {
+		order = &chunkedOrder{chunks: make(map[int]events.OrderCreatedChunkEvent), receivedAt: receivedAt}
+		s.orders[chunk.Order.ID] = order
+	}
+	if _, ok := order.chunks[chunk.ChunkIndex]; !ok && order.completedAt.IsZero() {
+		order.chunks[chunk.ChunkIndex] = chunk
+	}
+	return nil
+}
+
+// Complete replaces the order's chunks with a tombstone
+func (s *MemoryChunkStore) Complete(ctx context.Context, orderID string, completedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders[orderID] = &chunkedOrder{completedAt: completedAt}
+	return nil
+}
+
+// Expire drops orders started, and tombstones left, before cutoff
+func (s *MemoryChunkStore) Expire(ctx context.Context, cutoff time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, order := range s.orders {
+		since := order.receivedAt
+		if !order.completedAt.IsZero() {
+			since = order.completedAt
+		}
+		if since.Before(cutoff) {
+			delete(s.orders, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tanint/go-eda/internal/kafka"
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+func TestChunkedOrderIsReassembledByInventory(t *testing.T) {
+	producer := &publishRecorder{}
+	h := NewOrderHandler(producer, testTopics, zap.NewNop())
+	h.SetChunkSize(2)
+
+	items := make([]string, 5)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"product_id": "p%d", "quantity": %d, "price": 10}`, i+1, i+1)
+	}
+	body := `{"customer_id": "customer-1", "items": [` + strings.Join(items, ",") + `]}`
+	if w := postOrder(h, body, nil); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body)
+	}
+
+	chunks := producer.events()
+	if len(chunks) != 3 {
+		t.Fatalf("published %d events, want 3 chunks of at most 2 items", len(chunks))
+	}
+	var orderID string
+	for i, chunk := range chunks {
+		payload, err := events.DecodeData[events.OrderCreatedChunkEvent](chunk.event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chunk.event.Type != events.EventTypeOrderCreatedChunk || payload.ChunkIndex != i || payload.ChunkTotal != 3 {
+			t.Errorf("event %d is %s chunk %d of %d, want order.created.chunk %d of 3", i, chunk.event.Type, payload.ChunkIndex, payload.ChunkTotal, i)
+		}
+		if i == 0 {
+			orderID = payload.Order.ID
+		}
+		if payload.Order.ID != orderID || chunk.key != orderID {
+			t.Errorf("chunk %d of order %s keyed %s, want every chunk of order %s", i, payload.Order.ID, chunk.key, orderID)
+		}
+	}
+
+	// Delivered out of order, with a redelivery
+	inventory := &publishRecorder{}
+	handle := HandleOrderCreated(context.Background(), inventory, testTopics, PartitionByOrder, nil, nil)
+	for _, i := range []int{2, 0, 0, 1} {
+		if err := handle(context.Background(), eventMessage(t, chunks[i].event)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reserved := inventory.events()
+	if len(reserved) != 1 || reserved[0].event.Type != events.EventTypeInventoryReserved {
+		t.Fatalf("published %d events, want one inventory.reserved once the last chunk arrived", len(reserved))
+	}
+	payload, err := events.DecodeData[events.InventoryReservedEvent](reserved[0].event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, item := range payload.Items {
+		got = append(got, fmt.Sprintf("%s x%d", item.ProductID, item.Quantity))
+	}
+	want := []string{"p1 x1", "p2 x2", "p3 x3", "p4 x4", "p5 x5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reserved %v, want the original items %v", got, want)
+	}
+}
+
+// orderChunk returns chunk index of total of order-1, holding one item named after the index
+func orderChunk(index, total int) events.OrderCreatedChunkEvent {
+	return events.OrderCreatedChunkEvent{
+		Order: models.Order{
+			ID:         "order-1",
+			CustomerID: "customer-1",
+			Items:      []models.OrderItem{{ProductID: fmt.Sprintf("p%d", index+1), Quantity: 1, Price: 10}},
+		},
+		ChunkIndex: index,
+		ChunkTotal: total,
+	}
+}
+
+// chunkMessage returns an order.created.chunk message of the chunk
+func chunkMessage(t *testing.T, chunk events.OrderCreatedChunkEvent) *kafka.Message {
+	t.Helper()
+	return eventMessage(t, events.NewEvent(events.EventTypeOrderCreatedChunk, chunk))
+}
+
+// openChunkStore opens the SQLite chunk store at path, as a restarted service would
+func openChunkStore(t *testing.T, path string) (*SQLChunkStore, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store, err := NewSQLChunkStore(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, db
+}
+
+func TestOrderChunkReassemblerRejectsInvalidChunks(t *testing.T) {
+	ctx := context.Background()
+	r := NewOrderChunkReassembler(NewMemoryChunkStore(), time.Hour)
+	for _, c := range []events.OrderCreatedChunkEvent{orderChunk(2, 2), orderChunk(-1, 2), orderChunk(0, 0)} {
+		if _, err := r.Add(ctx, c); err == nil {
+			t.Errorf("accepted chunk %d of %d", c.ChunkIndex, c.ChunkTotal)
+		}
+	}
+
+	if order, err := r.Add(ctx, orderChunk(0, 2)); order != nil || err != nil {
+		t.Fatalf("Add() = %v, %v, want to wait for the second chunk", order, err)
+	}
+	if _, err := r.Add(ctx, orderChunk(1, 3)); err == nil {
+		t.Error("accepted a chunk whose total changed")
+	}
+}
+
+func TestChunksRedeliveredAfterCompletionAreIgnored(t *testing.T) {
+	stores := map[string]ChunkStore{"memory": NewMemoryChunkStore()}
+	stores["sql"], _ = openChunkStore(t, filepath.Join(t.TempDir(), "chunks.db"))
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			inventory := &publishRecorder{}
+			handle := HandleOrderCreated(context.Background(), inventory, testTopics, PartitionByOrder, nil, NewOrderChunkReassembler(store, time.Hour))
+			// The whole order, then its first chunk again as after a rebalance
+			for _, i := range []int{0, 1, 0} {
+				if err := handle(context.Background(), chunkMessage(t, orderChunk(i, 2))); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if reserved := inventory.events(); len(reserved) != 1 {
+				t.Errorf("published %d events, want the order reserved once", len(reserved))
+			}
+			chunks, completed, err := store.Chunks(context.Background(), "order-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !completed || len(chunks) != 0 {
+				t.Errorf("store holds %d chunks, completed %v, want only the order's tombstone", len(chunks), completed)
+			}
+		})
+	}
+}
+
+func TestChunkedOrderIsReassembledAfterARestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chunks.db")
+	store, db := openChunkStore(t, path)
+	handle := HandleOrderCreated(context.Background(), &publishRecorder{}, testTopics, PartitionByOrder, nil, NewOrderChunkReassembler(store, time.Hour))
+	for _, i := range []int{0, 2} {
+		if err := handle(context.Background(), chunkMessage(t, orderChunk(i, 3))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	// The chunks' offsets were committed; only the last one is delivered again
+	store, _ = openChunkStore(t, path)
+	inventory := &publishRecorder{}
+	handle = HandleOrderCreated(context.Background(), inventory, testTopics, PartitionByOrder, nil, NewOrderChunkReassembler(store, time.Hour))
+	if err := handle(context.Background(), chunkMessage(t, orderChunk(1, 3))); err != nil {
+		t.Fatal(err)
+	}
+
+	reserved := inventory.events()
+	if len(reserved) != 1 {
+		t.Fatalf("published %d events, want the order reserved after the restart", len(reserved))
+	}
+	payload, err := events.DecodeData[events.InventoryReservedEvent](reserved[0].event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, item := range payload.Items {
+		got = append(got, item.ProductID)
+	}
+	if want := []string{"p1", "p2", "p3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reserved %v, want %v", got, want)
+	}
+}
+
+func TestFailedProcessingKeepsTheChunksForTheRetry(t *testing.T) {
+	store := NewMemoryChunkStore()
+	producer := &publishRecorder{err: errors.New("broker unavailable")}
+	handle := HandleOrderCreated(context.Background(), producer, testTopics, PartitionByOrder, nil, NewOrderChunkReassembler(store, time.Hour))
+
+	if err := handle(context.Background(), chunkMessage(t, orderChunk(0, 1))); err == nil {
+		t.Fatal("handled the order although its reservation was not published")
+	}
+	chunks, completed, err := store.Chunks(context.Background(), "order-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completed || len(chunks) != 1 {
+		t.Errorf("store holds %d chunks, completed %v, want the chunk kept for the retry", len(chunks), completed)
+	}
+}
+
+func TestOrderChunkReassemblerExpiresOldOrders(t *testing.T) {
+	stores := map[string]ChunkStore{"memory": NewMemoryChunkStore()}
+	stores["sql"], _ = openChunkStore(t, filepath.Join(t.TempDir(), "chunks.db"))
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			r := NewOrderChunkReassembler(store, time.Hour)
+			r.now = func() time.Time { return now }
+
+			incomplete := orderChunk(0, 2)
+			incomplete.Order.ID = "order-incomplete"
+			if _, err := r.Add(ctx, incomplete); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := r.Add(ctx, orderChunk(0, 1)); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Complete(ctx, "order-1"); err != nil {
+				t.Fatal(err)
+			}
+
+			now = now.Add(2 * time.Hour)
+			other := orderChunk(0, 2)
+			other.Order.ID = "order-other"
+			if _, err := r.Add(ctx, other); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, id := range []string{"order-incomplete", "order-1"} {
+				chunks, completed, err := store.Chunks(ctx, id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if completed || len(chunks) != 0 {
+					t.Errorf("%s: %d chunks, completed %v after the TTL, want it forgotten", id, len(chunks), completed)
+				}
+			}
+			if chunks, _, _ := store.Chunks(ctx, "order-other"); len(chunks) != 1 {
+				t.Errorf("order-other has %d chunks, want the new order kept", len(chunks))
+			}
+		})
+	}
+}
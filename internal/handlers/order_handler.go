@@ -2,29 +2,138 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/tanint/go-eda/internal/kafka"
 	"github.com/tanint/go-eda/internal/logger"
 	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/internal/outbox"
 	"github.com/tanint/go-eda/pkg/events"
 	"go.uber.org/zap"
 )
 
 // OrderHandler handles order-related HTTP requests
 type OrderHandler struct {
-	producer *kafka.Producer
-	topics   map[string]string
+	producer         kafka.Publisher
+	topics           map[string]string
+	productValidator models.ProductValidator
+	prices           models.PriceProvider
+	chunkSize        int
+	converter        models.CurrencyConverter
+	baseCurrency     string
+	partitionBy      string
+	outbox           outbox.Outbox
+	orders           OrderStore
+	repo             OrderRepository
+	idempotency      IdempotencyStore
+	newBatch         func() BatchPublisher
+	log              logger.Logger
 }
 
-// NewOrderHandler creates a new order handler
-func NewOrderHandler(producer *kafka.Producer, topics map[string]string) *OrderHandler {
+// Partition key strategies of created orders
+const (
+	// PartitionByCustomer keeps all of a customer's orders on one partition
+	PartitionByCustomer = "customer"
+	// PartitionByOrder spreads orders evenly, ordering only each order's own events
+	PartitionByOrder = "order"
+)
+
+// NewOrderHandler creates a new order handler logging to log, or to the
+// global logger when log is nil
+func NewOrderHandler(producer kafka.Publisher, topics map[string]string, log logger.Logger) *OrderHandler {
+	if log == nil {
+		log = logger.Global()
+	}
 	return &OrderHandler{
-		producer: producer,
-		topics:   topics,
+		producer:    producer,
+		topics:      topics,
+		partitionBy: PartitionByOrder,
+		repo:        NewMemoryOrderRepository(),
+		prices:      models.NoopPriceProvider{},
+		log:         log,
+	}
+}
+
+// SetOrderRepository replaces the in-memory repository that created orders
+// are saved to and GetOrderStatus reads from
+func (h *OrderHandler) SetOrderRepository(repo OrderRepository) {
+	h.repo = repo
+}
+
+// SetOutbox makes CreateOrder write the order (through store, if not nil)
+// and its events to the outbox in one transaction instead of publishing the
+// events directly; a relay publishes them afterwards
+func (h *OrderHandler) SetOutbox(box outbox.Outbox, store OrderStore) {
+	h.outbox = box
+	h.orders = store
+}
+
+// SetPartitionBy selects how CreateOrder partitions order events: by order
+// (the default, empty strategy) or by customer
+func (h *OrderHandler) SetPartitionBy(strategy string) error {
+	switch strategy {
+	case "", PartitionByOrder:
+		h.partitionBy = PartitionByOrder
+	case PartitionByCustomer:
+		h.partitionBy = PartitionByCustomer
+	default:
+		return fmt.Errorf("invalid order partition strategy %q: must be customer or order", strategy)
+	}
+	return nil
+}
+
+// partitionKey is the partition key of the order's events
+func (h *OrderHandler) partitionKey(order *models.Order) string {
+	return orderPartitionKey(h.partitionBy, order.CustomerID)
+}
+
+// orderPartitionKey is the partition key of every event of a customer's order
+// under the partition strategy, so all of them land on one partition. An
+// empty key partitions by the message key, the order ID.
+func orderPartitionKey(partitionBy, customerID string) string {
+	if partitionBy != PartitionByCustomer {
+		return ""
 	}
+	return customerID
+}
+
+// SetProductValidator makes CreateOrder reject orders for unknown products
+// before any event is published
+func (h *OrderHandler) SetProductValidator(v models.ProductValidator) {
+	h.productValidator = v
+}
+
+// SetPriceProvider makes CreateOrder reject orders whose item prices differ
+// from the provider's, instead of trusting the client-supplied prices
+func (h *OrderHandler) SetPriceProvider(p models.PriceProvider) {
+	h.prices = p
+}
+
+// SetCurrencyConverter makes CreateOrder stamp each order with its total in
+// the base currency. Orders in currencies the converter can't convert are rejected.
+func (h *OrderHandler) SetCurrencyConverter(c models.CurrencyConverter, base string) {
+	h.converter = c
+	h.baseCurrency = strings.ToUpper(base)
+}
+
+// SetIdempotencyStore makes CreateOrder honor the Idempotency-Key header:
+// a retry with the key of an order the customer already created returns that
+// order with 200 instead of creating another one. Keys are scoped to the
+// customer. Without a store the header is ignored.
+func (h *OrderHandler) SetIdempotencyStore(s IdempotencyStore) {
+	h.idempotency = s
+}
+
+// SetChunkSize makes CreateOrder split orders with more than size items into
+// order.created.chunk events of at most size items each. 0 disables chunking.
+func (h *OrderHandler) SetChunkSize(size int) {
+	h.chunkSize = size
 }
 
 // CreateOrder handles order creation requests
@@ -32,7 +141,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var req models.CreateOrderRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Error("Invalid request body",
+		h.log.Error("Invalid request body",
 			zap.Error(err),
 		)
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -41,39 +150,206 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	var idempotencyKey string
+	if key := c.GetHeader(IdempotencyKeyHeader); key != "" && h.idempotency != nil {
+		idempotencyKey = scopedIdempotencyKey(req.CustomerID, key)
+		orderID, ok, err := h.idempotency.Get(c.Request.Context(), idempotencyKey)
+		if err != nil {
+			h.log.Error("Failed to look up idempotency key",
+				zap.Error(err),
+			)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to process order",
+			})
+			return
+		}
+		if ok {
+			h.replayOrder(c, orderID)
+			return
+		}
+	}
+
 	// Create order
+	order, status, err := h.prepareOrder(req)
+	if err != nil {
+		c.JSON(status, gin.H{
+			"error": rejection(status, err),
+		})
+		return
+	}
+
+	if idempotencyKey != "" {
+		// Claimed only now, so requests rejected above can be retried with the
+		// same key; a concurrent duplicate that got here first wins
+		existing, stored, err := h.idempotency.PutIfAbsent(c.Request.Context(), idempotencyKey, order.ID)
+		if err != nil {
+			h.log.Error("Failed to store idempotency key",
+				zap.Error(err),
+			)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to process order",
+			})
+			return
+		}
+		if !stored {
+			h.replayOrder(c, existing)
+			return
+		}
+		defer func() {
+			if c.Writer.Status() != http.StatusCreated {
+				h.releaseIdempotencyKey(c.Request.Context(), idempotencyKey)
+			}
+		}()
+	}
+
+	// Publish order created event keyed by order ID, or on the customer's
+	// partition so all of the customer's events stay ordered across
+	// co-partitioned topics
+	orderEvents := h.orderCreatedEvents(order)
+
+	topic := h.topics["order_created"]
+	if h.outbox != nil {
+		if err := h.saveWithEvents(c.Request.Context(), order, topic, orderEvents); err != nil {
+			h.log.Error("Failed to save order",
+				zap.Error(err),
+				zap.String("order_id", order.ID),
+			)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to process order",
+			})
+			return
+		}
+	} else {
+		if err := h.repo.Save(c.Request.Context(), order); err != nil {
+			h.log.Error("Failed to save order",
+				zap.Error(err),
+				zap.String("order_id", order.ID),
+			)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to process order",
+			})
+			return
+		}
+		for _, event := range orderEvents {
+			if err := h.producer.PublishEventWithPartitionKey(c.Request.Context(), topic, h.partitionKey(order), []byte(order.ID), event); err != nil {
+				h.log.Error("Failed to publish event",
+					zap.Error(err),
+					zap.String("topic", topic),
+				)
+				h.markUnpublished(c.Request.Context(), order)
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to process order",
+				})
+				return
+			}
+		}
+	}
+
+	h.log.Info("Order created successfully",
+		zap.String("order_id", order.ID),
+		zap.String("customer_id", order.CustomerID),
+		zap.Float64("total_price", order.TotalPrice),
+	)
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// prepareOrder creates the order of req and validates its products, prices
+// and currency. Rejected orders come with 400, internal failures with 500;
+// see rejection. Nothing is published for either: the order was never
+// accepted, so there is no order to reject downstream.
+func (h *OrderHandler) prepareOrder(req models.CreateOrderRequest) (*models.Order, int, error) {
 	order, err := models.NewOrder(req)
 	if err != nil {
-		logger.Error("Failed to create order",
+		h.log.Error("Failed to create order",
 			zap.Error(err),
 		)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
-		return
+		return nil, http.StatusBadRequest, err
 	}
 
-	// Publish order created event
-	event := events.NewEvent(events.EventTypeOrderCreated, events.OrderCreatedEvent{
+	if h.productValidator != nil {
+		if err := order.ValidateProducts(h.productValidator); err != nil {
+			h.log.Warn("Order rejected: unknown product",
+				zap.Error(err),
+				zap.String("customer_id", order.CustomerID),
+			)
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	if err := order.ValidatePrices(h.prices); err != nil {
+		h.log.Warn("Order rejected: price mismatch",
+			zap.Error(err),
+			zap.String("customer_id", order.CustomerID),
+		)
+		return nil, http.StatusBadRequest, err
+	}
+
+	if h.converter != nil {
+		if err := order.ConvertTotal(h.converter, h.baseCurrency); err != nil {
+			var unknown *models.UnknownCurrencyPairError
+			if !errors.As(err, &unknown) {
+				h.log.Error("Failed to convert order total",
+					zap.Error(err),
+				)
+				return nil, http.StatusInternalServerError, err
+			}
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	return order, http.StatusOK, nil
+}
+
+// rejection is what the client is told about an order prepareOrder failed
+// with status: the reason it was rejected, but not internal errors
+func rejection(status int, err error) string {
+	if status == http.StatusInternalServerError {
+		return "Failed to process order"
+	}
+	return err.Error()
+}
+
+// orderCreatedEvents returns the order.created event of the order, or its
+// order.created.chunk events when it has more than chunkSize items
+func (h *OrderHandler) orderCreatedEvents(order *models.Order) []*events.Event {
+	if h.chunkSize > 0 && len(order.Items) > h.chunkSize {
+		return events.NewOrderCreatedChunks(*order, h.chunkSize)
+	}
+	return []*events.Event{events.NewEvent(events.EventTypeOrderCreated, events.OrderCreatedEvent{
 		Order: *order,
-	})
+	})}
+}
 
-	eventData, err := event.Marshal()
+// markUnpublished marks an order whose events could not be published failed
+// and announces the transition
+func (h *OrderHandler) markUnpublished(ctx context.Context, order *models.Order) {
+	transition, err := h.repo.UpdateStatus(ctx, order.ID, models.OrderStatusFailed, actorOrderService)
 	if err != nil {
-		logger.Error("Failed to marshal event",
+		h.log.Warn("Failed to mark unpublished order failed",
 			zap.Error(err),
+			zap.String("order_id", order.ID),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to process order",
-		})
 		return
 	}
+	// Best effort, the broker just failed; errors are logged
+	_ = publishStatusChanged(ctx, h.producer, h.topics, h.partitionBy, nil, order, transition)
+}
 
-	topic := h.topics["order_created"]
-	if err := h.producer.Publish(c.Request.Context(), topic, []byte(order.ID), eventData); err != nil {
-		logger.Error("Failed to publish event",
+// replayOrder answers a retried request with the order its idempotency key
+// created. The key is claimed before the order is saved, so a duplicate
+// racing the original request can find no order yet.
+func (h *OrderHandler) replayOrder(c *gin.Context, orderID string) {
+	order, err := h.repo.FindByID(c.Request.Context(), orderID)
+	if errors.Is(err, models.ErrOrderNotFound) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "A request with this idempotency key is still being processed",
+		})
+		return
+	}
+	if err != nil {
+		h.log.Error("Failed to load order for idempotent replay",
 			zap.Error(err),
-			zap.String("topic", topic),
+			zap.String("order_id", orderID),
 		)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to process order",
@@ -81,103 +357,335 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	logger.Info("Order created successfully",
+	h.log.Info("Returning order of a repeated idempotency key",
 		zap.String("order_id", order.ID),
-		zap.String("customer_id", order.CustomerID),
-		zap.Float64("total_price", order.TotalPrice),
 	)
+	c.JSON(http.StatusOK, order)
+}
 
-	c.JSON(http.StatusCreated, order)
+// releaseIdempotencyKey frees the key of an order that could not be created
+func (h *OrderHandler) releaseIdempotencyKey(ctx context.Context, key string) {
+	if err := h.idempotency.Delete(ctx, key); err != nil {
+		h.log.Warn("Failed to release idempotency key",
+			zap.Error(err),
+		)
+	}
 }
 
-// GetOrderStatus handles order status requests (mock implementation)
+// saveWithEvents writes the order and its events to the outbox in one
+// transaction. Request context values are stamped on the events now, since
+// the relay publishes them without the request context.
+func (h *OrderHandler) saveWithEvents(ctx context.Context, order *models.Order, topic string, orderEvents []*events.Event) error {
+	tx, err := h.outbox.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if h.orders != nil {
+		if err := h.orders.SaveOrder(ctx, tx, order); err != nil {
+			return err
+		}
+	}
+	for _, event := range orderEvents {
+		kafka.StampContext(ctx, event)
+		msg := outbox.Message{Topic: topic, PartitionKey: h.partitionKey(order), Key: []byte(order.ID), Event: event}
+		if err := h.outbox.Enqueue(ctx, tx, msg); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if h.orders == nil {
+		return h.repo.Save(ctx, order)
+	}
+	return nil
+}
+
+// Page sizes of ListOrders
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// ListOrders returns a page of orders, newest first, optionally only a
+// customer's or those in a status, with the total number of matching orders
+func (h *OrderHandler) ListOrders(c *gin.Context) {
+	filter := OrderFilter{
+		CustomerID: c.Query("customer_id"),
+		Status:     models.OrderStatus(c.Query("status")),
+		Limit:      defaultListLimit,
+	}
+
+	if filter.Status != "" && !filter.Status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid status %q", filter.Status),
+		})
+		return
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid limit %q: must be between 1 and %d", raw, maxListLimit),
+			})
+			return
+		}
+		filter.Limit = limit
+	}
+	if raw := c.Query("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid offset %q: must be a non-negative integer", raw),
+			})
+			return
+		}
+		filter.Offset = offset
+	}
+
+	orders, total, err := h.repo.List(c.Request.Context(), filter)
+	if err != nil {
+		h.log.Error("Failed to list orders",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list orders",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orders": orders,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
+// GetOrderStatus handles order status requests
 func (h *OrderHandler) GetOrderStatus(c *gin.Context) {
 	orderID := c.Param("id")
 
-	// In a real application, you would fetch this from a database
+	order, err := h.repo.FindByID(c.Request.Context(), orderID)
+	if errors.Is(err, models.ErrOrderNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		h.log.Error("Failed to find order",
+			zap.Error(err),
+			zap.String("order_id", orderID),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get order",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"order_id": orderID,
-		"status":   "pending",
-		"message":  "This is a mock response. In production, implement database lookup.",
+		"order_id":   order.ID,
+		"status":     order.Status,
+		"updated_at": order.UpdatedAt,
 	})
 }
 
 // HealthCheck returns the health status of the service
 func (h *OrderHandler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status": "healthy",
+		"status":  "healthy",
 		"service": "order-service",
 	})
 }
 
-// HandleOrderCreated handles order created events (for inventory service)
-func HandleOrderCreated(ctx context.Context, producer *kafka.Producer, topics map[string]string) func(context.Context, *kafka.Message) error {
+// defaultChunkTTL is how long HandleOrderCreated keeps chunks of incomplete
+// orders when no reassembler is given
+const defaultChunkTTL = 24 * time.Hour
+
+// HandleOrderCreated handles order created events (for inventory service).
+// Chunked orders are reassembled by chunks and processed once their last
+// chunk arrives; without a reassembler their chunks are kept in memory.
+// Reservations are recorded in inventory, if not nil, so they can be released.
+// The events it publishes are partitioned like the order's, by partitionBy.
+func HandleOrderCreated(ctx context.Context, producer kafka.Publisher, topics map[string]string, partitionBy string, inventory *Inventory, chunks *OrderChunkReassembler) func(context.Context, *kafka.Message) error {
+	if chunks == nil {
+		chunks = NewOrderChunkReassembler(NewMemoryChunkStore(), defaultChunkTTL)
+	}
+
 	return func(ctx context.Context, msg *kafka.Message) error {
-		var event events.Event
-		if err := json.Unmarshal(msg.Value, &event); err != nil {
-			logger.Error("Failed to unmarshal event",
+		decoder := kafka.DecoderFromContext(ctx)
+		event, err := decoder.UnmarshalEvent(msg.Value)
+		if err != nil {
+			logger.FromContext(ctx).Error("Failed to unmarshal event",
 				zap.Error(err),
 			)
 			return err
 		}
 
-		// Parse the event data
-		eventDataJSON, err := json.Marshal(event.Data)
-		if err != nil {
-			logger.Error("Failed to marshal event data",
-				zap.Error(err),
-			)
-			return err
+		if event.Type == events.EventTypeOrderCreatedChunk {
+			chunk, err := events.DecodeDataWith[events.OrderCreatedChunkEvent](decoder, event)
+			if err != nil {
+				logger.FromContext(ctx).Error("Failed to unmarshal order created chunk event",
+					zap.Error(err),
+				)
+				return err
+			}
+
+			order, err := chunks.Add(ctx, chunk)
+			if errors.Is(err, ErrOrderReassembled) {
+				logger.FromContext(ctx).Debug("Ignoring chunk of a reassembled order",
+					zap.String("order_id", chunk.Order.ID),
+					zap.Int("chunk_index", chunk.ChunkIndex),
+				)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if order == nil {
+				logger.FromContext(ctx).Debug("Waiting for remaining order chunks",
+					zap.String("order_id", chunk.Order.ID),
+					zap.Int("chunk_index", chunk.ChunkIndex),
+					zap.Int("chunk_total", chunk.ChunkTotal),
+				)
+				return nil
+			}
+
+			// Complete only once processed, so a failure retries with all chunks
+			if err := reserveOrder(ctx, producer, topics, partitionBy, inventory, event, order); err != nil {
+				return err
+			}
+			return chunks.Complete(ctx, order.ID)
 		}
 
-		var orderCreated events.OrderCreatedEvent
-		if err := json.Unmarshal(eventDataJSON, &orderCreated); err != nil {
-			logger.Error("Failed to unmarshal order created event",
+		orderCreated, err := events.DecodeDataWith[events.OrderCreatedEvent](decoder, event)
+		if err != nil {
+			logger.FromContext(ctx).Error("Failed to unmarshal order created event",
 				zap.Error(err),
 			)
 			return err
 		}
+		return reserveOrder(ctx, producer, topics, partitionBy, inventory, event, &orderCreated.Order)
+	}
+}
 
-		logger.Info("Processing order created event",
-			zap.String("order_id", orderCreated.Order.ID),
-			zap.String("customer_id", orderCreated.Order.CustomerID),
+// reserveOrder reserves the items of a created order, or rejects it, in
+// reply to event
+func reserveOrder(ctx context.Context, producer kafka.Publisher, topics map[string]string, partitionBy string, inventory *Inventory, event *events.Event, order *models.Order) error {
+	logger.FromContext(ctx).Info("Processing order created event",
+		zap.String("order_id", order.ID),
+		zap.String("customer_id", order.CustomerID),
+	)
+
+	if reason := validateReservation(*order); reason != "" {
+		logger.FromContext(ctx).Warn("Order rejected by inventory",
+			zap.String("order_id", order.ID),
+			zap.String("reason", reason),
 		)
+		return publishOrderRejected(ctx, producer, topics, partitionBy, event, order, reason, actorInventoryService)
+	}
 
-		// Reserve inventory (mock logic)
-		reservations := make([]events.InventoryReservation, len(orderCreated.Order.Items))
-		for i, item := range orderCreated.Order.Items {
-			reservations[i] = events.InventoryReservation{
-				ProductID: item.ProductID,
-				Quantity:  item.Quantity,
-			}
+	// Reserve inventory (mock logic)
+	reservations := make([]events.InventoryReservation, len(order.Items))
+	for i, item := range order.Items {
+		reservations[i] = events.InventoryReservation{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
 		}
+	}
 
-		// Publish inventory reserved event
-		inventoryEvent := events.NewEvent(events.EventTypeInventoryReserved, events.InventoryReservedEvent{
-			OrderID: orderCreated.Order.ID,
-			Items:   reservations,
-		})
+	// Publish inventory reserved event
+	inventoryEvent := events.NewChildEvent(event, events.EventTypeInventoryReserved, events.InventoryReservedEvent{
+		OrderID: order.ID,
+		Items:   reservations,
+	})
 
-		inventoryData, err := inventoryEvent.Marshal()
-		if err != nil {
-			logger.Error("Failed to marshal inventory event",
-				zap.Error(err),
-			)
-			return err
-		}
+	topic := topics["inventory_reserved"]
+	if err := producer.PublishEventWithPartitionKey(ctx, topic, orderPartitionKey(partitionBy, order.CustomerID), []byte(order.ID), inventoryEvent); err != nil {
+		logger.FromContext(ctx).Error("Failed to publish inventory event",
+			zap.Error(err),
+		)
+		return err
+	}
 
-		topic := topics["inventory_reserved"]
-		if err := producer.Publish(ctx, topic, []byte(orderCreated.Order.ID), inventoryData); err != nil {
-			logger.Error("Failed to publish inventory event",
-				zap.Error(err),
-			)
-			return err
+	if inventory != nil {
+		inventory.Reserve(order.ID, order.CustomerID, reservations)
+	}
+
+	logger.FromContext(ctx).Info("Inventory reserved successfully",
+		zap.String("order_id", order.ID),
+	)
+
+	return nil
+}
+
+// validateReservation returns why the order's items cannot be reserved, or an empty string
+func validateReservation(order models.Order) string {
+	if len(order.Items) == 0 {
+		return "order has no items"
+	}
+	for _, item := range order.Items {
+		if err := item.Validate(); err != nil {
+			return err.Error()
 		}
+	}
+	return ""
+}
+
+// Actors recorded on the status changes made by the handlers
+const (
+	actorOrderService     = "order-service"
+	actorInventoryService = "inventory-service"
+)
 
-		logger.Info("Inventory reserved successfully",
-			zap.String("order_id", orderCreated.Order.ID),
+// publishOrderRejected publishes an order rejected event so the rejection
+// leaves an async trail, then moves the order to failed and publishes the
+// status change for the audit trail. Both events are children of cause, the
+// event that led to the rejection, if any.
+func publishOrderRejected(ctx context.Context, producer kafka.Publisher, topics map[string]string, partitionBy string, cause *events.Event, order *models.Order, reason, actor string) error {
+	transition, transitionErr := order.TransitionTo(models.OrderStatusFailed, actor)
+
+	event := events.NewChildEvent(cause, events.EventTypeOrderRejected, events.OrderRejectedEvent{
+		OrderID:    order.ID,
+		CustomerID: order.CustomerID,
+		Reason:     reason,
+		RejectedAt: time.Now(),
+	})
+
+	topic := topics["order_rejected"]
+	if err := producer.PublishEventWithPartitionKey(ctx, topic, orderPartitionKey(partitionBy, order.CustomerID), []byte(order.ID), event); err != nil {
+		logger.FromContext(ctx).Error("Failed to publish order rejected event",
+			zap.Error(err),
+			zap.String("topic", topic),
+			zap.String("order_id", order.ID),
 		)
+		return err
+	}
 
+	if transitionErr != nil {
+		logger.FromContext(ctx).Warn("Rejected order not moved to failed",
+			zap.Error(transitionErr),
+			zap.String("order_id", order.ID),
+		)
 		return nil
 	}
+	return publishStatusChanged(ctx, producer, topics, partitionBy, cause, order, transition)
+}
+
+// publishStatusChanged publishes the audit record of an order status transition
+func publishStatusChanged(ctx context.Context, producer kafka.Publisher, topics map[string]string, partitionBy string, cause *events.Event, order *models.Order, transition models.StatusTransition) error {
+	topic := topics["order_status_changed"]
+	event := events.NewOrderStatusChangedEvent(cause, transition)
+	if err := producer.PublishEventWithPartitionKey(ctx, topic, orderPartitionKey(partitionBy, order.CustomerID), []byte(order.ID), event); err != nil {
+		logger.FromContext(ctx).Error("Failed to publish order status changed event",
+			zap.Error(err),
+			zap.String("topic", topic),
+			zap.String("order_id", order.ID),
+		)
+		return err
+	}
+	return nil
 }
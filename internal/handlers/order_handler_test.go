@@ -0,0 +1,495 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanint/go-eda/internal/kafka"
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// publishedEvent is an event recorded by publishRecorder
+type publishedEvent struct {
+	topic        string
+	partitionKey string
+	key          string
+	event        *events.Event
+}
+
+// publishRecorder records published events instead of sending them to Kafka
+type publishRecorder struct {
+	mu        sync.Mutex
+	published []publishedEvent
+	err       error // returned by every publish when set
+}
+
+var _ kafka.Publisher = (*publishRecorder)(nil)
+
+func (r *publishRecorder) Publish(ctx context.Context, topic string, key, value []byte) error {
+	return errors.New("publishRecorder: raw publish not supported")
+}
+
+func (r *publishRecorder) PublishEvent(ctx context.Context, topic string, key []byte, event *events.Event) error {
+	return r.PublishEventWithPartitionKey(ctx, topic, "", key, event)
+}
+
+func (r *publishRecorder) PublishEventWithPartitionKey(ctx context.Context, topic, partitionKey string, key []byte, event *events.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.published = append(r.published, publishedEvent{topic: topic, partitionKey: partitionKey, key: string(key), event: event})
+	return nil
+}
+
+// events returns the events published so far
+func (r *publishRecorder) events() []publishedEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]publishedEvent(nil), r.published...)
+}
+
+// testTopics maps every topic key the handlers look up to a topic of the same name
+var testTopics = map[string]string{
+	"order_created":        "order_created",
+	"order_rejected":       "order_rejected",
+	"order_status_changed": "order_status_changed",
+	"inventory_reserved":   "inventory_reserved",
+	"inventory_released":   "inventory_released",
+}
+
+// postOrder sends a CreateOrder request with body to h
+func postOrder(h *OrderHandler, body string, headers map[string]string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/orders", h.CreateOrder)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// eventMessage returns the Kafka message carrying event
+func eventMessage(t *testing.T, event *events.Event) *kafka.Message {
+	t.Helper()
+	value, err := event.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &kafka.Message{Value: value}
+}
+
+const validOrder = `{"customer_id": "customer-1", "items": [{"product_id": "p1", "quantity": 2, "price": 10}]}`
+
+func TestEveryEventOfAnOrderUsesThePartitionKeyOfItsCreation(t *testing.T) {
+	for _, strategy := range []string{PartitionByOrder, PartitionByCustomer} {
+		t.Run(strategy, func(t *testing.T) {
+			producer := &publishRecorder{}
+			h := NewOrderHandler(producer, testTopics, zap.NewNop())
+			if err := h.SetPartitionBy(strategy); err != nil {
+				t.Fatal(err)
+			}
+
+			if w := postOrder(h, validOrder, nil); w.Code != http.StatusCreated {
+				t.Fatalf("status = %d, want 201: %s", w.Code, w.Body)
+			}
+			created := producer.events()[0]
+
+			// Inventory reserves it, then releases it once the order fails
+			inventory := NewInventory()
+			handle := HandleOrderCreated(context.Background(), producer, testTopics, strategy, inventory, nil)
+			if err := handle(context.Background(), eventMessage(t, created.event)); err != nil {
+				t.Fatal(err)
+			}
+			order, err := events.DecodeData[events.OrderCreatedEvent](created.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rejected := order.Order
+			transition, err := order.Order.TransitionTo(models.OrderStatusFailed, actorOrderService)
+			if err != nil {
+				t.Fatal(err)
+			}
+			changed := events.NewOrderStatusChangedEvent(created.event, transition)
+			data, err := events.DecodeData[events.OrderStatusChangedEvent](changed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := HandleOrderStatusChanged(producer, testTopics, strategy, inventory)(context.Background(), changed, data); err != nil {
+				t.Fatal(err)
+			}
+
+			// The same order, rejected by inventory instead
+			rejected.Items = nil
+			if err := publishOrderRejected(context.Background(), producer, testTopics, strategy, created.event, &rejected, "order has no items", actorInventoryService); err != nil {
+				t.Fatal(err)
+			}
+
+			published := producer.events()
+			if len(published) != 5 {
+				t.Fatalf("published %d events, want order.created, inventory.reserved, inventory.released, order.rejected and order.status_changed", len(published))
+			}
+			for _, p := range published {
+				if p.partitionKey != created.partitionKey || p.key != created.key {
+					t.Errorf("%s keyed (%q, %q), want the order.created event's (%q, %q)",
+						p.event.Type, p.partitionKey, p.key, created.partitionKey, created.key)
+				}
+			}
+			if strategy == PartitionByCustomer && created.partitionKey != "customer-1" {
+				t.Errorf("order.created partition key %q, want the customer ID", created.partitionKey)
+			}
+		})
+	}
+}
+
+func TestCreateOrderLogsPublishFailuresToItsLogger(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	producer := &publishRecorder{err: errors.New("broker unavailable")}
+	h := NewOrderHandler(producer, testTopics, zap.New(core))
+
+	if w := postOrder(h, validOrder, nil); w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500: %s", w.Code, w.Body)
+	}
+
+	entries := logs.FilterMessage("Failed to publish event").All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d publish failures, want 1: %v", len(entries), logs.All())
+	}
+	fields := entries[0].ContextMap()
+	if fields["error"] != "broker unavailable" || fields["topic"] != "order_created" {
+		t.Errorf("logged fields %v, want the error and topic", fields)
+	}
+	if n := logs.FilterMessage("Order created successfully").Len(); n != 0 {
+		t.Errorf("logged %d successes for a failed order", n)
+	}
+}
+
+func TestCreateOrderValidatesProductsBeforePublishing(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{name: "known products", body: validOrder, wantStatus: http.StatusCreated},
+		{
+			name:       "unknown product",
+			body:       `{"customer_id": "customer-1", "items": [{"product_id": "p1", "quantity": 1, "price": 10}, {"product_id": "p9", "quantity": 1, "price": 10}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "p9",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &publishRecorder{}
+			h := NewOrderHandler(producer, testTopics, zap.NewNop())
+			h.SetProductValidator(models.NewInMemoryProductValidator("p1", "p2"))
+
+			w := postOrder(h, tt.body, nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			published := len(producer.events())
+			if tt.wantError == "" {
+				if published != 1 {
+					t.Errorf("published %d events, want the order.created event", published)
+				}
+				return
+			}
+			if published != 0 {
+				t.Errorf("published %d events for a rejected order", published)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantError) {
+				t.Errorf("body %s does not name the unknown product %s", w.Body, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestInventoryRejectionPublishesOrderRejected(t *testing.T) {
+	tests := []struct {
+		name       string
+		items      []models.OrderItem
+		wantReason string
+	}{
+		{name: "no items", wantReason: "order has no items"},
+		{
+			name:       "invalid quantity",
+			items:      []models.OrderItem{{ProductID: "p1", Quantity: 0, Price: 10}},
+			wantReason: models.ErrInvalidQuantity.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &publishRecorder{}
+			order := models.Order{ID: "order-1", CustomerID: "customer-1", Items: tt.items, Status: models.OrderStatusPending}
+			created := events.NewEvent(events.EventTypeOrderCreated, events.OrderCreatedEvent{Order: order})
+
+			handle := HandleOrderCreated(context.Background(), producer, testTopics, PartitionByOrder, nil, nil)
+			if err := handle(context.Background(), eventMessage(t, created)); err != nil {
+				t.Fatal(err)
+			}
+
+			published := producer.events()
+			if len(published) != 2 {
+				t.Fatalf("published %d events, want order.rejected and order.status_changed", len(published))
+			}
+			rejected := published[0]
+			if rejected.event.Type != events.EventTypeOrderRejected || rejected.topic != "order_rejected" {
+				t.Fatalf("first event %s on %s, want order.rejected on order_rejected", rejected.event.Type, rejected.topic)
+			}
+			payload, err := events.DecodeData[events.OrderRejectedEvent](rejected.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if payload.Reason != tt.wantReason {
+				t.Errorf("reason %q, want %q", payload.Reason, tt.wantReason)
+			}
+			if payload.OrderID != "order-1" || payload.CustomerID != "customer-1" || payload.RejectedAt.IsZero() {
+				t.Errorf("payload %+v lacks the order, customer or rejection time", payload)
+			}
+			if rejected.event.CausationID != created.ID {
+				t.Errorf("order.rejected caused by %q, want the order.created event %q", rejected.event.CausationID, created.ID)
+			}
+			if published[1].event.Type != events.EventTypeOrderStatusChanged {
+				t.Errorf("second event %s, want order.status_changed", published[1].event.Type)
+			}
+		})
+	}
+}
+
+func TestCreateOrderDoesNotRejectOrdersItNeverAccepted(t *testing.T) {
+	producer := &publishRecorder{}
+	h := NewOrderHandler(producer, testTopics, zap.NewNop())
+
+	w := postOrder(h, `{"customer_id": "customer-1", "items": [{"product_id": "p1", "quantity": 0, "price": 10}]}`, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", w.Code, w.Body)
+	}
+	if published := producer.events(); len(published) != 0 {
+		t.Errorf("published %d events for an order rejected before acceptance", len(published))
+	}
+}
+
+func TestCreateOrderStampsTheBaseCurrencyTotal(t *testing.T) {
+	rates := models.NewStaticRateConverter()
+	rates.SetRate("EUR", "USD", 1.1)
+
+	tests := []struct {
+		name       string
+		currency   string
+		wantStatus int
+		wantBase   float64
+	}{
+		{name: "known pair", currency: "EUR", wantStatus: http.StatusCreated, wantBase: 22},
+		{name: "unknown pair", currency: "JPY", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &publishRecorder{}
+			h := NewOrderHandler(producer, testTopics, zap.NewNop())
+			h.SetCurrencyConverter(rates, "USD")
+
+			body := `{"customer_id": "customer-1", "currency": "` + tt.currency + `", "items": [{"product_id": "p1", "quantity": 2, "price": 10}]}`
+			w := postOrder(h, body, nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			published := producer.events()
+			if tt.wantStatus != http.StatusCreated {
+				if len(published) != 0 || !strings.Contains(w.Body.String(), "unknown currency pair") {
+					t.Errorf("published %d events, body %s; want the unknown pair rejected", len(published), w.Body)
+				}
+				return
+			}
+
+			created, err := events.DecodeData[events.OrderCreatedEvent](published[0].event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			order := created.Order
+			if order.Currency != "EUR" || order.TotalPrice != 20 || order.BaseCurrency != "USD" || order.BaseTotalPrice != tt.wantBase {
+				t.Errorf("order.created carries %s %v / %s %v, want EUR 20 / USD %v",
+					order.Currency, order.TotalPrice, order.BaseCurrency, order.BaseTotalPrice, tt.wantBase)
+			}
+		})
+	}
+}
+
+func TestOrderRejectionEmitsOneStatusChange(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      models.OrderStatus
+		wantChanges int
+	}{
+		{name: "pending order", status: models.OrderStatusPending, wantChanges: 1},
+		{name: "already failed", status: models.OrderStatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &publishRecorder{}
+			order := models.Order{ID: "order-1", CustomerID: "customer-1", Status: tt.status}
+			created := events.NewEvent(events.EventTypeOrderCreated, events.OrderCreatedEvent{Order: order})
+
+			handle := HandleOrderCreated(context.Background(), producer, testTopics, PartitionByOrder, nil, nil)
+			if err := handle(context.Background(), eventMessage(t, created)); err != nil {
+				t.Fatal(err)
+			}
+
+			var changes []publishedEvent
+			for _, p := range producer.events() {
+				if p.event.Type == events.EventTypeOrderStatusChanged {
+					changes = append(changes, p)
+				}
+			}
+			if len(changes) != tt.wantChanges {
+				t.Fatalf("published %d order.status_changed events, want %d", len(changes), tt.wantChanges)
+			}
+			if tt.wantChanges == 0 {
+				return
+			}
+
+			changed, err := events.DecodeData[events.OrderStatusChangedEvent](changes[0].event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changes[0].topic != "order_status_changed" || changes[0].key != "order-1" {
+				t.Errorf("published to %s keyed %s, want order_status_changed keyed order-1", changes[0].topic, changes[0].key)
+			}
+			if changed.OrderID != "order-1" || changed.From != models.OrderStatusPending || changed.To != models.OrderStatusFailed ||
+				changed.Actor != actorInventoryService || changed.ChangedAt.IsZero() {
+				t.Errorf("status change %+v, want order-1 from pending to failed by %s", changed, actorInventoryService)
+			}
+			if changes[0].event.CausationID != created.ID {
+				t.Errorf("status change caused by %q, want the order.created event %q", changes[0].event.CausationID, created.ID)
+			}
+		})
+	}
+}
+
+func TestCorrelationIDsPropagateThroughTheOrderChain(t *testing.T) {
+	producer := &publishRecorder{}
+	h := NewOrderHandler(producer, testTopics, zap.NewNop())
+	if w := postOrder(h, validOrder, nil); w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body)
+	}
+	created := producer.events()[0].event
+
+	// Hop 1: inventory reserves the order
+	inventory := NewInventory()
+	handle := HandleOrderCreated(context.Background(), producer, testTopics, PartitionByOrder, inventory, nil)
+	if err := handle(context.Background(), eventMessage(t, created)); err != nil {
+		t.Fatal(err)
+	}
+	reserved := producer.events()[1].event
+
+	// Hop 2: the order fails and inventory releases it, each event read back off the wire
+	order, err := events.DecodeData[events.OrderCreatedEvent](created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transition, err := order.Order.TransitionTo(models.OrderStatusFailed, actorOrderService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := events.NewOrderStatusChangedEvent(created, transition).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := events.UnmarshalEvent(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := events.DecodeData[events.OrderStatusChangedEvent](changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := HandleOrderStatusChanged(producer, testTopics, PartitionByOrder, inventory)(context.Background(), changed, data); err != nil {
+		t.Fatal(err)
+	}
+	released := producer.events()[2].event
+
+	tests := []struct {
+		event     *events.Event
+		wantCause string
+	}{
+		{created, ""},
+		{reserved, created.ID},
+		{changed, created.ID},
+		{released, changed.ID},
+	}
+	for _, tt := range tests {
+		if tt.event.CorrelationID != created.ID || tt.event.CausationID != tt.wantCause {
+			t.Errorf("%s: correlation %q, causation %q; want correlation %q, causation %q",
+				tt.event.Type, tt.event.CorrelationID, tt.event.CausationID, created.ID, tt.wantCause)
+		}
+	}
+}
+
+// priceList is a PriceProvider backed by a map
+type priceList map[string]float64
+
+func (p priceList) PriceFor(productID string) (float64, bool) {
+	price, ok := p[productID]
+	return price, ok
+}
+
+func TestCreateOrderRejectsMispricedItems(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "matching prices", body: validOrder, wantStatus: http.StatusCreated},
+		{
+			name:       "mismatched price",
+			body:       `{"customer_id": "customer-1", "items": [{"product_id": "p1", "quantity": 2, "price": 1}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unpriced product",
+			body:       `{"customer_id": "customer-1", "items": [{"product_id": "p9", "quantity": 1, "price": 3}]}`,
+			wantStatus: http.StatusCreated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &publishRecorder{}
+			h := NewOrderHandler(producer, testTopics, zap.NewNop())
+			h.SetPriceProvider(priceList{"p1": 10})
+
+			w := postOrder(h, tt.body, nil)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			published := len(producer.events())
+			if tt.wantStatus == http.StatusCreated {
+				if published != 1 {
+					t.Errorf("published %d events, want the order.created event", published)
+				}
+				return
+			}
+			if published != 0 {
+				t.Errorf("published %d events for a mispriced order", published)
+			}
+			if !strings.Contains(w.Body.String(), models.ErrPriceMismatch.Error()) {
+				t.Errorf("body %s, want the price mismatch reported", w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/tanint/go-eda/internal/kafka"
+	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/metrics"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+// OrderLatencyTracker measures end-to-end order latency: the time between
+// receiving an order's created event and its confirmed event. Orders that are
+// neither confirmed nor rejected within the TTL are evicted and counted as abandoned.
+type OrderLatencyTracker struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	started   map[string]time.Time // order ID -> created event received at
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewOrderLatencyTracker creates a tracker forgetting unconfirmed orders after ttl
+func NewOrderLatencyTracker(ttl time.Duration) *OrderLatencyTracker {
+	return &OrderLatencyTracker{
+		ttl:     ttl,
+		started: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Handle is a message handler for the order created, confirmed and rejected topics
+func (t *OrderLatencyTracker) Handle(ctx context.Context, msg *kafka.Message) error {
+	event, err := events.UnmarshalEvent(msg.Value)
+	if err != nil {
+		return err
+	}
+	return t.Observe(event)
+}
+
+// Observe records an order event
+func (t *OrderLatencyTracker) Observe(event *events.Event) error {
+	switch event.Type {
+	case events.EventTypeOrderCreated:
+		var payload events.OrderCreatedEvent
+		if err := decodeEventData(event, &payload); err != nil {
+			return err
+		}
+		t.start(payload.Order.ID)
+
+	case events.EventTypeOrderCreatedChunk:
+		var payload events.OrderCreatedChunkEvent
+		if err := decodeEventData(event, &payload); err != nil {
+			return err
+		}
+		t.start(payload.Order.ID)
+
+	case events.EventTypeOrderConfirmed:
+		var payload events.OrderConfirmedEvent
+		if err := decodeEventData(event, &payload); err != nil {
+			return err
+		}
+		if latency, ok := t.finish(payload.OrderID); ok {
+			metrics.RecordTiming(metrics.OrderConfirmationLatency, latency)
+			logger.Debug("Order confirmed",
+				zap.String("order_id", payload.OrderID),
+				zap.Duration("latency", latency),
+			)
+		}
+
+	case events.EventTypeOrderRejected:
+		var payload events.OrderRejectedEvent
+		if err := decodeEventData(event, &payload); err != nil {
+			return err
+		}
+		t.finish(payload.OrderID)
+	}
+	return nil
+}
+
+// start records when the order was created; redeliveries and later chunks keep the first time
+func (t *OrderLatencyTracker) start(orderID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.evictExpired(now)
+	if _, ok := t.started[orderID]; !ok {
+		t.started[orderID] = now
+	}
+}
+
+// finish forgets the order and returns how long ago it was created
+func (t *OrderLatencyTracker) finish(orderID string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.evictExpired(now)
+	startedAt, ok := t.started[orderID]
+	if !ok {
+		return 0, false
+	}
+	delete(t.started, orderID)
+
+	latency := now.Sub(startedAt)
+	if latency >= t.ttl {
+		metrics.IncCounter(metrics.OrdersAbandoned)
+		return 0, false
+	}
+	return latency, true
+}
+
+// Pending returns the number of orders awaiting confirmation
+func (t *OrderLatencyTracker) Pending() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.started)
+}
+
+// decodeEventData decodes the event payload into v
+func decodeEventData(event *events.Event, v interface{}) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	return events.Unmarshal(data, v)
+}
+
+// evictExpired drops orders older than the TTL, at most once per TTL
+func (t *OrderLatencyTracker) evictExpired(now time.Time) {
+	if now.Sub(t.lastSweep) < t.ttl {
+		return
+	}
+	t.lastSweep = now
+
+	for id, startedAt := range t.started {
+		if now.Sub(startedAt) >= t.ttl {
+			delete(t.started, id)
+			metrics.IncCounter(metrics.OrdersAbandoned)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tanint/go-eda/internal/metrics"
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// latencyRecorder is a metrics sink keeping the order confirmation latencies
+type latencyRecorder struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+}
+
+func (r *latencyRecorder) Count(string, int64, []metrics.Label) {}
+
+func (r *latencyRecorder) Gauge(string, int64, []metrics.Label) {}
+
+func (r *latencyRecorder) Timing(name string, d time.Duration, _ []metrics.Label) {
+	if name != metrics.OrderConfirmationLatency {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies = append(r.latencies, d)
+}
+
+func (r *latencyRecorder) observed() []time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]time.Duration(nil), r.latencies...)
+}
+
+// newTestLatencyTracker returns a tracker on a fake clock and the recorded latencies
+func newTestLatencyTracker(t *testing.T, ttl time.Duration) (*OrderLatencyTracker, *time.Time, *latencyRecorder) {
+	t.Helper()
+	recorder := &latencyRecorder{}
+	metrics.AddSink(recorder)
+
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := NewOrderLatencyTracker(ttl)
+	tracker.now = func() time.Time { return clock }
+	return tracker, &clock, recorder
+}
+
+func orderCreated(orderID string) *events.Event {
+	return events.NewEvent(events.EventTypeOrderCreated, events.OrderCreatedEvent{
+		Order: models.Order{ID: orderID, CustomerID: "customer-1"},
+	})
+}
+
+func orderConfirmed(orderID string) *events.Event {
+	return events.NewEvent(events.EventTypeOrderConfirmed, events.OrderConfirmedEvent{
+		OrderID:    orderID,
+		CustomerID: "customer-1",
+	})
+}
+
+func observe(t *testing.T, tracker *OrderLatencyTracker, event *events.Event) {
+	t.Helper()
+	if err := tracker.Observe(event); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOrderLatencyIsObservedForConfirmedOrders(t *testing.T) {
+	tracker, clock, recorder := newTestLatencyTracker(t, time.Hour)
+
+	observe(t, tracker, orderCreated("order-1"))
+	*clock = clock.Add(2 * time.Second)
+	observe(t, tracker, orderCreated("order-1")) // redelivered: keeps the first time
+	*clock = clock.Add(3 * time.Second)
+	observe(t, tracker, orderConfirmed("order-1"))
+	observe(t, tracker, orderConfirmed("order-1")) // already confirmed
+
+	if got := recorder.observed(); len(got) != 1 || got[0] != 5*time.Second {
+		t.Errorf("observed latencies %v, want [5s]", got)
+	}
+	if tracker.Pending() != 0 {
+		t.Errorf("%d orders pending after confirmation, want 0", tracker.Pending())
+	}
+}
+
+func TestOrderLatencyForgetsAbandonedOrders(t *testing.T) {
+	tracker, clock, recorder := newTestLatencyTracker(t, time.Minute)
+	abandoned := metrics.CounterValue(metrics.OrdersAbandoned)
+
+	observe(t, tracker, orderCreated("order-abandoned"))
+	*clock = clock.Add(30 * time.Second)
+	observe(t, tracker, orderCreated("order-2"))
+	*clock = clock.Add(45 * time.Second)
+	// Sweeping on the next event evicts the order created over a minute ago
+	observe(t, tracker, orderCreated("order-3"))
+
+	if tracker.Pending() != 2 {
+		t.Errorf("%d orders pending, want the 2 created within the TTL", tracker.Pending())
+	}
+	if got := metrics.CounterValue(metrics.OrdersAbandoned) - abandoned; got != 1 {
+		t.Errorf("counted %d abandoned orders, want 1", got)
+	}
+
+	observe(t, tracker, orderConfirmed("order-abandoned"))
+	if got := recorder.observed(); len(got) != 0 {
+		t.Errorf("observed latencies %v for an evicted order, want none", got)
+	}
+}
+
+func TestOrderLatencyIgnoresRejectedOrders(t *testing.T) {
+	tracker, _, recorder := newTestLatencyTracker(t, time.Hour)
+
+	observe(t, tracker, orderCreated("order-1"))
+	observe(t, tracker, events.NewEvent(events.EventTypeOrderRejected, events.OrderRejectedEvent{
+		OrderID:    "order-1",
+		CustomerID: "customer-1",
+		Reason:     "out of stock",
+	}))
+	observe(t, tracker, orderConfirmed("order-1"))
+
+	if got := recorder.observed(); len(got) != 0 || tracker.Pending() != 0 {
+		t.Errorf("observed %v with %d pending after rejection, want nothing", got, tracker.Pending())
+	}
+}
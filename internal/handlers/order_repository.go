@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/tanint/go-eda/internal/models"
+)
+
+// OrderRepository stores the orders created by the order service
+type OrderRepository interface {
+	Save(ctx context.Context, order *models.Order) error
+	// FindByID returns the order, or models.ErrOrderNotFound
+	FindByID(ctx context.Context, orderID string) (*models.Order, error)
+	// UpdateStatus moves the order to status on behalf of actor through
+	// Order.TransitionTo and returns the transition, or fails with
+	// models.ErrOrderNotFound or models.ErrInvalidStatusTransition
+	UpdateStatus(ctx context.Context, orderID string, status models.OrderStatus, actor string) (models.StatusTransition, error)
+	// List returns a page of the orders matching filter, newest first, and
+	// the number of matching orders
+	List(ctx context.Context, filter OrderFilter) ([]*models.Order, int, error)
+}
+
+// OrderFilter selects the orders List returns; empty fields match all orders
+type OrderFilter struct {
+	CustomerID string
+	Status     models.OrderStatus
+	Limit      int // 0 returns all matching orders
+	Offset     int
+}
+
+// matches reports whether the order passes the filter's criteria
+func (f OrderFilter) matches(order *models.Order) bool {
+	return (f.CustomerID == "" || order.CustomerID == f.CustomerID) &&
+		(f.Status == "" || order.Status == f.Status)
+}
+
+// MemoryOrderRepository keeps orders in memory; they are lost on restart
+type MemoryOrderRepository struct {
+	mu     sync.RWMutex
+	orders map[string]*models.Order
+}
+
+// NewMemoryOrderRepository creates an empty in-memory repository
+func NewMemoryOrderRepository() *MemoryOrderRepository {
+	return &MemoryOrderRepository{orders: make(map[string]*models.Order)}
+}
+
+// Save stores a copy of the order, replacing any order with the same ID
+func (r *MemoryOrderRepository) Save(ctx context.Context, order *models.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orders[order.ID] = copyOrder(order)
+	return nil
+}
+
+// FindByID returns a copy of the order
+func (r *MemoryOrderRepository) FindByID(ctx context.Context, orderID string) (*models.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	order, ok := r.orders[orderID]
+	if !ok {
+		return nil, models.ErrOrderNotFound
+	}
+	return copyOrder(order), nil
+}
+
+// UpdateStatus moves the order to status if the transition is allowed
+func (r *MemoryOrderRepository) UpdateStatus(ctx context.Context, orderID string, status models.OrderStatus, actor string) (models.StatusTransition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order, ok := r.orders[orderID]
+	if !ok {
+		return models.StatusTransition{}, models.ErrOrderNotFound
+	}
+	return order.TransitionTo(status, actor)
+}
+
+// List returns copies of the matching orders, newest first
+func (r *MemoryOrderRepository) List(ctx context.Context, filter OrderFilter) ([]*models.Order, int, error) {
+	r.mu.RLock()
+	var matching []*models.Order
+	for _, order := range r.orders {
+		if filter.matches(order) {
+			matching = append(matching, copyOrder(order))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(matching, func(i, j int) bool {
+		if !matching[i].CreatedAt.Equal(matching[j].CreatedAt) {
+			return matching[i].CreatedAt.After(matching[j].CreatedAt)
+		}
+		return matching[i].ID < matching[j].ID
+	})
+
+	total := len(matching)
+	if filter.Offset >= total {
+		return []*models.Order{}, total, nil
+	}
+	matching = matching[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(matching) {
+		matching = matching[:filter.Limit]
+	}
+	return matching, total, nil
+}
+
+func copyOrder(order *models.Order) *models.Order {
+	c := *order
+	c.Items = append([]models.OrderItem(nil), order.Items...)
+	return &c
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tanint/go-eda/internal/models"
+	"go.uber.org/zap"
+)
+
+// getOrderStatus sends a GetOrderStatus request for orderID to h
+func getOrderStatus(h *OrderHandler, orderID string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/orders/:id", h.GetOrderStatus)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/"+orderID, nil))
+	return w
+}
+
+func TestGetOrderStatusOfACreatedOrder(t *testing.T) {
+	h := NewOrderHandler(&publishRecorder{}, testTopics, zap.NewNop())
+	w := postOrder(h, validOrder, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", w.Code, w.Body)
+	}
+	var created models.Order
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+
+	w = getOrderStatus(h, created.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	var body struct {
+		OrderID string             `json:"order_id"`
+		Status  models.OrderStatus `json:"status"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.OrderID != created.ID || body.Status != models.OrderStatusPending {
+		t.Errorf("got order %s %s, want %s pending", body.OrderID, body.Status, created.ID)
+	}
+}
+
+func TestGetOrderStatusOfAnUnknownOrder(t *testing.T) {
+	h := NewOrderHandler(&publishRecorder{}, testTopics, zap.NewNop())
+	w := getOrderStatus(h, "missing")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404: %s", w.Code, w.Body)
+	}
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error != models.ErrOrderNotFound.Error() {
+		t.Errorf("body %s, want the ErrOrderNotFound message", w.Body)
+	}
+}
+
+func TestMemoryOrderRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryOrderRepository()
+	order := &models.Order{ID: "order-1", CustomerID: "customer-1", Status: models.OrderStatusPending,
+		Items: []models.OrderItem{{ProductID: "p1", Quantity: 1, Price: 5}}}
+	if err := repo.Save(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+	order.Items[0].Quantity = 99 // the repository keeps its own copy
+
+	if _, err := repo.FindByID(ctx, "order-2"); !errors.Is(err, models.ErrOrderNotFound) {
+		t.Errorf("FindByID(order-2) = %v, want ErrOrderNotFound", err)
+	}
+	if _, err := repo.UpdateStatus(ctx, "order-2", models.OrderStatusConfirmed, "test"); !errors.Is(err, models.ErrOrderNotFound) {
+		t.Errorf("UpdateStatus(order-2) = %v, want ErrOrderNotFound", err)
+	}
+
+	transition, err := repo.UpdateStatus(ctx, "order-1", models.OrderStatusConfirmed, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transition.From != models.OrderStatusPending || transition.To != models.OrderStatusConfirmed {
+		t.Errorf("transition %s to %s, want pending to confirmed", transition.From, transition.To)
+	}
+	if _, err := repo.UpdateStatus(ctx, "order-1", models.OrderStatusPending, "test"); !errors.Is(err, models.ErrInvalidStatusTransition) {
+		t.Errorf("UpdateStatus(confirmed to pending) = %v, want ErrInvalidStatusTransition", err)
+	}
+
+	found, err := repo.FindByID(ctx, "order-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Status != models.OrderStatusConfirmed || found.Items[0].Quantity != 1 {
+		t.Errorf("found %s order of %d, want the confirmed order as saved", found.Status, found.Items[0].Quantity)
+	}
+}
+
+// listOrders sends a ListOrders request with query to h
+func listOrders(h *OrderHandler, query string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/orders", h.ListOrders)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/orders?"+query, nil))
+	return w
+}
+
+func TestListOrders(t *testing.T) {
+	repo := NewMemoryOrderRepository()
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	seed := []struct {
+		id, customer string
+		status       models.OrderStatus
+	}{
+		{"order-1", "customer-1", models.OrderStatusConfirmed},
+		{"order-2", "customer-2", models.OrderStatusPending},
+		{"order-3", "customer-1", models.OrderStatusPending},
+		{"order-4", "customer-1", models.OrderStatusConfirmed},
+		{"order-5", "customer-2", models.OrderStatusFailed},
+	}
+	for i, o := range seed {
+		order := &models.Order{ID: o.id, CustomerID: o.customer, Status: o.status, CreatedAt: created.Add(time.Duration(i) * time.Minute)}
+		if err := repo.Save(context.Background(), order); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := NewOrderHandler(&publishRecorder{}, testTopics, zap.NewNop())
+	h.SetOrderRepository(repo)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantIDs    []string
+		wantTotal  int
+		wantLimit  int
+	}{
+		{name: "all, newest first", query: "", wantStatus: http.StatusOK,
+			wantIDs: []string{"order-5", "order-4", "order-3", "order-2", "order-1"}, wantTotal: 5, wantLimit: 20},
+		{name: "by customer", query: "customer_id=customer-1", wantStatus: http.StatusOK,
+			wantIDs: []string{"order-4", "order-3", "order-1"}, wantTotal: 3, wantLimit: 20},
+		{name: "by status", query: "status=pending", wantStatus: http.StatusOK,
+			wantIDs: []string{"order-3", "order-2"}, wantTotal: 2, wantLimit: 20},
+		{name: "by customer and status", query: "customer_id=customer-1&status=confirmed", wantStatus: http.StatusOK,
+			wantIDs: []string{"order-4", "order-1"}, wantTotal: 2, wantLimit: 20},
+		{name: "first page", query: "limit=2", wantStatus: http.StatusOK,
+			wantIDs: []string{"order-5", "order-4"}, wantTotal: 5, wantLimit: 2},
+		{name: "last partial page", query: "limit=2&offset=4", wantStatus: http.StatusOK,
+			wantIDs: []string{"order-1"}, wantTotal: 5, wantLimit: 2},
+		{name: "offset at the end", query: "offset=5", wantStatus: http.StatusOK,
+			wantIDs: []string{}, wantTotal: 5, wantLimit: 20},
+		{name: "offset beyond the end", query: "limit=100&offset=50", wantStatus: http.StatusOK,
+			wantIDs: []string{}, wantTotal: 5, wantLimit: 100},
+		{name: "no matches", query: "customer_id=customer-9", wantStatus: http.StatusOK,
+			wantIDs: []string{}, wantTotal: 0, wantLimit: 20},
+		{name: "limit zero", query: "limit=0", wantStatus: http.StatusBadRequest},
+		{name: "limit above the maximum", query: "limit=101", wantStatus: http.StatusBadRequest},
+		{name: "negative limit", query: "limit=-1", wantStatus: http.StatusBadRequest},
+		{name: "non-numeric limit", query: "limit=ten", wantStatus: http.StatusBadRequest},
+		{name: "negative offset", query: "offset=-1", wantStatus: http.StatusBadRequest},
+		{name: "unknown status", query: "status=shipped", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := listOrders(h, tt.query)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var page struct {
+				Orders []models.Order `json:"orders"`
+				Total  int            `json:"total"`
+				Limit  int            `json:"limit"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatal(err)
+			}
+			if page.Orders == nil {
+				t.Fatalf("body %s, want orders to be a list", w.Body)
+			}
+			ids := []string{}
+			for _, order := range page.Orders {
+				ids = append(ids, order.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) || page.Total != tt.wantTotal || page.Limit != tt.wantLimit {
+				t.Errorf("got %v of %d with limit %d, want %v of %d with limit %d",
+					ids, page.Total, page.Limit, tt.wantIDs, tt.wantTotal, tt.wantLimit)
+			}
+		})
+	}
+}
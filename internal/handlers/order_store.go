@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/internal/outbox"
+)
+
+const orderStoreSchema = `
+CREATE TABLE IF NOT EXISTS orders (
+	order_id    TEXT PRIMARY KEY,
+	customer_id TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	order_json  BLOB NOT NULL,
+	created_at  TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS orders_customer_created ON orders (customer_id, created_at)`
+
+// OrderStore persists created orders in an outbox transaction, so the order
+// and its events are committed together
+type OrderStore interface {
+	SaveOrder(ctx context.Context, tx outbox.Tx, order *models.Order) error
+}
+
+// SQLOrderStore writes orders to an orders table in the outbox's SQLite
+// database. It is also the OrderRepository reading them back.
+type SQLOrderStore struct {
+	db *sql.DB
+}
+
+var _ OrderRepository = (*SQLOrderStore)(nil)
+
+// NewSQLOrderStore creates the orders table if needed
+func NewSQLOrderStore(ctx context.Context, db *sql.DB) (*SQLOrderStore, error) {
+	if _, err := db.ExecContext(ctx, orderStoreSchema); err != nil {
+		return nil, fmt.Errorf("failed to create orders table: %w", err)
+	}
+	return &SQLOrderStore{db: db}, nil
+}
+
+// SaveOrder inserts the order in tx, which must be a *sql.Tx
+func (s *SQLOrderStore) SaveOrder(ctx context.Context, tx outbox.Tx, order *models.Order) error {
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return outbox.ErrForeignTx
+	}
+	return saveOrder(ctx, sqlTx, order)
+}
+
+// Save stores the order outside any outbox transaction, replacing any order
+// with the same ID
+func (s *SQLOrderStore) Save(ctx context.Context, order *models.Order) error {
+	return saveOrder(ctx, s.db, order)
+}
+
+// FindByID returns the order, or models.ErrOrderNotFound
+func (s *SQLOrderStore) FindByID(ctx context.Context, orderID string) (*models.Order, error) {
+	return findOrder(ctx, s.db, orderID)
+}
+
+// UpdateStatus moves the order to status if the transition is allowed
+func (s *SQLOrderStore) UpdateStatus(ctx context.Context, orderID string, status models.OrderStatus, actor string) (models.StatusTransition, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.StatusTransition{}, err
+	}
+	defer tx.Rollback()
+
+	order, err := findOrder(ctx, tx, orderID)
+	if err != nil {
+		return models.StatusTransition{}, err
+	}
+	transition, err := order.TransitionTo(status, actor)
+	if err != nil {
+		return models.StatusTransition{}, err
+	}
+	if err := saveOrder(ctx, tx, order); err != nil {
+		return models.StatusTransition{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.StatusTransition{}, err
+	}
+	return transition, nil
+}
+
+// List returns the matching orders, newest first
+func (s *SQLOrderStore) List(ctx context.Context, filter OrderFilter) ([]*models.Order, int, error) {
+	where := " WHERE 1 = 1"
+	var args []interface{}
+	if filter.CustomerID != "" {
+		where += " AND customer_id = ?"
+		args = append(args, filter.CustomerID)
+	}
+	if filter.Status != "" {
+		where += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM orders`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1 // SQLite: no limit
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT order_json FROM orders`+where+`
+		ORDER BY created_at DESC, order_id LIMIT ? OFFSET ?`,
+		append(args, limit, filter.Offset)...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []*models.Order{}
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan order: %w", err)
+		}
+		var order models.Order
+		if err := json.Unmarshal(data, &order); err != nil {
+			return nil, 0, fmt.Errorf("failed to decode order: %w", err)
+		}
+		orders = append(orders, &order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list orders: %w", err)
+	}
+	return orders, total, nil
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func saveOrder(ctx context.Context, q queryer, order *models.Order) error {
+	data, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order: %w", err)
+	}
+	_, err = q.ExecContext(ctx, `
+		INSERT OR REPLACE INTO orders (order_id, customer_id, status, order_json, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		order.ID, order.CustomerID, order.Status, data, order.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save order %s: %w", order.ID, err)
+	}
+	return nil
+}
+
+func findOrder(ctx context.Context, q queryer, orderID string) (*models.Order, error) {
+	var data []byte
+	err := q.QueryRowContext(ctx, `SELECT order_json FROM orders WHERE order_id = ?`, orderID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order %s: %w", orderID, err)
+	}
+	var order models.Order
+	if err := json.Unmarshal(data, &order); err != nil {
+		return nil, fmt.Errorf("failed to decode order %s: %w", orderID, err)
+	}
+	return &order, nil
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tanint/go-eda/internal/kafka"
+	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/metrics"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+// recentIDsPerKey bounds how many event IDs are remembered per key for duplicate detection
+const recentIDsPerKey = 100
+
+// AnomalyKind classifies an ordering anomaly
+type AnomalyKind string
+
+const (
+	AnomalyOutOfOrder AnomalyKind = "out_of_order"
+	AnomalyDuplicate  AnomalyKind = "duplicate"
+	AnomalyGap        AnomalyKind = "gap"
+)
+
+// Anomaly describes an ordering problem detected for an aggregate key
+type Anomaly struct {
+	Kind    AnomalyKind
+	Key     string
+	EventID string
+	Detail  string
+}
+
+// OrderingVerifier tracks events per aggregate key and detects out-of-order,
+// duplicate and missing events. It only observes; it never alters processing.
+type OrderingVerifier struct {
+	log logger.Logger
+
+	mu   sync.Mutex
+	keys map[string]*keyState
+}
+
+type keyState struct {
+	lastTimestamp time.Time
+	lastSequence  int64
+	recentIDs     []string
+}
+
+// NewOrderingVerifier creates a new ordering verifier logging to log, or to
+// the global logger when log is nil
+func NewOrderingVerifier(log logger.Logger) *OrderingVerifier {
+	if log == nil {
+		log = logger.Global()
+	}
+	return &OrderingVerifier{
+		log:  log,
+		keys: make(map[string]*keyState),
+	}
+}
+
+// Observe records the event for key and returns the anomalies it reveals.
+// Gaps are only detected for events carrying a sequence metadata value.
+func (v *OrderingVerifier) Observe(key string, event *events.Event) []Anomaly {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	state, ok := v.keys[key]
+	if !ok {
+		state = &keyState{}
+		v.keys[key] = state
+	}
+
+	for _, id := range state.recentIDs {
+		if id == event.ID {
+			return []Anomaly{{
+				Kind:    AnomalyDuplicate,
+				Key:     key,
+				EventID: event.ID,
+				Detail:  "event ID already seen",
+			}}
+		}
+	}
+	state.recentIDs = append(state.recentIDs, event.ID)
+	if len(state.recentIDs) > recentIDsPerKey {
+		state.recentIDs = state.recentIDs[1:]
+	}
+
+	var anomalies []Anomaly
+
+	if event.Timestamp.Before(state.lastTimestamp) {
+		anomalies = append(anomalies, Anomaly{
+			Kind:    AnomalyOutOfOrder,
+			Key:     key,
+			EventID: event.ID,
+			Detail:  "timestamp " + event.Timestamp.Format(time.RFC3339Nano) + " before " + state.lastTimestamp.Format(time.RFC3339Nano),
+		})
+	} else {
+		state.lastTimestamp = event.Timestamp
+	}
+
+	if raw, ok := event.Metadata[events.MetadataSequence]; ok {
+		seq, err := strconv.ParseInt(raw, 10, 64)
+		if err == nil {
+			switch {
+			case state.lastSequence == 0:
+				state.lastSequence = seq
+			case seq <= state.lastSequence:
+				anomalies = append(anomalies, Anomaly{
+					Kind:    AnomalyOutOfOrder,
+					Key:     key,
+					EventID: event.ID,
+					Detail:  "sequence " + raw + " after " + strconv.FormatInt(state.lastSequence, 10),
+				})
+			case seq > state.lastSequence+1:
+				anomalies = append(anomalies, Anomaly{
+					Kind:    AnomalyGap,
+					Key:     key,
+					EventID: event.ID,
+					Detail:  "sequence jumped from " + strconv.FormatInt(state.lastSequence, 10) + " to " + raw,
+				})
+				state.lastSequence = seq
+			default:
+				state.lastSequence = seq
+			}
+		}
+	}
+
+	return anomalies
+}
+
+// Handle is a message handler that verifies ordering and reports anomalies
+// through logs and metrics
+func (v *OrderingVerifier) Handle(ctx context.Context, msg *kafka.Message) error {
+	topic := *msg.TopicPartition.Topic
+
+	event, err := events.UnmarshalEvent(msg.Value)
+	if err != nil {
+		v.log.Warn("Skipping undecodable event during ordering verification",
+			zap.Error(err),
+			zap.String("topic", topic),
+		)
+		return nil
+	}
+
+	for _, anomaly := range v.Observe(string(msg.Key), event) {
+		metrics.IncCounter(metrics.OrderingAnomalies,
+			metrics.Topic(topic),
+			metrics.Label{Name: "kind", Value: string(anomaly.Kind)},
+		)
+		v.log.Warn("Event ordering anomaly detected",
+			zap.String("kind", string(anomaly.Kind)),
+			zap.String("topic", topic),
+			zap.String("key", anomaly.Key),
+			zap.String("event_id", anomaly.EventID),
+			zap.String("detail", anomaly.Detail),
+			zap.Int32("partition", msg.TopicPartition.Partition),
+			zap.String("offset", msg.TopicPartition.Offset.String()),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/metrics"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// sequencedEvent returns an event of order-1 with the given ID, timestamp
+// offset from a fixed base, and sequence number (none when 0)
+func sequencedEvent(id string, at time.Duration, seq int) *events.Event {
+	event := events.NewEvent(events.EventTypeOrderCreated, nil)
+	event.ID = id
+	event.Timestamp = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).Add(at)
+	if seq != 0 {
+		event.SetMetadata(events.MetadataSequence, strconv.Itoa(seq))
+	}
+	return event
+}
+
+func TestOrderingVerifierObserve(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream []*events.Event
+		want   []AnomalyKind
+	}{
+		{
+			name: "ordered",
+			stream: []*events.Event{
+				sequencedEvent("e1", 0, 1),
+				sequencedEvent("e2", time.Second, 2),
+				sequencedEvent("e3", 2*time.Second, 3),
+			},
+		},
+		{
+			name: "ordered without sequences",
+			stream: []*events.Event{
+				sequencedEvent("e1", 0, 0),
+				sequencedEvent("e2", time.Second, 0),
+				sequencedEvent("e3", time.Second, 0),
+			},
+		},
+		{
+			name: "reordered",
+			stream: []*events.Event{
+				sequencedEvent("e1", 0, 1),
+				sequencedEvent("e3", 2*time.Second, 2),
+				sequencedEvent("e2", time.Second, 1),
+			},
+			want: []AnomalyKind{AnomalyOutOfOrder, AnomalyOutOfOrder},
+		},
+		{
+			name: "duplicated",
+			stream: []*events.Event{
+				sequencedEvent("e1", 0, 1),
+				sequencedEvent("e2", time.Second, 2),
+				sequencedEvent("e2", time.Second, 2),
+			},
+			want: []AnomalyKind{AnomalyDuplicate},
+		},
+		{
+			name: "gap",
+			stream: []*events.Event{
+				sequencedEvent("e1", 0, 1),
+				sequencedEvent("e4", time.Second, 4),
+				sequencedEvent("e5", 2*time.Second, 5),
+			},
+			want: []AnomalyKind{AnomalyGap},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewOrderingVerifier(nil)
+			var got []AnomalyKind
+			for _, event := range tt.stream {
+				for _, anomaly := range v.Observe("order-1", event) {
+					if anomaly.Key != "order-1" || anomaly.EventID != event.ID {
+						t.Errorf("anomaly %+v not attributed to event %s of order-1", anomaly, event.ID)
+					}
+					got = append(got, anomaly.Kind)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("anomalies %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderingVerifierTracksKeysIndependently(t *testing.T) {
+	v := NewOrderingVerifier(nil)
+	v.Observe("order-1", sequencedEvent("e1", time.Second, 5))
+	if anomalies := v.Observe("order-2", sequencedEvent("e2", 0, 1)); len(anomalies) != 0 {
+		t.Errorf("first event of another key reported %v", anomalies)
+	}
+}
+
+func TestOrderingVerifierHandleCountsAnomalies(t *testing.T) {
+	topic := "ordering-verifier-test"
+	core, logs := observer.New(zap.WarnLevel)
+	v := NewOrderingVerifier(zap.New(core))
+	for _, event := range []*events.Event{
+		sequencedEvent("e1", 0, 1),
+		sequencedEvent("e1", 0, 1),
+		sequencedEvent("e3", time.Second, 3),
+	} {
+		msg := eventMessage(t, event)
+		msg.TopicPartition = kafka.TopicPartition{Topic: &topic}
+		msg.Key = []byte("order-1")
+		if err := v.Handle(t.Context(), msg); err != nil {
+			t.Fatalf("Handle() = %v, want verification never to fail processing", err)
+		}
+	}
+
+	for kind, want := range map[AnomalyKind]int64{AnomalyDuplicate: 1, AnomalyGap: 1, AnomalyOutOfOrder: 0} {
+		got := metrics.CounterValue(metrics.OrderingAnomalies, metrics.Topic(topic), metrics.Label{Name: "kind", Value: string(kind)})
+		if got != want {
+			t.Errorf("%s anomalies counted %v, want %v", kind, got, want)
+		}
+	}
+	if n := logs.FilterMessage("Event ordering anomaly detected").Len(); n != 2 {
+		t.Errorf("logged %d anomalies to the injected logger, want 2", n)
+	}
+}
@@ -0,0 +1,88 @@
+package heartbeat
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/tanint/go-eda/internal/kafka"
+	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/version"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+// Emitter periodically publishes a service.heartbeat event so monitors
+// watching the event stream can detect services that went silent
+type Emitter struct {
+	publisher kafka.Publisher
+	topic     string
+	interval  time.Duration
+	service   string
+	instance  string
+	version   string
+
+	now       func() time.Time
+	newTicker func(d time.Duration) (<-chan time.Time, func())
+}
+
+// NewEmitter creates an emitter for the named service. The instance is the
+// host name and the version the service version.
+func NewEmitter(publisher kafka.Publisher, topic, service string, interval time.Duration) *Emitter {
+	instance, err := os.Hostname()
+	if err != nil {
+		instance = "unknown"
+	}
+
+	return &Emitter{
+		publisher: publisher,
+		topic:     topic,
+		interval:  interval,
+		service:   service,
+		instance:  instance,
+		version:   version.Get(),
+		now:       time.Now,
+		newTicker: func(d time.Duration) (<-chan time.Time, func()) {
+			t := time.NewTicker(d)
+			return t.C, t.Stop
+		},
+	}
+}
+
+// Run publishes a heartbeat every interval until ctx is done. Publish failures
+// are logged and do not stop the emitter.
+func (e *Emitter) Run(ctx context.Context) {
+	ticks, stop := e.newTicker(e.interval)
+	defer stop()
+
+	logger.Info("Heartbeat emitter started",
+		zap.String("topic", e.topic),
+		zap.Duration("interval", e.interval),
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Heartbeat emitter stopped")
+			return
+		case <-ticks:
+			e.beat(ctx)
+		}
+	}
+}
+
+func (e *Emitter) beat(ctx context.Context) {
+	event := events.NewEvent(events.EventTypeServiceHeartbeat, events.ServiceHeartbeatEvent{
+		Service:   e.service,
+		Instance:  e.instance,
+		Version:   e.version,
+		Timestamp: e.now(),
+	})
+
+	if err := e.publisher.PublishEvent(ctx, e.topic, []byte(e.service+"/"+e.instance), event); err != nil {
+		logger.Warn("Failed to publish heartbeat",
+			zap.Error(err),
+			zap.String("topic", e.topic),
+		)
+	}
+}
@@ -0,0 +1,91 @@
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// beatRecorder passes every published event to beats
+type beatRecorder struct {
+	beats chan *events.Event
+	keys  chan string
+}
+
+func (r *beatRecorder) Publish(ctx context.Context, topic string, key, value []byte) error {
+	return errors.New("beatRecorder: raw publish not supported")
+}
+
+func (r *beatRecorder) PublishEvent(ctx context.Context, topic string, key []byte, event *events.Event) error {
+	r.keys <- topic + ":" + string(key)
+	r.beats <- event
+	return nil
+}
+
+func (r *beatRecorder) PublishEventWithPartitionKey(ctx context.Context, topic, partitionKey string, key []byte, event *events.Event) error {
+	return r.PublishEvent(ctx, topic, key, event)
+}
+
+func TestEmitterBeatsOnEveryTickUntilShutdown(t *testing.T) {
+	publisher := &beatRecorder{beats: make(chan *events.Event, 10), keys: make(chan string, 10)}
+	e := NewEmitter(publisher, "heartbeats", "order-service", 30*time.Second)
+	e.instance = "pod-1"
+	e.version = "1.2.3"
+
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return clock }
+	ticks := make(chan time.Time)
+	var interval time.Duration
+	stopped := make(chan struct{})
+	e.newTicker = func(d time.Duration) (<-chan time.Time, func()) {
+		interval = d
+		return ticks, func() { close(stopped) }
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx)
+		close(done)
+	}()
+
+	for i := 0; i < 3; i++ {
+		clock = clock.Add(30 * time.Second)
+		ticks <- clock
+		event := <-publisher.beats
+		payload, err := events.DecodeData[events.ServiceHeartbeatEvent](event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := events.ServiceHeartbeatEvent{Service: "order-service", Instance: "pod-1", Version: "1.2.3", Timestamp: clock}
+		if event.Type != events.EventTypeServiceHeartbeat || payload != want {
+			t.Errorf("beat %d: %s %+v, want service.heartbeat %+v", i, event.Type, payload, want)
+		}
+		if key := <-publisher.keys; key != "heartbeats:order-service/pod-1" {
+			t.Errorf("beat %d published as %s, want heartbeats:order-service/pod-1", i, key)
+		}
+	}
+	if interval != 30*time.Second {
+		t.Errorf("ticker interval %v, want the configured 30s", interval)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("emitter still running after shutdown")
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("ticker not stopped on shutdown")
+	}
+	select {
+	case ticks <- clock:
+		t.Error("emitter still reading ticks after shutdown")
+	default:
+	}
+}
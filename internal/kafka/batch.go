@@ -0,0 +1,180 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+// ErrBatchPublisherClosed is returned when adding events to a closed BatchPublisher
+var ErrBatchPublisherClosed = errors.New("batch publisher is closed")
+
+// Defaults for batch flush thresholds
+const (
+	defaultBatchMaxSize  = 500
+	defaultBatchMaxDelay = 100 * time.Millisecond
+)
+
+// batchSink is the part of Producer a BatchPublisher needs
+type batchSink interface {
+	encodeEvent(ctx context.Context, event *events.Event) ([]byte, error)
+	eventHeaders(event *events.Event) []kafka.Header
+	PublishBatch(ctx context.Context, messages []BatchMessage) error
+}
+
+// BatchPublisher buffers events and publishes them with PublishBatch once
+// MaxSize events are buffered or MaxDelay has passed since the first one,
+// whichever comes first. Close flushes whatever is still buffered.
+type BatchPublisher struct {
+	sink     batchSink
+	maxSize  int
+	maxDelay time.Duration
+
+	mu        sync.Mutex
+	pending   []BatchMessage
+	timer     *time.Timer
+	closed    bool
+	timerErrs []error // failed timer flushes, returned by the next Flush or Close
+
+	log logger.Logger
+}
+
+// NewBatchPublisher creates a batch publisher on top of the producer
+func NewBatchPublisher(producer *Producer, cfg config.BatchConfig) *BatchPublisher {
+	return newBatchPublisher(producer, cfg, producer.log)
+}
+
+func newBatchPublisher(sink batchSink, cfg config.BatchConfig, log logger.Logger) *BatchPublisher {
+	b := &BatchPublisher{
+		sink:     sink,
+		maxSize:  cfg.MaxSize,
+		maxDelay: cfg.MaxDelay,
+		log:      log,
+	}
+	if b.maxSize <= 0 {
+		b.maxSize = defaultBatchMaxSize
+	}
+	if b.maxDelay <= 0 {
+		b.maxDelay = defaultBatchMaxDelay
+	}
+	return b
+}
+
+// PublishEvent encodes the event and buffers it. When the buffer reaches
+// MaxSize it is flushed before returning, and the flush error is returned.
+// Failures of events flushed by the timer are logged and returned by the
+// next Flush or Close.
+func (b *BatchPublisher) PublishEvent(ctx context.Context, topic string, key []byte, event *events.Event) error {
+	return b.PublishEventWithPartitionKey(ctx, topic, "", key, event)
+}
+
+// PublishEventWithPartitionKey buffers the event like PublishEvent, to be
+// published to the partition partitionKey maps to; see
+// Producer.PublishEventWithPartitionKey
+func (b *BatchPublisher) PublishEventWithPartitionKey(ctx context.Context, topic, partitionKey string, key []byte, event *events.Event) error {
+	value, err := b.sink.encodeEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+	return b.add(ctx, BatchMessage{Topic: topic, Key: key, Value: value, Headers: b.sink.eventHeaders(event), PartitionKey: partitionKey})
+}
+
+// Publish buffers a raw message like PublishEvent
+func (b *BatchPublisher) Publish(ctx context.Context, topic string, key, value []byte) error {
+	return b.add(ctx, BatchMessage{Topic: topic, Key: key, Value: value})
+}
+
+var _ Publisher = (*BatchPublisher)(nil)
+
+// add buffers msg, flushing the buffer once it holds MaxSize messages
+func (b *BatchPublisher) add(ctx context.Context, msg BatchMessage) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBatchPublisherClosed
+	}
+	b.pending = append(b.pending, msg)
+	if len(b.pending) < b.maxSize {
+		if b.timer == nil {
+			b.timer = time.AfterFunc(b.maxDelay, b.flushOnTimer)
+		}
+		b.mu.Unlock()
+		return nil
+	}
+	batch := b.take()
+	b.mu.Unlock()
+
+	return b.sink.PublishBatch(ctx, batch)
+}
+
+// Buffered returns the number of events waiting to be flushed
+func (b *BatchPublisher) Buffered() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// Flush publishes the buffered events now. The error also covers timer
+// flushes that failed since the last Flush.
+func (b *BatchPublisher) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	batch := b.take()
+	timerErrs := b.takeTimerErrs()
+	b.mu.Unlock()
+
+	return errors.Join(append(timerErrs, b.sink.PublishBatch(ctx, batch))...)
+}
+
+// Close flushes the buffered events; further events are rejected with
+// ErrBatchPublisherClosed. Like Flush, its error also covers failed timer
+// flushes, so once Close returns nil every event was delivered. It does not
+// close the underlying producer.
+func (b *BatchPublisher) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	batch := b.take()
+	timerErrs := b.takeTimerErrs()
+	b.mu.Unlock()
+
+	return errors.Join(append(timerErrs, b.sink.PublishBatch(ctx, batch))...)
+}
+
+func (b *BatchPublisher) flushOnTimer() {
+	b.mu.Lock()
+	batch := b.take()
+	b.mu.Unlock()
+
+	if err := b.sink.PublishBatch(context.Background(), batch); err != nil {
+		b.log.Error("Failed to flush event batch",
+			zap.Error(err),
+		)
+		b.mu.Lock()
+		b.timerErrs = append(b.timerErrs, err)
+		b.mu.Unlock()
+	}
+}
+
+// takeTimerErrs returns and clears the failed timer flushes; b.mu must be held
+func (b *BatchPublisher) takeTimerErrs() []error {
+	errs := b.timerErrs
+	b.timerErrs = nil
+	return errs
+}
+
+// take returns and clears the buffer; b.mu must be held
+func (b *BatchPublisher) take() []BatchMessage {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	batch := b.pending
+	b.pending = nil
+	return batch
+}
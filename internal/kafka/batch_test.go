@@ -0,0 +1,160 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+// batchRecorder records flushed batches instead of producing them
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]BatchMessage
+	err     error         // returned by every flush when set
+	flushed chan struct{} // signalled after every flush
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{flushed: make(chan struct{}, 16)}
+}
+
+func (r *batchRecorder) encodeEvent(ctx context.Context, event *events.Event) ([]byte, error) {
+	return []byte(event.ID), nil
+}
+
+func (r *batchRecorder) eventHeaders(event *events.Event) []kafka.Header {
+	return []kafka.Header{{Key: "event-type", Value: []byte(string(event.Type))}}
+}
+
+func (r *batchRecorder) PublishBatch(ctx context.Context, messages []BatchMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	r.mu.Lock()
+	r.batches = append(r.batches, messages)
+	err := r.err
+	r.mu.Unlock()
+	r.flushed <- struct{}{}
+	return err
+}
+
+func (r *batchRecorder) sizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sizes := make([]int, len(r.batches))
+	for i, batch := range r.batches {
+		sizes[i] = len(batch)
+	}
+	return sizes
+}
+
+func (b *BatchPublisher) hasTimerErrs() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.timerErrs) > 0
+}
+
+func testEvent(id string) *events.Event {
+	return &events.Event{ID: id, Type: "order.created"}
+}
+
+func TestBatchPublisherFlushesBySize(t *testing.T) {
+	sink := newBatchRecorder()
+	b := newBatchPublisher(sink, config.BatchConfig{MaxSize: 3, MaxDelay: time.Hour}, zap.NewNop())
+	ctx := context.Background()
+
+	for _, id := range []string{"1", "2"} {
+		if err := b.PublishEvent(ctx, "orders", []byte(id), testEvent(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sizes := sink.sizes(); len(sizes) != 0 {
+		t.Fatalf("flushed %v before the batch was full", sizes)
+	}
+
+	if err := b.PublishEventWithPartitionKey(ctx, "orders", "customer-1", []byte("3"), testEvent("3")); err != nil {
+		t.Fatal(err)
+	}
+	sizes := sink.sizes()
+	if len(sizes) != 1 || sizes[0] != 3 {
+		t.Fatalf("flushed %v, want one batch of 3", sizes)
+	}
+	if b.Buffered() != 0 {
+		t.Errorf("%d events still buffered after a full flush", b.Buffered())
+	}
+
+	last := sink.batches[0][2]
+	if last.Topic != "orders" || string(last.Key) != "3" || string(last.Value) != "3" || last.PartitionKey != "customer-1" {
+		t.Errorf("flushed message %+v, want the encoded event with its partition key", last)
+	}
+	if len(last.Headers) != 1 || string(last.Headers[0].Value) != "order.created" {
+		t.Errorf("flushed headers %v, want the event headers", last.Headers)
+	}
+}
+
+func TestBatchPublisherFlushesByTime(t *testing.T) {
+	sink := newBatchRecorder()
+	b := newBatchPublisher(sink, config.BatchConfig{MaxSize: 100, MaxDelay: 10 * time.Millisecond}, zap.NewNop())
+
+	if err := b.PublishEvent(context.Background(), "orders", nil, testEvent("1")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-sink.flushed:
+	case <-time.After(time.Second):
+		t.Fatal("buffered event was not flushed after MaxDelay")
+	}
+	if sizes := sink.sizes(); len(sizes) != 1 || sizes[0] != 1 {
+		t.Errorf("flushed %v, want one batch of 1", sizes)
+	}
+}
+
+func TestBatchPublisherFlushesOnClose(t *testing.T) {
+	sink := newBatchRecorder()
+	b := newBatchPublisher(sink, config.BatchConfig{MaxSize: 100, MaxDelay: time.Hour}, zap.NewNop())
+	ctx := context.Background()
+
+	for _, id := range []string{"1", "2", "3"} {
+		if err := b.PublishEvent(ctx, "orders", nil, testEvent(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if sizes := sink.sizes(); len(sizes) != 1 || sizes[0] != 3 {
+		t.Fatalf("flushed %v on close, want one batch of 3", sizes)
+	}
+
+	if err := b.PublishEvent(ctx, "orders", nil, testEvent("4")); !errors.Is(err, ErrBatchPublisherClosed) {
+		t.Errorf("PublishEvent after Close = %v, want ErrBatchPublisherClosed", err)
+	}
+}
+
+func TestBatchPublisherCloseReturnsTimerFlushErrors(t *testing.T) {
+	sink := newBatchRecorder()
+	sink.err = errors.New("broker unavailable")
+	b := newBatchPublisher(sink, config.BatchConfig{MaxSize: 100, MaxDelay: 10 * time.Millisecond}, zap.NewNop())
+
+	if err := b.PublishEvent(context.Background(), "orders", nil, testEvent("1")); err != nil {
+		t.Fatal(err)
+	}
+	// The timer records its error once the flush returns
+	deadline := time.Now().Add(time.Second)
+	for !b.hasTimerErrs() {
+		if time.Now().After(deadline) {
+			t.Fatal("failed timer flush was not recorded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := b.Close(context.Background()); !errors.Is(err, sink.err) {
+		t.Errorf("Close = %v, want the failed timer flush", err)
+	}
+}
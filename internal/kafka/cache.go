@@ -0,0 +1,101 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ResultCache memoizes values handlers look up by key, e.g. enrichment data
+// fetched from a downstream service. Implementations must be safe for concurrent use.
+type ResultCache interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{})
+}
+
+type resultCacheKey struct{}
+
+// SetResultCache makes the cache available to handlers through their context
+func (c *Consumer) SetResultCache(cache ResultCache) {
+	c.cache = cache
+}
+
+// ResultCacheFromContext returns the cache set on the consumer, if any
+func ResultCacheFromContext(ctx context.Context) (ResultCache, bool) {
+	cache, ok := ctx.Value(resultCacheKey{}).(ResultCache)
+	return cache, ok
+}
+
+// CachedLoad returns the cached value for key or calls load and caches its
+// result. Errors are not cached. Without a cache in ctx, load is always called.
+func CachedLoad(ctx context.Context, key string, load func() (interface{}, error)) (interface{}, error) {
+	cache, ok := ResultCacheFromContext(ctx)
+	if !ok {
+		return load()
+	}
+	if value, ok := cache.Get(key); ok {
+		return value, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+	cache.Set(key, value)
+	return value, nil
+}
+
+// TTLCache is an in-memory ResultCache whose entries expire after a fixed TTL
+type TTLCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]cacheEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type cacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// NewTTLCache creates a cache keeping entries for ttl
+func NewTTLCache(ttl time.Duration) *TTLCache {
+	return &TTLCache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+// Get returns the value cached for key unless it has expired
+func (c *TTLCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set caches value for key for the cache's TTL
+func (c *TTLCache) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.entries[key] = cacheEntry{value: value, expiresAt: now.Add(c.ttl)}
+
+	// Drop expired entries at most once per TTL so the map doesn't grow unbounded
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	c.lastSweep = now
+	for k, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+}
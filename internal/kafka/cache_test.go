@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tanint/go-eda/internal/config"
+)
+
+func TestCachedLoadReusesResultsWithinTheTTL(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cache.now = clock.now
+
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	c.SetResultCache(cache)
+	fetches := make(map[string]int)
+	c.RegisterHandler("orders", func(ctx context.Context, msg *Message) error {
+		_, err := CachedLoad(ctx, string(msg.Key), func() (interface{}, error) {
+			fetches[string(msg.Key)]++
+			return "customer " + string(msg.Key), nil
+		})
+		return err
+	})
+	handle := func(key string) {
+		msg := testMessage("orders", 0)
+		msg.Key = []byte(key)
+		if err := c.processMessage(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handle("customer-1")
+	handle("customer-1")
+	handle("customer-2")
+	clock.advance(59 * time.Second)
+	handle("customer-1")
+	if fetches["customer-1"] != 1 || fetches["customer-2"] != 1 {
+		t.Errorf("fetched %v within the TTL, want each key once", fetches)
+	}
+
+	clock.advance(time.Second)
+	handle("customer-1")
+	if fetches["customer-1"] != 2 {
+		t.Errorf("fetched customer-1 %d times, want a re-fetch once it expired", fetches["customer-1"])
+	}
+}
+
+func TestCachedLoadDoesNotCacheErrors(t *testing.T) {
+	ctx := context.WithValue(context.Background(), resultCacheKey{}, NewTTLCache(time.Minute))
+	calls := 0
+	load := func() (interface{}, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("downstream unavailable")
+		}
+		return "value", nil
+	}
+
+	if _, err := CachedLoad(ctx, "key", load); err == nil {
+		t.Fatal("CachedLoad() succeeded, want the load error")
+	}
+	if value, err := CachedLoad(ctx, "key", load); err != nil || value != "value" || calls != 2 {
+		t.Errorf("CachedLoad() = %v, %v after %d loads, want the retried value", value, err, calls)
+	}
+}
+
+func TestCachedLoadWithoutACacheAlwaysLoads(t *testing.T) {
+	calls := 0
+	for i := 0; i < 2; i++ {
+		CachedLoad(context.Background(), "key", func() (interface{}, error) {
+			calls++
+			return nil, nil
+		})
+	}
+	if calls != 2 {
+		t.Errorf("loaded %d times, want every call without a cache", calls)
+	}
+}
@@ -2,162 +2,882 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/tanint/go-eda/internal/config"
 	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/metrics"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// defaultHandlerTimeout bounds a handler invocation when no timeout is configured
+const defaultHandlerTimeout = 30 * time.Second
+
+// Shutdown commit policies
+const (
+	// ShutdownCommit commits the last processed offsets when the consumer stops,
+	// minimizing reprocessing after a restart
+	ShutdownCommit = "commit"
+	// ShutdownDiscard leaves uncommitted offsets alone so those messages are
+	// processed again after a restart
+	ShutdownDiscard = "discard"
+)
+
+// Delivery modes
+const (
+	// DeliveryAtLeastOnce commits a message's offset after its handler ran, so
+	// a crash mid-processing redelivers it (handlers must be idempotent)
+	DeliveryAtLeastOnce = "at_least_once"
+	// DeliveryAtMostOnce commits a message's offset before its handler runs, so
+	// it is never redelivered: a crash or handler failure loses the message.
+	// Meant for low-value, high-volume streams where duplicates cost more than gaps.
+	DeliveryAtMostOnce = "at_most_once"
+)
+
+// Commit modes
+const (
+	// CommitSync commits every message's offset before reading the next one
+	CommitSync = "sync"
+	// CommitAsync commits processed offsets in batches every commit interval and
+	// on shutdown, so a crash may reprocess up to one interval of messages
+	CommitAsync = "async"
+	// CommitBatch commits processed offsets every commit batch size messages
+	// and on shutdown, so a crash may reprocess up to one batch of messages
+	CommitBatch = "batch"
+)
+
+// Defaults for batched commits
+const (
+	defaultCommitInterval  = 5 * time.Second
+	defaultCommitBatchSize = 100
+)
+
+// Message is a Kafka message as delivered to handlers
+type Message = kafka.Message
+
 // MessageHandler is a function type for handling consumed messages
-type MessageHandler func(ctx context.Context, msg *kafka.Message) error
+type MessageHandler func(ctx context.Context, msg *Message) error
+
+// PoisonMessageHandler is notified when a message is dead-lettered after its
+// handler failed; err wraps ErrHandlerFailed and the handler's final error
+type PoisonMessageHandler func(msg *Message, err error)
+
+// messageReader is the subset of the Kafka client used to read messages
+type messageReader interface {
+	ReadMessage(timeout time.Duration) (*kafka.Message, error)
+}
 
 // Consumer wraps Kafka consumer with additional functionality
 type Consumer struct {
+	// handlersMu guards handlers, which may be registered while Start runs
+	handlersMu sync.RWMutex
+
 	consumer *kafka.Consumer
+	reads    messageReader // the consumer, unless replaced in tests
+	commits  committer     // the consumer, unless replaced in tests
+	flow     partitionFlow // the consumer, unless replaced in tests
+	lags     lagSource     // the consumer, unless replaced in tests
 	config   config.KafkaConfig
-	handlers map[string]MessageHandler
+	handlers map[string]MessageHandler // guarded by handlersMu
+	typed    map[string]*eventRouter   // topics of RegisterHandlerT, guarded by handlersMu
+	delays   *delayQueue
+	now      func() time.Time
+	onPoison PoisonMessageHandler
+	onError  ErrorHandler
+	offsets  *offsetTracker
+	limiter  *rateLimiter
+	retries  *retryPolicies
+	sleep    func(ctx context.Context, d time.Duration) error
+
+	middleware []Middleware // wraps every handler, see Use; guarded by handlersMu
+
+	paused       atomic.Bool // set by Pause and Resume
+	pauseApplied bool        // whether the read loop has paused the assignment
+
+	nextCommit      time.Time      // next batched commit in async commit mode
+	committing      atomic.Bool    // whether an async commit is in flight
+	inFlightCommits sync.WaitGroup // async commits in flight
+
+	topics      []string
+	resubscribe chan chan error
+
+	quarantine      *Producer
+	quarantineTopic string
+
+	deadLetters deadLetterSink // nil unless SetDeadLetterQueue was called
+
+	cache ResultCache
+
+	startFrom time.Time // position for partitions without committed offsets
+
+	serializer events.Serializer // format of messages without a content-type header
+	decoder    events.Decoder    // decodes events for typed handlers, see DecoderFromContext
+
+	pool *workerPool // nil when messages are handled on the read loop
+
+	coordinator *coordinatorWait
+
+	hooks RebalanceHooks // set by Subscribe
+
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+
+	log logger.Logger
+
+	run run
 }
 
-// NewConsumer creates a new Kafka consumer
-func NewConsumer(cfg config.KafkaConfig, groupID string) (*Consumer, error) {
+// NewConsumer creates a new Kafka consumer logging to log, or to the global
+// logger when log is nil
+func NewConsumer(cfg config.KafkaConfig, groupID string, log logger.Logger) (*Consumer, error) {
+	if log == nil {
+		log = logger.Global()
+	}
+	isolationLevel, err := isolationLevel(cfg.Consumer)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Consumer.MissingTopics {
+	case "", MissingTopicsWarn, MissingTopicsFail:
+	default:
+		return nil, fmt.Errorf("invalid missing topics policy %q: must be %s or %s",
+			cfg.Consumer.MissingTopics, MissingTopicsWarn, MissingTopicsFail)
+	}
+
+	switch cfg.Consumer.DeliveryMode {
+	case "", DeliveryAtLeastOnce, DeliveryAtMostOnce:
+	default:
+		return nil, fmt.Errorf("invalid delivery mode %q: must be %s or %s",
+			cfg.Consumer.DeliveryMode, DeliveryAtLeastOnce, DeliveryAtMostOnce)
+	}
+
+	switch cfg.Consumer.CommitMode {
+	case "", CommitSync, CommitAsync, CommitBatch:
+	default:
+		return nil, fmt.Errorf("invalid commit mode %q: must be %s, %s or %s",
+			cfg.Consumer.CommitMode, CommitSync, CommitAsync, CommitBatch)
+	}
+
+	if shard := cfg.Consumer.Shard; shard.Total > 1 && (shard.Index < 0 || shard.Index >= shard.Total) {
+		return nil, fmt.Errorf("invalid shard index %d: must be between 0 and %d", shard.Index, shard.Total-1)
+	}
+
+	if slow := cfg.Consumer.SlowHandlerThreshold; slow > 0 {
+		timeout := cfg.Consumer.HandlerTimeout
+		if timeout <= 0 {
+			timeout = defaultHandlerTimeout
+		}
+		if slow >= timeout {
+			return nil, fmt.Errorf("invalid slow handler threshold %s: must be less than the handler timeout %s", slow, timeout)
+		}
+	}
+
+	serializer, err := newSerializer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var startFrom time.Time
+	if cfg.Consumer.StartFromTime != "" {
+		if startFrom, err = time.Parse(time.RFC3339, cfg.Consumer.StartFromTime); err != nil {
+			return nil, fmt.Errorf("invalid start_from_time %q (expected RFC3339): %w", cfg.Consumer.StartFromTime, err)
+		}
+	}
+
+	switch cfg.Consumer.ShutdownCommitPolicy {
+	case "", ShutdownCommit, ShutdownDiscard:
+	default:
+		return nil, fmt.Errorf("invalid shutdown commit policy %q: must be %s or %s",
+			cfg.Consumer.ShutdownCommitPolicy, ShutdownCommit, ShutdownDiscard)
+	}
+
+	autoOffsetReset := cfg.Consumer.AutoOffsetReset
+	switch autoOffsetReset {
+	case "":
+		autoOffsetReset = AutoOffsetResetEarliest
+	case AutoOffsetResetEarliest, AutoOffsetResetLatest, AutoOffsetResetError:
+	default:
+		return nil, fmt.Errorf("invalid auto offset reset %q: must be %s, %s or %s",
+			autoOffsetReset, AutoOffsetResetEarliest, AutoOffsetResetLatest, AutoOffsetResetError)
+	}
+
 	configMap := &kafka.ConfigMap{
-		"bootstrap.servers":  cfg.Brokers,
+		"bootstrap.servers":  strings.Join(cfg.Brokers, ","),
 		"group.id":           groupID,
-		"auto.offset.reset":  "earliest",
+		"auto.offset.reset":  autoOffsetReset,
 		"enable.auto.commit": false,
 		"session.timeout.ms": 6000,
+		"isolation.level":    isolationLevel,
 	}
 
-	// Add security configuration if needed
-	if cfg.SecurityProtocol != "PLAINTEXT" {
-		configMap.SetKey("security.protocol", cfg.SecurityProtocol)
-		configMap.SetKey("sasl.mechanism", cfg.SASLMechanism)
-		configMap.SetKey("sasl.username", cfg.SASLUsername)
-		configMap.SetKey("sasl.password", cfg.SASLPassword)
-	}
+	ApplySecurityConfig(configMap, cfg)
 
-	consumer, err := kafka.NewConsumer(configMap)
+	consumer, err := createWithRetry("consumer", cfg.StartupRetry, log, func() (*kafka.Consumer, error) {
+		return newKafkaConsumer(configMap)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create consumer: %w", err)
 	}
 
-	logger.Info("Kafka consumer initialized successfully",
+	log.Info("Kafka consumer initialized successfully",
 		zap.Strings("brokers", cfg.Brokers),
 		zap.String("group_id", groupID),
+		zap.String("isolation_level", isolationLevel),
+		zap.String("delivery_mode", cfg.Consumer.DeliveryMode),
+		zap.String("commit_mode", cfg.Consumer.CommitMode),
 	)
 
 	return &Consumer{
 		consumer: consumer,
+		reads:    consumer,
+		commits:  consumer,
+		flow:     consumer,
+		lags:     consumer,
 		config:   cfg,
 		handlers: make(map[string]MessageHandler),
+		delays:   newDelayQueue(),
+		now:      time.Now,
+		offsets:  newOffsetTracker(),
+		limiter:  newRateLimiter(cfg.Consumer.MaxMessagesPerSecond),
+		retries:  newRetryPolicies(cfg.Consumer.Retry),
+		sleep:    sleepContext,
+
+		resubscribe: make(chan chan error),
+		startFrom:   startFrom,
+		serializer:  serializer,
+		decoder:     events.Decoder{Strict: cfg.Consumer.StrictDecoding},
+		coordinator: &coordinatorWait{timeout: cfg.Consumer.CoordinatorTimeout, log: log},
+		tracer:      defaultTracer(),
+		propagator:  propagation.TraceContext{},
+		log:         log,
 	}, nil
 }
 
-// Subscribe subscribes to topics with their handlers
-func (c *Consumer) Subscribe(topics []string) error {
-	err := c.consumer.SubscribeTopics(topics, nil)
+// newKafkaConsumer creates the client of NewConsumer; replaceable for tests
+var newKafkaConsumer = kafka.NewConsumer
+
+// isolationLevel validates the configured isolation level. Consumers read only
+// committed messages unless told otherwise, so transactional producers are
+// honored by default.
+func isolationLevel(cfg config.ConsumerConfig) (string, error) {
+	switch cfg.IsolationLevel {
+	case "":
+		return "read_committed", nil
+	case "read_committed", "read_uncommitted":
+		return cfg.IsolationLevel, nil
+	default:
+		return "", fmt.Errorf("invalid isolation level %q: must be read_committed or read_uncommitted", cfg.IsolationLevel)
+	}
+}
+
+// Subscribe subscribes to topics with their handlers. Optional hooks are
+// notified when partitions are assigned or revoked; only the last is used.
+func (c *Consumer) Subscribe(topics []string, hooks ...RebalanceHooks) error {
+	if err := checkTopics(c.consumer, topics, c.config.Consumer.MissingTopics, c.log); err != nil {
+		return err
+	}
+
+	err := c.consumer.SubscribeTopics(topics, c.rebalance)
 	if err != nil {
 		return fmt.Errorf("failed to subscribe to topics: %w", err)
 	}
+	c.topics = topics
+	if len(hooks) > 0 {
+		c.hooks = hooks[len(hooks)-1]
+	}
 
-	logger.Info("Subscribed to topics",
+	c.log.Info("Subscribed to topics",
 		zap.Strings("topics", topics),
 	)
 
 	return nil
 }
 
-// RegisterHandler registers a message handler for a specific topic
+// RegisterHandler registers a message handler for a specific topic,
+// replacing any handler registered for it, typed ones included. It is safe
+// to call while the consumer is running.
 func (c *Consumer) RegisterHandler(topic string, handler MessageHandler) {
+	c.handlersMu.Lock()
 	c.handlers[topic] = handler
-	logger.Info("Registered handler for topic",
+	delete(c.typed, topic)
+	c.handlersMu.Unlock()
+	c.log.Info("Registered handler for topic",
 		zap.String("topic", topic),
 	)
 }
 
-// Start starts consuming messages
+// OnPoisonMessage registers a callback invoked when a message is dead-lettered
+// after its handler failed for good, so operators can be alerted. The callback runs in
+// its own goroutine and never blocks consumption.
+func (c *Consumer) OnPoisonMessage(fn PoisonMessageHandler) {
+	c.onPoison = fn
+}
+
+// Start starts consuming messages until ctx is cancelled or Shutdown is
+// called. In-flight messages are then finished and committed before it
+// returns; see ConsumerConfig.DrainTimeout.
 func (c *Consumer) Start(ctx context.Context) error {
-	logger.Info("Starting Kafka consumer...")
+	c.log.Info("Starting Kafka consumer...")
+
+	ctx, done := c.begin(ctx)
+	defer done()
+
+	if interval := c.config.Consumer.LagInterval; interval > 0 {
+		lagCtx, stopLag := context.WithCancel(ctx)
+		defer stopLag()
+		go c.reportLag(lagCtx, interval)
+	}
+
+	// Messages still queued on shutdown are skipped and left uncommitted
+	if workers := c.config.Consumer.Workers; workers > 1 {
+		c.pool = newWorkerPool(workers, c.config.Consumer.WorkerQueueSize, func(msg *kafka.Message) {
+			if ctx.Err() == nil {
+				c.handle(ctx, msg)
+			}
+		})
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
-			logger.Info("Consumer context cancelled, stopping...")
+			c.log.Info("Consumer context cancelled, stopping...")
+			if c.pool != nil {
+				c.pool.stop()
+			}
+			c.commitOnShutdown()
 			return ctx.Err()
+		case done := <-c.resubscribe:
+			if c.pool != nil {
+				c.pool.drain()
+			}
+			done <- c.rejoin()
 		default:
-			msg, err := c.consumer.ReadMessage(100 * time.Millisecond)
+			c.applyPause()
+			if !c.pauseApplied {
+				c.resumeDuePartitions()
+			}
+			c.commitDue()
+
+			msg, err := c.reads.ReadMessage(100 * time.Millisecond)
 			if err != nil {
+				err = readError(err)
 				// Timeout is not an error, continue
-				if err.(kafka.Error).Code() == kafka.ErrTimedOut {
+				if errors.Is(err, ErrConsumeTimeout) {
+					continue
+				}
+				// A coordinator still starting up is waited for quietly
+				if c.coordinator.waiting() && isCoordinatorUnavailable(err) {
+					backoff, err := c.coordinator.failed(c.now(), err)
+					if err != nil {
+						if c.pool != nil {
+							c.pool.stop()
+						}
+						return err
+					}
+					_ = c.sleep(ctx, backoff)
 					continue
 				}
-				logger.Error("Error reading message",
+				c.log.Error("Error reading message",
 					zap.Error(err),
 				)
+				c.reportError(err)
 				continue
 			}
+			c.coordinator.ready(c.now())
 
-			if err := c.processMessage(ctx, msg); err != nil {
-				logger.Error("Error processing message",
-					zap.Error(err),
-					zap.String("topic", *msg.TopicPartition.Topic),
-					zap.Int32("partition", msg.TopicPartition.Partition),
-					zap.String("offset", msg.TopicPartition.Offset.String()),
-				)
-				// Continue processing other messages even if one fails
+			// Other shards' partitions are left alone, uncommitted
+			if !c.inShard(msg.TopicPartition.Partition) {
 				continue
 			}
 
-			// Commit the message offset after successful processing
-			if _, err := c.consumer.CommitMessage(msg); err != nil {
-				logger.Error("Error committing message",
-					zap.Error(err),
+			if c.holdWhilePaused(msg) {
+				continue
+			}
+
+			// Scheduled messages that are not yet due are parked until their deliver-at time
+			if due, ok := deliverAt(msg); ok && c.now().Before(due) {
+				c.park(msg, due)
+				continue
+			}
+
+			// Throttle dispatch; a cancelled wait leaves the message uncommitted for redelivery
+			if err := c.limiter.wait(ctx); err != nil {
+				continue
+			}
+
+			if c.pool == nil {
+				c.handle(ctx, msg)
+				continue
+			}
+			// Blocks while the partition's worker is saturated; a cancelled
+			// submit leaves the message uncommitted for redelivery
+			c.pool.submit(ctx, msg)
+		}
+	}
+}
+
+// handle processes a message and records or commits its offset
+func (c *Consumer) handle(ctx context.Context, msg *kafka.Message) {
+	if c.config.Consumer.DeliveryMode == DeliveryAtMostOnce {
+		c.processAtMostOnce(ctx, msg)
+		return
+	}
+
+	if err := c.dispatch(ctx, msg); err != nil {
+		// Interrupted by shutdown: leave the message uncommitted for redelivery
+		if ctx.Err() != nil {
+			c.offsets.fail(msg)
+			return
+		}
+		c.log.Error("Error processing message",
+			zap.Error(err),
+			zap.String("topic", *msg.TopicPartition.Topic),
+			zap.Int32("partition", msg.TopicPartition.Partition),
+			zap.String("offset", msg.TopicPartition.Offset.String()),
+		)
+		c.reportError(handlerFailed(err))
+		if !c.deadLettering() {
+			// Continue processing other messages even if one fails; the
+			// failed one holds its partition's committed position, so it is
+			// redelivered after a restart or rebalance
+			c.offsets.fail(msg)
+			c.log.Warn("Holding partition's committed offset at failed message",
+				zap.String("topic", *msg.TopicPartition.Topic),
+				zap.Int32("partition", msg.TopicPartition.Partition),
+				zap.String("offset", msg.TopicPartition.Offset.String()),
+			)
+			return
+		}
+		// Commit past the message only once it is safely in the dead-letter
+		// topic; otherwise leave it uncommitted for redelivery
+		if err := c.deadLetter(ctx, msg, err); err != nil {
+			c.log.Error("Error dead-lettering message",
+				zap.Error(err),
+				zap.String("topic", *msg.TopicPartition.Topic),
+				zap.String("offset", msg.TopicPartition.Offset.String()),
+			)
+			c.offsets.fail(msg)
+			return
+		}
+		c.notifyPoison(msg, err)
+	}
+	// Behind a failed message of the partition nothing is committed
+	if !c.offsets.mark(msg) {
+		return
+	}
+
+	// Batched by commitDue in async and batch mode
+	if mode := c.config.Consumer.CommitMode; mode == CommitAsync || mode == CommitBatch {
+		return
+	}
+
+	// Commit the message offset after successful processing
+	if _, err := c.commits.CommitMessage(msg); err != nil {
+		c.log.Error("Error committing message",
+			zap.Error(err),
+			zap.String("topic", *msg.TopicPartition.Topic),
+		)
+		c.reportError(commitFailed(err))
+		return
+	}
+	c.offsets.committed(msg)
+}
+
+// processAtMostOnce commits the message's offset and only then runs its
+// handler; failures are reported but the message is not redelivered
+func (c *Consumer) processAtMostOnce(ctx context.Context, msg *kafka.Message) {
+	if _, err := c.commits.CommitMessage(msg); err != nil {
+		// Not committed, so skip it rather than risk processing it twice
+		c.log.Error("Error committing message before processing",
+			zap.Error(err),
+			zap.String("topic", *msg.TopicPartition.Topic),
+		)
+		c.reportError(commitFailed(err))
+		return
+	}
+
+	if err := c.dispatch(ctx, msg); err != nil {
+		c.log.Error("Error processing message, dropping it (at-most-once)",
+			zap.Error(err),
+			zap.String("topic", *msg.TopicPartition.Topic),
+			zap.Int32("partition", msg.TopicPartition.Partition),
+			zap.String("offset", msg.TopicPartition.Offset.String()),
+		)
+		c.reportError(handlerFailed(err))
+		if ctx.Err() == nil && c.deadLettering() {
+			if dlqErr := c.deadLetter(ctx, msg, err); dlqErr != nil {
+				c.log.Error("Error dead-lettering message",
+					zap.Error(dlqErr),
 					zap.String("topic", *msg.TopicPartition.Topic),
 				)
+				return
 			}
+			c.notifyPoison(msg, err)
 		}
 	}
 }
 
+// Resubscribe makes the consumer leave and rejoin its consumer group, forcing
+// a rebalance without restarting the process. The request is served by the
+// Start loop between messages, so in-flight work finishes and its offsets are
+// committed before the group is left.
+func (c *Consumer) Resubscribe(ctx context.Context) error {
+	done := make(chan error, 1)
+	select {
+	case c.resubscribe <- done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rejoin commits processed offsets, then unsubscribes and subscribes again
+func (c *Consumer) rejoin() error {
+	c.log.Info("Rejoining consumer group",
+		zap.Strings("topics", c.topics),
+	)
+
+	c.waitForCommits()
+	if pending := c.offsets.drain(); len(pending) > 0 {
+		if _, err := c.commits.CommitOffsets(pending); err != nil {
+			c.offsets.restore(pending)
+			return fmt.Errorf("%w before rejoining", commitFailed(err))
+		}
+	}
+
+	if err := c.consumer.Unsubscribe(); err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	// Paused partitions are released with the assignment; parked and failed
+	// messages were never committed and are redelivered after the rejoin
+	c.delays = newDelayQueue()
+	c.offsets.reset()
+
+	if err := c.consumer.SubscribeTopics(c.topics, c.rebalance); err != nil {
+		return fmt.Errorf("failed to resubscribe to topics: %w", err)
+	}
+
+	c.log.Info("Rejoined consumer group",
+		zap.Strings("topics", c.topics),
+	)
+	return nil
+}
+
+// inShard reports whether the partition belongs to this worker's shard:
+// partition % total == index. Without sharding every partition does.
+func (c *Consumer) inShard(partition int32) bool {
+	shard := c.config.Consumer.Shard
+	if shard.Total <= 1 {
+		return true
+	}
+	return int(partition)%shard.Total == shard.Index
+}
+
+// commitDue commits the offsets processed since the last batch once the
+// commit interval has elapsed (async mode) or the commit batch size messages
+// were processed (batch mode). It is a no-op in sync commit mode. Async
+// commits run in the background, one at a time, so a slow broker doesn't
+// hold up reading.
+func (c *Consumer) commitDue() {
+	switch c.config.Consumer.CommitMode {
+	case CommitAsync:
+		now := c.now()
+		if now.Before(c.nextCommit) || !c.committing.CompareAndSwap(false, true) {
+			return
+		}
+		c.nextCommit = now.Add(c.commitInterval())
+
+		pending := c.offsets.drain()
+		if len(pending) == 0 {
+			c.committing.Store(false)
+			return
+		}
+		c.inFlightCommits.Add(1)
+		go func() {
+			defer c.inFlightCommits.Done()
+			defer c.committing.Store(false)
+			c.commitPending(pending)
+		}()
+	case CommitBatch:
+		if c.offsets.marked() < c.commitBatchSize() {
+			return
+		}
+		if pending := c.offsets.drain(); len(pending) > 0 {
+			c.commitPending(pending)
+		}
+	}
+}
+
+// commitPending commits drained offsets, putting them back on failure so the
+// next commit retries them
+func (c *Consumer) commitPending(pending []kafka.TopicPartition) {
+	if _, err := c.commits.CommitOffsets(pending); err != nil {
+		c.log.Error("Error committing offsets",
+			zap.Error(err),
+			zap.Int("partitions", len(pending)),
+		)
+		c.offsets.restore(pending)
+		c.reportError(commitFailed(err))
+	}
+}
+
+// waitForCommits waits for an async commit in flight, so a later commit
+// cannot be overtaken by it
+func (c *Consumer) waitForCommits() {
+	c.inFlightCommits.Wait()
+}
+
+// commitInterval returns the configured interval between batched commits
+func (c *Consumer) commitInterval() time.Duration {
+	if c.config.Consumer.CommitInterval <= 0 {
+		return defaultCommitInterval
+	}
+	return c.config.Consumer.CommitInterval
+}
+
+// commitBatchSize returns the configured number of messages per batched commit
+func (c *Consumer) commitBatchSize() int {
+	if c.config.Consumer.CommitBatchSize <= 0 {
+		return defaultCommitBatchSize
+	}
+	return c.config.Consumer.CommitBatchSize
+}
+
+// commitOnShutdown applies the shutdown commit policy to offsets that were
+// processed but not yet committed
+func (c *Consumer) commitOnShutdown() {
+	c.waitForCommits()
+	pending := c.offsets.drain()
+	if len(pending) == 0 {
+		return
+	}
+
+	if c.config.Consumer.ShutdownCommitPolicy == ShutdownDiscard {
+		c.log.Info("Discarding uncommitted offsets on shutdown",
+			zap.Int("partitions", len(pending)),
+		)
+		return
+	}
+
+	if _, err := c.commits.CommitOffsets(pending); err != nil {
+		c.log.Error("Error committing offsets on shutdown",
+			zap.Error(err),
+		)
+		c.reportError(commitFailed(err))
+		return
+	}
+	c.log.Info("Committed processed offsets on shutdown",
+		zap.Int("partitions", len(pending)),
+	)
+}
+
+// park pauses the message's partition and rewinds it so the message is
+// redelivered once due, without holding it in memory meanwhile
+func (c *Consumer) park(msg *kafka.Message, due time.Time) {
+	tp := msg.TopicPartition
+	if !c.rewind(tp) {
+		return
+	}
+
+	c.delays.park(tp, due)
+	c.log.Debug("Parked scheduled message",
+		zap.String("topic", *tp.Topic),
+		zap.Int32("partition", tp.Partition),
+		zap.String("offset", tp.Offset.String()),
+		zap.Time("deliver_at", due),
+	)
+}
+
+// rewind pauses the partition and seeks it back to tp's offset, so that
+// message is read again once the partition is resumed. It reports whether the
+// partition was paused.
+func (c *Consumer) rewind(tp kafka.TopicPartition) bool {
+	if err := c.flow.Pause([]kafka.TopicPartition{tp}); err != nil {
+		c.log.Error("Error pausing partition",
+			zap.Error(err),
+			zap.String("topic", *tp.Topic),
+			zap.Int32("partition", tp.Partition),
+		)
+		return false
+	}
+	if err := c.flow.Seek(tp, 0); err != nil {
+		c.log.Error("Error rewinding partition",
+			zap.Error(err),
+			zap.String("topic", *tp.Topic),
+			zap.Int32("partition", tp.Partition),
+		)
+	}
+	return true
+}
+
+// resumeDuePartitions resumes partitions whose parked message is now due
+func (c *Consumer) resumeDuePartitions() {
+	due := c.delays.release(c.now())
+	if len(due) == 0 {
+		return
+	}
+	if err := c.flow.Resume(due); err != nil {
+		c.log.Error("Error resuming parked partitions",
+			zap.Error(err),
+		)
+	}
+}
+
+// notifyPoison invokes the poison message callback without blocking the consume loop
+func (c *Consumer) notifyPoison(msg *kafka.Message, err error) {
+	if c.onPoison == nil {
+		return
+	}
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				c.log.Error("Poison message callback panicked",
+					zap.Any("panic", r),
+					zap.String("topic", *msg.TopicPartition.Topic),
+				)
+			}
+		}()
+		c.onPoison(msg, handlerFailed(err))
+	}()
+}
+
 // processMessage processes a single message
 func (c *Consumer) processMessage(ctx context.Context, msg *kafka.Message) error {
 	topic := *msg.TopicPartition.Topic
 
-	logger.Debug("Received message",
+	c.log.Debug("Received message",
 		zap.String("topic", topic),
 		zap.Int32("partition", msg.TopicPartition.Partition),
 		zap.String("offset", msg.TopicPartition.Offset.String()),
 		zap.ByteString("key", msg.Key),
 	)
 
+	c.handlersMu.RLock()
 	handler, exists := c.handlers[topic]
+	middleware := c.middleware
+	c.handlersMu.RUnlock()
 	if !exists {
-		logger.Warn("No handler registered for topic",
+		c.log.Warn("No handler registered for topic",
 			zap.String("topic", topic),
 		)
 		return nil
 	}
+	// A panicking handler or middleware fails only its message
+	handler = Recover(c.log)(chain(handler, middleware))
 
-	// Process message with timeout
-	processCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	// Process message with timeout. The handler context is not cancelled on
+	// shutdown, so an in-flight handler (and anything it publishes) finishes
+	// before Start returns; the handler timeout still bounds it.
+	timeout := c.handlerTimeout()
+	processCtx, cancel := context.WithTimeout(c.handlerContext(ctx), timeout)
 	defer cancel()
+	if c.cache != nil {
+		processCtx = context.WithValue(processCtx, resultCacheKey{}, c.cache)
+	}
+
+	// Continue the publisher's trace, so events the handler publishes join it
+	processCtx, span := c.startProcessSpan(processCtx, msg)
+	processCtx = context.WithValue(processCtx, headersKey{}, MessageHeaders(msg))
+	processCtx = context.WithValue(processCtx, decoderKey{}, c.decoder)
+	if log, ok := c.log.(*zap.Logger); ok {
+		processCtx = logger.WithLogger(processCtx, log)
+	}
+	if id := c.correlationID(msg); id != "" {
+		processCtx = logger.WithCorrelationID(processCtx, id)
+	}
+
+	metrics.IncCounter(metrics.MessagesConsumed, metrics.Topic(topic))
+	start := time.Now()
+	stopWatchdog := c.watchSlowHandler(msg)
+	err := handler(processCtx, msg)
+	stopWatchdog()
+	endSpan(span, err)
+	metrics.RecordTiming(metrics.HandlerDuration, time.Since(start), metrics.Topic(topic))
+
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(processCtx.Err(), context.DeadlineExceeded) {
+			metrics.IncCounter(metrics.HandlerTimeouts, metrics.Topic(topic))
+			c.log.Warn("Handler timed out",
+				zap.String("topic", topic),
+				zap.Int32("partition", msg.TopicPartition.Partition),
+				zap.String("offset", msg.TopicPartition.Offset.String()),
+				zap.Duration("timeout", timeout),
+			)
+			return fmt.Errorf("handler timed out after %s: %w", timeout, err)
+		}
 
-	if err := handler(processCtx, msg); err != nil {
+		metrics.IncCounter(metrics.HandlerErrors, metrics.Topic(topic))
 		return fmt.Errorf("handler error: %w", err)
 	}
 
 	return nil
 }
 
+// correlationID returns the correlation ID of the message's event envelope,
+// decoded as handlers decode it, or an empty string for messages that are not
+// events. Messages of other serializers were transcoded to JSON by dispatch.
+func (c *Consumer) correlationID(msg *kafka.Message) string {
+	event, err := c.decoder.UnmarshalEnvelope(msg.Value)
+	if err != nil {
+		return ""
+	}
+	return event.CorrelationID
+}
+
+// handlerTimeout returns the configured per-message handler timeout
+func (c *Consumer) handlerTimeout() time.Duration {
+	if c.config.Consumer.HandlerTimeout <= 0 {
+		return defaultHandlerTimeout
+	}
+	return c.config.Consumer.HandlerTimeout
+}
+
+// watchSlowHandler reports the message's handler as slow if it is still
+// running after the slow handler threshold, without interrupting it. The
+// returned function stops the watch once the handler returns.
+func (c *Consumer) watchSlowHandler(msg *kafka.Message) (stop func()) {
+	threshold := c.config.Consumer.SlowHandlerThreshold
+	if threshold <= 0 {
+		return func() {}
+	}
+
+	timer := time.AfterFunc(threshold, func() {
+		topic := *msg.TopicPartition.Topic
+		metrics.IncCounter(metrics.SlowHandlers, metrics.Topic(topic))
+		c.log.Warn("Handler is running slowly",
+			zap.String("topic", topic),
+			zap.Int32("partition", msg.TopicPartition.Partition),
+			zap.String("offset", msg.TopicPartition.Offset.String()),
+			zap.Duration("threshold", threshold),
+			zap.Duration("timeout", c.handlerTimeout()),
+		)
+	})
+	return func() { timer.Stop() }
+}
+
 // Close closes the consumer
 func (c *Consumer) Close() error {
-	logger.Info("Closing Kafka consumer...")
+	c.log.Info("Closing Kafka consumer...")
 	if err := c.consumer.Close(); err != nil {
 		return fmt.Errorf("error closing consumer: %w", err)
 	}
-	logger.Info("Kafka consumer closed successfully")
+	c.log.Info("Kafka consumer closed successfully")
 	return nil
 }
@@ -0,0 +1,945 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/metrics"
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// commitRecorder records commits instead of sending them to a broker
+type commitRecorder struct {
+	mu       sync.Mutex
+	messages []kafka.TopicPartition   // positions committed by CommitMessage
+	batches  [][]kafka.TopicPartition // offsets committed by CommitOffsets
+	err      error                    // returned by every commit when set
+}
+
+func (r *commitRecorder) CommitMessage(msg *kafka.Message) ([]kafka.TopicPartition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.messages = append(r.messages, msg.TopicPartition)
+	return []kafka.TopicPartition{msg.TopicPartition}, nil
+}
+
+func (r *commitRecorder) CommitOffsets(offsets []kafka.TopicPartition) ([]kafka.TopicPartition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.batches = append(r.batches, offsets)
+	return offsets, nil
+}
+
+// committedOffset returns the offset of topic/partition in the last batch
+// committing it, or -1
+func (r *commitRecorder) committedOffset(topic string, partition int32) kafka.Offset {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.batches) - 1; i >= 0; i-- {
+		for _, tp := range r.batches[i] {
+			if *tp.Topic == topic && tp.Partition == partition {
+				return tp.Offset
+			}
+		}
+	}
+	return -1
+}
+
+// fakeClock is a settable clock for the consumer's now
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// newTestConsumer returns a consumer without a Kafka client, whose commits
+// are recorded and whose clock is fake
+func newTestConsumer(t *testing.T, cfg config.KafkaConfig) (*Consumer, *commitRecorder, *fakeClock) {
+	t.Helper()
+	serializer, err := events.NewSerializer(cfg.Serializer, cfg.CloudEventsSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commits := &commitRecorder{}
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := &Consumer{
+		commits:  commits,
+		config:   cfg,
+		handlers: make(map[string]MessageHandler),
+		delays:   newDelayQueue(),
+		now:      clock.now,
+		offsets:  newOffsetTracker(),
+		limiter:  newRateLimiter(0),
+		retries:  newRetryPolicies(cfg.Consumer.Retry),
+		sleep: func(ctx context.Context, d time.Duration) error {
+			clock.advance(d)
+			return ctx.Err()
+		},
+
+		serializer:  serializer,
+		decoder:     events.Decoder{Strict: cfg.Consumer.StrictDecoding},
+		coordinator: &coordinatorWait{log: zap.NewNop()},
+		tracer:      noop.NewTracerProvider().Tracer(tracerName),
+		propagator:  propagation.TraceContext{},
+		log:         zap.NewNop(),
+	}
+	return c, commits, clock
+}
+
+// testMessage returns the message at offset of partition 0 of topic
+func testMessage(topic string, offset kafka.Offset) *kafka.Message {
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 0, Offset: offset},
+		Key:            []byte("key"),
+		Value:          []byte(`{"id":"event-1"}`),
+	}
+}
+
+func succeed(context.Context, *Message) error { return nil }
+
+func TestSyncCommitModeCommitsEveryMessage(t *testing.T) {
+	for _, mode := range []string{"", CommitSync} {
+		c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{CommitMode: mode}})
+		c.RegisterHandler("orders", succeed)
+
+		for offset := kafka.Offset(0); offset < 3; offset++ {
+			c.handle(context.Background(), testMessage("orders", offset))
+		}
+
+		if len(commits.messages) != 3 {
+			t.Fatalf("mode %q: got %d message commits, want 3", mode, len(commits.messages))
+		}
+		if pending := c.offsets.drain(); len(pending) != 0 {
+			t.Errorf("mode %q: committed offsets still pending: %v", mode, pending)
+		}
+		c.commitDue()
+		if len(commits.batches) != 0 {
+			t.Errorf("mode %q: commitDue committed %d batches, want none", mode, len(commits.batches))
+		}
+	}
+}
+
+func TestAsyncCommitModeCommitsEveryInterval(t *testing.T) {
+	c, commits, clock := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		CommitMode:     CommitAsync,
+		CommitInterval: time.Second,
+	}})
+	c.RegisterHandler("orders", succeed)
+
+	c.handle(context.Background(), testMessage("orders", 0))
+	c.handle(context.Background(), testMessage("orders", 1))
+	if len(commits.messages) != 0 {
+		t.Fatalf("got %d message commits in async mode, want none", len(commits.messages))
+	}
+
+	c.commitDue()
+	c.waitForCommits()
+	if len(commits.batches) != 1 || commits.committedOffset("orders", 0) != 2 {
+		t.Fatalf("first commitDue: batches %v, want one committing offset 2", commits.batches)
+	}
+
+	c.handle(context.Background(), testMessage("orders", 2))
+	clock.advance(500 * time.Millisecond)
+	c.commitDue()
+	c.waitForCommits()
+	if len(commits.batches) != 1 {
+		t.Fatalf("commitDue before the interval elapsed committed; batches %v", commits.batches)
+	}
+
+	clock.advance(500 * time.Millisecond)
+	c.commitDue()
+	c.waitForCommits()
+	if len(commits.batches) != 2 || commits.committedOffset("orders", 0) != 3 {
+		t.Fatalf("commitDue after the interval: batches %v, want a second committing offset 3", commits.batches)
+	}
+}
+
+// slowCommitter holds offset commits until release is closed
+type slowCommitter struct {
+	*commitRecorder
+	started chan struct{} // signalled when a commit starts waiting
+	release chan struct{}
+}
+
+func (s *slowCommitter) CommitOffsets(offsets []kafka.TopicPartition) ([]kafka.TopicPartition, error) {
+	select {
+	case s.started <- struct{}{}:
+	default:
+	}
+	<-s.release
+	return s.commitRecorder.CommitOffsets(offsets)
+}
+
+func TestAsyncCommitDoesNotBlockReads(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		CommitMode:     CommitAsync,
+		CommitInterval: time.Millisecond,
+	}})
+	c.now = time.Now
+	slow := &slowCommitter{commitRecorder: commits, started: make(chan struct{}, 1), release: make(chan struct{})}
+	c.commits = slow
+	handled := make(chan kafka.Offset, 3)
+	c.RegisterHandler("orders", func(ctx context.Context, msg *Message) error {
+		handled <- msg.TopicPartition.Offset
+		return nil
+	})
+
+	feed, result := startTestConsumer(context.Background(), c)
+	send := func(offset kafka.Offset) {
+		t.Helper()
+		select {
+		case feed <- testMessage("orders", offset):
+		case <-time.After(time.Second):
+			t.Fatalf("message %d was not read while a commit was in flight", offset)
+		}
+		select {
+		case <-handled:
+		case <-time.After(time.Second):
+			t.Fatalf("message %d was not handled", offset)
+		}
+	}
+
+	send(0)
+	select {
+	case <-slow.started:
+	case <-time.After(time.Second):
+		t.Fatal("the processed offset was not committed")
+	}
+	send(1)
+	send(2)
+
+	close(slow.release)
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-result
+	if offset := commits.committedOffset("orders", 0); offset != 3 {
+		t.Errorf("committed offset %d after shutdown, want 3", offset)
+	}
+}
+
+func TestBatchCommitModeCommitsEveryBatchSize(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		CommitMode:      CommitBatch,
+		CommitBatchSize: 3,
+	}})
+	c.RegisterHandler("orders", succeed)
+
+	for offset := kafka.Offset(0); offset < 2; offset++ {
+		c.handle(context.Background(), testMessage("orders", offset))
+		c.commitDue()
+	}
+	if len(commits.batches) != 0 || len(commits.messages) != 0 {
+		t.Fatalf("committed before the batch was full: batches %v, messages %v", commits.batches, commits.messages)
+	}
+
+	c.handle(context.Background(), testMessage("orders", 2))
+	c.commitDue()
+	if len(commits.batches) != 1 || commits.committedOffset("orders", 0) != 3 {
+		t.Fatalf("full batch: batches %v, want one committing offset 3", commits.batches)
+	}
+}
+
+func TestFailedBatchCommitIsRetried(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		CommitMode:      CommitBatch,
+		CommitBatchSize: 1,
+	}})
+	c.RegisterHandler("orders", succeed)
+
+	commits.err = errors.New("broker unavailable")
+	c.handle(context.Background(), testMessage("orders", 0))
+	c.commitDue()
+
+	commits.err = nil
+	c.handle(context.Background(), testMessage("orders", 1))
+	c.commitDue()
+	if commits.committedOffset("orders", 0) != 2 {
+		t.Fatalf("batches %v, want offset 2 committed after the failed commit", commits.batches)
+	}
+}
+
+func TestShutdownFlushesPendingOffsets(t *testing.T) {
+	for _, mode := range []string{CommitAsync, CommitBatch} {
+		c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+			CommitMode:      mode,
+			CommitInterval:  time.Hour,
+			CommitBatchSize: 100,
+		}})
+		c.RegisterHandler("orders", succeed)
+
+		c.handle(context.Background(), testMessage("orders", 0))
+		c.commitDue() // async commits on its first call
+		c.handle(context.Background(), testMessage("orders", 1))
+		c.handle(context.Background(), testMessage("orders", 2))
+		c.commitDue()
+		c.waitForCommits()
+
+		batches := len(commits.batches)
+		c.commitOnShutdown()
+		if len(commits.batches) != batches+1 || commits.committedOffset("orders", 0) != 3 {
+			t.Errorf("mode %q: shutdown batches %v, want a final commit of offset 3", mode, commits.batches)
+		}
+	}
+}
+
+func TestShutdownCommitPolicy(t *testing.T) {
+	tests := []struct {
+		policy     string
+		wantCommit bool
+	}{
+		{policy: "", wantCommit: true},
+		{policy: ShutdownCommit, wantCommit: true},
+		{policy: ShutdownDiscard, wantCommit: false},
+	}
+	for _, tt := range tests {
+		c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+			CommitMode:           CommitAsync,
+			CommitInterval:       time.Hour,
+			ShutdownCommitPolicy: tt.policy,
+		}})
+		c.RegisterHandler("orders", succeed)
+		c.nextCommit = c.now().Add(time.Hour)
+
+		c.handle(context.Background(), testMessage("orders", 0))
+		c.commitOnShutdown()
+
+		if committed := len(commits.batches) == 1; committed != tt.wantCommit {
+			t.Errorf("policy %q: final commit = %v, want %v", tt.policy, committed, tt.wantCommit)
+		}
+	}
+}
+
+func TestFailedMessageIsNotCommittedWithoutDeadLetterTopic(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		CommitMode: CommitAsync,
+	}})
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		return errors.New("database unavailable")
+	})
+	c.nextCommit = c.now().Add(time.Hour)
+
+	c.handle(context.Background(), testMessage("orders", 0))
+	c.commitOnShutdown()
+
+	if len(commits.batches) != 0 || len(commits.messages) != 0 {
+		t.Fatalf("failed message was committed: batches %v, messages %v", commits.batches, commits.messages)
+	}
+}
+
+func TestFailedMessageHoldsItsPartitionsCommittedOffset(t *testing.T) {
+	for _, mode := range []string{CommitSync, CommitAsync} {
+		c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+			CommitMode: mode,
+		}})
+		c.RegisterHandler("orders", func(ctx context.Context, msg *Message) error {
+			if msg.TopicPartition.Partition == 0 && msg.TopicPartition.Offset == 1 {
+				return errors.New("database unavailable")
+			}
+			return nil
+		})
+		c.nextCommit = c.now().Add(time.Hour)
+
+		for offset := kafka.Offset(0); offset < 4; offset++ {
+			c.handle(context.Background(), testMessage("orders", offset))
+		}
+		other := testMessage("orders", 9)
+		other.TopicPartition.Partition = 1
+		c.handle(context.Background(), other)
+		c.commitOnShutdown()
+
+		// Offset commits carry the next offset to read, message commits the message's
+		if mode == CommitAsync && commits.committedOffset("orders", 0) != 1 {
+			t.Errorf("mode %q: committed position %d, want 1, the failed message", mode, commits.committedOffset("orders", 0))
+		}
+		if mode == CommitSync && (len(commits.messages) != 2 || commits.messages[1].Partition != 1) {
+			t.Errorf("mode %q: message commits %v, want offset 0 and the other partition's message", mode, commits.messages)
+		}
+	}
+}
+
+func TestRevokeReleasesFailedMessageGap(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{})
+	fail := true
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		if fail {
+			return errors.New("database unavailable")
+		}
+		return nil
+	})
+
+	c.handle(context.Background(), testMessage("orders", 0))
+	topic := "orders"
+	if err := c.rebalance(nil, kafka.RevokedPartitions{Partitions: []kafka.TopicPartition{{Topic: &topic, Partition: 0}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reassigned later, the partition is read again from the failed message
+	fail = false
+	c.handle(context.Background(), testMessage("orders", 0))
+	if len(commits.messages) != 1 || commits.messages[0].Offset != 0 {
+		t.Errorf("committed %v after the revoke, want the redelivered message", commits.messages)
+	}
+}
+
+// Run with -race: handlers and middleware are registered while workers
+// look them up for the messages they handle
+func TestRegisterHandlerWhileProcessing(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{})
+	c.RegisterHandler("orders", succeed)
+
+	pool := newWorkerPool(4, 10, func(msg *kafka.Message) {
+		c.handle(context.Background(), msg)
+	})
+
+	registered := make(chan struct{})
+	go func() {
+		defer close(registered)
+		for i := 0; i < 100; i++ {
+			c.RegisterHandler("orders", succeed)
+			c.RegisterHandler("payments", succeed)
+			c.Use(func(next MessageHandler) MessageHandler { return next })
+		}
+	}()
+
+	const messages = 200
+	for offset := kafka.Offset(0); offset < messages; offset++ {
+		msg := testMessage("orders", offset)
+		msg.TopicPartition.Partition = int32(offset % 4)
+		pool.submit(context.Background(), msg)
+	}
+	pool.stop()
+	<-registered
+
+	commits.mu.Lock()
+	defer commits.mu.Unlock()
+	if len(commits.messages) != messages {
+		t.Errorf("committed %d messages, want %d", len(commits.messages), messages)
+	}
+}
+
+func TestConsumerLogsToTheInjectedLogger(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	cluster := newMockCluster(t)
+	c, err := NewConsumer(config.KafkaConfig{Brokers: []string{cluster.BootstrapServers()}}, "orders", zap.New(core))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.commits = &commitRecorder{}
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		return errors.New("inventory unavailable")
+	})
+
+	c.handle(context.Background(), testMessage("orders", 3))
+
+	if n := logs.FilterMessage("Kafka consumer initialized successfully").Len(); n != 1 {
+		t.Errorf("logged %d initializations, want 1", n)
+	}
+	entries := logs.FilterMessage("Error processing message").All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d handler failures, want 1: %v", len(entries), logs.All())
+	}
+	if fields := entries[0].ContextMap(); fields["error"] != "handler error: inventory unavailable" || fields["offset"] != "3" {
+		t.Errorf("logged fields %v, want the handler error and offset", fields)
+	}
+}
+
+func TestNewConsumerSetsIsolationLevel(t *testing.T) {
+	tests := []struct {
+		configured string
+		want       string
+		wantErr    bool
+	}{
+		{configured: "", want: "read_committed"},
+		{configured: "read_committed", want: "read_committed"},
+		{configured: "read_uncommitted", want: "read_uncommitted"},
+		{configured: "committed", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.configured, func(t *testing.T) {
+			var configMap *kafka.ConfigMap
+			errStop := errors.New("not connecting")
+			newKafkaConsumer = func(cm *kafka.ConfigMap) (*kafka.Consumer, error) {
+				configMap = cm
+				return nil, errStop
+			}
+			t.Cleanup(func() { newKafkaConsumer = kafka.NewConsumer })
+
+			cfg := config.KafkaConfig{Consumer: config.ConsumerConfig{IsolationLevel: tt.configured}}
+			_, err := NewConsumer(cfg, "orders", zap.NewNop())
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "invalid isolation level") || configMap != nil {
+					t.Fatalf("got %v, want the invalid level rejected before creating the client", err)
+				}
+				return
+			}
+			if !errors.Is(err, errStop) {
+				t.Fatalf("got %v, want the client creation error", err)
+			}
+			if level, _ := configMap.Get("isolation.level", nil); level != tt.want {
+				t.Errorf("isolation.level = %v, want %s", level, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerTimeoutsAreCountedApartFromErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     MessageHandler
+		wantTimeout int64
+		wantError   int64
+	}{
+		{
+			name: "slow handler",
+			handler: func(ctx context.Context, msg *Message) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			wantTimeout: 1,
+		},
+		{
+			name: "slow handler wrapping the timeout",
+			handler: func(ctx context.Context, msg *Message) error {
+				<-ctx.Done()
+				return fmt.Errorf("inventory lookup: %w", ctx.Err())
+			},
+			wantTimeout: 1,
+		},
+		{
+			name: "failing handler",
+			handler: func(context.Context, *Message) error {
+				return errors.New("inventory unavailable")
+			},
+			wantError: 1,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, _ := newTestConsumer(t, config.KafkaConfig{
+				Consumer: config.ConsumerConfig{HandlerTimeout: 20 * time.Millisecond},
+			})
+			core, logs := observer.New(zap.InfoLevel)
+			c.log = zap.New(core)
+			topic := fmt.Sprintf("timeouts-%d", i)
+			c.RegisterHandler(topic, tt.handler)
+
+			c.handle(context.Background(), testMessage(topic, 0))
+
+			if n := metrics.CounterValue(metrics.HandlerTimeouts, metrics.Topic(topic)); n != tt.wantTimeout {
+				t.Errorf("%s = %d, want %d", metrics.HandlerTimeouts, n, tt.wantTimeout)
+			}
+			if n := metrics.CounterValue(metrics.HandlerErrors, metrics.Topic(topic)); n != tt.wantError {
+				t.Errorf("%s = %d, want %d", metrics.HandlerErrors, n, tt.wantError)
+			}
+			timeouts := logs.FilterMessage("Handler timed out").All()
+			if int64(len(timeouts)) != tt.wantTimeout {
+				t.Fatalf("logged %d timeouts, want %d", len(timeouts), tt.wantTimeout)
+			}
+			if len(timeouts) > 0 && timeouts[0].ContextMap()["timeout"] != 20*time.Millisecond {
+				t.Errorf("logged timeout %v, want the configured 20ms", timeouts[0].ContextMap()["timeout"])
+			}
+		})
+	}
+}
+
+func TestSlowHandlersAreReportedWithoutBeingCancelled(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		wantSlow int64
+	}{
+		{name: "fast handler", duration: 0},
+		{name: "slow handler", duration: 60 * time.Millisecond, wantSlow: 1},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+				HandlerTimeout:       time.Second,
+				SlowHandlerThreshold: 20 * time.Millisecond,
+			}})
+			core, logs := observer.New(zap.InfoLevel)
+			c.log = zap.New(core)
+			topic := fmt.Sprintf("slow-%d", i)
+			slow := metrics.CounterValue(metrics.SlowHandlers, metrics.Topic(topic))
+			var handlerErr error
+			c.RegisterHandler(topic, func(ctx context.Context, msg *Message) error {
+				time.Sleep(tt.duration)
+				handlerErr = ctx.Err()
+				return handlerErr
+			})
+
+			c.handle(context.Background(), testMessage(topic, 0))
+
+			if handlerErr != nil {
+				t.Fatalf("handler context ended with %v, want it left running", handlerErr)
+			}
+			if len(commits.messages) != 1 {
+				t.Errorf("committed %v, want the handled message committed", commits.messages)
+			}
+			if n := metrics.CounterValue(metrics.SlowHandlers, metrics.Topic(topic)) - slow; n != tt.wantSlow {
+				t.Errorf("%s = %d, want %d", metrics.SlowHandlers, n, tt.wantSlow)
+			}
+			warnings := logs.FilterMessage("Handler is running slowly").All()
+			if int64(len(warnings)) != tt.wantSlow {
+				t.Fatalf("logged %d slow handler warnings, want %d", len(warnings), tt.wantSlow)
+			}
+			if len(warnings) > 0 && warnings[0].ContextMap()["threshold"] != 20*time.Millisecond {
+				t.Errorf("logged threshold %v, want the configured 20ms", warnings[0].ContextMap()["threshold"])
+			}
+		})
+	}
+}
+
+func TestAtMostOnceCommitsBeforeTheHandlerRuns(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		DeliveryMode: DeliveryAtMostOnce,
+	}})
+	var committedBeforeHandling []int
+	c.RegisterHandler("orders", func(ctx context.Context, msg *Message) error {
+		committedBeforeHandling = append(committedBeforeHandling, len(commits.messages))
+		if msg.TopicPartition.Offset == 1 {
+			panic("handler crashed")
+		}
+		return nil
+	})
+
+	for offset := kafka.Offset(0); offset < 3; offset++ {
+		c.handle(context.Background(), testMessage("orders", offset))
+	}
+
+	if want := []int{1, 2, 3}; !slices.Equal(committedBeforeHandling, want) {
+		t.Errorf("commits seen by the handler %v, want %v: each message committed before it is handled", committedBeforeHandling, want)
+	}
+	// The crashed message stays committed, so it is not redelivered
+	c.commitOnShutdown()
+	if pending := c.offsets.drain(); len(pending) != 0 {
+		t.Errorf("offsets %v held back after the handler crashed, want none", pending)
+	}
+	if len(commits.messages) != 3 || commits.messages[1].Offset != 1 {
+		t.Errorf("committed %v, want every message including the crashed one", commits.messages)
+	}
+}
+
+func TestAtMostOnceSkipsMessagesItCannotCommit(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		DeliveryMode: DeliveryAtMostOnce,
+	}})
+	commits.err = errors.New("coordinator unavailable")
+	handled := 0
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		handled++
+		return nil
+	})
+
+	c.handle(context.Background(), testMessage("orders", 0))
+	if handled != 0 {
+		t.Errorf("handled a message whose commit failed %d times, want it skipped", handled)
+	}
+}
+
+func TestAtLeastOnceCommitsAfterTheHandlerRuns(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{})
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		if len(commits.messages) != 0 {
+			t.Error("message committed before its handler ran")
+		}
+		return nil
+	})
+
+	c.handle(context.Background(), testMessage("orders", 0))
+	if len(commits.messages) != 1 {
+		t.Errorf("got %d commits after handling, want 1", len(commits.messages))
+	}
+}
+
+func TestResubscribeDrainsRejoinsAndResumes(t *testing.T) {
+	cluster := newMockCluster(t)
+	if err := cluster.CreateTopic("rejoin", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	p := newClusterProducer(t, cluster)
+	c, err := NewConsumer(config.KafkaConfig{
+		Brokers:  []string{cluster.BootstrapServers()},
+		Consumer: config.ConsumerConfig{Workers: 2},
+	}, "rejoin", zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var mu sync.Mutex
+	var handled []string
+	c.RegisterHandler("rejoin", func(ctx context.Context, msg *Message) error {
+		if string(msg.Key) == "slow" {
+			close(started)
+			<-release
+		}
+		mu.Lock()
+		handled = append(handled, string(msg.Key))
+		mu.Unlock()
+		return nil
+	})
+	handledKeys := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), handled...)
+	}
+	if err := c.Subscribe([]string{"rejoin"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- c.Start(ctx) }()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	if err := p.Publish(ctx, "rejoin", []byte("slow"), []byte("order")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-started:
+	case <-time.After(20 * time.Second):
+		t.Fatal("first message never handled")
+	}
+
+	rejoined := make(chan error, 1)
+	go func() { rejoined <- c.Resubscribe(ctx) }()
+	select {
+	case err := <-rejoined:
+		t.Fatalf("Resubscribe() = %v before the in-flight message finished", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	close(release)
+	if err := <-rejoined; err != nil {
+		t.Fatal(err)
+	}
+	if keys := handledKeys(); !slices.Equal(keys, []string{"slow"}) {
+		t.Fatalf("handled %v when Resubscribe returned, want the drained in-flight message", keys)
+	}
+
+	if err := p.Publish(ctx, "rejoin", []byte("after"), []byte("order")); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(20 * time.Second)
+	for len(handledKeys()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	// The drained message was committed before rejoining, so it is not redelivered
+	if keys := handledKeys(); !slices.Equal(keys, []string{"slow", "after"}) {
+		t.Errorf("handled %v, want processing to resume after the rejoin", keys)
+	}
+}
+
+func TestShardProcessesOnlyItsPartitions(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		Shard: config.ShardConfig{Index: 0, Total: 2},
+	}})
+	var handled []int32
+	c.RegisterHandler("orders", func(ctx context.Context, msg *Message) error {
+		handled = append(handled, msg.TopicPartition.Partition)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	feed, result := startTestConsumer(ctx, c)
+	for partition := int32(0); partition < 4; partition++ {
+		msg := testMessage("orders", 10)
+		msg.TopicPartition.Partition = partition
+		feed <- msg
+	}
+	cancel()
+	<-result
+
+	if want := []int32{0, 2}; !slices.Equal(handled, want) {
+		t.Errorf("handled partitions %v, want only the even ones %v", handled, want)
+	}
+	for _, tp := range commits.messages {
+		if tp.Partition%2 != 0 {
+			t.Errorf("committed %v of another shard", tp)
+		}
+	}
+	for _, partition := range []int32{1, 3} {
+		if offset := commits.committedOffset("orders", partition); offset != -1 {
+			t.Errorf("committed offset %d of partition %d, want it left to its shard", offset, partition)
+		}
+	}
+}
+
+func TestNewConsumerRejectsShardIndexOutOfRange(t *testing.T) {
+	for _, index := range []int{-1, 2} {
+		_, err := NewConsumer(config.KafkaConfig{
+			Brokers:  []string{"localhost:9092"},
+			Consumer: config.ConsumerConfig{Shard: config.ShardConfig{Index: index, Total: 2}},
+		}, "orders", zap.NewNop())
+		if err == nil || !strings.Contains(err.Error(), "invalid shard index") {
+			t.Errorf("shard %d of 2: NewConsumer() = %v, want an invalid shard index error", index, err)
+		}
+	}
+}
+
+func TestPanickingHandlerDoesNotStopTheConsumer(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	core, logs := observer.New(zap.ErrorLevel)
+	c.log = zap.New(core)
+	handled := make(chan kafka.Offset, 2)
+	c.RegisterHandler("orders", func(ctx context.Context, msg *Message) error {
+		if msg.TopicPartition.Offset == 0 {
+			panic("handler crashed")
+		}
+		handled <- msg.TopicPartition.Offset
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	feed, result := startTestConsumer(ctx, c)
+	feed <- testMessage("orders", 0)
+	feed <- testMessage("orders", 1)
+	if offset := <-handled; offset != 1 {
+		t.Errorf("handled offset %d, want the message after the panic", offset)
+	}
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Start returned %v, want it to run until shutdown", err)
+	}
+
+	panics := logs.FilterMessage("Handler panicked").All()
+	if len(panics) != 1 {
+		t.Fatalf("logged %d panics, want 1", len(panics))
+	}
+	if fields := panics[0].ContextMap(); fields["panic"] != "handler crashed" || !strings.Contains(fields["stack"].(string), "TestPanickingHandlerDoesNotStopTheConsumer") {
+		t.Errorf("logged %v, want the panic value and a stack trace through the handler", fields)
+	}
+}
+
+func TestHandlerLogsCarryTheEventsCorrelationID(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	core, logs := observer.New(zap.InfoLevel)
+	c.log = zap.New(core)
+	c.RegisterHandler("orders", func(ctx context.Context, msg *Message) error {
+		logger.FromContext(ctx).Info("Handling order", zap.String("offset", msg.TopicPartition.Offset.String()))
+		return nil
+	})
+
+	c.handle(context.Background(), eventMessage("orders", 0, `{"id": "event-1", "type": "order.created", "correlation_id": "chain-1"}`))
+	c.handle(context.Background(), eventMessage("orders", 1, `{"id": "event-2", "type": "order.created", "correlation_id": "chain-2"}`))
+	c.handle(context.Background(), eventMessage("orders", 2, `{"id": "event-3", "type": "order.created"}`))
+
+	got := make(map[string]string)
+	for _, entry := range logs.FilterMessage("Handling order").All() {
+		fields := entry.ContextMap()
+		id, _ := fields["correlation_id"].(string)
+		got[fields["offset"].(string)] = id
+	}
+	if want := map[string]string{"0": "chain-1", "1": "chain-2", "2": ""}; !maps.Equal(got, want) {
+		t.Errorf("handler logged correlation IDs %v by offset, want %v", got, want)
+	}
+}
+
+// schemaRegistryStub stores the schemas the Avro serializer registers, by ID
+func schemaRegistryStub(t *testing.T) *events.SchemaRegistryClient {
+	t.Helper()
+	var mu sync.Mutex
+	var schemas []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPost {
+			var body struct {
+				Schema string `json:"schema"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			schemas = append(schemas, body.Schema)
+			json.NewEncoder(w).Encode(map[string]int{"id": len(schemas)})
+			return
+		}
+		id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/schemas/ids/"))
+		if id < 1 || id > len(schemas) {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"schema": schemas[id-1]})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := events.NewSchemaRegistryClient(server.URL, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestHandlerLogsCarryTheCorrelationIDOfEverySerializer(t *testing.T) {
+	avro := events.NewAvroSerializer(schemaRegistryStub(t), nil)
+	serializers := map[string]events.Serializer{
+		"json":        events.JSONSerializer{},
+		"protobuf":    events.ProtobufSerializer{},
+		"cloudevents": events.CloudEventsSerializer{Source: "/go-eda/order-service"},
+		"avro":        avro,
+	}
+	for name, serializer := range serializers {
+		t.Run(name, func(t *testing.T) {
+			c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+			c.SetSerializer(avro) // the schema registry of Avro messages
+			core, logs := observer.New(zap.InfoLevel)
+			c.log = zap.New(core)
+			c.RegisterHandler("orders", func(ctx context.Context, msg *Message) error {
+				logger.FromContext(ctx).Info("Handling order")
+				return nil
+			})
+
+			event := events.NewEvent(events.EventTypeOrderCreated, events.OrderCreatedEvent{
+				Order: models.Order{ID: "order-1", CustomerID: "customer-1", Currency: "EUR"},
+			})
+			event.CorrelationID = "chain-" + name
+			value, err := serializer.Marshal(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg := testMessage("orders", 0)
+			msg.Value = value
+			msg.Headers = []kafka.Header{{Key: HeaderContentType, Value: []byte(serializer.ContentType())}}
+			c.handle(context.Background(), msg)
+
+			// Logged to the consumer's logger, not the global one
+			entries := logs.FilterMessage("Handling order").All()
+			if len(entries) != 1 {
+				t.Fatalf("the consumer's logger got %d handler entries, want 1", len(entries))
+			}
+			if id := entries[0].ContextMap()["correlation_id"]; id != event.CorrelationID {
+				t.Errorf("correlation_id %v, want %s", id, event.CorrelationID)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// ContextKey identifies a request-scoped context value that is stamped on
+// published events as metadata under the same name
+type ContextKey string
+
+// Context keys propagated to event metadata by default
+const (
+	ContextTenant    ContextKey = "tenant"
+	ContextPrincipal ContextKey = "principal"
+	ContextTrace     ContextKey = "trace_id"
+)
+
+// defaultContextKeys are propagated by every producer
+var defaultContextKeys = []ContextKey{ContextTenant, ContextPrincipal, ContextTrace}
+
+// WithContextValue returns a copy of ctx carrying value under key, to be
+// picked up by PublishEvent and PublishEventWithPartitionKey
+func WithContextValue(ctx context.Context, key ContextKey, value string) context.Context {
+	return context.WithValue(ctx, key, value)
+}
+
+// PropagateContextKeys registers additional context keys whose values are
+// stamped on published events
+func (p *Producer) PropagateContextKeys(keys ...ContextKey) {
+	p.contextKeys = append(p.contextKeys, keys...)
+}
+
+// stampContext copies the registered context values onto the event metadata.
+// Metadata already set on the event wins.
+func (p *Producer) stampContext(ctx context.Context, event *events.Event) {
+	stampContext(ctx, event, p.contextKeys)
+}
+
+// StampContext copies the values of the default context keys (tenant,
+// principal, trace ID) onto the event metadata now, for events published
+// later without the request context, e.g. through an outbox
+func StampContext(ctx context.Context, event *events.Event) {
+	stampContext(ctx, event, defaultContextKeys)
+}
+
+func stampContext(ctx context.Context, event *events.Event, keys []ContextKey) {
+	for _, key := range keys {
+		value, ok := ctx.Value(key).(string)
+		if !ok || value == "" {
+			continue
+		}
+		if _, set := event.Metadata[string(key)]; set {
+			continue
+		}
+		event.SetMetadata(string(key), value)
+	}
+}
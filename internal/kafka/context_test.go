@@ -0,0 +1,41 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+func TestPublishEventStampsContextValues(t *testing.T) {
+	cluster := newMockCluster(t)
+	if err := cluster.CreateTopic("stamped", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	p := newClusterProducer(t, cluster)
+	serializer := &snapshotSerializer{}
+	p.SetSerializer(serializer)
+	p.PropagateContextKeys("region")
+
+	ctx := WithContextValue(context.Background(), ContextTenant, "acme")
+	ctx = WithContextValue(ctx, ContextPrincipal, "user-1")
+	ctx = WithContextValue(ctx, "region", "eu")
+	ctx = WithContextValue(ctx, "unregistered", "ignored")
+
+	event := events.NewEvent(events.EventTypeOrderCreated, nil)
+	event.SetMetadata("principal", "service-account") // set by the caller, wins
+	if err := p.PublishEvent(ctx, "stamped", nil, event); err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := serializer.metadata[0]
+	want := map[string]string{"tenant": "acme", "principal": "service-account", "region": "eu"}
+	for key, value := range want {
+		if got := metadata[key]; got != value {
+			t.Errorf("metadata %s = %q, want %q", key, got, value)
+		}
+	}
+	if got, ok := metadata["unregistered"]; ok {
+		t.Errorf("metadata carries the unregistered key: %q", got)
+	}
+}
@@ -0,0 +1,82 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Backoff between polls while waiting for the group coordinator
+const (
+	coordinatorBackoff    = 100 * time.Millisecond
+	coordinatorMaxBackoff = 5 * time.Second
+)
+
+// isCoordinatorUnavailable reports whether err means the consumer group's
+// coordinator can't be reached yet, as while a broker starts up
+func isCoordinatorUnavailable(err error) bool {
+	var kerr kafka.Error
+	if !errors.As(err, &kerr) {
+		return false
+	}
+	switch kerr.Code() {
+	case kafka.ErrWaitCoord, kafka.ErrCoordinatorNotAvailable,
+		kafka.ErrNotCoordinator, kafka.ErrCoordinatorLoadInProgress:
+		return true
+	}
+	return false
+}
+
+// coordinatorWait tolerates the group coordinator being unavailable until
+// the consumer first joins its group: the wait is logged once and polling
+// backs off, until the timeout (0 waits indefinitely) turns it into an error.
+// It is only used from the read loop.
+type coordinatorWait struct {
+	timeout time.Duration
+	joined  bool
+	since   time.Time // first failure of the current wait; zero when not waiting
+	retries int
+
+	log logger.Logger
+}
+
+// failed records a coordinator-unavailable error at now and returns how long
+// to back off before polling again, or an error once the wait timed out
+func (w *coordinatorWait) failed(now time.Time, err error) (time.Duration, error) {
+	if w.since.IsZero() {
+		w.since = now
+		w.log.Warn("Waiting for group coordinator",
+			zap.Error(err),
+			zap.Duration("timeout", w.timeout),
+		)
+	}
+	if w.timeout > 0 && now.Sub(w.since) >= w.timeout {
+		return 0, fmt.Errorf("group coordinator unavailable for %s: %w", w.timeout, err)
+	}
+
+	w.retries++
+	return exponentialBackoff(coordinatorBackoff, 2, coordinatorMaxBackoff, w.retries), nil
+}
+
+// ready records that the consumer joined its group; later coordinator
+// errors are no longer tolerated as startup delays
+func (w *coordinatorWait) ready(now time.Time) {
+	if w.joined {
+		return
+	}
+	w.joined = true
+	if !w.since.IsZero() {
+		w.log.Info("Group coordinator available",
+			zap.Duration("waited", now.Sub(w.since)),
+		)
+	}
+}
+
+// waiting reports whether coordinator errors are still treated as transient
+func (w *coordinatorWait) waiting() bool {
+	return !w.joined
+}
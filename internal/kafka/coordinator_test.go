@@ -0,0 +1,112 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// scriptedReads fails the first reads with errs, then serves feed
+type scriptedReads struct {
+	errs []error
+	feed messageFeed
+}
+
+func (r *scriptedReads) ReadMessage(timeout time.Duration) (*kafka.Message, error) {
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		return nil, err
+	}
+	return r.feed.ReadMessage(timeout)
+}
+
+func coordinatorUnavailable() error {
+	return kafka.NewError(kafka.ErrCoordinatorNotAvailable, "coordinator not available", false)
+}
+
+func TestConsumerWaitsQuietlyForTheCoordinator(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	core, logs := observer.New(zap.InfoLevel)
+	c.log = zap.New(core)
+	c.coordinator = &coordinatorWait{timeout: time.Minute, log: c.log}
+	var sleeps []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	errs := &errorRecorder{}
+	c.OnError(errs.record)
+	handled := make(chan struct{})
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		close(handled)
+		return nil
+	})
+
+	feed := make(messageFeed)
+	c.reads = &scriptedReads{
+		errs: []error{coordinatorUnavailable(), kafka.NewError(kafka.ErrWaitCoord, "waiting for coordinator", false), coordinatorUnavailable()},
+		feed: feed,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- c.Start(ctx) }()
+
+	feed <- testMessage("orders", 0)
+	<-handled
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("Start returned %v, want context.Canceled", err)
+	}
+
+	if want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}; !slices.Equal(sleeps, want) {
+		t.Errorf("backed off %v, want %v", sleeps, want)
+	}
+	if n := logs.FilterMessage("Waiting for group coordinator").Len(); n != 1 {
+		t.Errorf("logged the wait %d times, want once", n)
+	}
+	if n := logs.FilterMessage("Group coordinator available").Len(); n != 1 {
+		t.Errorf("logged the coordinator becoming available %d times, want once", n)
+	}
+	if n := logs.FilterLevelExact(zap.ErrorLevel).Len(); n != 0 || len(errs.errors) != 0 {
+		t.Errorf("logged %d errors and reported %v, want the wait tolerated", n, errs.errors)
+	}
+}
+
+func TestConsumerFailsOnceTheCoordinatorWaitTimesOut(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	c.coordinator = &coordinatorWait{timeout: 10 * time.Second, log: zap.NewNop()}
+	errs := make([]error, 100)
+	for i := range errs {
+		errs[i] = coordinatorUnavailable()
+	}
+	c.reads = &scriptedReads{errs: errs, feed: make(messageFeed)}
+
+	err := c.Start(context.Background())
+	if !isCoordinatorUnavailable(err) {
+		t.Fatalf("Start returned %v, want the coordinator error", err)
+	}
+	if len(c.reads.(*scriptedReads).errs) == 0 {
+		t.Error("kept polling through every error, want to give up after the timeout")
+	}
+}
+
+func TestCoordinatorErrorsAfterJoiningAreNotTolerated(t *testing.T) {
+	w := &coordinatorWait{timeout: time.Minute, log: zap.NewNop()}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := w.failed(now, coordinatorUnavailable()); err != nil || !w.waiting() {
+		t.Fatalf("failed() = %v, waiting %v; want the startup wait tolerated", err, w.waiting())
+	}
+	w.ready(now.Add(time.Second))
+	if w.waiting() {
+		t.Error("still waiting for the coordinator after joining the group")
+	}
+}
@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// HeaderDeliverAt carries the time (RFC3339) before which a message must not be processed
+const HeaderDeliverAt = "deliver-at"
+
+// deliverAt returns the due time carried by the message headers, if any
+func deliverAt(msg *kafka.Message) (time.Time, bool) {
+	for _, h := range msg.Headers {
+		if h.Key != HeaderDeliverAt {
+			continue
+		}
+		due, err := time.Parse(time.RFC3339Nano, string(h.Value))
+		if err != nil {
+			return time.Time{}, false
+		}
+		return due, true
+	}
+	return time.Time{}, false
+}
+
+// partitionFlow is the subset of the Kafka client used to pause, rewind and
+// resume assigned partitions
+type partitionFlow interface {
+	Assignment() ([]kafka.TopicPartition, error)
+	Pause(partitions []kafka.TopicPartition) error
+	Resume(partitions []kafka.TopicPartition) error
+	Seek(partition kafka.TopicPartition, ignoredTimeoutMs int) error
+}
+
+// delayQueue tracks partitions that are paused until their head message is due.
+// Parked messages are not held in memory: the partition is paused and rewound to
+// the parked offset, so memory is bounded by the number of assigned partitions.
+type delayQueue struct {
+	parked map[string]parkedPartition
+}
+
+type parkedPartition struct {
+	partition kafka.TopicPartition
+	due       time.Time
+}
+
+func newDelayQueue() *delayQueue {
+	return &delayQueue{
+		parked: make(map[string]parkedPartition),
+	}
+}
+
+// park records that the message's partition must stay paused until due
+func (q *delayQueue) park(tp kafka.TopicPartition, due time.Time) {
+	q.parked[partitionKey(tp)] = parkedPartition{partition: tp, due: due}
+}
+
+// release removes and returns all partitions whose head message is due at now
+func (q *delayQueue) release(now time.Time) []kafka.TopicPartition {
+	var due []kafka.TopicPartition
+	for key, p := range q.parked {
+		if now.Before(p.due) {
+			continue
+		}
+		due = append(due, p.partition)
+		delete(q.parked, key)
+	}
+	return due
+}
+
+// remove forgets the parked partitions among partitions, such as revoked ones
+// whose new owner reads the parked messages from the committed position
+func (q *delayQueue) remove(partitions []kafka.TopicPartition) {
+	for _, tp := range partitions {
+		delete(q.parked, partitionKey(tp))
+	}
+}
+
+// unparked returns the partitions that are not parked
+func (q *delayQueue) unparked(partitions []kafka.TopicPartition) []kafka.TopicPartition {
+	var unparked []kafka.TopicPartition
+	for _, tp := range partitions {
+		if _, ok := q.parked[partitionKey(tp)]; !ok {
+			unparked = append(unparked, tp)
+		}
+	}
+	return unparked
+}
+
+func partitionKey(tp kafka.TopicPartition) string {
+	return fmt.Sprintf("%s/%d", *tp.Topic, tp.Partition)
+}
@@ -0,0 +1,209 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+)
+
+// flowRecorder records pauses, seeks and resumes instead of applying them
+type flowRecorder struct {
+	assignment []kafka.TopicPartition
+	paused     []kafka.TopicPartition
+	resumed    []kafka.TopicPartition
+	seeks      []kafka.TopicPartition
+}
+
+func (r *flowRecorder) Assignment() ([]kafka.TopicPartition, error) {
+	return r.assignment, nil
+}
+
+func (r *flowRecorder) Pause(partitions []kafka.TopicPartition) error {
+	r.paused = append(r.paused, partitions...)
+	return nil
+}
+
+func (r *flowRecorder) Resume(partitions []kafka.TopicPartition) error {
+	r.resumed = append(r.resumed, partitions...)
+	return nil
+}
+
+func (r *flowRecorder) Seek(partition kafka.TopicPartition, ignoredTimeoutMs int) error {
+	r.seeks = append(r.seeks, partition)
+	return nil
+}
+
+func scheduledMessage(topic string, partition int32, offset kafka.Offset, due time.Time) *kafka.Message {
+	msg := testMessage(topic, offset)
+	msg.TopicPartition.Partition = partition
+	msg.Headers = []kafka.Header{{Key: HeaderDeliverAt, Value: []byte(due.Format(time.RFC3339Nano))}}
+	return msg
+}
+
+func TestDeliverAt(t *testing.T) {
+	due := time.Date(2024, 1, 1, 12, 0, 0, 500, time.UTC)
+	tests := []struct {
+		name    string
+		headers []kafka.Header
+		want    time.Time
+		wantOK  bool
+	}{
+		{name: "no header"},
+		{name: "RFC3339Nano", headers: []kafka.Header{{Key: HeaderDeliverAt, Value: []byte(due.Format(time.RFC3339Nano))}}, want: due, wantOK: true},
+		{name: "RFC3339", headers: []kafka.Header{{Key: HeaderDeliverAt, Value: []byte("2024-01-01T12:00:00Z")}}, want: due.Truncate(time.Second), wantOK: true},
+		{name: "malformed", headers: []kafka.Header{{Key: HeaderDeliverAt, Value: []byte("tomorrow")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := deliverAt(&kafka.Message{Headers: tt.headers})
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("deliverAt = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDelayQueueReleasesPartitionsWhenDue(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	queue := newDelayQueue()
+	orders := testMessage("orders", 5).TopicPartition
+	payments := testMessage("payments", 9).TopicPartition
+
+	queue.park(orders, clock.now().Add(time.Minute))
+	queue.park(payments, clock.now().Add(time.Hour))
+
+	if due := queue.release(clock.now()); len(due) != 0 {
+		t.Fatalf("released %v before anything was due", due)
+	}
+
+	clock.advance(time.Minute)
+	due := queue.release(clock.now())
+	if len(due) != 1 || *due[0].Topic != "orders" || due[0].Offset != 5 {
+		t.Fatalf("released %v at the orders due time, want only orders at offset 5", due)
+	}
+	if due := queue.release(clock.now()); len(due) != 0 {
+		t.Fatalf("released %v again", due)
+	}
+
+	unparked := queue.unparked([]kafka.TopicPartition{orders, payments})
+	if len(unparked) != 1 || *unparked[0].Topic != "orders" {
+		t.Errorf("unparked = %v, want only orders", unparked)
+	}
+
+	clock.advance(time.Hour)
+	if due := queue.release(clock.now()); len(due) != 1 || *due[0].Topic != "payments" {
+		t.Errorf("released %v at the payments due time, want payments", due)
+	}
+}
+
+func TestDelayQueueReparkingReplacesDueTime(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	queue := newDelayQueue()
+	tp := testMessage("orders", 5).TopicPartition
+
+	queue.park(tp, clock.now().Add(time.Hour))
+	queue.park(tp, clock.now().Add(time.Minute))
+
+	clock.advance(time.Minute)
+	if due := queue.release(clock.now()); len(due) != 1 {
+		t.Fatalf("released %v, want the partition at its latest due time", due)
+	}
+}
+
+func TestScheduledMessageIsParkedUntilDue(t *testing.T) {
+	c, _, clock := newTestConsumer(t, config.KafkaConfig{})
+	flow := &flowRecorder{}
+	c.flow = flow
+
+	msg := scheduledMessage("orders", 2, 41, clock.now().Add(30*time.Second))
+	due, ok := deliverAt(msg)
+	if !ok || !clock.now().Before(due) {
+		t.Fatalf("deliverAt = %v, %v; want a future due time", due, ok)
+	}
+	c.park(msg, due)
+
+	if len(flow.paused) != 1 || flow.paused[0].Partition != 2 {
+		t.Fatalf("paused %v, want partition 2", flow.paused)
+	}
+	if len(flow.seeks) != 1 || flow.seeks[0].Offset != 41 {
+		t.Fatalf("seeks %v, want a rewind to offset 41", flow.seeks)
+	}
+
+	clock.advance(29 * time.Second)
+	c.resumeDuePartitions()
+	if len(flow.resumed) != 0 {
+		t.Fatalf("resumed %v before the message was due", flow.resumed)
+	}
+
+	clock.advance(time.Second)
+	c.resumeDuePartitions()
+	if len(flow.resumed) != 1 || flow.resumed[0].Partition != 2 {
+		t.Fatalf("resumed %v once due, want partition 2", flow.resumed)
+	}
+
+	// Redelivered once due, the message is handled instead of parked again
+	handled := false
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		handled = true
+		return nil
+	})
+	if due, _ := deliverAt(msg); c.now().Before(due) {
+		t.Fatal("message still not due after resuming")
+	}
+	c.handle(context.Background(), msg)
+	if !handled {
+		t.Error("due message was not handled")
+	}
+}
+
+func TestPausedConsumerDoesNotResumeParkedPartitions(t *testing.T) {
+	c, _, clock := newTestConsumer(t, config.KafkaConfig{})
+	parked := testMessage("orders", 3).TopicPartition
+	active := testMessage("payments", 8).TopicPartition
+	flow := &flowRecorder{assignment: []kafka.TopicPartition{parked, active}}
+	c.flow = flow
+
+	c.park(&kafka.Message{TopicPartition: parked}, clock.now().Add(time.Minute))
+	c.Pause()
+	c.applyPause()
+	c.Resume()
+	c.applyPause()
+
+	if len(flow.resumed) != 1 || *flow.resumed[0].Topic != "payments" {
+		t.Errorf("resuming the consumer resumed %v, want only the unparked payments partition", flow.resumed)
+	}
+}
+
+func TestRevokeForgetsParkedPartitions(t *testing.T) {
+	c, _, clock := newTestConsumer(t, config.KafkaConfig{})
+	revoked := testMessage("orders", 3).TopicPartition
+	kept := testMessage("payments", 8).TopicPartition
+	flow := &flowRecorder{}
+	c.flow = flow
+
+	c.park(&kafka.Message{TopicPartition: revoked}, clock.now().Add(time.Minute))
+	c.park(&kafka.Message{TopicPartition: kept}, clock.now().Add(time.Minute))
+	if err := c.rebalance(nil, kafka.RevokedPartitions{Partitions: []kafka.TopicPartition{revoked}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Assigned back, the partition is not held paused by the stale entry
+	flow.assignment = []kafka.TopicPartition{revoked, kept}
+	c.Pause()
+	c.applyPause()
+	c.Resume()
+	c.applyPause()
+	if len(flow.resumed) != 1 || *flow.resumed[0].Topic != "orders" {
+		t.Fatalf("resuming the consumer resumed %v, want only the revoked orders partition", flow.resumed)
+	}
+
+	flow.resumed = nil
+	clock.advance(time.Minute)
+	c.resumeDuePartitions()
+	if len(flow.resumed) != 1 || *flow.resumed[0].Topic != "payments" {
+		t.Errorf("resumed %v once due, want only the kept payments partition", flow.resumed)
+	}
+}
@@ -0,0 +1,91 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// Headers added to messages moved to a dead-letter topic
+const (
+	HeaderDLQError             = "dlq-error"
+	HeaderDLQTimestamp         = "dlq-timestamp" // RFC3339Nano
+	HeaderDLQRetryCount        = "dlq-retry-count"
+	HeaderDLQOriginalTopic     = "dlq-original-topic"
+	HeaderDLQOriginalPartition = "dlq-original-partition"
+	HeaderDLQOriginalOffset    = "dlq-original-offset"
+)
+
+// deadLetterSink is the part of Producer dead-lettering needs
+type deadLetterSink interface {
+	PublishWithHeaders(ctx context.Context, topic string, key, value []byte, headers []kafka.Header) error
+}
+
+// SetDeadLetterQueue sets the producer failed messages are published with
+// when the dead-letter queue is enabled in config. Each topic's failures go
+// to the topic name plus the configured suffix.
+func (c *Consumer) SetDeadLetterQueue(publisher *Producer) {
+	c.deadLetters = publisher
+}
+
+// deadLettering reports whether failed messages go to a dead-letter topic
+func (c *Consumer) deadLettering() bool {
+	return c.config.DLQ.Enabled && c.deadLetters != nil
+}
+
+// deadLetter publishes the original message, with its key and headers, plus
+// why and when its handling failed to the topic's dead-letter topic
+func (c *Consumer) deadLetter(ctx context.Context, msg *kafka.Message, cause error) error {
+	topic := *msg.TopicPartition.Topic
+	dlqTopic := topic + c.config.DLQ.Suffix
+
+	attempts := 1
+	var exhausted *retriesExhaustedError
+	if errors.As(cause, &exhausted) {
+		attempts = exhausted.attempts
+	}
+
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderDLQError, Value: []byte(cause.Error())},
+		kafka.Header{Key: HeaderDLQTimestamp, Value: []byte(c.now().UTC().Format(time.RFC3339Nano))},
+		kafka.Header{Key: HeaderDLQRetryCount, Value: []byte(strconv.Itoa(attempts - 1))},
+		kafka.Header{Key: HeaderDLQOriginalTopic, Value: []byte(topic)},
+		kafka.Header{Key: HeaderDLQOriginalPartition, Value: []byte(strconv.Itoa(int(msg.TopicPartition.Partition)))},
+		kafka.Header{Key: HeaderDLQOriginalOffset, Value: []byte(msg.TopicPartition.Offset.String())},
+	)
+	if err := c.deadLetters.PublishWithHeaders(ctx, dlqTopic, msg.Key, msg.Value, headers); err != nil {
+		return fmt.Errorf("failed to dead-letter message: %w", err)
+	}
+
+	metrics.IncCounter(metrics.DeadLetteredEvents, metrics.Topic(topic))
+	c.log.Warn("Moved failed message to dead-letter topic",
+		zap.String("topic", topic),
+		zap.Int32("partition", msg.TopicPartition.Partition),
+		zap.String("offset", msg.TopicPartition.Offset.String()),
+		zap.String("dlq_topic", dlqTopic),
+		zap.Int("attempts", attempts),
+	)
+	return nil
+}
+
+// retriesExhaustedError is the last handler error of a message, with how
+// many attempts were made
+type retriesExhaustedError struct {
+	err      error
+	attempts int
+}
+
+func (e *retriesExhaustedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retriesExhaustedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,198 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+)
+
+// deadLetterRecorder records dead-lettered messages instead of publishing them
+type deadLetterRecorder struct {
+	mu       sync.Mutex
+	messages []*kafka.Message
+	err      error // returned by every publish when set
+}
+
+func (r *deadLetterRecorder) PublishWithHeaders(ctx context.Context, topic string, key, value []byte, headers []kafka.Header) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.messages = append(r.messages, &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic},
+		Key:            key,
+		Value:          value,
+		Headers:        headers,
+	})
+	return nil
+}
+
+func newDeadLetteringConsumer(t *testing.T) (*Consumer, *commitRecorder, *deadLetterRecorder) {
+	t.Helper()
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{
+		DLQ: config.DLQConfig{Enabled: true, Suffix: ".dlq"},
+		Consumer: config.ConsumerConfig{
+			CommitMode: CommitAsync,
+			Retry:      config.RetryConfig{MaxAttempts: 3, Backoff: time.Second},
+		},
+	})
+	deadLetters := &deadLetterRecorder{}
+	c.deadLetters = deadLetters
+	c.nextCommit = c.now().Add(time.Hour)
+	return c, commits, deadLetters
+}
+
+func TestFailedMessageIsDeadLettered(t *testing.T) {
+	c, commits, deadLetters := newDeadLetteringConsumer(t)
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		return errors.New("inventory unavailable")
+	})
+
+	msg := testMessage("orders", 7)
+	msg.Headers = []kafka.Header{{Key: "tenant", Value: []byte("acme")}}
+	c.handle(context.Background(), msg)
+
+	if len(deadLetters.messages) != 1 {
+		t.Fatalf("got %d dead-lettered messages, want 1", len(deadLetters.messages))
+	}
+	dead := deadLetters.messages[0]
+	if *dead.TopicPartition.Topic != "orders.dlq" {
+		t.Errorf("dead-letter topic = %q, want orders.dlq", *dead.TopicPartition.Topic)
+	}
+	if string(dead.Key) != string(msg.Key) || string(dead.Value) != string(msg.Value) {
+		t.Errorf("dead-lettered key/value = %q/%q, want the original %q/%q", dead.Key, dead.Value, msg.Key, msg.Value)
+	}
+
+	headers := MessageHeaders(dead)
+	want := map[string]string{
+		"tenant":                   "acme",
+		HeaderDLQRetryCount:        "2",
+		HeaderDLQOriginalTopic:     "orders",
+		HeaderDLQOriginalPartition: "0",
+		HeaderDLQOriginalOffset:    "7",
+		HeaderDLQTimestamp:         c.now().UTC().Format(time.RFC3339Nano),
+	}
+	for key, value := range want {
+		if got, _ := headers.Get(key); got != value {
+			t.Errorf("header %s = %q, want %q", key, got, value)
+		}
+	}
+	if reason, _ := headers.Get(HeaderDLQError); !strings.Contains(reason, "inventory unavailable") {
+		t.Errorf("header %s = %q, want the handler error", HeaderDLQError, reason)
+	}
+
+	c.commitOnShutdown()
+	if commits.committedOffset("orders", 0) != 8 {
+		t.Errorf("batches %v, want the dead-lettered message committed", commits.batches)
+	}
+}
+
+func TestMessageIsNotCommittedWhenDeadLetteringFails(t *testing.T) {
+	c, commits, deadLetters := newDeadLetteringConsumer(t)
+	deadLetters.err = errors.New("dead-letter topic unavailable")
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		return errors.New("inventory unavailable")
+	})
+
+	c.handle(context.Background(), testMessage("orders", 0))
+	c.commitOnShutdown()
+
+	if len(commits.batches) != 0 || len(commits.messages) != 0 {
+		t.Fatalf("message that never reached the dead-letter topic was committed: batches %v", commits.batches)
+	}
+}
+
+func TestPoisonCallbackFiresWhenMessageIsDeadLettered(t *testing.T) {
+	c, _, _ := newDeadLetteringConsumer(t)
+	handlerErr := errors.New("inventory unavailable")
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		return handlerErr
+	})
+
+	type poison struct {
+		msg *Message
+		err error
+	}
+	notified := make(chan poison, 1)
+	c.OnPoisonMessage(func(msg *Message, err error) {
+		notified <- poison{msg: msg, err: err}
+	})
+
+	msg := testMessage("orders", 3)
+	c.handle(context.Background(), msg)
+
+	select {
+	case got := <-notified:
+		if got.msg != msg {
+			t.Errorf("callback got message %v, want the original message", got.msg.TopicPartition)
+		}
+		if !errors.Is(got.err, handlerErr) {
+			t.Errorf("callback error %v does not wrap the handler's final error", got.err)
+		}
+		if !errors.Is(got.err, ErrHandlerFailed) {
+			t.Errorf("callback error %v does not wrap ErrHandlerFailed", got.err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("poison callback was not called")
+	}
+}
+
+func TestPoisonCallbackDoesNotFireWithoutDeadLettering(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		dlqError error
+	}{
+		{name: "no dead-letter topic"},
+		{name: "dead-lettering failed", enabled: true, dlqError: errors.New("dead-letter topic unavailable")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, deadLetters := newDeadLetteringConsumer(t)
+			c.config.DLQ.Enabled = tt.enabled
+			deadLetters.err = tt.dlqError
+			c.RegisterHandler("orders", func(context.Context, *Message) error {
+				return errors.New("inventory unavailable")
+			})
+			notified := make(chan struct{}, 1)
+			c.OnPoisonMessage(func(*Message, error) { notified <- struct{}{} })
+
+			c.handle(context.Background(), testMessage("orders", 0))
+
+			select {
+			case <-notified:
+				t.Fatal("poison callback was called for a message that was not dead-lettered")
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestPoisonCallbackDoesNotBlockConsumption(t *testing.T) {
+	c, _, _ := newDeadLetteringConsumer(t)
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		return errors.New("inventory unavailable")
+	})
+	release := make(chan struct{})
+	defer close(release)
+	c.OnPoisonMessage(func(*Message, error) { <-release })
+
+	done := make(chan struct{})
+	go func() {
+		c.handle(context.Background(), testMessage("orders", 0))
+		c.handle(context.Background(), testMessage("orders", 1))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a blocked poison callback held up consumption")
+	}
+}
@@ -0,0 +1,59 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Categories of consumer errors, for callers to tell apart with errors.Is
+var (
+	// ErrConsumeTimeout reports a read returning no message in time
+	ErrConsumeTimeout = errors.New("timed out waiting for message")
+	// ErrHandlerFailed wraps the error a message handler failed with
+	ErrHandlerFailed = errors.New("message handler failed")
+	// ErrCommitFailed wraps the error committing offsets failed with
+	ErrCommitFailed = errors.New("failed to commit offsets")
+)
+
+// ErrorHandler is notified of an error the consumer recovered from
+type ErrorHandler func(err error)
+
+// OnError registers a callback notified of the errors the consumer logs and
+// keeps consuming after: failed handlers (ErrHandlerFailed), failed commits
+// (ErrCommitFailed) and failed reads. It is called on the goroutine that hit
+// the error, a worker when the worker pool is enabled or the background
+// commit in async commit mode, so it must be safe
+// for concurrent use and return quickly.
+func (c *Consumer) OnError(fn ErrorHandler) {
+	c.onError = fn
+}
+
+// reportError notifies the error callback, if any
+func (c *Consumer) reportError(err error) {
+	if c.onError != nil {
+		c.onError(err)
+	}
+}
+
+// handlerFailed classifies the final error of a message's handler
+func handlerFailed(err error) error {
+	return fmt.Errorf("%w: %w", ErrHandlerFailed, err)
+}
+
+// commitFailed classifies an error committing offsets
+func commitFailed(err error) error {
+	return fmt.Errorf("%w: %w", ErrCommitFailed, err)
+}
+
+// readError classifies an error returned by ReadMessage. Errors that are not
+// Kafka errors, which librdkafka isn't documented to return, are passed on
+// as they are.
+func readError(err error) error {
+	var kerr kafka.Error
+	if errors.As(err, &kerr) && kerr.Code() == kafka.ErrTimedOut {
+		return fmt.Errorf("%w: %w", ErrConsumeTimeout, err)
+	}
+	return err
+}
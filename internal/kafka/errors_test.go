@@ -0,0 +1,131 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+)
+
+func TestReadErrorClassification(t *testing.T) {
+	plain := errors.New("not a kafka error")
+	tests := []struct {
+		name        string
+		err         error
+		wantTimeout bool
+	}{
+		{name: "non-kafka error", err: plain},
+		{name: "wrapped non-kafka error", err: errors.Join(plain, errors.New("other"))},
+		{name: "timeout", err: kafka.NewError(kafka.ErrTimedOut, "timed out", false), wantTimeout: true},
+		{name: "wrapped timeout", err: errors.Join(kafka.NewError(kafka.ErrTimedOut, "timed out", false)), wantTimeout: true},
+		{name: "other kafka error", err: kafka.NewError(kafka.ErrAllBrokersDown, "all brokers down", false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := readError(tt.err)
+			if errors.Is(got, ErrConsumeTimeout) != tt.wantTimeout {
+				t.Errorf("readError(%v) = %v; timeout = %v, want %v", tt.err, got, !tt.wantTimeout, tt.wantTimeout)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("readError(%v) = %v, which does not wrap the original error", tt.err, got)
+			}
+		})
+	}
+}
+
+// errorRecorder collects the errors reported to OnError
+type errorRecorder struct {
+	mu     sync.Mutex
+	errors []error
+}
+
+func (r *errorRecorder) record(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, err)
+}
+
+func (r *errorRecorder) only(t *testing.T, target error) error {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.errors) != 1 {
+		t.Fatalf("got %d reported errors %v, want 1", len(r.errors), r.errors)
+	}
+	if !errors.Is(r.errors[0], target) {
+		t.Fatalf("reported error %v does not wrap %v", r.errors[0], target)
+	}
+	return r.errors[0]
+}
+
+func TestOnErrorReportsHandlerFailures(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	handlerErr := errors.New("inventory unavailable")
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		return handlerErr
+	})
+	var reported errorRecorder
+	c.OnError(reported.record)
+
+	c.handle(context.Background(), testMessage("orders", 0))
+
+	err := reported.only(t, ErrHandlerFailed)
+	if !errors.Is(err, handlerErr) {
+		t.Errorf("reported error %v does not wrap the handler error", err)
+	}
+	if errors.Is(err, ErrCommitFailed) {
+		t.Errorf("handler failure %v classified as a commit failure", err)
+	}
+}
+
+func TestOnErrorReportsCommitFailures(t *testing.T) {
+	commitErr := errors.New("coordinator unavailable")
+	tests := []struct {
+		mode   string
+		commit func(c *Consumer)
+	}{
+		{mode: CommitSync},
+		{mode: CommitAsync, commit: func(c *Consumer) {
+			c.commitDue()
+			c.waitForCommits()
+		}},
+		{mode: CommitBatch, commit: (*Consumer).commitOnShutdown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{CommitMode: tt.mode}})
+			c.RegisterHandler("orders", succeed)
+			commits.err = commitErr
+			var reported errorRecorder
+			c.OnError(reported.record)
+
+			c.handle(context.Background(), testMessage("orders", 0))
+			if tt.commit != nil {
+				tt.commit(c)
+			}
+
+			if err := reported.only(t, ErrCommitFailed); !errors.Is(err, commitErr) {
+				t.Errorf("reported error %v does not wrap the commit error", err)
+			}
+		})
+	}
+}
+
+func TestRevokeReportsCommitFailures(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{CommitMode: CommitAsync}})
+	c.RegisterHandler("orders", succeed)
+	var reported errorRecorder
+	c.OnError(reported.record)
+	c.nextCommit = c.now().Add(time.Hour)
+
+	msg := testMessage("orders", 0)
+	c.handle(context.Background(), msg)
+	commits.err = errors.New("rebalance in progress")
+	c.finishRevoked([]kafka.TopicPartition{msg.TopicPartition})
+
+	reported.only(t, ErrCommitFailed)
+}
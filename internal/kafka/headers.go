@@ -0,0 +1,82 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// Headers stamped on published messages, besides HeaderContentType
+const (
+	// HeaderTimestamp carries the time (RFC3339) the message was produced
+	HeaderTimestamp = "timestamp"
+	// HeaderEventType carries the type of an event message
+	HeaderEventType = "event-type"
+	// HeaderSchemaVersion carries the payload schema version of an event
+	// message, when the event has one
+	HeaderSchemaVersion = "schema-version"
+)
+
+// Headers are the headers of a message, read and written as strings
+type Headers []kafka.Header
+
+// MessageHeaders returns the headers of msg. Set does not change msg unless
+// the result is assigned back to msg.Headers.
+func MessageHeaders(msg *Message) Headers {
+	return Headers(msg.Headers)
+}
+
+// Get returns the value of the last header named key
+func (h Headers) Get(key string) (string, bool) {
+	for i := len(h) - 1; i >= 0; i-- {
+		if h[i].Key == key {
+			return string(h[i].Value), true
+		}
+	}
+	return "", false
+}
+
+// Set replaces the headers named key with a single one. It builds a new
+// slice, so the message the headers were read from is left unchanged.
+func (h *Headers) Set(key, value string) {
+	headers := make(Headers, 0, len(*h)+1)
+	for _, header := range *h {
+		if header.Key != key {
+			headers = append(headers, header)
+		}
+	}
+	*h = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+type headersKey struct{}
+
+// HeadersFromContext returns the headers of the message being handled, for
+// handlers that only get the decoded event, such as TypedHandler
+func HeadersFromContext(ctx context.Context) (Headers, bool) {
+	headers, ok := ctx.Value(headersKey{}).(Headers)
+	return headers, ok
+}
+
+// eventHeaders returns the headers stamped on the published event
+func (p *Producer) eventHeaders(event *events.Event) []kafka.Header {
+	headers := Headers{{Key: HeaderContentType, Value: []byte(p.serializer.ContentType())}}
+	headers.Set(HeaderEventType, string(event.Type))
+	if event.Version > 0 {
+		headers.Set(HeaderSchemaVersion, strconv.Itoa(event.Version))
+	}
+	return headers
+}
+
+// header returns the value of the message's last header named key
+func header(msg *kafka.Message, key string) (string, bool) {
+	return MessageHeaders(msg).Get(key)
+}
+
+// setHeader replaces the message's headers named key with a single one
+func setHeader(msg *kafka.Message, key, value string) {
+	headers := MessageHeaders(msg)
+	headers.Set(key, value)
+	msg.Headers = headers
+}
@@ -0,0 +1,117 @@
+package kafka
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+func TestHeadersGetAndSet(t *testing.T) {
+	headers := Headers{
+		{Key: "tenant", Value: []byte("acme")},
+		{Key: HeaderEventType, Value: []byte("order.created")},
+		{Key: HeaderEventType, Value: []byte("order.updated")},
+	}
+	if value, ok := headers.Get(HeaderEventType); !ok || value != "order.updated" {
+		t.Errorf("Get(event-type) = %q, %v; want the last value order.updated", value, ok)
+	}
+	if _, ok := headers.Get("missing"); ok {
+		t.Error("Get found a header that was never set")
+	}
+
+	headers.Set(HeaderEventType, "order.confirmed")
+	headers.Set("trace", "abc")
+	if len(headers) != 3 {
+		t.Errorf("headers %v, want event-type replaced and trace added", headers)
+	}
+	for key, want := range map[string]string{"tenant": "acme", HeaderEventType: "order.confirmed", "trace": "abc"} {
+		if value, _ := headers.Get(key); value != want {
+			t.Errorf("Get(%s) = %q, want %q", key, value, want)
+		}
+	}
+}
+
+func TestHandlerSetLeavesTheMessageHeadersUnchanged(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	c.RegisterHandler("orders", func(ctx context.Context, msg *Message) error {
+		headers := MessageHeaders(msg)
+		headers.Set("tenant", "other")
+		fromCtx, _ := HeadersFromContext(ctx)
+		fromCtx.Set(HeaderEventType, "order.confirmed")
+		return nil
+	})
+
+	msg := testMessage("orders", 0)
+	msg.Headers = []kafka.Header{
+		{Key: "tenant", Value: []byte("acme")},
+		{Key: HeaderEventType, Value: []byte("order.created")},
+		{Key: "trace", Value: []byte("abc")},
+	}
+	want := slices.Clone(msg.Headers)
+	c.handle(context.Background(), msg)
+
+	if !reflect.DeepEqual(msg.Headers, want) {
+		t.Errorf("message headers %v after the handler set headers, want %v", msg.Headers, want)
+	}
+}
+
+func TestHeadersRoundTripThroughProduceAndConsume(t *testing.T) {
+	const topic = "stamped-headers"
+	cluster := newMockCluster(t)
+	if err := cluster.CreateTopic(topic, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	p := newClusterProducer(t, cluster)
+	event := events.NewEvent(events.EventTypeOrderCreated, events.OrderCreatedEvent{
+		Order: models.Order{ID: "order-1", CustomerID: "customer-1"},
+	})
+	if err := p.PublishEvent(context.Background(), topic, []byte("order-1"), event); err != nil {
+		t.Fatal(err)
+	}
+	messages := readMessages(t, cluster, topic, 1)
+	if len(messages) != 1 {
+		t.Fatalf("read %d messages, want 1", len(messages))
+	}
+
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	var got Headers
+	RegisterHandlerT(c, topic, events.EventTypeOrderCreated, func(ctx context.Context, event *events.Event, created events.OrderCreatedEvent) error {
+		got, _ = HeadersFromContext(ctx)
+		return nil
+	})
+	c.handle(context.Background(), messages[0])
+
+	want := map[string]string{
+		HeaderEventType:     "order.created",
+		HeaderContentType:   "application/json",
+		HeaderSchemaVersion: strconv.Itoa(events.CurrentVersion(events.EventTypeOrderCreated)),
+	}
+	for key, value := range want {
+		if v, ok := got.Get(key); v != value {
+			t.Errorf("handler read %s = %q (present %v), want %q", key, v, ok, value)
+		}
+	}
+	stamped, _ := got.Get(HeaderTimestamp)
+	if _, err := time.Parse(time.RFC3339, stamped); err != nil {
+		t.Errorf("timestamp header %q is not RFC 3339: %v", stamped, err)
+	}
+}
+
+func TestRawMessagesCarryOnlyTheTimestamp(t *testing.T) {
+	msg := newMessage("orders", nil, []byte("order"))
+	headers := MessageHeaders(msg)
+	if _, ok := headers.Get(HeaderTimestamp); !ok || len(headers) != 1 {
+		t.Errorf("headers %v, want only the timestamp", headers)
+	}
+	if _, ok := header(&kafka.Message{}, HeaderEventType); ok {
+		t.Error("found an event type on a message without headers")
+	}
+}
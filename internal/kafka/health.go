@@ -0,0 +1,47 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+)
+
+// healthTimeoutMs bounds the metadata request of a health check, well below
+// the usual probe timeouts
+const healthTimeoutMs = 2000
+
+// ErrNoBrokers reports cluster metadata listing no reachable broker
+var ErrNoBrokers = errors.New("no Kafka brokers available")
+
+// Healthy reports whether the producer is open and can reach the cluster.
+// The metadata request runs without closeMu, so a slow broker doesn't hold up
+// Close; Close waits for it before closing the client.
+func (p *Producer) Healthy() error {
+	p.closeMu.RLock()
+	if p.closed {
+		p.closeMu.RUnlock()
+		return ErrProducerClosed
+	}
+	p.healthChecks.Add(1)
+	p.closeMu.RUnlock()
+	defer p.healthChecks.Done()
+
+	return checkBrokers(p.producer)
+}
+
+// Healthy reports whether the consumer can reach the cluster
+func (c *Consumer) Healthy() error {
+	return checkBrokers(c.consumer)
+}
+
+// checkBrokers requests metadata without topics, which needs a broker to
+// answer but doesn't trigger topic auto-creation
+func checkBrokers(src metadataSource) error {
+	metadata, err := src.GetMetadata(nil, false, healthTimeoutMs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch cluster metadata: %w", err)
+	}
+	if len(metadata.Brokers) == 0 {
+		return ErrNoBrokers
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+func TestCheckBrokers(t *testing.T) {
+	timedOut := kafka.NewError(kafka.ErrTransport, "all brokers are down", false)
+	if err := checkBrokers(metadataStub{brokers: []kafka.BrokerMetadata{{ID: 1, Host: "localhost", Port: 9092}}}); err != nil {
+		t.Errorf("reachable cluster unhealthy: %v", err)
+	}
+	if err := checkBrokers(metadataStub{}); !errors.Is(err, ErrNoBrokers) {
+		t.Errorf("cluster without brokers reported %v, want ErrNoBrokers", err)
+	}
+	if err := checkBrokers(metadataStub{err: timedOut}); !errors.Is(err, timedOut) {
+		t.Errorf("unreachable cluster reported %v, want the metadata error", err)
+	}
+}
+
+func TestProducerUnhealthyWhileTheBrokerIsDown(t *testing.T) {
+	cluster := newMockCluster(t)
+	p := newClusterProducer(t, cluster)
+	if err := p.Healthy(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cluster.SetBrokerDown(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Healthy(); err == nil {
+		t.Error("healthy while the only broker is down")
+	}
+
+	if err := cluster.SetBrokerUp(1); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	err := p.Healthy()
+	for err != nil && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		err = p.Healthy()
+	}
+	if err != nil {
+		t.Errorf("still unhealthy after the broker came back: %v", err)
+	}
+}
+
+func TestProducerHealthy(t *testing.T) {
+	p := newMockProducer(t)
+	if err := p.Healthy(); err != nil {
+		t.Errorf("open producer unhealthy: %v", err)
+	}
+	p.Close()
+	if err := p.Healthy(); !errors.Is(err, ErrProducerClosed) {
+		t.Errorf("closed producer reported %v, want ErrProducerClosed", err)
+	}
+}
+
+func TestProducerHealthyDoesNotHoldCloseLock(t *testing.T) {
+	cluster := newMockCluster(t)
+	p := newClusterProducer(t, cluster)
+	if err := p.Healthy(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cluster.SetRoundtripDuration(1, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	checked := make(chan error, 1)
+	go func() { checked <- p.Healthy() }()
+	time.Sleep(100 * time.Millisecond) // let the metadata request start
+
+	locked := make(chan struct{})
+	go func() {
+		p.closeMu.Lock()
+		p.closeMu.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-checked:
+		t.Fatal("health check finished before the broker answered")
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("close lock held during the metadata request")
+	}
+	if err := <-checked; err != nil {
+		t.Errorf("health check failed: %v", err)
+	}
+}
+
+func TestProducerCloseWaitsForHealthChecks(t *testing.T) {
+	cluster := newMockCluster(t)
+	p := newClusterProducer(t, cluster)
+	if err := p.Healthy(); err != nil {
+		t.Fatal(err)
+	}
+	if err := cluster.SetRoundtripDuration(1, 500*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	checked := make(chan error, 1)
+	go func() { checked <- p.Healthy() }()
+	time.Sleep(100 * time.Millisecond)
+
+	// Closing the client under the running request would crash
+	p.Close()
+	select {
+	case err := <-checked:
+		if err != nil {
+			t.Errorf("health check failed: %v", err)
+		}
+	default:
+		t.Error("Close returned before the health check finished")
+	}
+}
@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// TopicPartition identifies a partition by value, e.g. as a map key
+type TopicPartition struct {
+	Topic     string
+	Partition int32
+}
+
+// lagSource is the subset of the Kafka client used to compute consumer lag
+type lagSource interface {
+	Assignment() ([]kafka.TopicPartition, error)
+	Committed(partitions []kafka.TopicPartition, timeoutMs int) ([]kafka.TopicPartition, error)
+	QueryWatermarkOffsets(topic string, partition int32, timeoutMs int) (low, high int64, err error)
+}
+
+// Lag returns, for each assigned partition, how many messages lie between
+// the group's committed offset and the partition's high watermark. Partitions
+// the group never committed count from the low watermark.
+func (c *Consumer) Lag() (map[TopicPartition]int64, error) {
+	return partitionLag(c.lags)
+}
+
+func partitionLag(src lagSource) (map[TopicPartition]int64, error) {
+	assignment, err := src.Assignment()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch assignment: %w", err)
+	}
+	lag := make(map[TopicPartition]int64, len(assignment))
+	if len(assignment) == 0 {
+		return lag, nil
+	}
+
+	committed, err := src.Committed(assignment, metadataTimeoutMs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %w", err)
+	}
+
+	for _, tp := range committed {
+		topic := *tp.Topic
+		low, high, err := src.QueryWatermarkOffsets(topic, tp.Partition, metadataTimeoutMs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query watermarks for %s[%d]: %w", topic, tp.Partition, err)
+		}
+
+		position := low
+		if tp.Offset >= 0 {
+			position = int64(tp.Offset)
+		}
+		lag[TopicPartition{Topic: topic, Partition: tp.Partition}] = max(high-position, 0)
+	}
+	return lag, nil
+}
+
+// reportLag records the lag of every assigned partition as the consumer_lag
+// gauge every interval until ctx is done
+func (c *Consumer) reportLag(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			lag, err := c.Lag()
+			if err != nil {
+				c.log.Warn("Failed to compute consumer lag",
+					zap.Error(err),
+				)
+				continue
+			}
+			for tp, messages := range lag {
+				metrics.SetGauge(metrics.ConsumerLag, messages, metrics.Topic(tp.Topic), metrics.Partition(tp.Partition))
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/metrics"
+)
+
+// lagStub answers lag queries from fixed committed offsets and watermarks
+type lagStub struct {
+	committed  map[TopicPartition]kafka.Offset // missing partitions were never committed
+	watermarks map[TopicPartition][2]int64     // low and high
+	err        error                           // returned by QueryWatermarkOffsets when set
+}
+
+func (s *lagStub) Assignment() ([]kafka.TopicPartition, error) {
+	var assignment []kafka.TopicPartition
+	for tp := range s.watermarks {
+		assignment = append(assignment, kafka.TopicPartition{Topic: &tp.Topic, Partition: tp.Partition})
+	}
+	return assignment, nil
+}
+
+func (s *lagStub) Committed(partitions []kafka.TopicPartition, timeoutMs int) ([]kafka.TopicPartition, error) {
+	committed := make([]kafka.TopicPartition, len(partitions))
+	for i, tp := range partitions {
+		offset, ok := s.committed[TopicPartition{Topic: *tp.Topic, Partition: tp.Partition}]
+		if !ok {
+			offset = kafka.OffsetInvalid
+		}
+		committed[i] = kafka.TopicPartition{Topic: tp.Topic, Partition: tp.Partition, Offset: offset}
+	}
+	return committed, nil
+}
+
+func (s *lagStub) QueryWatermarkOffsets(topic string, partition int32, timeoutMs int) (int64, int64, error) {
+	if s.err != nil {
+		return 0, 0, s.err
+	}
+	w := s.watermarks[TopicPartition{Topic: topic, Partition: partition}]
+	return w[0], w[1], nil
+}
+
+func TestConsumerLag(t *testing.T) {
+	reserved0 := TopicPartition{Topic: "inventory.reserved", Partition: 0}
+	reserved1 := TopicPartition{Topic: "inventory.reserved", Partition: 1}
+	created := TopicPartition{Topic: "order.created", Partition: 0}
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	c.lags = &lagStub{
+		committed: map[TopicPartition]kafka.Offset{reserved0: 90, created: 40},
+		watermarks: map[TopicPartition][2]int64{
+			reserved0: {0, 100},
+			reserved1: {20, 50}, // never committed, so lags from the low watermark
+			created:   {0, 40},
+		},
+	}
+
+	lag, err := c.Lag()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[TopicPartition]int64{reserved0: 10, reserved1: 30, created: 0}; !maps.Equal(lag, want) {
+		t.Errorf("Lag() = %v, want %v", lag, want)
+	}
+}
+
+func TestConsumerLagWithoutAnAssignment(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	c.lags = &lagStub{}
+	lag, err := c.Lag()
+	if err != nil || len(lag) != 0 {
+		t.Errorf("Lag() = %v, %v, want no partitions", lag, err)
+	}
+}
+
+func TestConsumerLagFailsWhenWatermarksAreUnavailable(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	c.lags = &lagStub{
+		watermarks: map[TopicPartition][2]int64{{Topic: "orders", Partition: 0}: {0, 10}},
+		err:        errors.New("broker unavailable"),
+	}
+	if _, err := c.Lag(); err == nil {
+		t.Error("computed lag without watermarks")
+	}
+}
+
+func TestReportLagSetsTheGauge(t *testing.T) {
+	const topic = "lag-gauge-orders"
+	tp := TopicPartition{Topic: topic, Partition: 3}
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	c.lags = &lagStub{
+		committed:  map[TopicPartition]kafka.Offset{tp: 5},
+		watermarks: map[TopicPartition][2]int64{tp: {0, 12}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.reportLag(ctx, time.Millisecond)
+		close(done)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for metrics.GaugeValue(metrics.ConsumerLag, metrics.Topic(topic), metrics.Partition(3)) != 7 {
+		if time.Now().After(deadline) {
+			t.Fatal("consumer_lag gauge never reported the lag of 7")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,59 @@
+package kafka
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/metrics"
+)
+
+func TestPublishAndConsumeEmitStatsDLines(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	sink, err := metrics.NewStatsDSink(server.LocalAddr().String(), "eda", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	metrics.AddSink(sink)
+
+	const topic = "statsd-orders"
+	p := newMockProducer(t)
+	if err := p.Publish(context.Background(), topic, nil, []byte("order")); err != nil {
+		t.Fatal(err)
+	}
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	c.RegisterHandler(topic, func(context.Context, *Message) error { return nil })
+	c.handle(context.Background(), testMessage(topic, 0))
+
+	// Other tests' metrics reach the sink too; only this topic's lines count
+	want := []string{
+		"eda.messages_published:1|c|#topic:" + topic,
+		"eda.publish_duration:",
+		"eda.messages_consumed:1|c|#topic:" + topic,
+		"eda.handler_duration:",
+	}
+	buf := make([]byte, 1024)
+	server.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for len(want) > 0 {
+		n, _, err := server.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("missing StatsD lines %q: %v", want, err)
+		}
+		line := string(buf[:n])
+		if !strings.HasSuffix(line, "#topic:"+topic) {
+			continue
+		}
+		if !strings.HasPrefix(line, want[0]) {
+			t.Fatalf("received %q, want a line starting with %q", line, want[0])
+		}
+		want = want[1:]
+	}
+}
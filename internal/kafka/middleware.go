@@ -0,0 +1,101 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tanint/go-eda/internal/logger"
+	"go.uber.org/zap"
+)
+
+// ErrHandlerPanicked is wrapped by the error Recover returns for a panicking handler
+var ErrHandlerPanicked = errors.New("handler panicked")
+
+// Middleware wraps a message handler, e.g. to add logging or recovery
+type Middleware func(next MessageHandler) MessageHandler
+
+// Use adds middleware wrapping every registered handler, including those
+// registered later. The first middleware added is the outermost: it sees the
+// message first and the handler's result last. It is safe to call while the
+// consumer is running.
+func (c *Consumer) Use(middleware ...Middleware) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// chain wraps handler in the middleware, the first added outermost
+func chain(handler MessageHandler, middleware []Middleware) MessageHandler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// Recover turns a handler panic into an error wrapping ErrHandlerPanicked, so
+// the message takes the usual failure path (dead letter queue, poison
+// callback) instead of the panic crashing the consumer. Panics are logged to
+// log. The consumer always applies it outside all other middleware, with its
+// own logger; use it to wrap handlers called by other means.
+func Recover(log logger.Logger) Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg *Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error("Handler panicked",
+						zap.Any("panic", r),
+						zap.String("topic", *msg.TopicPartition.Topic),
+						zap.Int32("partition", msg.TopicPartition.Partition),
+						zap.String("offset", msg.TopicPartition.Offset.String()),
+						zap.Stack("stack"),
+					)
+					err = fmt.Errorf("%w: %v", ErrHandlerPanicked, r)
+				}
+			}()
+			return next(ctx, msg)
+		}
+	}
+}
+
+// LogMessages logs every handled message to log with its position, how long
+// the handler took and its error, if any
+func LogMessages(log logger.Logger) Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+
+			fields := []zap.Field{
+				zap.String("topic", *msg.TopicPartition.Topic),
+				zap.Int32("partition", msg.TopicPartition.Partition),
+				zap.String("offset", msg.TopicPartition.Offset.String()),
+				zap.Duration("duration", time.Since(start)),
+			}
+			if err != nil {
+				log.Warn("Message handler failed", append(fields, zap.Error(err))...)
+			} else {
+				log.Info("Message handled", fields...)
+			}
+			return err
+		}
+	}
+}
+
+// Timeout bounds each message's handler by d, on top of the consumer's
+// handler timeout, e.g. to give one topic's handler a shorter deadline:
+//
+//	consumer.RegisterHandler(topic, kafka.Timeout(time.Second)(handler))
+//
+// Like the consumer's timeout it cancels the handler's context, so the
+// handler must honour ctx to be interrupted.
+func Timeout(d time.Duration) Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg *Message) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next(ctx, msg)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tanint/go-eda/internal/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// traceMiddleware records name before and after calling the next handler
+func traceMiddleware(trace *[]string, name string) Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg *Message) error {
+			*trace = append(*trace, name+" before")
+			err := next(ctx, msg)
+			*trace = append(*trace, name+" after")
+			return err
+		}
+	}
+}
+
+func TestMiddlewareWrapsHandlersInTheOrderItWasAdded(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	var trace []string
+	c.Use(traceMiddleware(&trace, "outer"))
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		trace = append(trace, "handler")
+		return nil
+	})
+	// Middleware added later still wraps handlers registered earlier
+	c.Use(traceMiddleware(&trace, "middle"), traceMiddleware(&trace, "inner"))
+
+	c.handle(context.Background(), testMessage("orders", 0))
+
+	want := []string{"outer before", "middle before", "inner before", "handler", "inner after", "middle after", "outer after"}
+	if !slices.Equal(trace, want) {
+		t.Errorf("ran %v, want %v", trace, want)
+	}
+}
+
+func TestPanickingMiddlewareTakesTheFailurePath(t *testing.T) {
+	c, commits, deadLetters := newDeadLetteringConsumer(t)
+	var reported errorRecorder
+	c.OnError(reported.record)
+	c.Use(func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg *Message) error {
+			panic("middleware crashed")
+		}
+	})
+	c.RegisterHandler("orders", succeed)
+
+	c.handle(context.Background(), testMessage("orders", 0))
+
+	if err := reported.only(t, ErrHandlerFailed); !errors.Is(err, ErrHandlerPanicked) {
+		t.Errorf("reported %v, want the panic as a handler error", err)
+	}
+	if len(deadLetters.messages) != 1 {
+		t.Errorf("dead-lettered %d messages, want the one whose middleware panicked", len(deadLetters.messages))
+	}
+	c.commitOnShutdown()
+	if commits.committedOffset("orders", 0) != 1 {
+		t.Errorf("batches %v, want the dead-lettered message committed", commits.batches)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	handler := Recover(zap.New(core))(func(context.Context, *Message) error {
+		var orders map[string]int
+		orders["order-1"]++ // assignment to a nil map
+		return nil
+	})
+
+	err := handler(context.Background(), testMessage("orders", 7))
+	if !errors.Is(err, ErrHandlerPanicked) {
+		t.Fatalf("handler returned %v, want ErrHandlerPanicked", err)
+	}
+	entries := logs.FilterMessage("Handler panicked").All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d panics, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["topic"] != "orders" || fields["offset"] != "7" || fields["stack"] == "" {
+		t.Errorf("logged %v, want the topic, offset and stack", fields)
+	}
+
+	passed := Recover(zap.NewNop())(succeed)
+	if err := passed(context.Background(), testMessage("orders", 0)); err != nil {
+		t.Errorf("Recover changed a successful handler's result to %v", err)
+	}
+}
+
+func TestLogMessages(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	log := LogMessages(zap.New(core))
+	handlerErr := errors.New("inventory unavailable")
+
+	if err := log(succeed)(context.Background(), testMessage("orders", 3)); err != nil {
+		t.Fatal(err)
+	}
+	failing := func(context.Context, *Message) error { return handlerErr }
+	if err := log(failing)(context.Background(), testMessage("orders", 4)); err != handlerErr {
+		t.Errorf("LogMessages returned %v, want the handler's error", err)
+	}
+
+	handled := logs.FilterMessage("Message handled").All()
+	failed := logs.FilterMessage("Message handler failed").All()
+	if len(handled) != 1 || len(failed) != 1 {
+		t.Fatalf("logged %d handled and %d failed messages, want one of each", len(handled), len(failed))
+	}
+	fields := handled[0].ContextMap()
+	if _, ok := fields["duration"]; !ok || fields["topic"] != "orders" || fields["partition"] != int32(0) || fields["offset"] != "3" {
+		t.Errorf("logged %v, want the topic, partition, offset and duration", fields)
+	}
+	if failed[0].Level != zap.WarnLevel || failed[0].ContextMap()["error"] != handlerErr.Error() {
+		t.Errorf("logged the failure at %s with %v, want a warning with the error", failed[0].Level, failed[0].ContextMap())
+	}
+}
+
+func TestTimeoutCancelsSlowHandlers(t *testing.T) {
+	handler := Timeout(10 * time.Millisecond)(func(ctx context.Context, msg *Message) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- handler(context.Background(), testMessage("orders", 0)) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("handler returned %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler still running after its timeout")
+	}
+}
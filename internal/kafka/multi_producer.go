@@ -0,0 +1,158 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+// Publish quorums for MultiProducer
+const (
+	// QuorumAll requires every cluster to acknowledge a publish
+	QuorumAll = "all"
+	// QuorumAny requires at least one cluster to acknowledge a publish
+	QuorumAny = "any"
+)
+
+// MultiPublishError reports the clusters a fan-out publish failed on
+type MultiPublishError struct {
+	Errors map[string]error
+}
+
+func (e *MultiPublishError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s: %v", name, e.Errors[name]))
+	}
+	return "publish failed on " + strings.Join(parts, "; ")
+}
+
+// MultiProducer fans out every publish to several clusters (e.g. for
+// active-active multi-region setups) and succeeds according to its quorum
+type MultiProducer struct {
+	quorum   string
+	clusters map[string]Publisher
+	log      logger.Logger
+}
+
+var _ Publisher = (*MultiProducer)(nil)
+
+// NewMultiProducer creates a producer publishing to all the named clusters,
+// logging to log or to the global logger when log is nil
+func NewMultiProducer(quorum string, clusters map[string]Publisher, log logger.Logger) (*MultiProducer, error) {
+	if quorum != QuorumAll && quorum != QuorumAny {
+		return nil, fmt.Errorf("invalid quorum %q: must be %s or %s", quorum, QuorumAll, QuorumAny)
+	}
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("multi producer needs at least one cluster")
+	}
+
+	if log == nil {
+		log = logger.Global()
+	}
+
+	return &MultiProducer{
+		quorum:   quorum,
+		clusters: clusters,
+		log:      log,
+	}, nil
+}
+
+// Publish publishes the message to every cluster
+func (m *MultiProducer) Publish(ctx context.Context, topic string, key, value []byte) error {
+	return m.fanOut(topic, func(p Publisher) error {
+		return p.Publish(ctx, topic, key, value)
+	})
+}
+
+// PublishEvent publishes the event to every cluster. Each cluster gets its own
+// copy of the event so per-producer enrichers don't race.
+func (m *MultiProducer) PublishEvent(ctx context.Context, topic string, key []byte, event *events.Event) error {
+	return m.fanOut(topic, func(p Publisher) error {
+		return p.PublishEvent(ctx, topic, key, cloneEvent(event))
+	})
+}
+
+// PublishEventWithPartitionKey publishes the event to every cluster on the partition partitionKey maps to
+func (m *MultiProducer) PublishEventWithPartitionKey(ctx context.Context, topic, partitionKey string, key []byte, event *events.Event) error {
+	return m.fanOut(topic, func(p Publisher) error {
+		return p.PublishEventWithPartitionKey(ctx, topic, partitionKey, key, cloneEvent(event))
+	})
+}
+
+// cloneEvent copies the event and its metadata so concurrent enrichers don't share state
+func cloneEvent(event *events.Event) *events.Event {
+	clone := *event
+	if event.Metadata != nil {
+		clone.Metadata = make(map[string]string, len(event.Metadata))
+		for k, v := range event.Metadata {
+			clone.Metadata[k] = v
+		}
+	}
+	return &clone
+}
+
+// fanOut runs publish against every cluster concurrently and applies the quorum
+func (m *MultiProducer) fanOut(topic string, publish func(Publisher) error) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make(map[string]error)
+	)
+
+	for name, cluster := range m.clusters {
+		wg.Add(1)
+		go func(name string, cluster Publisher) {
+			defer wg.Done()
+			if err := publish(cluster); err != nil {
+				m.log.Error("Publish to cluster failed",
+					zap.Error(err),
+					zap.String("cluster", name),
+					zap.String("topic", topic),
+				)
+				mu.Lock()
+				errs[name] = err
+				mu.Unlock()
+			}
+		}(name, cluster)
+	}
+	wg.Wait()
+
+	if len(errs) == 0 {
+		return nil
+	}
+	if m.quorum == QuorumAny && len(errs) < len(m.clusters) {
+		return nil
+	}
+	return &MultiPublishError{Errors: errs}
+}
+
+// Close closes every cluster producer that supports closing
+func (m *MultiProducer) Close() error {
+	var errs []string
+	for name, cluster := range m.clusters {
+		closer, ok := cluster.(interface{ Close() error })
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("error closing producers: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// stubPublisher records the events published to it and fails with err when set
+type stubPublisher struct {
+	err error
+
+	mu     sync.Mutex
+	events []*events.Event
+}
+
+func (s *stubPublisher) Publish(ctx context.Context, topic string, key, value []byte) error {
+	return s.err
+}
+
+func (s *stubPublisher) PublishEvent(ctx context.Context, topic string, key []byte, event *events.Event) error {
+	s.mu.Lock()
+	s.events = append(s.events, event)
+	s.mu.Unlock()
+	return s.err
+}
+
+func (s *stubPublisher) PublishEventWithPartitionKey(ctx context.Context, topic, partitionKey string, key []byte, event *events.Event) error {
+	return s.PublishEvent(ctx, topic, key, event)
+}
+
+func TestMultiProducerQuorum(t *testing.T) {
+	down := errors.New("cluster down")
+	tests := []struct {
+		name       string
+		quorum     string
+		east, west error
+		wantFailed []string // clusters reported in the MultiPublishError; nil for success
+	}{
+		{name: "all succeed", quorum: QuorumAll},
+		{name: "all succeed with any quorum", quorum: QuorumAny},
+		{name: "one failure with any quorum", quorum: QuorumAny, west: down},
+		{name: "one failure with all quorum", quorum: QuorumAll, west: down, wantFailed: []string{"west"}},
+		{name: "all fail", quorum: QuorumAny, east: down, west: down, wantFailed: []string{"east", "west"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			east, west := &stubPublisher{err: tt.east}, &stubPublisher{err: tt.west}
+			core, logs := observer.New(zap.InfoLevel)
+			m, err := NewMultiProducer(tt.quorum, map[string]Publisher{"east": east, "west": west}, zap.New(core))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = m.PublishEvent(context.Background(), "orders", nil, events.NewEvent("order.created", nil))
+			if len(east.events) != 1 || len(west.events) != 1 {
+				t.Errorf("published to east %d and west %d times, want once each", len(east.events), len(west.events))
+			}
+			var failures int
+			for _, err := range []error{tt.east, tt.west} {
+				if err != nil {
+					failures++
+				}
+			}
+			if n := logs.FilterMessage("Publish to cluster failed").Len(); n != failures {
+				t.Errorf("logged %d cluster failures to the injected logger, want %d", n, failures)
+			}
+			if tt.wantFailed == nil {
+				if err != nil {
+					t.Errorf("PublishEvent() = %v, want success", err)
+				}
+				return
+			}
+
+			var multiErr *MultiPublishError
+			if !errors.As(err, &multiErr) {
+				t.Fatalf("PublishEvent() = %v, want a MultiPublishError", err)
+			}
+			if len(multiErr.Errors) != len(tt.wantFailed) {
+				t.Errorf("errors reported for %v, want %v", multiErr.Errors, tt.wantFailed)
+			}
+			for _, name := range tt.wantFailed {
+				if !errors.Is(multiErr.Errors[name], down) {
+					t.Errorf("error of %s = %v, want %v", name, multiErr.Errors[name], down)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiProducerGivesEachClusterItsOwnEvent(t *testing.T) {
+	east, west := &stubPublisher{}, &stubPublisher{}
+	m, err := NewMultiProducer(QuorumAll, map[string]Publisher{"east": east, "west": west}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := events.NewEvent("order.created", nil)
+	event.SetMetadata("tenant", "acme")
+
+	if err := m.PublishEvent(context.Background(), "orders", nil, event); err != nil {
+		t.Fatal(err)
+	}
+	east.events[0].SetMetadata("cluster", "east")
+	if _, ok := west.events[0].Metadata["cluster"]; ok {
+		t.Error("clusters share the event's metadata")
+	}
+	if west.events[0].Metadata["tenant"] != "acme" {
+		t.Errorf("cluster copy lost metadata: %v", west.events[0].Metadata)
+	}
+}
+
+func TestNewMultiProducerRejectsInvalidSetup(t *testing.T) {
+	if _, err := NewMultiProducer("most", map[string]Publisher{"east": &stubPublisher{}}, nil); err == nil {
+		t.Error("accepted an unknown quorum")
+	}
+	if _, err := NewMultiProducer(QuorumAll, nil, nil); err == nil {
+		t.Error("accepted no clusters")
+	}
+}
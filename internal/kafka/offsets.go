@@ -0,0 +1,124 @@
+package kafka
+
+import (
+	"sync"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// committer is the subset of the Kafka client used to commit offsets
+type committer interface {
+	CommitMessage(msg *kafka.Message) ([]kafka.TopicPartition, error)
+	CommitOffsets(offsets []kafka.TopicPartition) ([]kafka.TopicPartition, error)
+}
+
+// offsetTracker records, per partition, the next offset to consume after the
+// last message whose processing finished but whose position is not yet
+// committed. A message that failed leaves a gap: no later message of its
+// partition moves that position past it.
+type offsetTracker struct {
+	mu      sync.Mutex
+	pending map[string]kafka.TopicPartition
+	gaps    map[string]kafka.Offset // first failed offset per partition
+	count   int                     // messages marked since the last drain
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		pending: make(map[string]kafka.TopicPartition),
+		gaps:    make(map[string]kafka.Offset),
+	}
+}
+
+// mark records that processing of msg has finished. It reports whether the
+// partition's position advanced, which it does not past a failed message.
+func (t *offsetTracker) mark(msg *kafka.Message) bool {
+	tp := msg.TopicPartition
+	tp.Offset++
+	tp.Error = nil
+	key := partitionKey(tp)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if gap, ok := t.gaps[key]; ok && gap <= msg.TopicPartition.Offset {
+		return false
+	}
+	t.pending[key] = tp
+	t.count++
+	return true
+}
+
+// fail records that msg was not processed, so the committed position of its
+// partition stays at msg until the partition is reset
+func (t *offsetTracker) fail(msg *kafka.Message) {
+	key := partitionKey(msg.TopicPartition)
+	offset := msg.TopicPartition.Offset
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if gap, ok := t.gaps[key]; !ok || offset < gap {
+		t.gaps[key] = offset
+	}
+}
+
+// reset forgets the failed messages of the partitions, e.g. once they are
+// revoked or rewound and will be read again from their committed position.
+// Without partitions it forgets all of them.
+func (t *offsetTracker) reset(partitions ...kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(partitions) == 0 {
+		t.gaps = make(map[string]kafka.Offset)
+		return
+	}
+	for _, tp := range partitions {
+		delete(t.gaps, partitionKey(tp))
+	}
+}
+
+// marked returns how many messages were marked since the last drain
+func (t *offsetTracker) marked() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.count
+}
+
+// committed forgets the pending offset of msg's partition if it has not advanced since
+func (t *offsetTracker) committed(msg *kafka.Message) {
+	key := partitionKey(msg.TopicPartition)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tp, ok := t.pending[key]; ok && tp.Offset <= msg.TopicPartition.Offset+1 {
+		delete(t.pending, key)
+	}
+}
+
+// restore puts back offsets returned by drain that could not be committed,
+// unless their partition has advanced meanwhile
+func (t *offsetTracker) restore(offsets []kafka.TopicPartition) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, tp := range offsets {
+		key := partitionKey(tp)
+		if current, ok := t.pending[key]; ok && current.Offset >= tp.Offset {
+			continue
+		}
+		t.pending[key] = tp
+	}
+}
+
+// drain returns and forgets all pending offsets
+func (t *offsetTracker) drain() []kafka.TopicPartition {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	offsets := make([]kafka.TopicPartition, 0, len(t.pending))
+	for key, tp := range t.pending {
+		offsets = append(offsets, tp)
+		delete(t.pending, key)
+	}
+	t.count = 0
+	return offsets
+}
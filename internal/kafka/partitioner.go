@@ -0,0 +1,104 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// Partitioner picks the partition a key is written to
+type Partitioner interface {
+	Partition(key []byte, numPartitions int32) int32
+}
+
+// PartitionKeyFunc derives the partition key of an event, e.g. its customer
+// ID; an empty key leaves partitioning to the message key
+type PartitionKeyFunc func(event *events.Event) string
+
+// Murmur2Partitioner maps keys to partitions exactly like Kafka's default
+// (Java client) partitioner, so keys land on the same partition regardless of
+// which client produced them and co-partitioned topics stay aligned
+type Murmur2Partitioner struct{}
+
+// Partition returns the partition for key among numPartitions partitions
+func (Murmur2Partitioner) Partition(key []byte, numPartitions int32) int32 {
+	if numPartitions <= 0 {
+		return 0
+	}
+	return int32(murmur2(key)&0x7fffffff) % numPartitions
+}
+
+// murmur2 is the 32-bit MurmurHash2 variant used by Kafka
+func murmur2(data []byte) uint32 {
+	const (
+		seed uint32 = 0x9747b28c
+		m    uint32 = 0x5bd1e995
+		r           = 24
+	)
+
+	length := len(data)
+	h := seed ^ uint32(length)
+
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return h
+}
+
+// partitionFor resolves the partition of partitionKey on topic
+func (p *Producer) partitionFor(topic string, partitionKey []byte) (int32, error) {
+	count, err := p.partitionCount(topic)
+	if err != nil {
+		return 0, err
+	}
+	return p.partitioner.Partition(partitionKey, count), nil
+}
+
+// partitionCount returns the number of partitions of topic, cached after the first lookup
+func (p *Producer) partitionCount(topic string) (int32, error) {
+	if count, ok := p.partitionCounts.Load(topic); ok {
+		return count.(int32), nil
+	}
+
+	p.closeMu.RLock()
+	if p.closed {
+		p.closeMu.RUnlock()
+		return 0, ErrProducerClosed
+	}
+	metadata, err := p.producer.GetMetadata(&topic, false, 5000)
+	p.closeMu.RUnlock()
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch metadata for topic %s: %w", topic, err)
+	}
+	topicMetadata, ok := metadata.Topics[topic]
+	if !ok || len(topicMetadata.Partitions) == 0 {
+		return 0, fmt.Errorf("topic %s has no partitions", topic)
+	}
+
+	count := int32(len(topicMetadata.Partitions))
+	p.partitionCounts.Store(topic, count)
+	return count, nil
+}
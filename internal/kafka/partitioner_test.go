@@ -0,0 +1,115 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+func TestMurmur2PartitionerMatchesKafka(t *testing.T) {
+	const partitions = 7
+	cluster := newMockCluster(t)
+	if err := cluster.CreateTopic("murmur2", partitions, 1); err != nil {
+		t.Fatal(err)
+	}
+	// librdkafka's murmur2 partitioner is the Java client's default
+	producer, err := kafka.NewProducer(&kafka.ConfigMap{
+		"bootstrap.servers": cluster.BootstrapServers(),
+		"partitioner":       "murmur2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer producer.Close()
+
+	topic := "murmur2"
+	deliveries := make(chan kafka.Event, 1)
+	for i := 0; i < 50; i++ {
+		key := []byte(fmt.Sprintf("customer-%d", i))
+		if err := producer.Produce(&kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+			Key:            key,
+		}, deliveries); err != nil {
+			t.Fatal(err)
+		}
+		msg := (<-deliveries).(*kafka.Message)
+		if msg.TopicPartition.Error != nil {
+			t.Fatal(msg.TopicPartition.Error)
+		}
+		if got, want := (Murmur2Partitioner{}).Partition(key, partitions), msg.TopicPartition.Partition; got != want {
+			t.Errorf("key %s: partition %d, Kafka's murmur2 partitioner chose %d", key, got, want)
+		}
+	}
+}
+
+func TestCustomerEventsShareAPartitionAcrossTopics(t *testing.T) {
+	cluster := newMockCluster(t)
+	topics := []string{"customer-orders", "customer-inventory"}
+	for _, topic := range topics {
+		if err := cluster.CreateTopic(topic, 6, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := newClusterProducer(t, cluster)
+
+	published := []struct {
+		topic     string
+		eventType events.EventType
+	}{
+		{"customer-orders", events.EventTypeOrderCreated},
+		{"customer-orders", events.EventTypeOrderConfirmed},
+		{"customer-inventory", events.EventTypeInventoryReserved},
+		{"customer-inventory", events.EventTypeInventoryReleased},
+	}
+	for i, pub := range published {
+		key := []byte(fmt.Sprintf("order-%d", i)) // distinct message keys
+		event := events.NewEvent(pub.eventType, nil)
+		if err := p.PublishEventWithPartitionKey(context.Background(), pub.topic, "customer-42", key, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	partitions := readPartitions(t, cluster, topics, len(published))
+	if len(partitions) != len(published) {
+		t.Fatalf("read back %d of the %d events", len(partitions), len(published))
+	}
+	want := (Murmur2Partitioner{}).Partition([]byte("customer-42"), 6)
+	for key, partition := range partitions {
+		if partition != want {
+			t.Errorf("%s on partition %d, want the customer's partition %d", key, partition, want)
+		}
+	}
+}
+
+// readPartitions reads n messages of topics from the start and returns the
+// partition of each message key
+func readPartitions(t *testing.T, cluster *kafka.MockCluster, topics []string, n int) map[string]int32 {
+	t.Helper()
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": cluster.BootstrapServers(),
+		"group.id":          "readback",
+		"auto.offset.reset": "earliest",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+	if err := consumer.SubscribeTopics(topics, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	partitions := make(map[string]int32)
+	deadline := time.Now().Add(10 * time.Second)
+	for len(partitions) < n && time.Now().Before(deadline) {
+		msg, err := consumer.ReadMessage(100 * time.Millisecond)
+		if err != nil {
+			continue
+		}
+		partitions[string(msg.Key)] = msg.TopicPartition.Partition
+	}
+	return partitions
+}
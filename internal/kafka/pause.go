@@ -0,0 +1,72 @@
+package kafka
+
+import (
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.uber.org/zap"
+)
+
+// Pause stops the consumer reading messages while it stays in its group and
+// keeps its assignment, e.g. while a downstream dependency is down. It takes
+// effect on the next poll of Start's loop; messages already being handled
+// finish. Partitions assigned while paused stay paused too.
+func (c *Consumer) Pause() {
+	if !c.paused.Swap(true) {
+		c.log.Info("Pausing consumer")
+	}
+}
+
+// Resume undoes Pause. Partitions holding a scheduled message that is not
+// yet due stay paused until it is.
+func (c *Consumer) Resume() {
+	if c.paused.Swap(false) {
+		c.log.Info("Resuming consumer")
+	}
+}
+
+// Paused reports whether Pause was called without a matching Resume
+func (c *Consumer) Paused() bool {
+	return c.paused.Load()
+}
+
+// applyPause pauses or resumes the assigned partitions after Pause or Resume.
+// It runs on the read loop, which owns the delay queue; failures are retried
+// on the next poll.
+func (c *Consumer) applyPause() {
+	paused := c.paused.Load()
+	if paused == c.pauseApplied {
+		return
+	}
+
+	assignment, err := c.flow.Assignment()
+	if err == nil {
+		if paused {
+			err = c.flow.Pause(assignment)
+		} else {
+			err = c.flow.Resume(c.delays.unparked(assignment))
+		}
+	}
+	if err != nil {
+		c.log.Error("Error applying consumer pause state",
+			zap.Error(err),
+			zap.Bool("paused", paused),
+		)
+		return
+	}
+
+	c.pauseApplied = paused
+	c.log.Info("Consumer pause state applied",
+		zap.Bool("paused", paused),
+		zap.Int("partitions", len(assignment)),
+	)
+}
+
+// holdWhilePaused pauses and rewinds the partition of a message read while
+// the consumer is paused, e.g. from a partition assigned after Pause, so the
+// message is read again after Resume. It reports whether it held the message.
+func (c *Consumer) holdWhilePaused(msg *kafka.Message) bool {
+	if !c.pauseApplied {
+		return false
+	}
+	c.rewind(msg.TopicPartition)
+	return true
+}
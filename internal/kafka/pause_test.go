@@ -0,0 +1,97 @@
+package kafka
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+)
+
+// signalingFlow is a partitionFlow safe to inspect while Start runs, which
+// signals every Resume
+type signalingFlow struct {
+	mu         sync.Mutex
+	assignment []kafka.TopicPartition
+	paused     []kafka.TopicPartition
+	seeks      []kafka.TopicPartition
+	resumes    chan []kafka.TopicPartition
+}
+
+func (f *signalingFlow) Assignment() ([]kafka.TopicPartition, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.assignment, nil
+}
+
+func (f *signalingFlow) Pause(partitions []kafka.TopicPartition) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paused = append(f.paused, partitions...)
+	return nil
+}
+
+func (f *signalingFlow) Resume(partitions []kafka.TopicPartition) error {
+	f.resumes <- partitions
+	return nil
+}
+
+func (f *signalingFlow) Seek(partition kafka.TopicPartition, ignoredTimeoutMs int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seeks = append(f.seeks, partition)
+	return nil
+}
+
+func TestPausedConsumerHandlesNothingUntilResumed(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	flow := &signalingFlow{assignment: newAssignment("orders", 2), resumes: make(chan []kafka.TopicPartition, 1)}
+	c.flow = flow
+	var handled atomic.Int32
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		handled.Add(1)
+		return nil
+	})
+
+	c.Pause()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	feed, result := startTestConsumer(ctx, c)
+
+	// The loop reads one message at a time, so the first was held once the second is taken
+	feed <- testMessage("orders", 0)
+	feed <- testMessage("orders", 1)
+	if n := handled.Load(); n != 0 {
+		t.Fatalf("handled %d messages while paused, want none", n)
+	}
+
+	c.Resume()
+	select {
+	case resumed := <-flow.resumes:
+		if len(resumed) != 2 {
+			t.Errorf("resumed %v, want the whole assignment", resumed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("partitions not resumed")
+	}
+	// Read again from the rewound position
+	feed <- testMessage("orders", 0)
+	feed <- testMessage("orders", 1)
+	deadline := time.Now().Add(time.Second)
+	for handled.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-result
+
+	if n := handled.Load(); n != 2 {
+		t.Errorf("handled %d messages after Resume, want both", n)
+	}
+	if len(flow.paused) != 4 || !slices.Equal(offsetsOf(flow.seeks), []kafka.Offset{0, 1}) {
+		t.Errorf("paused %v and rewound to %v, want the assignment paused and both held messages rewound", flow.paused, offsetsOf(flow.seeks))
+	}
+}
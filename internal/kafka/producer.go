@@ -2,68 +2,385 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/tanint/go-eda/internal/config"
 	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/metrics"
+	"github.com/tanint/go-eda/internal/version"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// ErrProducerClosed is returned when publishing through a producer after Close
+var ErrProducerClosed = errors.New("kafka producer is closed")
+
+// EnrichFunc stamps cross-cutting fields on an event before it is published
+type EnrichFunc func(event *events.Event)
+
 // Producer wraps Kafka producer with additional functionality
 type Producer struct {
-	producer *kafka.Producer
-	config   config.KafkaConfig
+	producer        *kafka.Producer
+	config          config.KafkaConfig
+	enrichers       []EnrichFunc
+	partitioner     Partitioner
+	partitionKey    PartitionKeyFunc
+	partitionCounts sync.Map // topic -> int32
+	contextKeys     []ContextKey
+	serializer      events.Serializer
+	tracer          trace.Tracer
+	propagator      propagation.TextMapPropagator
+	log             logger.Logger
+
+	closeMu      sync.RWMutex // held for reading while producing, for writing while closing
+	closed       bool
+	healthChecks sync.WaitGroup // in-flight Healthy calls, awaited before closing the client
+	done         chan struct{}  // closed once Close has flushed and closed the client
 }
 
-// NewProducer creates a new Kafka producer
-func NewProducer(cfg config.KafkaConfig) (*Producer, error) {
+// NewProducer creates a new Kafka producer logging to log, or to the global
+// logger when log is nil
+func NewProducer(cfg config.KafkaConfig, log logger.Logger) (*Producer, error) {
+	if log == nil {
+		log = logger.Global()
+	}
+	serializer, err := newSerializer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	configMap := &kafka.ConfigMap{
-		"bootstrap.servers": cfg.Brokers,
-		"client.id":         "go-eda-producer",
-		"acks":              "all",
-		"retries":           3,
+		"bootstrap.servers":                     strings.Join(cfg.Brokers, ","),
+		"client.id":                             "go-eda-producer",
 		"max.in.flight.requests.per.connection": 5,
-		"compression.type":                      "snappy",
-		"linger.ms":                             5,
-		"batch.size":                            16384,
 	}
-
-	// Add security configuration if needed
-	if cfg.SecurityProtocol != "PLAINTEXT" {
-		configMap.SetKey("security.protocol", cfg.SecurityProtocol)
-		configMap.SetKey("sasl.mechanism", cfg.SASLMechanism)
-		configMap.SetKey("sasl.username", cfg.SASLUsername)
-		configMap.SetKey("sasl.password", cfg.SASLPassword)
+	if err := applyProducerConfig(configMap, cfg.Producer); err != nil {
+		return nil, err
 	}
 
-	producer, err := kafka.NewProducer(configMap)
+	ApplySecurityConfig(configMap, cfg)
+
+	producer, err := createWithRetry("producer", cfg.StartupRetry, log, func() (*kafka.Producer, error) {
+		return kafka.NewProducer(configMap)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create producer: %w", err)
 	}
 
+	if cfg.Producer.TransactionalID != "" {
+		// Registers the transactional ID and fences older producers using it
+		ctx, cancel := context.WithTimeout(context.Background(), initTransactionsTimeout)
+		err := producer.InitTransactions(ctx)
+		cancel()
+		if err != nil {
+			producer.Close()
+			return nil, fmt.Errorf("failed to initialize transactions: %w", err)
+		}
+	}
+
 	p := &Producer{
-		producer: producer,
-		config:   cfg,
+		producer:    producer,
+		config:      cfg,
+		partitioner: Murmur2Partitioner{},
+		contextKeys: append([]ContextKey(nil), defaultContextKeys...),
+		serializer:  serializer,
+		tracer:      defaultTracer(),
+		propagator:  propagation.TraceContext{},
+		log:         log,
+		done:        make(chan struct{}),
 	}
+	p.AddEnricher(stampServiceVersion)
 
 	// Start delivery report handler
 	go p.handleDeliveryReports()
 
-	logger.Info("Kafka producer initialized successfully",
+	p.log.Info("Kafka producer initialized successfully",
 		zap.Strings("brokers", cfg.Brokers),
+		zap.Bool("transactional", p.Transactional()),
 	)
 
 	return p, nil
 }
 
+// Producer tuning defaults for unset settings; retries and linger have
+// meaningful zero values, so their defaults come from config
+const (
+	defaultProducerAcks        = "all"
+	defaultProducerCompression = "snappy"
+	defaultProducerBatchSize   = 16384
+)
+
+// applyProducerConfig validates the durability and batching settings and
+// sets them on the client config
+func applyProducerConfig(configMap *kafka.ConfigMap, cfg config.ProducerConfig) error {
+	acks := cfg.Acks
+	switch acks {
+	case "":
+		acks = defaultProducerAcks
+	case "0", "1", "all":
+	default:
+		return fmt.Errorf("invalid producer acks %q: must be 0, 1 or all", cfg.Acks)
+	}
+
+	compression := cfg.CompressionType
+	switch compression {
+	case "":
+		compression = defaultProducerCompression
+	case "none", "gzip", "snappy", "lz4", "zstd":
+	default:
+		return fmt.Errorf("invalid producer compression type %q: must be none, gzip, snappy, lz4 or zstd", cfg.CompressionType)
+	}
+
+	if cfg.Retries < 0 {
+		return fmt.Errorf("invalid producer retries %d: must not be negative", cfg.Retries)
+	}
+	if cfg.Linger < 0 {
+		return fmt.Errorf("invalid producer linger %s: must not be negative", cfg.Linger)
+	}
+
+	batchSize := cfg.BatchSize
+	if batchSize < 0 {
+		return fmt.Errorf("invalid producer batch size %d: must not be negative", cfg.BatchSize)
+	}
+	if batchSize == 0 {
+		batchSize = defaultProducerBatchSize
+	}
+
+	// Idempotence, which transactions build on, needs every write acknowledged and retried
+	idempotent := cfg.Idempotent || cfg.TransactionalID != ""
+	if idempotent && acks != "all" {
+		return fmt.Errorf("invalid producer acks %q: idempotent and transactional producers require all", acks)
+	}
+	if idempotent && cfg.Retries == 0 {
+		return fmt.Errorf("invalid producer retries 0: idempotent and transactional producers require retries")
+	}
+
+	settings := kafka.ConfigMap{
+		"acks":               acks,
+		"retries":            cfg.Retries,
+		"compression.type":   compression,
+		"linger.ms":          int(cfg.Linger / time.Millisecond),
+		"batch.size":         batchSize,
+		"enable.idempotence": idempotent,
+	}
+	if cfg.TransactionalID != "" {
+		settings["transactional.id"] = cfg.TransactionalID
+	}
+	for key, value := range settings {
+		if err := configMap.SetKey(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
 // Publish publishes a message to the specified topic
 func (p *Producer) Publish(ctx context.Context, topic string, key, value []byte) error {
-	deliveryChan := make(chan kafka.Event, 1)
-	defer close(deliveryChan)
+	return p.produce(ctx, newMessage(topic, key, value))
+}
 
-	err := p.producer.Produce(&kafka.Message{
+// BatchMessage is a single message of a PublishBatch call
+type BatchMessage struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers []kafka.Header
+	// PartitionKey picks the partition like PublishEventWithPartitionKey;
+	// empty partitions by Key
+	PartitionKey string
+}
+
+// PublishBatch enqueues all messages at once and then waits for every
+// delivery report, which is much faster than publishing them one by one.
+// The returned error joins the failures of individual messages.
+func (p *Producer) PublishBatch(ctx context.Context, messages []BatchMessage) (err error) {
+	if len(messages) == 0 {
+		return nil
+	}
+	ctx, span := p.tracer.Start(ctx, "publish batch",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.Int("messaging.batch.message_count", len(messages)),
+		),
+	)
+	defer func() { endSpan(span, err) }()
+
+	deliveryChan := make(chan kafka.Event, len(messages))
+	start := time.Now()
+
+	var errs []error
+	produced := 0
+	p.closeMu.RLock()
+	if p.closed {
+		p.closeMu.RUnlock()
+		return ErrProducerClosed
+	}
+	for _, m := range messages {
+		msg := newMessage(m.Topic, m.Key, m.Value)
+		if m.PartitionKey != "" {
+			partition, err := p.partitionFor(m.Topic, []byte(m.PartitionKey))
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			msg.TopicPartition.Partition = partition
+		}
+		msg.Headers = append(msg.Headers, m.Headers...)
+		p.propagator.Inject(ctx, messageCarrier{msg})
+		if err := p.producer.Produce(msg, deliveryChan); err != nil {
+			metrics.IncCounter(metrics.PublishErrors, metrics.Topic(m.Topic))
+			errs = append(errs, fmt.Errorf("failed to produce message to %s: %w", m.Topic, err))
+			continue
+		}
+		produced++
+	}
+	p.closeMu.RUnlock()
+
+	for i := 0; i < produced; i++ {
+		select {
+		case e := <-deliveryChan:
+			m, ok := e.(*kafka.Message)
+			if !ok {
+				errs = append(errs, fmt.Errorf("unexpected delivery event %T: %v", e, e))
+				continue
+			}
+			topic := *m.TopicPartition.Topic
+			if m.TopicPartition.Error != nil {
+				metrics.IncCounter(metrics.PublishErrors, metrics.Topic(topic))
+				errs = append(errs, fmt.Errorf("delivery to %s failed: %w", topic, m.TopicPartition.Error))
+				continue
+			}
+			metrics.IncCounter(metrics.MessagesPublished, metrics.Topic(topic))
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	p.log.Debug("Batch delivered",
+		zap.Int("messages", len(messages)),
+		zap.Int("failed", len(errs)),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return errors.Join(errs...)
+}
+
+// PublishWithHeaders publishes a message carrying additional headers
+func (p *Producer) PublishWithHeaders(ctx context.Context, topic string, key, value []byte, headers []kafka.Header) error {
+	msg := newMessage(topic, key, value)
+	msg.Headers = append(msg.Headers, headers...)
+	return p.produce(ctx, msg)
+}
+
+// AddEnricher registers enrichers applied, in registration order, to every event
+// published through PublishEvent
+func (p *Producer) AddEnricher(fns ...EnrichFunc) {
+	p.enrichers = append(p.enrichers, fns...)
+}
+
+// stampServiceVersion records the publishing service's version on the event
+func stampServiceVersion(event *events.Event) {
+	if _, ok := event.Metadata[events.MetadataServiceVersion]; !ok {
+		event.SetMetadata(events.MetadataServiceVersion, version.Get())
+	}
+}
+
+// PublishEvent stamps the event with the propagated context values, enriches
+// and serializes it, then publishes it to the specified topic. With a
+// partition key function set, the event goes to the partition of its key.
+func (p *Producer) PublishEvent(ctx context.Context, topic string, key []byte, event *events.Event) error {
+	if p.partitionKey != nil {
+		return p.PublishEventWithPartitionKey(ctx, topic, p.partitionKey(event), key, event)
+	}
+
+	value, err := p.encodeEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	return p.PublishWithHeaders(ctx, topic, key, value, p.eventHeaders(event))
+}
+
+// SetPartitioner replaces the partitioner used by PublishEventWithPartitionKey
+func (p *Producer) SetPartitioner(partitioner Partitioner) {
+	p.partitioner = partitioner
+}
+
+// SetPartitionKeyFunc makes PublishEvent derive each event's partition key
+// with fn instead of partitioning by the message key. nil restores the default.
+func (p *Producer) SetPartitionKeyFunc(fn PartitionKeyFunc) {
+	p.partitionKey = fn
+}
+
+// PublishEventWithPartitionKey publishes the event to the partition partitionKey
+// maps to, independently of the message key. Events sharing a partition key
+// (e.g. a customer ID) land on the same partition of every co-partitioned topic.
+// An empty partition key leaves the partition to the client (PartitionAny),
+// which partitions by the message key.
+func (p *Producer) PublishEventWithPartitionKey(ctx context.Context, topic, partitionKey string, key []byte, event *events.Event) error {
+	value, err := p.encodeEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	msg := newMessage(topic, key, value)
+	if partitionKey != "" {
+		partition, err := p.partitionFor(topic, []byte(partitionKey))
+		if err != nil {
+			return err
+		}
+		msg.TopicPartition.Partition = partition
+	}
+	msg.Headers = append(msg.Headers, p.eventHeaders(event)...)
+	return p.produce(ctx, msg)
+}
+
+// PublishToPartition publishes a message to a specific partition of the topic
+func (p *Producer) PublishToPartition(ctx context.Context, topic string, partition int32, key, value []byte) error {
+	if partition < 0 {
+		return fmt.Errorf("invalid partition %d: must be non-negative", partition)
+	}
+
+	msg := newMessage(topic, key, value)
+	msg.TopicPartition.Partition = partition
+	return p.produce(ctx, msg)
+}
+
+// encodeEvent stamps context values, applies the enrichers and serializes the event
+func (p *Producer) encodeEvent(ctx context.Context, event *events.Event) ([]byte, error) {
+	p.stampContext(ctx, event)
+	for _, enrich := range p.enrichers {
+		enrich(event)
+	}
+
+	value, err := p.serializer.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return value, nil
+}
+
+// PublishDelayed publishes a message that consumers must not process before delay has elapsed
+func (p *Producer) PublishDelayed(ctx context.Context, topic string, key, value []byte, delay time.Duration) error {
+	msg := newMessage(topic, key, value)
+	msg.Headers = append(msg.Headers, kafka.Header{
+		Key:   HeaderDeliverAt,
+		Value: []byte(time.Now().Add(delay).UTC().Format(time.RFC3339Nano)),
+	})
+	return p.produce(ctx, msg)
+}
+
+// newMessage builds a message for the given topic with the default headers
+func newMessage(topic string, key, value []byte) *kafka.Message {
+	return &kafka.Message{
 		TopicPartition: kafka.TopicPartition{
 			Topic:     &topic,
 			Partition: kafka.PartitionAny,
@@ -71,54 +388,142 @@ func (p *Producer) Publish(ctx context.Context, topic string, key, value []byte)
 		Key:   key,
 		Value: value,
 		Headers: []kafka.Header{
-			{Key: "timestamp", Value: []byte(time.Now().Format(time.RFC3339))},
+			{Key: HeaderTimestamp, Value: []byte(time.Now().Format(time.RFC3339))},
 		},
-	}, deliveryChan)
+	}
+}
 
-	if err != nil {
-		logger.Error("Failed to produce message",
-			zap.Error(err),
-			zap.String("topic", topic),
-		)
-		return fmt.Errorf("failed to produce message: %w", err)
+// produce sends the message and waits for its delivery report.
+//
+// Each call gets its own delivery channel: librdkafka routes the report of a
+// message produced with a channel to that channel only, never to the shared
+// Events() channel drained by handleDeliveryReports, so concurrent publishes
+// cannot see each other's results. The channel is buffered and deliberately
+// left open: if ctx is done first, the late report is written into the buffer
+// and garbage collected with the channel instead of panicking on a closed one.
+func (p *Producer) produce(ctx context.Context, msg *kafka.Message) (err error) {
+	ctx, span := p.startPublishSpan(ctx, msg)
+	defer func() { endSpan(span, err) }()
+
+	deliveryChan := make(chan kafka.Event, 1)
+	start := time.Now()
+	if err := p.enqueue(msg, deliveryChan); err != nil {
+		return err
 	}
 
 	// Wait for delivery report or context cancellation
 	select {
 	case e := <-deliveryChan:
-		m := e.(*kafka.Message)
-		if m.TopicPartition.Error != nil {
-			logger.Error("Message delivery failed",
-				zap.Error(m.TopicPartition.Error),
-				zap.String("topic", topic),
-			)
-			return fmt.Errorf("delivery failed: %w", m.TopicPartition.Error)
-		}
-		logger.Debug("Message delivered successfully",
-			zap.String("topic", *m.TopicPartition.Topic),
-			zap.Int32("partition", m.TopicPartition.Partition),
-			zap.String("offset", m.TopicPartition.Offset.String()),
-		)
+		return p.delivered(e, *msg.TopicPartition.Topic, start)
 	case <-ctx.Done():
 		return ctx.Err()
 	}
+}
+
+// PublishAsync enqueues a message and returns without waiting for its
+// delivery. The returned channel always receives exactly one result and is
+// then closed: nil once the message is delivered, the delivery or enqueue
+// error, or ErrProducerClosed when the producer is closed before the
+// message's delivery report arrives. Callers that don't care about the
+// result may ignore it. Like the other publish methods it continues the
+// trace of ctx; cancelling ctx does not cancel the delivery.
+func (p *Producer) PublishAsync(ctx context.Context, topic string, key, value []byte) <-chan error {
+	result := make(chan error, 1)
+
+	msg := newMessage(topic, key, value)
+	_, span := p.startPublishSpan(ctx, msg)
+	deliveryChan := make(chan kafka.Event, 1)
+	start := time.Now()
+	if err := p.enqueue(msg, deliveryChan); err != nil {
+		endSpan(span, err)
+		result <- err
+		close(result)
+		return result
+	}
+
+	go func() {
+		var err error
+		select {
+		case e := <-deliveryChan:
+			err = p.delivered(e, topic, start)
+		case <-p.done:
+			// Close flushed first, so a report that arrived is in the buffer
+			select {
+			case e := <-deliveryChan:
+				err = p.delivered(e, topic, start)
+			default:
+				err = fmt.Errorf("%w before the message was delivered", ErrProducerClosed)
+			}
+		}
+		endSpan(span, err)
+		result <- err
+		close(result)
+	}()
+	return result
+}
+
+// enqueue hands the message to librdkafka; its delivery report goes to deliveryChan
+func (p *Producer) enqueue(msg *kafka.Message, deliveryChan chan kafka.Event) error {
+	topic := *msg.TopicPartition.Topic
+
+	p.closeMu.RLock()
+	if p.closed {
+		p.closeMu.RUnlock()
+		return ErrProducerClosed
+	}
+	err := p.producer.Produce(msg, deliveryChan)
+	p.closeMu.RUnlock()
 
+	if err != nil {
+		metrics.IncCounter(metrics.PublishErrors, metrics.Topic(topic))
+		p.log.Error("Failed to produce message",
+			zap.Error(err),
+			zap.String("topic", topic),
+		)
+		return fmt.Errorf("failed to produce message: %w", err)
+	}
+	return nil
+}
+
+// delivered records the delivery report of a message produced at start and
+// returns the delivery error, if any
+func (p *Producer) delivered(e kafka.Event, topic string, start time.Time) error {
+	m, ok := e.(*kafka.Message)
+	if !ok {
+		return fmt.Errorf("unexpected delivery event %T: %v", e, e)
+	}
+	if m.TopicPartition.Error != nil {
+		metrics.IncCounter(metrics.PublishErrors, metrics.Topic(topic))
+		p.log.Error("Message delivery failed",
+			zap.Error(m.TopicPartition.Error),
+			zap.String("topic", topic),
+		)
+		return fmt.Errorf("delivery failed: %w", m.TopicPartition.Error)
+	}
+	metrics.IncCounter(metrics.MessagesPublished, metrics.Topic(topic))
+	metrics.RecordTiming(metrics.PublishDuration, time.Since(start), metrics.Topic(topic))
+	p.log.Debug("Message delivered successfully",
+		zap.String("topic", *m.TopicPartition.Topic),
+		zap.Int32("partition", m.TopicPartition.Partition),
+		zap.String("offset", m.TopicPartition.Offset.String()),
+	)
 	return nil
 }
 
-// handleDeliveryReports handles delivery reports from Kafka
+// handleDeliveryReports handles events not claimed by a per-message delivery
+// channel: client-level errors and reports for messages produced without one
 func (p *Producer) handleDeliveryReports() {
 	for e := range p.producer.Events() {
 		switch ev := e.(type) {
 		case *kafka.Message:
 			if ev.TopicPartition.Error != nil {
-				logger.Error("Delivery failed",
+				p.log.Error("Delivery failed",
 					zap.Error(ev.TopicPartition.Error),
 					zap.String("topic", *ev.TopicPartition.Topic),
 				)
 			}
 		case kafka.Error:
-			logger.Error("Kafka error",
+			p.log.Error("Kafka error",
 				zap.Error(ev),
 				zap.String("code", ev.Code().String()),
 			)
@@ -126,19 +531,30 @@ func (p *Producer) handleDeliveryReports() {
 	}
 }
 
-// Close closes the producer and flushes any pending messages
+// Close closes the producer and flushes any pending messages. Publishing
+// afterwards fails with ErrProducerClosed; closing again is a no-op.
 func (p *Producer) Close() error {
-	logger.Info("Closing Kafka producer...")
+	p.closeMu.Lock()
+	if p.closed {
+		p.closeMu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.closeMu.Unlock()
+
+	p.log.Info("Closing Kafka producer...")
 
 	// Wait for all messages to be delivered (with timeout)
 	outstanding := p.producer.Flush(15 * 1000) // 15 seconds
 	if outstanding > 0 {
-		logger.Warn("Some messages were not delivered before close",
+		p.log.Warn("Some messages were not delivered before close",
 			zap.Int("outstanding", outstanding),
 		)
 	}
 
+	p.healthChecks.Wait()
 	p.producer.Close()
-	logger.Info("Kafka producer closed successfully")
+	close(p.done)
+	p.log.Info("Kafka producer closed successfully")
 	return nil
 }
@@ -0,0 +1,428 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/version"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+// newMockCluster starts an in-process mock cluster, which acknowledges
+// messages like a broker
+func newMockCluster(tb testing.TB) *kafka.MockCluster {
+	tb.Helper()
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(cluster.Close)
+	return cluster
+}
+
+// newMockProducer returns a producer publishing to a new mock cluster
+func newMockProducer(tb testing.TB) *Producer {
+	tb.Helper()
+	return newClusterProducer(tb, newMockCluster(tb))
+}
+
+func newClusterProducer(tb testing.TB, cluster *kafka.MockCluster) *Producer {
+	tb.Helper()
+	p, err := NewProducer(config.KafkaConfig{
+		Brokers:  []string{cluster.BootstrapServers()},
+		Producer: config.ProducerConfig{Retries: 3},
+	}, zap.NewNop())
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { p.Close() })
+	return p
+}
+
+// readKeys reads n messages of topic from the start and returns their keys
+func readKeys(t *testing.T, cluster *kafka.MockCluster, topic string, n int) map[string]bool {
+	t.Helper()
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": cluster.BootstrapServers(),
+		"group.id":          "readback",
+		"auto.offset.reset": "earliest",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+	if err := consumer.Subscribe(topic, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make(map[string]bool)
+	deadline := time.Now().Add(10 * time.Second)
+	for len(keys) < n && time.Now().Before(deadline) {
+		msg, err := consumer.ReadMessage(100 * time.Millisecond)
+		if err != nil {
+			continue
+		}
+		keys[string(msg.Key)] = true
+	}
+	return keys
+}
+
+func TestPublishBatchWaitsForEveryDeliveryReport(t *testing.T) {
+	cluster := newMockCluster(t)
+	if err := cluster.CreateTopic("orders", 3, 1); err != nil {
+		t.Fatal(err)
+	}
+	p := newClusterProducer(t, cluster)
+	messages := make([]BatchMessage, 100)
+	for i := range messages {
+		messages[i] = BatchMessage{Topic: "orders", Key: []byte(fmt.Sprint(i)), Value: []byte("order")}
+	}
+
+	if err := p.PublishBatch(context.Background(), messages); err != nil {
+		t.Fatal(err)
+	}
+	if n := p.producer.Len(); n != 0 {
+		t.Errorf("%d messages still queued after PublishBatch returned, want all delivered", n)
+	}
+	if keys := readKeys(t, cluster, "orders", len(messages)); len(keys) != len(messages) {
+		t.Errorf("read back %d of the %d batch messages", len(keys), len(messages))
+	}
+}
+
+func TestPublishBatchJoinsFailuresOfSingleMessages(t *testing.T) {
+	p := newMockProducer(t)
+	tooLarge := make([]byte, 2<<20) // above the default message.max.bytes
+	messages := []BatchMessage{
+		{Topic: "orders", Key: []byte("1"), Value: []byte("order")},
+		{Topic: "orders", Key: []byte("2"), Value: tooLarge},
+		{Topic: "orders", Key: []byte("3"), Value: []byte("order")},
+	}
+
+	err := p.PublishBatch(context.Background(), messages)
+	var kafkaErr kafka.Error
+	if !errors.As(err, &kafkaErr) || kafkaErr.Code() != kafka.ErrMsgSizeTooLarge {
+		t.Errorf("got %v, want the oversized message's error", err)
+	}
+	if n := p.producer.Len(); n != 0 {
+		t.Errorf("%d messages still queued, want the others delivered", n)
+	}
+}
+
+func TestPublishAsyncReportsDelivery(t *testing.T) {
+	p := newMockProducer(t)
+	results := make([]<-chan error, 10)
+	for i := range results {
+		results[i] = p.PublishAsync(context.Background(), "orders", nil, []byte("order"))
+	}
+	for i, result := range results {
+		if err := <-result; err != nil {
+			t.Errorf("message %d: %v", i, err)
+		}
+	}
+}
+
+func TestPublishingAfterCloseReturnsErrProducerClosed(t *testing.T) {
+	p := newMockProducer(t)
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	event := events.NewEvent(events.EventTypeOrderCreated, map[string]string{"order_id": "order-1"})
+	publishes := map[string]func() error{
+		"Publish":            func() error { return p.Publish(ctx, "orders", nil, []byte("order")) },
+		"PublishWithHeaders": func() error { return p.PublishWithHeaders(ctx, "orders", nil, []byte("order"), nil) },
+		"PublishEvent":       func() error { return p.PublishEvent(ctx, "orders", nil, event) },
+		"PublishEventWithPartitionKey": func() error {
+			return p.PublishEventWithPartitionKey(ctx, "orders", "customer-1", nil, event)
+		},
+		"PublishToPartition": func() error { return p.PublishToPartition(ctx, "orders", 0, nil, []byte("order")) },
+		"PublishDelayed":     func() error { return p.PublishDelayed(ctx, "orders", nil, []byte("order"), time.Minute) },
+		"PublishAsync":       func() error { return <-p.PublishAsync(ctx, "orders", nil, []byte("order")) },
+	}
+	for name, publish := range publishes {
+		t.Run(name, func(t *testing.T) {
+			if err := publish(); !errors.Is(err, ErrProducerClosed) {
+				t.Errorf("got %v, want ErrProducerClosed", err)
+			}
+		})
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("closing again: %v", err)
+	}
+}
+
+func TestPublishBatchFailsOnClosedProducer(t *testing.T) {
+	p := newMockProducer(t)
+	p.Close()
+	err := p.PublishBatch(context.Background(), []BatchMessage{{Topic: "orders", Value: []byte("order")}})
+	if !errors.Is(err, ErrProducerClosed) {
+		t.Errorf("got %v, want ErrProducerClosed", err)
+	}
+}
+
+func TestConcurrentPublishesGetTheirOwnDeliveryReports(t *testing.T) {
+	p := newMockProducer(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := fmt.Sprint(i)
+			deliveryChan := make(chan kafka.Event, 2)
+			if err := p.enqueue(newMessage("orders", []byte(key), []byte("order")), deliveryChan); err != nil {
+				t.Error(err)
+				return
+			}
+
+			report, ok := (<-deliveryChan).(*kafka.Message)
+			if !ok || string(report.Key) != key {
+				t.Errorf("publish of key %s got the delivery report %v", key, report)
+			}
+			select {
+			case e := <-deliveryChan:
+				t.Errorf("publish of key %s got a second delivery report %v", key, e)
+			case <-time.After(100 * time.Millisecond):
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.Publish(context.Background(), "orders", nil, []byte("order")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestPublishToPartitionTargetsThePartition(t *testing.T) {
+	cluster := newMockCluster(t)
+	if err := cluster.CreateTopic("pinned", 4, 1); err != nil {
+		t.Fatal(err)
+	}
+	p := newClusterProducer(t, cluster)
+
+	want := map[string]int32{"a": 3, "b": 3, "c": 1}
+	for key, partition := range want {
+		if err := p.PublishToPartition(context.Background(), "pinned", partition, []byte(key), []byte("order")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := readPartitions(t, cluster, []string{"pinned"}, len(want))
+	if !maps.Equal(got, want) {
+		t.Errorf("messages delivered to partitions %v, want %v", got, want)
+	}
+}
+
+func TestPublishToPartitionRejectsNegativePartitions(t *testing.T) {
+	p := newMockProducer(t)
+	if err := p.PublishToPartition(context.Background(), "pinned", -1, nil, []byte("order")); err == nil {
+		t.Error("published to partition -1")
+	}
+}
+
+// snapshotSerializer records the metadata of each event it marshals
+type snapshotSerializer struct {
+	events.JSONSerializer
+	metadata []map[string]string
+}
+
+func (s *snapshotSerializer) Marshal(event *events.Event) ([]byte, error) {
+	s.metadata = append(s.metadata, maps.Clone(event.Metadata))
+	return s.JSONSerializer.Marshal(event)
+}
+
+func TestPublishEventAppliesEnrichersInOrderBeforeMarshalling(t *testing.T) {
+	cluster := newMockCluster(t)
+	if err := cluster.CreateTopic("enriched", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	p := newClusterProducer(t, cluster)
+	serializer := &snapshotSerializer{}
+	p.SetSerializer(serializer)
+
+	var order []string
+	p.AddEnricher(
+		func(event *events.Event) {
+			order = append(order, "tenant")
+			event.SetMetadata("tenant", "acme")
+		},
+		func(event *events.Event) {
+			order = append(order, "region")
+			event.SetMetadata("region", event.Metadata["tenant"]+"-eu")
+		},
+	)
+
+	event := events.NewEvent("order.created", map[string]string{"order_id": "order-1"})
+	if err := p.PublishEvent(context.Background(), "enriched", []byte("order-1"), event); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"tenant", "region"}; !slices.Equal(order, want) {
+		t.Errorf("enrichers ran in order %v, want %v", order, want)
+	}
+	if len(serializer.metadata) != 1 {
+		t.Fatalf("serializer marshalled %d events, want 1", len(serializer.metadata))
+	}
+	marshalled := serializer.metadata[0]
+	if marshalled["tenant"] != "acme" || marshalled["region"] != "acme-eu" {
+		t.Errorf("marshalled metadata = %v, want the enriched fields", marshalled)
+	}
+	if marshalled[events.MetadataServiceVersion] == "" {
+		t.Error("marshalled event lacks the service version stamped by the default enricher")
+	}
+}
+
+func TestPublishEventStampsTheServiceVersion(t *testing.T) {
+	linked := version.Version
+	version.Version = "v1.2.3"
+	t.Cleanup(func() { version.Version = linked })
+
+	cluster := newMockCluster(t)
+	if err := cluster.CreateTopic("versioned", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	p := newClusterProducer(t, cluster)
+	serializer := &snapshotSerializer{}
+	p.SetSerializer(serializer)
+
+	stamped := events.NewEvent("order.created", map[string]string{"order_id": "order-1"})
+	relayed := events.NewEvent("order.created", map[string]string{"order_id": "order-2"})
+	relayed.SetMetadata(events.MetadataServiceVersion, "v1.0.0") // published by an older service
+	for _, event := range []*events.Event{stamped, relayed} {
+		if err := p.PublishEvent(context.Background(), "versioned", nil, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	for _, metadata := range serializer.metadata {
+		got = append(got, metadata[events.MetadataServiceVersion])
+	}
+	if want := []string{"v1.2.3", "v1.0.0"}; !slices.Equal(got, want) {
+		t.Errorf("service versions %v, want %v", got, want)
+	}
+}
+
+const benchmarkMessages = 1000
+
+func BenchmarkPublish(b *testing.B) {
+	p := newMockProducer(b)
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < benchmarkMessages; j++ {
+			if err := p.Publish(ctx, "orders", nil, []byte("order")); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkPublishBatch(b *testing.B) {
+	p := newMockProducer(b)
+	ctx := context.Background()
+	messages := make([]BatchMessage, benchmarkMessages)
+	for i := range messages {
+		messages[i] = BatchMessage{Topic: "orders", Value: []byte("order")}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := p.PublishBatch(ctx, messages); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPublishAsync(b *testing.B) {
+	p := newMockProducer(b)
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		for j := 0; j < benchmarkMessages; j++ {
+			result := p.PublishAsync(ctx, "orders", nil, []byte("order"))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := <-result; err != nil {
+					b.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func TestProducerConfigFlowsIntoTheClientConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.ProducerConfig
+		want    kafka.ConfigMap
+		wantErr bool
+	}{
+		{
+			name: "unset values use the defaults",
+			want: kafka.ConfigMap{"acks": "all", "retries": 0, "compression.type": "snappy", "linger.ms": 0, "batch.size": 16384},
+		},
+		{
+			name: "configured values",
+			cfg:  config.ProducerConfig{Acks: "1", Retries: 10, CompressionType: "zstd", Linger: 50 * time.Millisecond, BatchSize: 1 << 20},
+			want: kafka.ConfigMap{"acks": "1", "retries": 10, "compression.type": "zstd", "linger.ms": 50, "batch.size": 1 << 20},
+		},
+		{
+			name: "fire and forget",
+			cfg:  config.ProducerConfig{Acks: "0", CompressionType: "none"},
+			want: kafka.ConfigMap{"acks": "0", "retries": 0, "compression.type": "none", "linger.ms": 0, "batch.size": 16384},
+		},
+		{name: "invalid acks", cfg: config.ProducerConfig{Acks: "2"}, wantErr: true},
+		{name: "invalid compression", cfg: config.ProducerConfig{CompressionType: "brotli"}, wantErr: true},
+		{name: "negative retries", cfg: config.ProducerConfig{Retries: -1}, wantErr: true},
+		{name: "negative linger", cfg: config.ProducerConfig{Linger: -time.Millisecond}, wantErr: true},
+		{name: "negative batch size", cfg: config.ProducerConfig{BatchSize: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configMap := kafka.ConfigMap{"bootstrap.servers": "localhost:9092"}
+			err := applyProducerConfig(&configMap, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyProducerConfig() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for key, value := range tt.want {
+				if configMap[key] != value {
+					t.Errorf("%s = %v, want %v", key, configMap[key], value)
+				}
+			}
+			if configMap["bootstrap.servers"] != "localhost:9092" {
+				t.Errorf("bootstrap.servers = %v, want the existing setting kept", configMap["bootstrap.servers"])
+			}
+		})
+	}
+}
+
+func TestNewProducerRejectsInvalidProducerConfig(t *testing.T) {
+	_, err := NewProducer(config.KafkaConfig{
+		Brokers:  []string{newMockCluster(t).BootstrapServers()},
+		Producer: config.ProducerConfig{Acks: "-1"},
+	}, zap.NewNop())
+	if err == nil {
+		t.Error("created a producer with acks -1")
+	}
+}
@@ -0,0 +1,16 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// Publisher publishes messages and events to Kafka
+type Publisher interface {
+	Publish(ctx context.Context, topic string, key, value []byte) error
+	PublishEvent(ctx context.Context, topic string, key []byte, event *events.Event) error
+	PublishEventWithPartitionKey(ctx context.Context, topic, partitionKey string, key []byte, event *events.Event) error
+}
+
+var _ Publisher = (*Producer)(nil)
@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/metrics"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+// HeaderQuarantineReason explains why a message was moved to the quarantine topic
+const HeaderQuarantineReason = "quarantine-reason"
+
+// SetQuarantine sets where messages failing pre-handler validation, such as
+// future-dated events, are moved to instead of being handled
+func (c *Consumer) SetQuarantine(publisher *Producer, topic string) {
+	c.quarantine = publisher
+	c.quarantineTopic = topic
+}
+
+// dispatch validates the message, then runs its handler with retries. Messages
+// whose payload the handler found invalid are quarantined when possible.
+func (c *Consumer) dispatch(ctx context.Context, msg *kafka.Message) error {
+	if err := c.transcode(msg); err != nil {
+		if c.quarantine != nil && errors.Is(err, events.ErrInvalidPayload) {
+			return c.quarantineMessage(ctx, msg, err.Error())
+		}
+		return err
+	}
+
+	if reason := c.futureDated(msg); reason != "" {
+		if c.quarantine == nil {
+			c.log.Warn("Processing future-dated event: no quarantine configured",
+				zap.String("topic", *msg.TopicPartition.Topic),
+				zap.String("offset", msg.TopicPartition.Offset.String()),
+				zap.String("reason", reason),
+			)
+		} else {
+			return c.quarantineMessage(ctx, msg, reason)
+		}
+	}
+
+	err := c.processWithRetry(ctx, msg)
+	if err != nil && c.quarantine != nil && errors.Is(err, events.ErrInvalidPayload) {
+		return c.quarantineMessage(ctx, msg, err.Error())
+	}
+	return err
+}
+
+// futureDated reports why the event's timestamp is beyond the configured
+// clock skew tolerance, or an empty string when it is acceptable
+func (c *Consumer) futureDated(msg *kafka.Message) string {
+	tolerance := c.config.Consumer.FutureSkewTolerance
+	if tolerance <= 0 {
+		return ""
+	}
+
+	var envelope struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	if err := json.Unmarshal(msg.Value, &envelope); err != nil || envelope.Timestamp.IsZero() {
+		return ""
+	}
+
+	skew := envelope.Timestamp.Sub(c.now())
+	if skew <= tolerance {
+		return ""
+	}
+	return fmt.Sprintf("timestamp %s is %s in the future (tolerance %s)",
+		envelope.Timestamp.Format(time.RFC3339Nano), skew.Round(time.Millisecond), tolerance)
+}
+
+// quarantineMessage republishes the message to the quarantine topic, keeping its key and headers
+func (c *Consumer) quarantineMessage(ctx context.Context, msg *kafka.Message, reason string) error {
+	topic := *msg.TopicPartition.Topic
+	metrics.IncCounter(metrics.QuarantinedEvents, metrics.Topic(topic))
+	c.log.Warn("Quarantining event",
+		zap.String("topic", topic),
+		zap.Int32("partition", msg.TopicPartition.Partition),
+		zap.String("offset", msg.TopicPartition.Offset.String()),
+		zap.String("quarantine_topic", c.quarantineTopic),
+		zap.String("reason", reason),
+	)
+
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderQuarantineReason, Value: []byte(reason)},
+		kafka.Header{Key: "source-topic", Value: []byte(topic)},
+	)
+	if err := c.quarantine.PublishWithHeaders(ctx, c.quarantineTopic, msg.Key, msg.Value, headers); err != nil {
+		return fmt.Errorf("failed to quarantine message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package kafka
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// readMessages reads n messages of topic from the start
+func readMessages(t *testing.T, cluster *kafka.MockCluster, topic string, n int) []*kafka.Message {
+	t.Helper()
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": cluster.BootstrapServers(),
+		"group.id":          "readback",
+		"auto.offset.reset": "earliest",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+	if err := consumer.Subscribe(topic, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var messages []*kafka.Message
+	deadline := time.Now().Add(10 * time.Second)
+	for len(messages) < n && time.Now().Before(deadline) {
+		msg, err := consumer.ReadMessage(100 * time.Millisecond)
+		if err != nil {
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// newQuarantiningConsumer returns a test consumer tolerating a minute of clock
+// skew, quarantining to events.quarantine on a mock cluster
+func newQuarantiningConsumer(t *testing.T) (*Consumer, *kafka.MockCluster, *fakeClock) {
+	t.Helper()
+	cluster := newMockCluster(t)
+	if err := cluster.CreateTopic("events.quarantine", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	c, _, clock := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		FutureSkewTolerance: time.Minute,
+	}})
+	c.SetQuarantine(newClusterProducer(t, cluster), "events.quarantine")
+	return c, cluster, clock
+}
+
+func TestFutureDatedEvents(t *testing.T) {
+	tests := []struct {
+		name        string
+		skew        time.Duration
+		quarantined bool
+	}{
+		{name: "in the past", skew: -time.Hour},
+		{name: "within the tolerance", skew: 59 * time.Second},
+		{name: "beyond the tolerance", skew: 2 * time.Minute, quarantined: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, cluster, clock := newQuarantiningConsumer(t)
+			handled := 0
+			c.RegisterHandler("orders", func(context.Context, *Message) error {
+				handled++
+				return nil
+			})
+
+			timestamp := clock.now().Add(tt.skew).Format(time.RFC3339Nano)
+			msg := eventMessage("orders", 0, `{"id": "event-1", "type": "order.created", "timestamp": "`+timestamp+`"}`)
+			if err := c.dispatch(context.Background(), msg); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.quarantined == (handled == 1) {
+				t.Errorf("handled %d times, want quarantined %v", handled, tt.quarantined)
+			}
+			if !tt.quarantined {
+				return
+			}
+			quarantined := readMessages(t, cluster, "events.quarantine", 1)
+			if len(quarantined) != 1 {
+				t.Fatal("nothing published to the quarantine topic")
+			}
+			got := quarantined[0]
+			if string(got.Key) != "key" || string(got.Value) != string(msg.Value) {
+				t.Errorf("quarantined %s=%s, want the original message", got.Key, got.Value)
+			}
+			source, _ := header(got, "source-topic")
+			if reason, ok := header(got, HeaderQuarantineReason); source != "orders" || !ok || reason == "" {
+				t.Errorf("quarantined with headers %v, want the source topic and a reason", got.Headers)
+			}
+		})
+	}
+}
+
+func TestFutureDatedEventsAreHandledWithoutAQuarantine(t *testing.T) {
+	c, _, clock := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		FutureSkewTolerance: time.Minute,
+	}})
+	handled := 0
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		handled++
+		return nil
+	})
+
+	timestamp := clock.now().Add(time.Hour).Format(time.RFC3339Nano)
+	msg := eventMessage("orders", 0, `{"id": "event-1", "type": "order.created", "timestamp": "`+timestamp+`"}`)
+	if err := c.dispatch(context.Background(), msg); err != nil || handled != 1 {
+		t.Errorf("dispatch() = %v after %d handler runs, want the event handled", err, handled)
+	}
+}
+
+func TestInvalidPayloadsAreQuarantinedWithoutRetries(t *testing.T) {
+	c, cluster, _ := newQuarantiningConsumer(t)
+	c.SetRetryPolicy(events.EventTypeInventoryReserved, RetryPolicy{MaxAttempts: 5, Backoff: time.Second})
+	handled := 0
+	RegisterHandlerT(c, "inventory", events.EventTypeInventoryReserved, func(context.Context, *events.Event, events.InventoryReservedEvent) error {
+		handled++
+		return nil
+	})
+	retries := 0
+	c.sleep = func(context.Context, time.Duration) error {
+		retries++
+		return nil
+	}
+
+	value := `{"id": "event-1", "type": "inventory.reserved", "data": {"items": [{"product_id": "p1", "quantity": -1}]}}`
+	if err := c.dispatch(context.Background(), eventMessage("inventory", 0, value)); err != nil {
+		t.Fatal(err)
+	}
+	if handled != 0 || retries != 0 {
+		t.Errorf("handler called %d times with an invalid payload after %d retries, want neither", handled, retries)
+	}
+
+	quarantined := readMessages(t, cluster, "events.quarantine", 1)
+	if len(quarantined) != 1 || string(quarantined[0].Value) != value {
+		t.Fatalf("quarantined %d messages, want the invalid one", len(quarantined))
+	}
+	if reason, _ := header(quarantined[0], HeaderQuarantineReason); !strings.Contains(reason, events.ErrInvalidPayload.Error()) {
+		t.Errorf("quarantine reason %q, want the validation error", reason)
+	}
+}
@@ -0,0 +1,64 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterCapsDispatchRate(t *testing.T) {
+	const (
+		perSecond = 50
+		interval  = 200 * time.Millisecond
+	)
+	l := newRateLimiter(perSecond)
+	ctx, cancel := context.WithTimeout(context.Background(), interval)
+	defer cancel()
+
+	dispatched := 0
+	for l.wait(ctx) == nil {
+		dispatched++
+	}
+
+	// One message right away, then one per 20ms
+	want := int(perSecond * interval.Seconds())
+	if dispatched > want+1 {
+		t.Errorf("dispatched %d messages in %v, want at most %d", dispatched, interval, want+1)
+	}
+	if dispatched < want/2 {
+		t.Errorf("dispatched only %d messages in %v, want close to %d", dispatched, interval, want)
+	}
+}
+
+func TestRateLimiterWaitHonorsCancellation(t *testing.T) {
+	l := newRateLimiter(0.1) // one message per 10s
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	if err := l.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait() = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("wait returned %v after cancellation, want promptly", elapsed)
+	}
+}
+
+func TestRateLimiterUnlimitedByDefault(t *testing.T) {
+	l := newRateLimiter(0)
+	if l != nil {
+		t.Fatalf("newRateLimiter(0) = %+v, want nil (unlimited)", l)
+	}
+	for i := 0; i < 1000; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
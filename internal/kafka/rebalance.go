@@ -0,0 +1,76 @@
+package kafka
+
+import (
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.uber.org/zap"
+)
+
+// PartitionsHandler is notified of partitions changing owner in a rebalance
+type PartitionsHandler func(partitions []kafka.TopicPartition)
+
+// RebalanceHooks are notified when the group assignment changes, so handlers
+// can load or flush per-partition state. They run on the read loop and hold
+// up consumption while they run.
+type RebalanceHooks struct {
+	// OnAssigned is called with newly assigned partitions before any of their
+	// messages is handled
+	OnAssigned PartitionsHandler
+	// OnRevoked is called with revoked partitions after their in-flight
+	// messages were handled and the processed offsets committed
+	OnRevoked PartitionsHandler
+}
+
+// rebalance is called on the read loop when the group assignment changes.
+// Partitions the callback doesn't assign or unassign itself get the default.
+func (c *Consumer) rebalance(consumer *kafka.Consumer, event kafka.Event) error {
+	switch e := event.(type) {
+	case kafka.AssignedPartitions:
+		c.coordinator.ready(c.now())
+		if err := c.assignFromStartTime(consumer, e.Partitions); err != nil {
+			return err
+		}
+		if c.hooks.OnAssigned != nil {
+			c.hooks.OnAssigned(e.Partitions)
+		}
+	case kafka.RevokedPartitions:
+		c.finishRevoked(e.Partitions)
+		if c.hooks.OnRevoked != nil {
+			c.hooks.OnRevoked(e.Partitions)
+		}
+	}
+	return nil
+}
+
+// finishRevoked lets the worker pool handle the messages it already has, so
+// none of them is handled after the partition moved to another consumer, then
+// commits the processed offsets while this consumer still owns them. Parked
+// messages of revoked partitions are forgotten: they were never committed, so
+// the new owner reads them again, and a partition assigned back later must
+// not be resumed by a stale due time.
+func (c *Consumer) finishRevoked(revoked []kafka.TopicPartition) {
+	c.delays.remove(revoked)
+	if c.pool != nil {
+		c.pool.drain()
+	}
+	// The new owners read failed messages again from the committed position
+	defer c.offsets.reset(revoked...)
+	c.waitForCommits()
+
+	pending := c.offsets.drain()
+	if len(pending) == 0 {
+		return
+	}
+	if _, err := c.commits.CommitOffsets(pending); err != nil {
+		// The new owners resume from the last commit and reprocess the rest
+		c.log.Error("Error committing offsets of revoked partitions",
+			zap.Error(err),
+			zap.Int("revoked", len(revoked)),
+		)
+		c.reportError(commitFailed(err))
+		return
+	}
+	c.log.Info("Committed processed offsets before partitions were revoked",
+		zap.Int("revoked", len(revoked)),
+		zap.Int("partitions", len(pending)),
+	)
+}
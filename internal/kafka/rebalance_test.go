@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+)
+
+func TestRebalanceHooksRunOnAssignAndRevoke(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		CommitMode:     CommitAsync,
+		CommitInterval: time.Hour,
+	}})
+	c.RegisterHandler("orders", succeed)
+
+	var calls []string
+	var committedOnRevoke kafka.Offset
+	c.hooks = RebalanceHooks{
+		OnAssigned: func(partitions []kafka.TopicPartition) {
+			calls = append(calls, "assigned "+partitionKeys(partitions))
+		},
+		OnRevoked: func(partitions []kafka.TopicPartition) {
+			calls = append(calls, "revoked "+partitionKeys(partitions))
+			committedOnRevoke = commits.committedOffset("orders", 0)
+		},
+	}
+
+	assignment := newAssignment("orders", 2)
+	if err := c.rebalance(nil, kafka.AssignedPartitions{Partitions: assignment}); err != nil {
+		t.Fatal(err)
+	}
+	c.handle(context.Background(), testMessage("orders", 4))
+	if len(commits.batches) != 0 {
+		t.Fatalf("committed %v before the revoke, want the offset pending", commits.batches)
+	}
+	if err := c.rebalance(nil, kafka.RevokedPartitions{Partitions: assignment}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"assigned " + partitionKeys(assignment), "revoked " + partitionKeys(assignment)}
+	if !slices.Equal(calls, want) {
+		t.Errorf("hooks called %q, want %q", calls, want)
+	}
+	if committedOnRevoke != 5 {
+		t.Errorf("OnRevoked saw offset %d committed, want the processed offset 5 committed first", committedOnRevoke)
+	}
+}
+
+func TestRebalanceWithoutHooks(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		CommitMode:     CommitAsync,
+		CommitInterval: time.Hour,
+	}})
+	c.RegisterHandler("orders", succeed)
+
+	assignment := newAssignment("orders", 1)
+	if err := c.rebalance(nil, kafka.AssignedPartitions{Partitions: assignment}); err != nil {
+		t.Fatal(err)
+	}
+	c.handle(context.Background(), testMessage("orders", 0))
+	if err := c.rebalance(nil, kafka.RevokedPartitions{Partitions: assignment}); err != nil {
+		t.Fatal(err)
+	}
+	if commits.committedOffset("orders", 0) != 1 {
+		t.Errorf("batches %v, want the processed offset committed on revoke", commits.batches)
+	}
+}
+
+// partitionKeys joins the keys of the partitions
+func partitionKeys(partitions []kafka.TopicPartition) string {
+	var keys string
+	for i, tp := range partitions {
+		if i > 0 {
+			keys += ","
+		}
+		keys += partitionKey(tp)
+	}
+	return keys
+}
@@ -0,0 +1,159 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/google/uuid"
+	"github.com/tanint/go-eda/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Headers of request and reply messages
+const (
+	// HeaderReplyTo carries the topic a request's reply is published to
+	HeaderReplyTo = "reply-to"
+	// HeaderCorrelationID matches a reply to its request
+	HeaderCorrelationID = "correlation-id"
+	// HeaderReplyError carries the error a request failed with; the reply
+	// then has no value
+	HeaderReplyError = "reply-error"
+)
+
+var (
+	// ErrRequestTimeout reports no reply arriving in time
+	ErrRequestTimeout = errors.New("timed out waiting for reply")
+	// ErrRequestFailed reports a reply carrying the error the request failed with
+	ErrRequestFailed = errors.New("request failed")
+)
+
+// headerPublisher is the subset of Producer used to publish requests and replies
+type headerPublisher interface {
+	PublishWithHeaders(ctx context.Context, topic string, key, value []byte, headers []kafka.Header) error
+}
+
+// RequestReply sends requests and waits for their replies on a reply topic.
+// Replies are delivered by HandleReply, which must be registered on a
+// consumer of the reply topic. That consumer needs a group of its own, or
+// the reply topic must be dedicated to this instance, so replies are not
+// consumed by another instance of the service.
+type RequestReply struct {
+	publisher  headerPublisher
+	replyTopic string
+
+	mu      sync.Mutex
+	pending map[string]chan *Message
+}
+
+// NewRequestReply creates a RequestReply receiving replies on replyTopic
+func NewRequestReply(publisher headerPublisher, replyTopic string) *RequestReply {
+	return &RequestReply{
+		publisher:  publisher,
+		replyTopic: replyTopic,
+		pending:    make(map[string]chan *Message),
+	}
+}
+
+// Request publishes value to topic and waits up to timeout for its reply.
+// It returns ErrRequestTimeout when none arrives in time, and wraps
+// ErrRequestFailed when the server replied with an error.
+func (r *RequestReply) Request(ctx context.Context, topic string, key, value []byte, timeout time.Duration) (*Message, error) {
+	correlationID := uuid.NewString()
+	replies := make(chan *Message, 1)
+
+	r.mu.Lock()
+	r.pending[correlationID] = replies
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, correlationID)
+		r.mu.Unlock()
+	}()
+
+	var headers Headers
+	headers.Set(HeaderReplyTo, r.replyTopic)
+	headers.Set(HeaderCorrelationID, correlationID)
+	if err := r.publisher.PublishWithHeaders(ctx, topic, key, value, headers); err != nil {
+		return nil, fmt.Errorf("failed to publish request: %w", err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case reply := <-replies:
+		if reason, ok := MessageHeaders(reply).Get(HeaderReplyError); ok {
+			return nil, fmt.Errorf("%w: %s", ErrRequestFailed, reason)
+		}
+		return reply, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: request to %s after %s", ErrRequestTimeout, topic, timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// HandleReply delivers a reply to the request waiting for it. Replies to
+// requests that timed out or were sent by another instance are dropped.
+func (r *RequestReply) HandleReply(ctx context.Context, msg *Message) error {
+	correlationID, ok := MessageHeaders(msg).Get(HeaderCorrelationID)
+	if !ok {
+		return invalidPayload(fmt.Errorf("reply has no %s header", HeaderCorrelationID))
+	}
+
+	r.mu.Lock()
+	replies, ok := r.pending[correlationID]
+	r.mu.Unlock()
+	if !ok {
+		logger.FromContext(ctx).Debug("Dropping reply to unknown request",
+			zap.String("correlation_id", correlationID),
+		)
+		return nil
+	}
+
+	// Buffered for one reply; a duplicate is dropped
+	select {
+	case replies <- msg:
+	default:
+	}
+	return nil
+}
+
+// ReplyFunc handles a request and returns the value of its reply. An error
+// is sent back to the requester instead of a value.
+type ReplyFunc func(ctx context.Context, msg *Message) ([]byte, error)
+
+// ServeRequests returns a handler for a request topic that publishes what fn
+// returns to the request's reply topic. Requests without reply-to or
+// correlation-id headers fail with events.ErrInvalidPayload, so they are not
+// retried.
+func ServeRequests(publisher headerPublisher, fn ReplyFunc) MessageHandler {
+	return func(ctx context.Context, msg *Message) error {
+		requestHeaders := MessageHeaders(msg)
+		replyTo, ok := requestHeaders.Get(HeaderReplyTo)
+		if !ok {
+			return invalidPayload(fmt.Errorf("request has no %s header", HeaderReplyTo))
+		}
+		correlationID, ok := requestHeaders.Get(HeaderCorrelationID)
+		if !ok {
+			return invalidPayload(fmt.Errorf("request has no %s header", HeaderCorrelationID))
+		}
+
+		var headers Headers
+		headers.Set(HeaderCorrelationID, correlationID)
+		value, err := fn(ctx, msg)
+		if err != nil {
+			headers.Set(HeaderReplyError, err.Error())
+			value = nil
+		}
+
+		if err := publisher.PublishWithHeaders(ctx, replyTo, msg.Key, value, headers); err != nil {
+			return fmt.Errorf("failed to publish reply: %w", err)
+		}
+		return nil
+	}
+}
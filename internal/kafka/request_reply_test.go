@@ -0,0 +1,137 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// loopback delivers published messages to the handler of their topic, in the
+// background as a broker and consumer would. Messages to topics without a
+// handler are lost.
+type loopback struct {
+	handlers map[string]MessageHandler
+	errs     chan error
+}
+
+func newLoopback() *loopback {
+	return &loopback{handlers: make(map[string]MessageHandler), errs: make(chan error, 10)}
+}
+
+func (l *loopback) PublishWithHeaders(ctx context.Context, topic string, key, value []byte, headers []kafka.Header) error {
+	handler, ok := l.handlers[topic]
+	if !ok {
+		return nil
+	}
+	msg := &Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic},
+		Key:            key,
+		Value:          value,
+		Headers:        headers,
+	}
+	go func() { l.errs <- handler(context.Background(), msg) }()
+	return nil
+}
+
+func TestRequestReplyRoundTrip(t *testing.T) {
+	broker := newLoopback()
+	client := NewRequestReply(broker, "stock-replies")
+	broker.handlers["stock-replies"] = client.HandleReply
+	broker.handlers["stock-requests"] = ServeRequests(broker, func(ctx context.Context, msg *Message) ([]byte, error) {
+		if string(msg.Value) != `{"product_id": "product-1"}` {
+			return nil, errors.New("unexpected request")
+		}
+		return []byte(`{"in_stock": true}`), nil
+	})
+
+	reply, err := client.Request(context.Background(), "stock-requests", []byte("product-1"), []byte(`{"product_id": "product-1"}`), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reply.Value) != `{"in_stock": true}` || string(reply.Key) != "product-1" {
+		t.Errorf("reply %s: %s, want the request's key and the server's value", reply.Key, reply.Value)
+	}
+	if _, ok := MessageHeaders(reply).Get(HeaderCorrelationID); !ok {
+		t.Errorf("reply headers %v, want the correlation ID", reply.Headers)
+	}
+	for range 2 {
+		if err := <-broker.errs; err != nil {
+			t.Errorf("handler failed: %v", err)
+		}
+	}
+}
+
+func TestRequestReplyTimesOut(t *testing.T) {
+	broker := newLoopback()
+	client := NewRequestReply(broker, "stock-replies")
+	// No server consumes stock-requests, so no reply ever comes
+
+	start := time.Now()
+	_, err := client.Request(context.Background(), "stock-requests", nil, []byte("{}"), 50*time.Millisecond)
+	if !errors.Is(err, ErrRequestTimeout) {
+		t.Fatalf("Request() = %v, want ErrRequestTimeout", err)
+	}
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Errorf("gave up after %s, want the full timeout", waited)
+	}
+	if len(client.pending) != 0 {
+		t.Errorf("%d requests still pending after the timeout", len(client.pending))
+	}
+
+	var headers Headers
+	headers.Set(HeaderCorrelationID, "late")
+	if err := client.HandleReply(context.Background(), &Message{Headers: headers}); err != nil {
+		t.Errorf("HandleReply() of a late reply = %v, want it dropped", err)
+	}
+}
+
+func TestRequestReplyReturnsTheServersError(t *testing.T) {
+	broker := newLoopback()
+	client := NewRequestReply(broker, "stock-replies")
+	broker.handlers["stock-replies"] = client.HandleReply
+	broker.handlers["stock-requests"] = ServeRequests(broker, func(context.Context, *Message) ([]byte, error) {
+		return nil, errors.New("unknown product")
+	})
+
+	_, err := client.Request(context.Background(), "stock-requests", nil, []byte("{}"), time.Second)
+	if !errors.Is(err, ErrRequestFailed) || !strings.Contains(err.Error(), "unknown product") {
+		t.Errorf("Request() = %v, want ErrRequestFailed with the server's reason", err)
+	}
+}
+
+func TestRequestReplyStopsWaitingOnCancellation(t *testing.T) {
+	client := NewRequestReply(newLoopback(), "stock-replies")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := client.Request(ctx, "stock-requests", nil, []byte("{}"), time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("Request() = %v, want context.Canceled", err)
+	}
+}
+
+func TestServeRequestsRejectsRequestsWithoutReplyHeaders(t *testing.T) {
+	broker := newLoopback()
+	serve := ServeRequests(broker, func(context.Context, *Message) ([]byte, error) {
+		t.Error("served a request that cannot be replied to")
+		return nil, nil
+	})
+
+	var replyToOnly, correlationOnly Headers
+	replyToOnly.Set(HeaderReplyTo, "stock-replies")
+	correlationOnly.Set(HeaderCorrelationID, "request-1")
+	for _, headers := range []Headers{nil, replyToOnly, correlationOnly} {
+		if err := serve(context.Background(), &Message{Headers: headers}); !errors.Is(err, events.ErrInvalidPayload) {
+			t.Errorf("request with headers %v: %v, want ErrInvalidPayload", headers, err)
+		}
+	}
+
+	client := NewRequestReply(broker, "stock-replies")
+	if err := client.HandleReply(context.Background(), &Message{}); !errors.Is(err, events.ErrInvalidPayload) {
+		t.Errorf("HandleReply() without a correlation ID = %v, want ErrInvalidPayload", err)
+	}
+}
@@ -0,0 +1,179 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+// RetryPolicy controls how often a failing handler is retried before the
+// message is given up on, and how long to wait between attempts
+type RetryPolicy struct {
+	MaxAttempts int           // total handler invocations; 1 disables retries
+	Backoff     time.Duration // wait before the first retry
+	Multiplier  float64       // growth of the wait per retry; 1 or less keeps it constant
+	MaxBackoff  time.Duration // cap on the wait; 0 means uncapped
+}
+
+// backoff returns the wait after the given failed attempt (1-based)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	return exponentialBackoff(p.Backoff, p.Multiplier, p.MaxBackoff, attempt)
+}
+
+// exponentialBackoff returns initial grown by multiplier for each attempt
+// after the first, capped at max when max is positive
+func exponentialBackoff(initial time.Duration, multiplier float64, max time.Duration, attempt int) time.Duration {
+	backoff := float64(initial)
+	if multiplier > 1 {
+		backoff *= math.Pow(multiplier, float64(attempt-1))
+	}
+	if max > 0 && backoff > float64(max) {
+		return max
+	}
+	return time.Duration(backoff)
+}
+
+// RetryableError lets a handler that knows its failure is transient (e.g. a
+// downstream answered 429) ask for the next attempt to wait After instead of
+// the policy's backoff. The message still counts against MaxAttempts.
+type RetryableError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryableError) Error() string {
+	return fmt.Sprintf("retry after %s: %v", e.After, e.Err)
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+// retryPolicies resolves the retry policy for a message: by event type first,
+// then by topic, falling back to the default policy
+type retryPolicies struct {
+	mu          sync.RWMutex
+	defaults    RetryPolicy
+	byEventType map[events.EventType]RetryPolicy
+	byTopic     map[string]RetryPolicy
+}
+
+func newRetryPolicies(cfg config.RetryConfig) *retryPolicies {
+	return &retryPolicies{
+		defaults: RetryPolicy{
+			MaxAttempts: cfg.MaxAttempts,
+			Backoff:     cfg.Backoff,
+			Multiplier:  cfg.Multiplier,
+			MaxBackoff:  cfg.MaxBackoff,
+		},
+		byEventType: make(map[events.EventType]RetryPolicy),
+		byTopic:     make(map[string]RetryPolicy),
+	}
+}
+
+func (r *retryPolicies) forMessage(msg *kafka.Message) RetryPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.byEventType) > 0 {
+		if policy, ok := r.byEventType[peekEventType(msg)]; ok {
+			return policy
+		}
+	}
+	if policy, ok := r.byTopic[*msg.TopicPartition.Topic]; ok {
+		return policy
+	}
+	return r.defaults
+}
+
+// peekEventType reads the event type from the message envelope without decoding the payload
+func peekEventType(msg *kafka.Message) events.EventType {
+	var envelope struct {
+		Type events.EventType `json:"type"`
+	}
+	if err := json.Unmarshal(msg.Value, &envelope); err != nil {
+		return ""
+	}
+	return envelope.Type
+}
+
+// SetRetryPolicy sets the retry policy for messages carrying the given event type.
+// It takes precedence over topic and default policies.
+func (c *Consumer) SetRetryPolicy(eventType events.EventType, policy RetryPolicy) {
+	c.retries.mu.Lock()
+	defer c.retries.mu.Unlock()
+	c.retries.byEventType[eventType] = policy
+}
+
+// SetTopicRetryPolicy sets the retry policy for messages consumed from the topic
+func (c *Consumer) SetTopicRetryPolicy(topic string, policy RetryPolicy) {
+	c.retries.mu.Lock()
+	defer c.retries.mu.Unlock()
+	c.retries.byTopic[topic] = policy
+}
+
+// processWithRetry runs the handler, retrying failures with the backoff of
+// the message's retry policy. Retries stop early when the next attempt could
+// not start before ctx's deadline. The final failure records how many
+// attempts were made; a shutdown during the backoff returns ctx's error.
+func (c *Consumer) processWithRetry(ctx context.Context, msg *kafka.Message) error {
+	policy := c.retries.forMessage(msg)
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = c.processMessage(ctx, msg); err == nil {
+			return nil
+		}
+		// Invalid payloads fail the same way on every attempt
+		if attempt >= policy.MaxAttempts || errors.Is(err, events.ErrInvalidPayload) {
+			return &retriesExhaustedError{err: err, attempts: attempt}
+		}
+
+		backoff := policy.backoff(attempt)
+		var retryable *RetryableError
+		if errors.As(err, &retryable) {
+			backoff = retryable.After
+		}
+		if deadline, ok := ctx.Deadline(); ok && c.now().Add(backoff).After(deadline) {
+			return &retriesExhaustedError{err: err, attempts: attempt}
+		}
+
+		c.log.Warn("Handler failed, retrying",
+			zap.Error(err),
+			zap.String("topic", *msg.TopicPartition.Topic),
+			zap.String("offset", msg.TopicPartition.Offset.String()),
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", policy.MaxAttempts),
+			zap.Duration("backoff", backoff),
+		)
+		if err := c.sleep(ctx, backoff); err != nil {
+			return err
+		}
+	}
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
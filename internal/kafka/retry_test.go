@@ -0,0 +1,232 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+func TestEventTypesOnOneTopicFollowTheirRetryPolicies(t *testing.T) {
+	c, _, deadLetters := newDeadLetteringConsumer(t)
+	c.SetRetryPolicy(events.EventTypeInventoryReserved, RetryPolicy{MaxAttempts: 2, Backoff: time.Second})
+	c.SetRetryPolicy(events.EventTypeOrderConfirmed, RetryPolicy{MaxAttempts: 5, Backoff: time.Second})
+
+	attempts := make(map[events.EventType]int)
+	c.RegisterHandler("mixed", func(ctx context.Context, msg *Message) error {
+		attempts[peekEventType(msg)]++
+		return errors.New("downstream unavailable")
+	})
+
+	c.handle(context.Background(), eventMessage("mixed", 0, `{"id": "event-1", "type": "inventory.reserved"}`))
+	c.handle(context.Background(), eventMessage("mixed", 1, `{"id": "event-2", "type": "order.confirmed"}`))
+	c.handle(context.Background(), eventMessage("mixed", 2, `{"id": "event-3", "type": "order.created"}`))
+
+	want := map[events.EventType]int{
+		events.EventTypeInventoryReserved: 2,
+		events.EventTypeOrderConfirmed:    5,
+		events.EventTypeOrderCreated:      3, // the consumer's default policy
+	}
+	for eventType, n := range want {
+		if attempts[eventType] != n {
+			t.Errorf("%s handled %d times before dead-lettering, want %d", eventType, attempts[eventType], n)
+		}
+	}
+	if len(deadLetters.messages) != 3 {
+		t.Errorf("dead-lettered %d messages, want all 3 once their retries ran out", len(deadLetters.messages))
+	}
+}
+
+func TestRetryPolicyPrecedence(t *testing.T) {
+	c, _, _ := newDeadLetteringConsumer(t)
+	c.SetTopicRetryPolicy("notifications", RetryPolicy{MaxAttempts: 10})
+	c.SetRetryPolicy(events.EventTypeOrderConfirmed, RetryPolicy{MaxAttempts: 4})
+
+	tests := []struct {
+		name string
+		msg  *Message
+		want int
+	}{
+		{"event type over topic", eventMessage("notifications", 0, `{"type": "order.confirmed"}`), 4},
+		{"topic over default", eventMessage("notifications", 0, `{"type": "order.created"}`), 10},
+		{"undecodable envelope uses the topic", eventMessage("notifications", 0, `not json`), 10},
+		{"default", eventMessage("orders", 0, `{"type": "order.created"}`), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.retries.forMessage(tt.msg).MaxAttempts; got != tt.want {
+				t.Errorf("max attempts %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryableErrorOverridesTheBackoff(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		Retry: config.RetryConfig{MaxAttempts: 4, Backoff: time.Second},
+	}})
+	var sleeps []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+
+	attempts := 0
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		attempts++
+		switch attempts {
+		case 1:
+			return &RetryableError{Err: errors.New("429 too many requests"), After: 5 * time.Second}
+		case 2:
+			return errors.New("connection reset")
+		case 3:
+			return fmt.Errorf("reserving stock: %w", &RetryableError{Err: errors.New("429 too many requests"), After: 250 * time.Millisecond})
+		}
+		return nil
+	})
+
+	if err := c.processWithRetry(context.Background(), testMessage("orders", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []time.Duration{5 * time.Second, time.Second, 250 * time.Millisecond}; !slices.Equal(sleeps, want) {
+		t.Errorf("waited %v between attempts, want %v", sleeps, want)
+	}
+}
+
+func TestRetryableErrorStillCountsAgainstMaxAttempts(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		Retry: config.RetryConfig{MaxAttempts: 2, Backoff: time.Second},
+	}})
+	attempts := 0
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		attempts++
+		return &RetryableError{Err: errors.New("429 too many requests"), After: 5 * time.Second}
+	})
+
+	err := c.processWithRetry(context.Background(), testMessage("orders", 0))
+	var retryable *RetryableError
+	if !errors.As(err, &retryable) || attempts != 2 {
+		t.Errorf("got %v after %d attempts, want the retryable error after 2", err, attempts)
+	}
+}
+
+func TestHandlerFailingTwiceThenSucceedingIsCommitted(t *testing.T) {
+	c, commits, deadLetters := newDeadLetteringConsumer(t)
+	c.SetTopicRetryPolicy("orders", RetryPolicy{MaxAttempts: 5, Backoff: time.Second, Multiplier: 2, MaxBackoff: 3 * time.Second})
+	var sleeps []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+
+	attempts := 0
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		attempts++
+		if attempts <= 2 {
+			return errors.New("inventory unavailable")
+		}
+		return nil
+	})
+	c.handle(context.Background(), testMessage("orders", 4))
+
+	if attempts != 3 || len(deadLetters.messages) != 0 {
+		t.Errorf("handled %d times with %d dead letters, want 3 attempts and none", attempts, len(deadLetters.messages))
+	}
+	if want := []time.Duration{time.Second, 2 * time.Second}; !slices.Equal(sleeps, want) {
+		t.Errorf("waited %v between attempts, want %v", sleeps, want)
+	}
+	c.commitOnShutdown()
+	if commits.committedOffset("orders", 0) != 5 {
+		t.Errorf("batches %v, want the message committed once it succeeded", commits.batches)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   []time.Duration
+	}{
+		{
+			name:   "constant",
+			policy: RetryPolicy{Backoff: time.Second},
+			want:   []time.Duration{time.Second, time.Second, time.Second},
+		},
+		{
+			name:   "exponential",
+			policy: RetryPolicy{Backoff: 100 * time.Millisecond, Multiplier: 3},
+			want:   []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond},
+		},
+		{
+			name:   "capped",
+			policy: RetryPolicy{Backoff: time.Second, Multiplier: 2, MaxBackoff: 3 * time.Second},
+			want:   []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []time.Duration
+			for attempt := 1; attempt <= len(tt.want); attempt++ {
+				got = append(got, tt.policy.backoff(attempt))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("backoffs %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryBackoffIsInterruptedByShutdown(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		Retry: config.RetryConfig{MaxAttempts: 3, Backoff: time.Hour},
+	}})
+	c.sleep = sleepContext
+	ctx, cancel := context.WithCancel(context.Background())
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		cancel()
+		return errors.New("inventory unavailable")
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- c.processWithRetry(ctx, testMessage("orders", 0)) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("processWithRetry() = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("still backing off after shutdown")
+	}
+}
+
+func TestRetriesStopWhenTheBackoffPassesTheDeadline(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		Retry: config.RetryConfig{MaxAttempts: 5, Backoff: time.Minute, Multiplier: 2},
+	}})
+	c.now = time.Now
+	var sleeps []time.Duration
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	attempts := 0
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		attempts++
+		return errors.New("inventory unavailable")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+	err := c.processWithRetry(ctx, testMessage("orders", 0))
+
+	// The 1m wait fits before the deadline, the 2m one would not
+	var exhausted *retriesExhaustedError
+	if !errors.As(err, &exhausted) || attempts != 2 || !slices.Equal(sleeps, []time.Duration{time.Minute}) {
+		t.Errorf("got %v after %d attempts and waits %v, want to give up after 2 attempts and one wait", err, attempts, sleeps)
+	}
+}
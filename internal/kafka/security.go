@@ -0,0 +1,42 @@
+package kafka
+
+import (
+	"strings"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+)
+
+// ApplySecurityConfig sets the security protocol of cfg on a client
+// configuration: SASL credentials for the SASL_* protocols and TLS
+// certificates for SSL and SASL_SSL. PLAINTEXT sets nothing.
+func ApplySecurityConfig(configMap *kafka.ConfigMap, cfg config.KafkaConfig) {
+	protocol := strings.ToUpper(cfg.SecurityProtocol)
+	if protocol == "" || protocol == "PLAINTEXT" {
+		return
+	}
+	configMap.SetKey("security.protocol", cfg.SecurityProtocol)
+
+	if strings.HasPrefix(protocol, "SASL_") {
+		configMap.SetKey("sasl.mechanism", cfg.SASLMechanism)
+		configMap.SetKey("sasl.username", cfg.SASLUsername)
+		configMap.SetKey("sasl.password", cfg.SASLPassword)
+	}
+
+	if strings.HasSuffix(protocol, "SSL") {
+		setIfNotEmpty(configMap, "ssl.ca.location", cfg.SSLCALocation)
+		setIfNotEmpty(configMap, "ssl.certificate.location", cfg.SSLCertLocation)
+		setIfNotEmpty(configMap, "ssl.key.location", cfg.SSLKeyLocation)
+		setIfNotEmpty(configMap, "ssl.key.password", cfg.SSLKeyPassword)
+		if cfg.SSLSkipVerify {
+			configMap.SetKey("enable.ssl.certificate.verification", false)
+		}
+	}
+}
+
+// setIfNotEmpty leaves librdkafka's default in place for empty values
+func setIfNotEmpty(configMap *kafka.ConfigMap, key, value string) {
+	if value != "" {
+		configMap.SetKey(key, value)
+	}
+}
@@ -0,0 +1,107 @@
+package kafka
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestApplySecurityConfig(t *testing.T) {
+	tls := config.KafkaConfig{
+		SSLCALocation:   "/etc/kafka/ca.pem",
+		SSLCertLocation: "/etc/kafka/client.pem",
+		SSLKeyLocation:  "/etc/kafka/client.key",
+		SSLKeyPassword:  "secret",
+	}
+	withProtocol := func(cfg config.KafkaConfig, protocol string) config.KafkaConfig {
+		cfg.SecurityProtocol = protocol
+		cfg.SASLMechanism = "PLAIN"
+		cfg.SASLUsername = "user"
+		cfg.SASLPassword = "password"
+		return cfg
+	}
+	tlsKeys := kafka.ConfigMap{
+		"ssl.ca.location":          "/etc/kafka/ca.pem",
+		"ssl.certificate.location": "/etc/kafka/client.pem",
+		"ssl.key.location":         "/etc/kafka/client.key",
+		"ssl.key.password":         "secret",
+	}
+	saslKeys := kafka.ConfigMap{"sasl.mechanism": "PLAIN", "sasl.username": "user", "sasl.password": "password"}
+	merge := func(maps ...kafka.ConfigMap) kafka.ConfigMap {
+		merged := kafka.ConfigMap{}
+		for _, m := range maps {
+			for k, v := range m {
+				merged[k] = v
+			}
+		}
+		return merged
+	}
+
+	skipVerify := withProtocol(config.KafkaConfig{SSLSkipVerify: true}, "SSL")
+	tests := []struct {
+		name string
+		cfg  config.KafkaConfig
+		want kafka.ConfigMap
+	}{
+		{name: "plaintext", cfg: withProtocol(tls, "PLAINTEXT"), want: kafka.ConfigMap{}},
+		{name: "unset", cfg: tls, want: kafka.ConfigMap{}},
+		{name: "ssl", cfg: withProtocol(tls, "SSL"), want: merge(kafka.ConfigMap{"security.protocol": "SSL"}, tlsKeys)},
+		{name: "sasl_ssl", cfg: withProtocol(tls, "SASL_SSL"), want: merge(kafka.ConfigMap{"security.protocol": "SASL_SSL"}, saslKeys, tlsKeys)},
+		{name: "sasl_plaintext ignores TLS", cfg: withProtocol(tls, "SASL_PLAINTEXT"), want: merge(kafka.ConfigMap{"security.protocol": "SASL_PLAINTEXT"}, saslKeys)},
+		{name: "lower case", cfg: withProtocol(tls, "ssl"), want: merge(kafka.ConfigMap{"security.protocol": "ssl"}, tlsKeys)},
+		{name: "system CAs and skip verify", cfg: skipVerify, want: kafka.ConfigMap{"security.protocol": "SSL", "enable.ssl.certificate.verification": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configMap := kafka.ConfigMap{}
+			ApplySecurityConfig(&configMap, tt.cfg)
+			if len(configMap) != len(tt.want) {
+				t.Errorf("set %v, want %v", configMap, tt.want)
+			}
+			for key, value := range tt.want {
+				if configMap[key] != value {
+					t.Errorf("%s = %v, want %v", key, configMap[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestNewConsumerAppliesTheTLSSettings(t *testing.T) {
+	var configMap *kafka.ConfigMap
+	newKafkaConsumer = func(cm *kafka.ConfigMap) (*kafka.Consumer, error) {
+		configMap = cm
+		return nil, errors.New("not connecting in this test")
+	}
+	t.Cleanup(func() { newKafkaConsumer = kafka.NewConsumer })
+
+	_, err := NewConsumer(config.KafkaConfig{
+		Brokers:          []string{"localhost:9093"},
+		SecurityProtocol: "SSL",
+		SSLCALocation:    "/etc/kafka/ca.pem",
+	}, "orders", zap.NewNop())
+	if err == nil {
+		t.Fatal("NewConsumer succeeded without a client")
+	}
+	if (*configMap)["security.protocol"] != "SSL" || (*configMap)["ssl.ca.location"] != "/etc/kafka/ca.pem" {
+		t.Errorf("consumer config %v, want the TLS settings", *configMap)
+	}
+}
+
+func TestNewProducerAppliesTheTLSSettings(t *testing.T) {
+	// librdkafka loads the CA certificate when the client is created
+	_, err := NewProducer(config.KafkaConfig{
+		Brokers:          []string{"localhost:9093"},
+		SecurityProtocol: "SSL",
+		SSLCALocation:    filepath.Join(t.TempDir(), "missing-ca.pem"),
+		Producer:         config.ProducerConfig{Retries: 3},
+	}, zap.NewNop())
+	if err == nil || !strings.Contains(err.Error(), "ssl.ca.location") {
+		t.Errorf("NewProducer() = %v, want the unreadable ssl.ca.location reported", err)
+	}
+}
@@ -0,0 +1,127 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.uber.org/zap"
+)
+
+// ErrPartitionNotAssigned is returned when seeking a partition that is not
+// assigned to the consumer
+var ErrPartitionNotAssigned = errors.New("partition is not assigned to this consumer")
+
+// Auto offset reset policies, where partitions without a committed offset start
+const (
+	AutoOffsetResetEarliest = "earliest"
+	AutoOffsetResetLatest   = "latest"
+	AutoOffsetResetError    = "error"
+)
+
+// seekClient is the subset of the Kafka client used to reposition assigned partitions
+type seekClient interface {
+	Assignment() ([]kafka.TopicPartition, error)
+	OffsetsForTimes(times []kafka.TopicPartition, timeoutMs int) ([]kafka.TopicPartition, error)
+	Seek(partition kafka.TopicPartition, ignoredTimeoutMs int) error
+}
+
+// SeekToOffset makes the consumer read the partition from offset next, e.g.
+// to reprocess messages while debugging. The partition must be assigned to
+// the consumer, so call it once Start has joined the group. Offsets committed
+// afterwards follow the new position.
+func (c *Consumer) SeekToOffset(topic string, partition int32, offset int64) error {
+	if err := seekToOffset(c.consumer, topic, partition, kafka.Offset(offset)); err != nil {
+		return err
+	}
+	c.offsets.reset(kafka.TopicPartition{Topic: &topic, Partition: partition})
+	c.log.Info("Seeked partition to offset",
+		zap.String("topic", topic),
+		zap.Int32("partition", partition),
+		zap.Int64("offset", offset),
+	)
+	return nil
+}
+
+// SeekToTimestamp makes the consumer read every partition of topic assigned
+// to it from the first message at or after t. Partitions without such a
+// message move to their end. It returns the partitions it repositioned.
+func (c *Consumer) SeekToTimestamp(topic string, t time.Time) ([]kafka.TopicPartition, error) {
+	positioned, err := seekToTimestamp(c.consumer, topic, t)
+	if err != nil {
+		return nil, err
+	}
+	c.offsets.reset(positioned...)
+	c.log.Info("Seeked partitions to timestamp",
+		zap.String("topic", topic),
+		zap.Time("timestamp", t),
+		zap.Int("partitions", len(positioned)),
+	)
+	return positioned, nil
+}
+
+func seekToOffset(client seekClient, topic string, partition int32, offset kafka.Offset) error {
+	assigned, err := assignedPartitions(client, topic)
+	if err != nil {
+		return err
+	}
+	for _, tp := range assigned {
+		if tp.Partition == partition {
+			return seek(client, kafka.TopicPartition{Topic: &topic, Partition: partition, Offset: offset})
+		}
+	}
+	return fmt.Errorf("%w: %s[%d]", ErrPartitionNotAssigned, topic, partition)
+}
+
+func seekToTimestamp(client seekClient, topic string, t time.Time) ([]kafka.TopicPartition, error) {
+	assigned, err := assignedPartitions(client, topic)
+	if err != nil {
+		return nil, err
+	}
+	if len(assigned) == 0 {
+		return nil, fmt.Errorf("%w: no partition of %s", ErrPartitionNotAssigned, topic)
+	}
+
+	query := make([]kafka.TopicPartition, len(assigned))
+	for i, tp := range assigned {
+		query[i] = kafka.TopicPartition{Topic: &topic, Partition: tp.Partition, Offset: kafka.Offset(t.UnixMilli())}
+	}
+	offsets, err := client.OffsetsForTimes(query, metadataTimeoutMs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up offsets for %s: %w", t.Format(time.RFC3339), err)
+	}
+
+	for _, tp := range offsets {
+		if tp.Error != nil {
+			return nil, fmt.Errorf("failed to look up offset for %s: %w", partitionKey(tp), tp.Error)
+		}
+		// No message at or after t: OffsetEnd, i.e. only new messages
+		if err := seek(client, tp); err != nil {
+			return nil, err
+		}
+	}
+	return offsets, nil
+}
+
+// assignedPartitions returns the partitions of topic assigned to the consumer
+func assignedPartitions(client seekClient, topic string) ([]kafka.TopicPartition, error) {
+	assignment, err := client.Assignment()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the assignment: %w", err)
+	}
+	var assigned []kafka.TopicPartition
+	for _, tp := range assignment {
+		if tp.Topic != nil && *tp.Topic == topic {
+			assigned = append(assigned, tp)
+		}
+	}
+	return assigned, nil
+}
+
+func seek(client seekClient, tp kafka.TopicPartition) error {
+	if err := client.Seek(tp, 0); err != nil {
+		return fmt.Errorf("failed to seek %s to %s: %w", partitionKey(tp), tp.Offset, err)
+	}
+	return nil
+}
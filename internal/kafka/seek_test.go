@@ -0,0 +1,127 @@
+package kafka
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"go.uber.org/zap"
+)
+
+// seekStub answers like a consumer assigned assignment, serving the offsets
+// for times of offsetStub and recording seeks
+type seekStub struct {
+	offsetStub
+	assignment []kafka.TopicPartition
+	seeks      []string
+}
+
+func (s *seekStub) Assignment() ([]kafka.TopicPartition, error) {
+	return s.assignment, nil
+}
+
+func (s *seekStub) Seek(tp kafka.TopicPartition, ignoredTimeoutMs int) error {
+	s.seeks = append(s.seeks, partitionKey(tp)+"@"+tp.Offset.String())
+	return nil
+}
+
+func TestSeekToOffset(t *testing.T) {
+	client := &seekStub{assignment: append(newAssignment("orders", 2), newAssignment("payments", 1)...)}
+
+	if err := seekToOffset(client, "orders", 1, 42); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{partitionKey(kafka.TopicPartition{Topic: strPtr("orders"), Partition: 1}) + "@42"}; !slices.Equal(client.seeks, want) {
+		t.Errorf("seeks %v, want %v", client.seeks, want)
+	}
+
+	for _, tt := range []struct {
+		topic     string
+		partition int32
+	}{{"orders", 2}, {"inventory", 0}} {
+		if err := seekToOffset(client, tt.topic, tt.partition, 0); !errors.Is(err, ErrPartitionNotAssigned) {
+			t.Errorf("seekToOffset(%s, %d) = %v, want ErrPartitionNotAssigned", tt.topic, tt.partition, err)
+		}
+	}
+	if len(client.seeks) != 1 {
+		t.Errorf("seeks %v, want none for unassigned partitions", client.seeks)
+	}
+}
+
+func TestSeekToTimestamp(t *testing.T) {
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	client := &seekStub{
+		assignment: append(newAssignment("orders", 3), newAssignment("payments", 1)...),
+		offsetStub: offsetStub{forTimes: map[int32]kafka.Offset{0: 10, 1: 25, 2: kafka.OffsetEnd}},
+	}
+
+	positioned, err := seekToTimestamp(client, "orders", at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.queries) != 1 || len(client.queries[0]) != 3 {
+		t.Fatalf("queried %v, want one lookup of the 3 assigned orders partitions", client.queries)
+	}
+	for _, tp := range client.queries[0] {
+		if *tp.Topic != "orders" || int64(tp.Offset) != at.UnixMilli() {
+			t.Errorf("queried %s at %d, want orders at %d", partitionKey(tp), tp.Offset, at.UnixMilli())
+		}
+	}
+	if got := offsetsOf(positioned); !slices.Equal(got, []kafka.Offset{10, 25, kafka.OffsetEnd}) {
+		t.Errorf("positioned at %v, want 10, 25 and the end", got)
+	}
+	if len(client.seeks) != 3 {
+		t.Errorf("seeks %v, want each orders partition", client.seeks)
+	}
+
+	if _, err := seekToTimestamp(client, "inventory", at); !errors.Is(err, ErrPartitionNotAssigned) {
+		t.Errorf("seekToTimestamp(inventory) = %v, want ErrPartitionNotAssigned", err)
+	}
+
+	failing := &seekStub{assignment: newAssignment("orders", 1), offsetStub: offsetStub{timeErr: kafka.NewError(kafka.ErrUnknownPartition, "unknown partition", false)}}
+	if _, err := seekToTimestamp(failing, "orders", at); err == nil || len(failing.seeks) != 0 {
+		t.Errorf("seekToTimestamp() = %v after seeks %v, want the lookup error and no seek", err, failing.seeks)
+	}
+}
+
+func TestAutoOffsetResetIsConfigurable(t *testing.T) {
+	var configMap *kafka.ConfigMap
+	newKafkaConsumer = func(cm *kafka.ConfigMap) (*kafka.Consumer, error) {
+		configMap = cm
+		return nil, errors.New("not connecting in this test")
+	}
+	t.Cleanup(func() { newKafkaConsumer = kafka.NewConsumer })
+
+	tests := []struct {
+		policy  string
+		want    string
+		wantErr bool
+	}{
+		{policy: "", want: AutoOffsetResetEarliest},
+		{policy: AutoOffsetResetLatest, want: AutoOffsetResetLatest},
+		{policy: AutoOffsetResetError, want: AutoOffsetResetError},
+		{policy: "smallest", wantErr: true},
+	}
+	for _, tt := range tests {
+		configMap = nil
+		NewConsumer(config.KafkaConfig{
+			Brokers:  []string{"localhost:9092"},
+			Consumer: config.ConsumerConfig{AutoOffsetReset: tt.policy},
+		}, "orders", zap.NewNop())
+
+		if tt.wantErr {
+			if configMap != nil {
+				t.Errorf("auto offset reset %q: created a client, want the policy rejected", tt.policy)
+			}
+			continue
+		}
+		if configMap == nil || (*configMap)["auto.offset.reset"] != tt.want {
+			t.Errorf("auto offset reset %q: client config %v, want auto.offset.reset %s", tt.policy, configMap, tt.want)
+		}
+	}
+}
+
+func strPtr(s string) *string { return &s }
@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// HeaderContentType names the serialization of an event message, see events.Serializer
+const HeaderContentType = "content-type"
+
+// serializerAvro selects the schema registry-backed Avro serializer
+const serializerAvro = "avro"
+
+// newSerializer returns the serializer configured by kafka.serializer. The
+// avro serializer connects to the configured schema registry.
+func newSerializer(cfg config.KafkaConfig) (events.Serializer, error) {
+	if cfg.Serializer != serializerAvro {
+		return events.NewSerializer(cfg.Serializer, cfg.CloudEventsSource)
+	}
+
+	registry := cfg.SchemaRegistry
+	if registry.URL == "" {
+		return nil, fmt.Errorf("the avro serializer requires a schema registry URL")
+	}
+	client, err := events.NewSchemaRegistryClient(registry.URL, registry.Username, registry.Password)
+	if err != nil {
+		return nil, err
+	}
+	return events.NewAvroSerializer(client, nil), nil
+}
+
+// SetSerializer replaces the serializer configured by kafka.serializer for
+// events published through PublishEvent and PublishEventWithPartitionKey
+func (p *Producer) SetSerializer(serializer events.Serializer) {
+	p.serializer = serializer
+}
+
+// SetSerializer replaces the serializer configured by kafka.serializer for
+// messages without a content-type header
+func (c *Consumer) SetSerializer(serializer events.Serializer) {
+	c.serializer = serializer
+}
+
+// transcode rewrites non-JSON event messages as JSON, so handlers decode
+// every message with events.UnmarshalEvent whatever the producer's format.
+// The format is taken from the content-type header, falling back to the
+// configured serializer. Avro messages need the avro serializer configured,
+// which knows the schema registry.
+func (c *Consumer) transcode(msg *kafka.Message) error {
+	serializer := c.serializer
+	if ct, ok := header(msg, HeaderContentType); ok && ct != serializer.ContentType() {
+		s, known := events.SerializerForContentType(ct)
+		if !known {
+			return fmt.Errorf("%w: unsupported content type %q", events.ErrInvalidPayload, ct)
+		}
+		serializer = s
+	}
+	if serializer.ContentType() == events.ContentTypeJSON {
+		return nil
+	}
+
+	event, err := serializer.Unmarshal(msg.Value)
+	if err != nil {
+		return fmt.Errorf("%w: failed to decode %s event: %v", events.ErrInvalidPayload, serializer.ContentType(), err)
+	}
+	value, err := event.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to transcode event to JSON: %w", err)
+	}
+
+	msg.Value = value
+	setHeader(msg, HeaderContentType, events.ContentTypeJSON)
+	return nil
+}
@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// run holds the state of a running Start, for Shutdown and the handlers
+type run struct {
+	mu       sync.RWMutex
+	stop     context.CancelFunc // cancels Start's context
+	stopped  chan struct{}      // closed when Start returns
+	handlers context.Context    // parent of handler contexts
+}
+
+// begin makes Start's context cancellable by Shutdown and sets up the
+// context handlers run in: it outlives ctx, so in-flight handlers finish on
+// shutdown, until the drain timeout (if any) cancels it. The returned
+// function must be called when Start returns.
+func (c *Consumer) begin(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	handlers, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+
+	stopDrain := func() bool { return false }
+	if timeout := c.config.Consumer.DrainTimeout; timeout > 0 {
+		stopDrain = context.AfterFunc(ctx, func() {
+			time.AfterFunc(timeout, cancelHandlers)
+		})
+	}
+
+	stopped := make(chan struct{})
+	c.run.mu.Lock()
+	c.run.stop = cancel
+	c.run.stopped = stopped
+	c.run.handlers = handlers
+	c.run.mu.Unlock()
+
+	return ctx, func() {
+		stopDrain()
+		cancelHandlers()
+		cancel()
+		close(stopped)
+	}
+}
+
+// handlerContext returns the parent of handler contexts: the one set up by
+// Start, or ctx without its cancellation outside of Start
+func (c *Consumer) handlerContext(ctx context.Context) context.Context {
+	c.run.mu.RLock()
+	defer c.run.mu.RUnlock()
+	if c.run.handlers != nil {
+		return c.run.handlers
+	}
+	return context.WithoutCancel(ctx)
+}
+
+// Shutdown stops Start gracefully: no more messages are read, in-flight
+// messages finish (within the drain timeout) and their offsets are committed
+// before Start returns. It waits for Start to return or ctx to be done,
+// whichever comes first. Shutdown does nothing if Start was never called.
+func (c *Consumer) Shutdown(ctx context.Context) error {
+	c.run.mu.RLock()
+	stop, stopped := c.run.stop, c.run.stopped
+	c.run.mu.RUnlock()
+	if stop == nil {
+		return nil
+	}
+
+	stop()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,194 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+)
+
+// messageFeed serves the messages sent on it to ReadMessage, which times out
+// like the Kafka client when none arrives in time
+type messageFeed chan *kafka.Message
+
+func (f messageFeed) ReadMessage(timeout time.Duration) (*kafka.Message, error) {
+	select {
+	case msg := <-f:
+		return msg, nil
+	case <-time.After(timeout):
+		return nil, kafka.NewError(kafka.ErrTimedOut, "timed out", false)
+	}
+}
+
+// startTestConsumer runs Start on c, reading from the returned feed; the
+// returned channel receives Start's error
+func startTestConsumer(ctx context.Context, c *Consumer) (messageFeed, <-chan error) {
+	feed := make(messageFeed)
+	c.reads = feed
+	result := make(chan error, 1)
+	go func() { result <- c.Start(ctx) }()
+	return feed, result
+}
+
+// blockingHandler signals started when called and returns once release is
+// closed, or with its context's error if that is cancelled first
+func blockingHandler(started chan<- struct{}, release <-chan struct{}) MessageHandler {
+	return func(ctx context.Context, msg *Message) error {
+		close(started)
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func TestShutdownCommitsInFlightMessage(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{})
+	started, release := make(chan struct{}), make(chan struct{})
+	c.RegisterHandler("orders", blockingHandler(started, release))
+
+	feed, result := startTestConsumer(context.Background(), c)
+	feed <- testMessage("orders", 7)
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- c.Shutdown(context.Background()) }()
+
+	select {
+	case err := <-result:
+		t.Fatalf("Start returned %v while its handler was still running", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("Start returned %v, want context.Canceled", err)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown returned %v", err)
+	}
+
+	commits.mu.Lock()
+	defer commits.mu.Unlock()
+	if len(commits.messages) != 1 || commits.messages[0].Offset != 7 {
+		t.Errorf("committed %v, want the in-flight message at offset 7", commits.messages)
+	}
+}
+
+func TestCancelledContextCommitsInFlightMessage(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		CommitMode:     CommitAsync,
+		CommitInterval: time.Hour,
+	}})
+	started, release := make(chan struct{}), make(chan struct{})
+	c.RegisterHandler("orders", blockingHandler(started, release))
+	c.nextCommit = c.now().Add(time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	feed, result := startTestConsumer(ctx, c)
+	feed <- testMessage("orders", 3)
+	<-started
+	cancel()
+	close(release)
+
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("Start returned %v, want context.Canceled", err)
+	}
+	if got := commits.committedOffset("orders", 0); got != 4 {
+		t.Errorf("committed offset %d on shutdown, want 4, past the in-flight message", got)
+	}
+}
+
+func TestDrainTimeoutCancelsInFlightHandlers(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		DrainTimeout: 50 * time.Millisecond,
+	}})
+	started, never := make(chan struct{}), make(chan struct{})
+	handlerErr := make(chan error, 1)
+	handler := blockingHandler(started, never)
+	c.RegisterHandler("orders", func(ctx context.Context, msg *Message) error {
+		err := handler(ctx, msg)
+		handlerErr <- err
+		return err
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	feed, result := startTestConsumer(ctx, c)
+	feed <- testMessage("orders", 0)
+	<-started
+	cancel()
+
+	select {
+	case err := <-handlerErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("handler context ended with %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("drain timeout did not cancel the in-flight handler")
+	}
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Errorf("Start returned %v, want context.Canceled", err)
+	}
+
+	commits.mu.Lock()
+	defer commits.mu.Unlock()
+	if len(commits.messages) != 0 {
+		t.Errorf("committed %v, want the interrupted message left for redelivery", commits.messages)
+	}
+}
+
+func TestShutdownWithoutStart(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown before Start returned %v", err)
+	}
+}
+
+func TestInFlightPublishCompletesBeforeTheProducerCloses(t *testing.T) {
+	cluster := newMockCluster(t)
+	if err := cluster.CreateTopic("inventory.reserved", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	producer := newClusterProducer(t, cluster)
+
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	started, release := make(chan struct{}), make(chan struct{})
+	published := make(chan error, 1)
+	c.RegisterHandler("orders", func(ctx context.Context, msg *Message) error {
+		close(started)
+		<-release
+		err := producer.Publish(ctx, "inventory.reserved", []byte("order-1"), []byte("reserved"))
+		published <- err
+		return err
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	feed, result := startTestConsumer(ctx, c)
+	feed <- testMessage("orders", 0)
+	<-started
+	cancel()
+	close(release)
+
+	// The shutdown sequence: the consume loop returns, then the producer closes
+	<-result
+	select {
+	case err := <-published:
+		if err != nil {
+			t.Fatalf("in-flight publish failed during shutdown: %v", err)
+		}
+	default:
+		t.Fatal("consume loop returned before the in-flight handler finished publishing")
+	}
+	if err := producer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if keys := readKeys(t, cluster, "inventory.reserved", 1); !keys["order-1"] {
+		t.Error("in-flight publish not delivered")
+	}
+}
@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.uber.org/zap"
+)
+
+// offsetSource is the subset of the Kafka client used to position newly assigned partitions
+type offsetSource interface {
+	Committed(partitions []kafka.TopicPartition, timeoutMs int) ([]kafka.TopicPartition, error)
+	OffsetsForTimes(times []kafka.TopicPartition, timeoutMs int) ([]kafka.TopicPartition, error)
+}
+
+// startOffsets positions the partitions the group has no committed offset for
+// at the first message at or after start. It reports whether any partition
+// was positioned; the others keep the invalid offset, meaning "use the committed one".
+func startOffsets(src offsetSource, partitions []kafka.TopicPartition, start time.Time) ([]kafka.TopicPartition, bool, error) {
+	committed, err := src.Committed(partitions, metadataTimeoutMs)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch committed offsets: %w", err)
+	}
+
+	var query []kafka.TopicPartition
+	for _, tp := range committed {
+		if tp.Offset == kafka.OffsetInvalid {
+			tp.Offset = kafka.Offset(start.UnixMilli())
+			tp.Error = nil
+			query = append(query, tp)
+		}
+	}
+	if len(query) == 0 {
+		return partitions, false, nil
+	}
+
+	offsets, err := src.OffsetsForTimes(query, metadataTimeoutMs)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to look up offsets for %s: %w", start.Format(time.RFC3339), err)
+	}
+
+	byPartition := make(map[string]kafka.Offset, len(offsets))
+	for _, tp := range offsets {
+		if tp.Error != nil {
+			return nil, false, fmt.Errorf("failed to look up offset for %s: %w", partitionKey(tp), tp.Error)
+		}
+		// No message at or after start: OffsetEnd, i.e. only new messages
+		byPartition[partitionKey(tp)] = tp.Offset
+	}
+
+	assignment := make([]kafka.TopicPartition, len(partitions))
+	for i, tp := range partitions {
+		tp.Offset = kafka.OffsetInvalid
+		if offset, ok := byPartition[partitionKey(tp)]; ok {
+			tp.Offset = offset
+		}
+		assignment[i] = tp
+	}
+	return assignment, true, nil
+}
+
+// assignFromStartTime seeks assigned partitions without a committed offset to
+// the configured start time. Without a start time the default assignment applies.
+func (c *Consumer) assignFromStartTime(consumer *kafka.Consumer, partitions []kafka.TopicPartition) error {
+	if c.startFrom.IsZero() {
+		return nil
+	}
+
+	assignment, positioned, err := startOffsets(consumer, partitions, c.startFrom)
+	if err != nil {
+		// Fall back to the default assignment (auto.offset.reset)
+		c.log.Error("Could not position partitions at the start time",
+			zap.Error(err),
+			zap.Time("start_from_time", c.startFrom),
+		)
+		return nil
+	}
+	if !positioned {
+		return nil
+	}
+
+	c.log.Info("Starting partitions without committed offsets from the configured time",
+		zap.Time("start_from_time", c.startFrom),
+		zap.Int("partitions", len(assignment)),
+	)
+	return consumer.Assign(assignment)
+}
@@ -0,0 +1,124 @@
+package kafka
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"go.uber.org/zap"
+)
+
+// offsetStub serves committed offsets and the offsets for times by partition,
+// recording the OffsetsForTimes queries
+type offsetStub struct {
+	committed map[int32]kafka.Offset
+	forTimes  map[int32]kafka.Offset
+	timeErr   error
+	queries   [][]kafka.TopicPartition
+}
+
+func (s *offsetStub) Committed(partitions []kafka.TopicPartition, timeoutMs int) ([]kafka.TopicPartition, error) {
+	committed := make([]kafka.TopicPartition, len(partitions))
+	for i, tp := range partitions {
+		tp.Offset = kafka.OffsetInvalid
+		if offset, ok := s.committed[tp.Partition]; ok {
+			tp.Offset = offset
+		}
+		committed[i] = tp
+	}
+	return committed, nil
+}
+
+func (s *offsetStub) OffsetsForTimes(times []kafka.TopicPartition, timeoutMs int) ([]kafka.TopicPartition, error) {
+	s.queries = append(s.queries, times)
+	offsets := make([]kafka.TopicPartition, len(times))
+	for i, tp := range times {
+		tp.Offset = s.forTimes[tp.Partition]
+		tp.Error = s.timeErr
+		offsets[i] = tp
+	}
+	return offsets, nil
+}
+
+// newAssignment returns partitions 0 to n-1 of topic, as the group assigns them
+func newAssignment(topic string, n int32) []kafka.TopicPartition {
+	partitions := make([]kafka.TopicPartition, n)
+	for i := range partitions {
+		partitions[i] = kafka.TopicPartition{Topic: &topic, Partition: int32(i), Offset: kafka.OffsetInvalid}
+	}
+	return partitions
+}
+
+// offsetsOf returns the offsets of the partitions, in order
+func offsetsOf(partitions []kafka.TopicPartition) []kafka.Offset {
+	offsets := make([]kafka.Offset, len(partitions))
+	for i, tp := range partitions {
+		offsets[i] = tp.Offset
+	}
+	return offsets
+}
+
+func TestStartOffsetsSeeksUncommittedPartitionsToTheStartTime(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	src := &offsetStub{
+		committed: map[int32]kafka.Offset{0: 42},
+		forTimes:  map[int32]kafka.Offset{1: 7, 2: kafka.OffsetEnd},
+	}
+
+	assignment, positioned, err := startOffsets(src, newAssignment("orders", 3), start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !positioned {
+		t.Error("reported no partition positioned")
+	}
+
+	if len(src.queries) != 1 {
+		t.Fatalf("called OffsetsForTimes %d times, want once", len(src.queries))
+	}
+	for _, tp := range src.queries[0] {
+		if tp.Partition == 0 || tp.Offset != kafka.Offset(start.UnixMilli()) {
+			t.Errorf("looked up %v, want only uncommitted partitions at %d", tp, start.UnixMilli())
+		}
+	}
+	// The committed partition keeps the invalid offset: resume from the commit
+	if want := []kafka.Offset{kafka.OffsetInvalid, 7, kafka.OffsetEnd}; !slices.Equal(offsetsOf(assignment), want) {
+		t.Errorf("assigned offsets %v, want %v", offsetsOf(assignment), want)
+	}
+}
+
+func TestStartOffsetsLeavesCommittedGroupsAlone(t *testing.T) {
+	src := &offsetStub{committed: map[int32]kafka.Offset{0: 42, 1: 3}}
+
+	assignment, positioned, err := startOffsets(src, newAssignment("orders", 2), time.Now())
+	if err != nil || positioned {
+		t.Fatalf("startOffsets() positioned %v, %v, want the committed offsets used", positioned, err)
+	}
+	if len(src.queries) != 0 {
+		t.Errorf("looked up offsets for times %v, want no lookup", src.queries)
+	}
+	if want := []kafka.Offset{kafka.OffsetInvalid, kafka.OffsetInvalid}; !slices.Equal(offsetsOf(assignment), want) {
+		t.Errorf("assigned offsets %v, want %v", offsetsOf(assignment), want)
+	}
+}
+
+func TestStartOffsetsReportsLookupErrors(t *testing.T) {
+	src := &offsetStub{timeErr: kafka.NewError(kafka.ErrUnknownTopicOrPart, "unknown partition", false)}
+
+	if _, _, err := startOffsets(src, newAssignment("orders", 1), time.Now()); err == nil {
+		t.Error("startOffsets() succeeded despite a failed lookup")
+	}
+}
+
+func TestNewConsumerRejectsInvalidStartTime(t *testing.T) {
+	_, err := NewConsumer(config.KafkaConfig{
+		Brokers:  []string{"localhost:9092"},
+		Consumer: config.ConsumerConfig{StartFromTime: "yesterday"},
+	}, "backfill", zap.NewNop())
+	if err == nil || !strings.Contains(err.Error(), "start_from_time") {
+		t.Errorf("NewConsumer() = %v, want an invalid start_from_time error", err)
+	}
+}
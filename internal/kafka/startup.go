@@ -0,0 +1,33 @@
+package kafka
+
+import (
+	"time"
+
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/logger"
+	"go.uber.org/zap"
+)
+
+// startupSleep waits between client creation attempts; replaceable for tests
+var startupSleep = time.Sleep
+
+// createWithRetry calls create until it succeeds or the configured attempts
+// are used up, so a briefly unavailable broker at startup doesn't stop the service
+func createWithRetry[T any](client string, cfg config.RetryConfig, log logger.Logger, create func() (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		c, err := create()
+		if err == nil || attempt >= cfg.MaxAttempts {
+			return c, err
+		}
+
+		backoff := exponentialBackoff(cfg.Backoff, cfg.Multiplier, cfg.MaxBackoff, attempt)
+		log.Warn("Failed to create Kafka client, retrying",
+			zap.Error(err),
+			zap.String("client", client),
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", cfg.MaxAttempts),
+			zap.Duration("backoff", backoff),
+		)
+		startupSleep(backoff)
+	}
+}
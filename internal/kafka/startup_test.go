@@ -0,0 +1,97 @@
+package kafka
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"go.uber.org/zap"
+)
+
+// stubFactory fails its first failures calls, then returns the next client number
+type stubFactory struct {
+	failures int
+	calls    int
+}
+
+func (f *stubFactory) create() (int, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return 0, errors.New("dns lookup failed")
+	}
+	return f.calls, nil
+}
+
+// recordSleeps replaces startupSleep for the test and returns the waits
+func recordSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var sleeps []time.Duration
+	startupSleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	t.Cleanup(func() { startupSleep = time.Sleep })
+	return &sleeps
+}
+
+func TestCreateWithRetry(t *testing.T) {
+	retry := config.RetryConfig{MaxAttempts: 3, Backoff: time.Second, Multiplier: 2}
+	tests := []struct {
+		name       string
+		cfg        config.RetryConfig
+		failures   int
+		wantErr    bool
+		wantCalls  int
+		wantSleeps []time.Duration
+	}{
+		{name: "first attempt", cfg: retry, wantCalls: 1},
+		{name: "fails twice then succeeds", cfg: retry, failures: 2, wantCalls: 3, wantSleeps: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "attempts used up", cfg: retry, failures: 3, wantErr: true, wantCalls: 3, wantSleeps: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "retries disabled", cfg: config.RetryConfig{MaxAttempts: 1}, failures: 1, wantErr: true, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sleeps := recordSleeps(t)
+			factory := &stubFactory{failures: tt.failures}
+
+			client, err := createWithRetry("producer", tt.cfg, zap.NewNop(), factory.create)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("createWithRetry() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && client != tt.wantCalls {
+				t.Errorf("got client %d, want the one created by attempt %d", client, tt.wantCalls)
+			}
+			if factory.calls != tt.wantCalls {
+				t.Errorf("factory called %d times, want %d", factory.calls, tt.wantCalls)
+			}
+			if !slices.Equal(*sleeps, tt.wantSleeps) {
+				t.Errorf("slept %v, want %v", *sleeps, tt.wantSleeps)
+			}
+		})
+	}
+}
+
+func TestNewConsumerRetriesClientCreation(t *testing.T) {
+	sleeps := recordSleeps(t)
+	calls := 0
+	newKafkaConsumer = func(cm *kafka.ConfigMap) (*kafka.Consumer, error) {
+		calls++
+		if calls <= 2 {
+			return nil, errors.New("dns lookup failed")
+		}
+		return kafka.NewConsumer(cm)
+	}
+	t.Cleanup(func() { newKafkaConsumer = kafka.NewConsumer })
+
+	c, err := NewConsumer(config.KafkaConfig{
+		Brokers:      []string{newMockCluster(t).BootstrapServers()},
+		StartupRetry: config.RetryConfig{MaxAttempts: 3, Backoff: time.Second},
+	}, "orders", zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewConsumer() = %v, want success on the third attempt", err)
+	}
+	defer c.Close()
+	if calls != 3 || len(*sleeps) != 2 {
+		t.Errorf("created the client %d times with %d waits, want 3 and 2", calls, len(*sleeps))
+	}
+}
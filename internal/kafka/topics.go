@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Policies for subscribed topics missing from the cluster
+const (
+	MissingTopicsWarn = "warn"
+	MissingTopicsFail = "fail"
+)
+
+// metadataTimeoutMs bounds cluster metadata requests
+const metadataTimeoutMs = 5000
+
+// metadataSource is the subset of the Kafka client used to inspect cluster metadata
+type metadataSource interface {
+	GetMetadata(topic *string, allTopics bool, timeoutMs int) (*kafka.Metadata, error)
+}
+
+// missingTopics returns the topics that do not exist on the cluster. All topics
+// are listed rather than queried by name, which could trigger auto-creation.
+func missingTopics(src metadataSource, topics []string) ([]string, error) {
+	metadata, err := src.GetMetadata(nil, true, metadataTimeoutMs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch cluster metadata: %w", err)
+	}
+
+	var missing []string
+	for _, topic := range topics {
+		t, ok := metadata.Topics[topic]
+		if !ok || t.Error.Code() == kafka.ErrUnknownTopicOrPart || t.Error.Code() == kafka.ErrUnknownTopic {
+			missing = append(missing, topic)
+		}
+	}
+	return missing, nil
+}
+
+// checkTopics applies the missing-topics policy before subscribing, so a
+// subscription to a nonexistent topic doesn't look like an idle consumer
+func checkTopics(src metadataSource, topics []string, policy string, log logger.Logger) error {
+	missing, err := missingTopics(src, topics)
+	if err != nil {
+		if policy == MissingTopicsFail {
+			return err
+		}
+		log.Warn("Could not verify subscribed topics exist",
+			zap.Error(err),
+		)
+		return nil
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if policy == MissingTopicsFail {
+		return fmt.Errorf("subscribed topics do not exist: %v", missing)
+	}
+	log.Warn("Subscribed topics do not exist; the consumer will receive nothing from them until they are created",
+		zap.Strings("topics", missing),
+	)
+	return nil
+}
@@ -0,0 +1,66 @@
+package kafka
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// metadataStub serves fixed cluster metadata, or err when set
+type metadataStub struct {
+	brokers []kafka.BrokerMetadata
+	topics  map[string]kafka.TopicMetadata
+	err     error
+}
+
+func (s metadataStub) GetMetadata(topic *string, allTopics bool, timeoutMs int) (*kafka.Metadata, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &kafka.Metadata{Brokers: s.brokers, Topics: s.topics}, nil
+}
+
+func TestCheckTopics(t *testing.T) {
+	cluster := metadataStub{topics: map[string]kafka.TopicMetadata{
+		"orders":    {Topic: "orders"},
+		"deleted":   {Topic: "deleted", Error: kafka.NewError(kafka.ErrUnknownTopicOrPart, "unknown topic", false)},
+		"inventory": {Topic: "inventory"},
+	}}
+	unreachable := metadataStub{err: errors.New("all brokers down")}
+
+	tests := []struct {
+		name      string
+		src       metadataStub
+		topics    []string
+		policy    string
+		wantErr   string // empty when no error is expected
+		wantWarns int
+	}{
+		{name: "all exist", src: cluster, topics: []string{"orders", "inventory"}, policy: MissingTopicsFail},
+		{name: "missing with warn", src: cluster, topics: []string{"orders", "payments"}, policy: MissingTopicsWarn, wantWarns: 1},
+		{name: "missing with default policy", src: cluster, topics: []string{"payments"}, wantWarns: 1},
+		{name: "missing with fail", src: cluster, topics: []string{"orders", "payments"}, policy: MissingTopicsFail, wantErr: "[payments]"},
+		{name: "topic error with fail", src: cluster, topics: []string{"deleted"}, policy: MissingTopicsFail, wantErr: "[deleted]"},
+		{name: "unreachable with warn", src: unreachable, topics: []string{"orders"}, policy: MissingTopicsWarn, wantWarns: 1},
+		{name: "unreachable with fail", src: unreachable, topics: []string{"orders"}, policy: MissingTopicsFail, wantErr: "all brokers down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.WarnLevel)
+			err := checkTopics(tt.src, tt.topics, tt.policy, zap.New(core))
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("checkTopics() = %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("checkTopics() = %v, want an error containing %q", err, tt.wantErr)
+			}
+			if logs.Len() != tt.wantWarns {
+				t.Errorf("logged %d warnings, want %d: %v", logs.Len(), tt.wantWarns, logs.All())
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// W3C Trace Context headers carried by published messages
+const (
+	HeaderTraceparent = "traceparent"
+	HeaderTracestate  = "tracestate"
+)
+
+// tracerName is the instrumentation scope of the spans started here
+const tracerName = "github.com/tanint/go-eda/internal/kafka"
+
+// messageCarrier adapts message headers to OpenTelemetry propagators
+type messageCarrier struct {
+	msg *kafka.Message
+}
+
+var _ propagation.TextMapCarrier = messageCarrier{}
+
+// Get returns the value of the header named key
+func (c messageCarrier) Get(key string) string {
+	value, _ := header(c.msg, key)
+	return value
+}
+
+// Set replaces the headers named key, so forwarded messages carry only the
+// current trace context
+func (c messageCarrier) Set(key, value string) {
+	setHeader(c.msg, key, value)
+}
+
+// Keys returns the header names
+func (c messageCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
+
+// SetTracerProvider makes the producer start a span from provider around
+// every publish, whose context is injected into the message headers. The
+// default is the global provider (otel.SetTracerProvider), which records
+// nothing until one is installed.
+func (p *Producer) SetTracerProvider(provider trace.TracerProvider) {
+	p.tracer = provider.Tracer(tracerName)
+}
+
+// SetPropagator replaces the producer's W3C trace context propagator,
+// propagation.TraceContext
+func (p *Producer) SetPropagator(propagator propagation.TextMapPropagator) {
+	p.propagator = propagator
+}
+
+// SetTracerProvider makes the consumer start a span from provider around
+// every handler call, a child of the span propagated in the message headers.
+// The default is the global provider.
+func (c *Consumer) SetTracerProvider(provider trace.TracerProvider) {
+	c.tracer = provider.Tracer(tracerName)
+}
+
+// SetPropagator replaces the consumer's W3C trace context propagator,
+// propagation.TraceContext
+func (c *Consumer) SetPropagator(propagator propagation.TextMapPropagator) {
+	c.propagator = propagator
+}
+
+// defaultTracer returns the tracer of the global provider; spans started
+// before a provider is installed are not recorded
+func defaultTracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// startPublishSpan starts the span of publishing msg and injects its context
+// into the message headers, so consumers continue the trace
+func (p *Producer) startPublishSpan(ctx context.Context, msg *kafka.Message) (context.Context, trace.Span) {
+	ctx, span := p.tracer.Start(ctx, "publish "+*msg.TopicPartition.Topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", *msg.TopicPartition.Topic),
+		),
+	)
+	p.propagator.Inject(ctx, messageCarrier{msg})
+	return ctx, span
+}
+
+// startProcessSpan continues the trace propagated in msg's headers and
+// starts the span of handling msg
+func (c *Consumer) startProcessSpan(ctx context.Context, msg *kafka.Message) (context.Context, trace.Span) {
+	ctx = c.propagator.Extract(ctx, messageCarrier{msg})
+	return c.tracer.Start(ctx, "process "+*msg.TopicPartition.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.source.name", *msg.TopicPartition.Topic),
+			attribute.Int("messaging.kafka.partition", int(msg.TopicPartition.Partition)),
+			attribute.Int64("messaging.kafka.offset", int64(msg.TopicPartition.Offset)),
+		),
+	)
+}
+
+// endSpan finishes span, recording err as its status when non-nil
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
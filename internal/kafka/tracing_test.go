@@ -0,0 +1,80 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceContinuesFromPublishToProcess(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	producer := &Producer{propagator: propagation.TraceContext{}}
+	producer.SetTracerProvider(provider)
+	consumer := &Consumer{propagator: propagation.TraceContext{}}
+	consumer.SetTracerProvider(provider)
+
+	msg := newMessage("orders", []byte("key"), []byte("value"))
+	_, publishSpan := producer.startPublishSpan(context.Background(), msg)
+	endSpan(publishSpan, nil)
+
+	if _, ok := header(msg, HeaderTraceparent); !ok {
+		t.Fatalf("published message has no %s header", HeaderTraceparent)
+	}
+
+	ctx, processSpan := consumer.startProcessSpan(context.Background(), msg)
+	endSpan(processSpan, errors.New("handler failed"))
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d ended spans, want 2", len(spans))
+	}
+	publish, process := spans[0], spans[1]
+
+	if publish.SpanKind() != trace.SpanKindProducer || process.SpanKind() != trace.SpanKindConsumer {
+		t.Errorf("span kinds = %v, %v; want producer, consumer", publish.SpanKind(), process.SpanKind())
+	}
+	if process.Parent().SpanID() != publish.SpanContext().SpanID() {
+		t.Errorf("process span parent = %s, want publish span %s", process.Parent().SpanID(), publish.SpanContext().SpanID())
+	}
+	if process.SpanContext().TraceID() != publish.SpanContext().TraceID() {
+		t.Errorf("process span trace = %s, want %s", process.SpanContext().TraceID(), publish.SpanContext().TraceID())
+	}
+	if trace.SpanContextFromContext(ctx).SpanID() != process.SpanContext().SpanID() {
+		t.Error("handler context does not carry the process span")
+	}
+	if process.Status().Code != codes.Error {
+		t.Errorf("process span status = %v, want error", process.Status().Code)
+	}
+}
+
+func TestInjectReplacesForwardedTraceHeaders(t *testing.T) {
+	producer := &Producer{propagator: propagation.TraceContext{}}
+	producer.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	msg := newMessage("orders", nil, nil)
+	setHeader(msg, HeaderTraceparent, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	_, span := producer.startPublishSpan(context.Background(), msg)
+	endSpan(span, nil)
+
+	count := 0
+	for _, h := range msg.Headers {
+		if h.Key == HeaderTraceparent {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Fatalf("message has %d %s headers, want 1", count, HeaderTraceparent)
+	}
+	value, _ := header(msg, HeaderTraceparent)
+	if want := span.SpanContext().TraceID().String(); value[3:35] != want {
+		t.Errorf("traceparent %s does not carry trace %s", value, want)
+	}
+}
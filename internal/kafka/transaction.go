@@ -0,0 +1,155 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.uber.org/zap"
+)
+
+// ErrNotTransactional is returned by the transaction methods of a producer
+// created without a transactional ID
+var ErrNotTransactional = errors.New("producer is not transactional")
+
+// initTransactionsTimeout bounds InitTransactions in NewProducer
+const initTransactionsTimeout = 30 * time.Second
+
+// Retriable CommitTransaction errors are retried up to commitAttempts times,
+// waiting commitBackoff doubled per attempt up to commitMaxBackoff
+const (
+	commitAttempts   = 5
+	commitBackoff    = 100 * time.Millisecond
+	commitMaxBackoff = 2 * time.Second
+)
+
+// Transactional reports whether the producer was created with a transactional ID
+func (p *Producer) Transactional() bool {
+	return p.config.Producer.TransactionalID != ""
+}
+
+// BeginTransaction starts a transaction: messages published until it is
+// committed or aborted are only visible to read_committed consumers once it
+// commits. Only one transaction can be open per producer at a time.
+func (p *Producer) BeginTransaction() error {
+	if !p.Transactional() {
+		return ErrNotTransactional
+	}
+	if err := p.producer.BeginTransaction(); err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return nil
+}
+
+// SendOffsetsToTransaction adds the consumer's offsets to the open
+// transaction, so they are committed if and only if the transaction commits.
+// offsets are the next offsets to consume, i.e. processed offset + 1.
+func (p *Producer) SendOffsetsToTransaction(ctx context.Context, offsets []kafka.TopicPartition, consumer *Consumer) error {
+	if !p.Transactional() {
+		return ErrNotTransactional
+	}
+	metadata, err := consumer.consumer.GetConsumerGroupMetadata()
+	if err != nil {
+		return fmt.Errorf("failed to get consumer group metadata: %w", err)
+	}
+	if err := p.producer.SendOffsetsToTransaction(ctx, offsets, metadata); err != nil {
+		return fmt.Errorf("failed to send offsets to transaction: %w", err)
+	}
+	return nil
+}
+
+// CommitTransaction commits the open transaction, retrying errors Kafka
+// reports as retriable with a growing backoff, as long as ctx allows the
+// wait. When the transaction can only be aborted, it is aborted and the
+// commit error returned; when retries run out it is left open, and a later
+// CommitTransaction resumes the commit.
+func (p *Producer) CommitTransaction(ctx context.Context) error {
+	if !p.Transactional() {
+		return ErrNotTransactional
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := p.producer.CommitTransaction(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var kerr kafka.Error
+		if errors.As(err, &kerr) && kerr.IsRetriable() && attempt < commitAttempts {
+			backoff := exponentialBackoff(commitBackoff, 2, commitMaxBackoff, attempt)
+			if deadline, ok := ctx.Deadline(); !ok || time.Now().Add(backoff).Before(deadline) {
+				p.log.Warn("Retrying transaction commit",
+					zap.Error(err),
+					zap.Int("attempt", attempt),
+					zap.Duration("backoff", backoff),
+				)
+				if sleepContext(ctx, backoff) == nil {
+					continue
+				}
+			}
+		}
+		if errors.As(err, &kerr) && kerr.TxnRequiresAbort() {
+			if abortErr := p.AbortTransaction(ctx); abortErr != nil {
+				return errors.Join(fmt.Errorf("failed to commit transaction: %w", err), abortErr)
+			}
+		}
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+}
+
+// AbortTransaction aborts the open transaction; its messages are never
+// visible to read_committed consumers and its offsets are not committed
+func (p *Producer) AbortTransaction(ctx context.Context) error {
+	if !p.Transactional() {
+		return ErrNotTransactional
+	}
+	if err := p.producer.AbortTransaction(ctx); err != nil {
+		return fmt.Errorf("failed to abort transaction: %w", err)
+	}
+	return nil
+}
+
+// TransactionalHandler wraps a consume-transform-produce handler so that
+// what it publishes through producer and the consumed message's offset are
+// committed atomically: the handler runs in a transaction that also carries
+// the offset, committed when the handler succeeds and aborted when it fails.
+// Handlers are serialized, since a producer has one transaction at a time.
+//
+// The consumer then commits the same offset again, as for any handled
+// message. That second commit is redundant but harmless: it only repeats
+// the offset the transaction committed, and follows the consumer's commit
+// mode, so the transaction stays the one that makes the offset durable.
+func TransactionalHandler(producer *Producer, consumer *Consumer, handler MessageHandler) MessageHandler {
+	var mu sync.Mutex
+
+	return func(ctx context.Context, msg *Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err := producer.BeginTransaction(); err != nil {
+			return err
+		}
+
+		err := handler(ctx, msg)
+		if err == nil {
+			next := msg.TopicPartition
+			next.Offset++
+			next.Error = nil
+			err = producer.SendOffsetsToTransaction(ctx, []kafka.TopicPartition{next}, consumer)
+		}
+		if err != nil {
+			if abortErr := producer.AbortTransaction(context.WithoutCancel(ctx)); abortErr != nil {
+				producer.log.Error("Failed to abort transaction",
+					zap.Error(abortErr),
+					zap.String("topic", *msg.TopicPartition.Topic),
+					zap.String("offset", msg.TopicPartition.Offset.String()),
+				)
+			}
+			return err
+		}
+		return producer.CommitTransaction(ctx)
+	}
+}
@@ -0,0 +1,179 @@
+//go:build integration
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/google/uuid"
+	"github.com/tanint/go-eda/internal/config"
+	"go.uber.org/zap"
+)
+
+// Run with a broker that can host the transaction state log, e.g. the
+// docker-compose one:
+//
+//	go test -tags integration ./internal/kafka -run Transaction
+//
+// KAFKA_BROKERS overrides the default localhost:9092.
+
+func integrationConfig(t *testing.T) config.KafkaConfig {
+	t.Helper()
+	brokers := os.Getenv("KAFKA_BROKERS")
+	if brokers == "" {
+		brokers = "localhost:9092"
+	}
+	return config.KafkaConfig{Brokers: strings.Split(brokers, ",")}
+}
+
+// newIntegrationTopic creates a single-partition topic unique to the test
+func newIntegrationTopic(t *testing.T, cfg config.KafkaConfig) string {
+	t.Helper()
+	admin, err := kafka.NewAdminClient(&kafka.ConfigMap{"bootstrap.servers": strings.Join(cfg.Brokers, ",")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	topic := "txn-test-" + uuid.NewString()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	results, err := admin.CreateTopics(ctx, []kafka.TopicSpecification{{Topic: topic, NumPartitions: 1, ReplicationFactor: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := results[0].Error.Code(); code != kafka.ErrNoError && code != kafka.ErrTopicAlreadyExists {
+		t.Fatal(results[0].Error)
+	}
+	return topic
+}
+
+func newTransactionalProducer(t *testing.T, cfg config.KafkaConfig) *Producer {
+	t.Helper()
+	cfg.Producer = config.ProducerConfig{Retries: 3, TransactionalID: "txn-test-" + uuid.NewString()}
+	p, err := NewProducer(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+// readCommitted reads the values of topic visible to read_committed
+// consumers, until want values were read or the timeout passes
+func readCommitted(t *testing.T, cfg config.KafkaConfig, topic string, want int) []string {
+	t.Helper()
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": strings.Join(cfg.Brokers, ","),
+		"group.id":          "txn-test-reader-" + uuid.NewString(),
+		"auto.offset.reset": "earliest",
+		"isolation.level":   "read_committed",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer consumer.Close()
+	if err := consumer.Subscribe(topic, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var values []string
+	deadline := time.Now().Add(15 * time.Second)
+	for len(values) < want && time.Now().Before(deadline) {
+		msg, err := consumer.ReadMessage(200 * time.Millisecond)
+		if err != nil {
+			continue
+		}
+		values = append(values, string(msg.Value))
+	}
+	// Anything beyond want would be a message that should not be visible
+	if msg, err := consumer.ReadMessage(time.Second); err == nil {
+		values = append(values, string(msg.Value))
+	}
+	return values
+}
+
+func TestTransactionCommitAndAbort(t *testing.T) {
+	cfg := integrationConfig(t)
+	topic := newIntegrationTopic(t, cfg)
+	p := newTransactionalProducer(t, cfg)
+	ctx := context.Background()
+
+	if err := p.BeginTransaction(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Publish(ctx, topic, nil, []byte("aborted")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AbortTransaction(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.BeginTransaction(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Publish(ctx, topic, nil, []byte("committed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.CommitTransaction(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	values := readCommitted(t, cfg, topic, 1)
+	if len(values) != 1 || values[0] != "committed" {
+		t.Errorf("read %q, want only the committed message", values)
+	}
+}
+
+func TestTransactionalHandlerCommitsOutputWithOffset(t *testing.T) {
+	cfg := integrationConfig(t)
+	input := newIntegrationTopic(t, cfg)
+	output := newIntegrationTopic(t, cfg)
+	p := newTransactionalProducer(t, cfg)
+	groupID := "txn-test-group-" + uuid.NewString()
+	consumer, err := NewConsumer(cfg, groupID, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { consumer.Close() })
+
+	handler := TransactionalHandler(p, consumer, func(ctx context.Context, msg *Message) error {
+		if err := p.Publish(ctx, output, nil, msg.Value); err != nil {
+			return err
+		}
+		if string(msg.Value) == "fail" {
+			return errors.New("handler failed")
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+	failed := testMessage(input, 0)
+	failed.Value = []byte("fail")
+	if err := handler(ctx, failed); err == nil {
+		t.Fatal("failing handler returned no error")
+	}
+	ok := testMessage(input, 1)
+	ok.Value = []byte("ok")
+	if err := handler(ctx, ok); err != nil {
+		t.Fatal(err)
+	}
+
+	values := readCommitted(t, cfg, output, 1)
+	if len(values) != 1 || values[0] != "ok" {
+		t.Errorf("read %q, want only the output of the successful handler", values)
+	}
+	committed, err := consumer.consumer.Committed([]kafka.TopicPartition{{Topic: &input, Partition: 0}}, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if committed[0].Offset != 2 {
+		t.Errorf("committed offset %v, want 2, past the handled message", committed[0].Offset)
+	}
+}
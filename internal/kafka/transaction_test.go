@@ -0,0 +1,63 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCommitTransactionStopsRetryingAtTheDeadline(t *testing.T) {
+	cluster := newMockCluster(t)
+	core, logs := observer.New(zap.WarnLevel)
+	p, err := NewProducer(config.KafkaConfig{
+		Brokers:  []string{cluster.BootstrapServers()},
+		Producer: config.ProducerConfig{Retries: 3, TransactionalID: "commit-retries"},
+	}, zap.New(core))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+
+	if err := p.BeginTransaction(); err != nil {
+		t.Fatal(err)
+	}
+	topic := "orders"
+	if err := p.producer.Produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Value:          []byte("order-1"),
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+	// The message cannot be flushed, which Kafka reports as retriable
+	cluster.SetBrokerDown(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = p.CommitTransaction(ctx)
+
+	var kerr kafka.Error
+	if !errors.As(err, &kerr) || !kerr.IsRetriable() {
+		t.Fatalf("got %v, want the retriable commit error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("returned after %s, want by the 500ms deadline", elapsed)
+	}
+	if n := logs.FilterMessage("Retrying transaction commit").Len(); n >= commitAttempts {
+		t.Errorf("retried %d times, want fewer than %d attempts", n, commitAttempts)
+	}
+
+	// The transaction was left open, and commits once the broker is back
+	cluster.SetBrokerUp(1)
+	retryCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := p.CommitTransaction(retryCtx); err != nil {
+		t.Errorf("committing again after the broker came back: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+// TypedHandler handles an event whose payload has been decoded into T
+type TypedHandler[T any] func(ctx context.Context, event *events.Event, payload T) error
+
+// RegisterHandlerT registers a handler for eventType events on topic that
+// receives the decoded envelope and payload. Handlers of several event types
+// can share a topic; events of types without a handler are skipped.
+// Envelopes or payloads that cannot be decoded fail with
+// events.ErrInvalidPayload, so they are not retried and go to the quarantine
+// topic when one is set, or else to the dead-letter topic. It replaces a
+// handler registered for topic with RegisterHandler, and one already
+// registered for eventType on topic.
+func RegisterHandlerT[T any](c *Consumer, topic string, eventType events.EventType, handler TypedHandler[T]) {
+	c.handlersMu.Lock()
+	if c.typed == nil {
+		c.typed = make(map[string]*eventRouter)
+	}
+	router, ok := c.typed[topic]
+	if !ok {
+		router = newEventRouter(topic, c.log)
+		c.typed[topic] = router
+	}
+	c.handlers[topic] = router.handle
+	c.handlersMu.Unlock()
+
+	router.register(eventType, func(ctx context.Context, event *events.Event) error {
+		payload, err := events.DecodeDataWith[T](DecoderFromContext(ctx), event)
+		if err != nil {
+			return invalidPayload(err)
+		}
+		return handler(ctx, event, payload)
+	})
+	c.log.Info("Registered typed handler for topic",
+		zap.String("topic", topic),
+		zap.String("event_type", string(eventType)),
+	)
+}
+
+// eventRouter dispatches the events of a topic to the handler of their type
+type eventRouter struct {
+	topic string
+	log   logger.Logger
+
+	mu       sync.RWMutex
+	handlers map[events.EventType]func(ctx context.Context, event *events.Event) error
+}
+
+func newEventRouter(topic string, log logger.Logger) *eventRouter {
+	return &eventRouter{
+		topic:    topic,
+		log:      log,
+		handlers: make(map[events.EventType]func(ctx context.Context, event *events.Event) error),
+	}
+}
+
+func (r *eventRouter) register(eventType events.EventType, handler func(ctx context.Context, event *events.Event) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[eventType] = handler
+}
+
+// handle decodes the message's envelope and runs the handler of its event type
+func (r *eventRouter) handle(ctx context.Context, msg *Message) error {
+	event, err := DecoderFromContext(ctx).UnmarshalEvent(msg.Value)
+	if err != nil {
+		return invalidPayload(fmt.Errorf("failed to decode event envelope: %w", err))
+	}
+
+	r.mu.RLock()
+	handler, ok := r.handlers[event.Type]
+	r.mu.RUnlock()
+	if !ok {
+		r.log.Debug("Skipping event of unhandled type",
+			zap.String("topic", r.topic),
+			zap.String("event_type", string(event.Type)),
+		)
+		return nil
+	}
+	return handler(ctx, event)
+}
+
+type decoderKey struct{}
+
+// DecoderFromContext returns the decoder of the consumer handling the
+// message, which is strict when the consumer's strict_decoding is set. It
+// returns the lenient zero Decoder outside a handler.
+func DecoderFromContext(ctx context.Context) events.Decoder {
+	decoder, _ := ctx.Value(decoderKey{}).(events.Decoder)
+	return decoder
+}
+
+// invalidPayload marks a decode error as events.ErrInvalidPayload
+func invalidPayload(err error) error {
+	if errors.Is(err, events.ErrInvalidPayload) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", events.ErrInvalidPayload, err)
+}
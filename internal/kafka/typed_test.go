@@ -0,0 +1,168 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// eventMessage returns the message at offset of partition 0 of topic carrying value
+func eventMessage(topic string, offset kafka.Offset, value string) *kafka.Message {
+	msg := testMessage(topic, offset)
+	msg.Value = []byte(value)
+	return msg
+}
+
+func TestTypedHandlersDispatchByEventType(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{})
+
+	var confirmed []events.OrderConfirmedEvent
+	var changed []events.OrderStatusChangedEvent
+	RegisterHandlerT(c, "orders", events.EventTypeOrderConfirmed, func(ctx context.Context, event *events.Event, payload events.OrderConfirmedEvent) error {
+		confirmed = append(confirmed, payload)
+		return nil
+	})
+	RegisterHandlerT(c, "orders", events.EventTypeOrderStatusChanged, func(ctx context.Context, event *events.Event, payload events.OrderStatusChangedEvent) error {
+		if event.ID != "event-2" {
+			t.Errorf("handler got event %q, want event-2", event.ID)
+		}
+		changed = append(changed, payload)
+		return nil
+	})
+
+	c.handle(context.Background(), eventMessage("orders", 0,
+		`{"id": "event-1", "type": "order.confirmed", "data": {"order_id": "order-1", "customer_id": "customer-1"}}`))
+	c.handle(context.Background(), eventMessage("orders", 1,
+		`{"id": "event-2", "type": "order.status_changed", "data": {"order_id": "order-1", "from": "pending", "to": "confirmed", "actor": "order-service"}}`))
+	c.handle(context.Background(), eventMessage("orders", 2,
+		`{"id": "event-3", "type": "inventory.reserved", "data": {"order_id": "order-1", "items": [{"product_id": "p1", "quantity": 1}]}}`))
+
+	if len(confirmed) != 1 || confirmed[0].OrderID != "order-1" || confirmed[0].CustomerID != "customer-1" {
+		t.Errorf("order.confirmed handler got %+v, want the decoded payload", confirmed)
+	}
+	if len(changed) != 1 || changed[0].To != models.OrderStatusConfirmed {
+		t.Errorf("order.status_changed handler got %+v, want the decoded payload", changed)
+	}
+	if len(commits.messages) != 3 {
+		t.Errorf("committed %d messages, want all 3, the unhandled type skipped", len(commits.messages))
+	}
+}
+
+func TestUndecodableTypedMessagesAreDeadLettered(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "malformed envelope", value: `{"id": "event-1", "type": `},
+		{name: "malformed payload", value: `{"id": "event-1", "type": "order.confirmed", "data": {"order_id": 42}}`},
+		{name: "invalid payload", value: `{"id": "event-1", "type": "order.confirmed", "data": {"order_id": "order-1"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, commits, deadLetters := newDeadLetteringConsumer(t)
+			called := false
+			RegisterHandlerT(c, "orders", events.EventTypeOrderConfirmed, func(context.Context, *events.Event, events.OrderConfirmedEvent) error {
+				called = true
+				return nil
+			})
+			errs := make(chan error, 1)
+			c.OnError(func(err error) { errs <- err })
+
+			c.handle(context.Background(), eventMessage("orders", 4, tt.value))
+
+			if called {
+				t.Error("handler was called with an undecodable message")
+			}
+			if len(deadLetters.messages) != 1 || string(deadLetters.messages[0].Value) != tt.value {
+				t.Fatalf("dead-lettered %d messages, want the undecodable one", len(deadLetters.messages))
+			}
+			if retries, _ := MessageHeaders(deadLetters.messages[0]).Get(HeaderDLQRetryCount); retries != "0" {
+				t.Errorf("retry count %s, want 0: invalid payloads are not retried", retries)
+			}
+			if err := <-errs; !errors.Is(err, events.ErrInvalidPayload) {
+				t.Errorf("reported error %v, want events.ErrInvalidPayload", err)
+			}
+			c.commitOnShutdown()
+			if commits.committedOffset("orders", 0) != 5 {
+				t.Errorf("batches %v, want the dead-lettered message committed", commits.batches)
+			}
+		})
+	}
+}
+
+func TestRegisterHandlerReplacesTypedHandlers(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{})
+	typed := 0
+	RegisterHandlerT(c, "orders", events.EventTypeOrderConfirmed, func(context.Context, *events.Event, events.OrderConfirmedEvent) error {
+		typed++
+		return nil
+	})
+	raw := 0
+	c.RegisterHandler("orders", func(context.Context, *Message) error {
+		raw++
+		return nil
+	})
+	msg := `{"id": "event-1", "type": "order.confirmed", "data": {"order_id": "order-1", "customer_id": "customer-1"}}`
+	c.handle(context.Background(), eventMessage("orders", 0, msg))
+
+	// A typed handler registered afterwards starts over without the replaced ones
+	RegisterHandlerT(c, "orders", events.EventTypeOrderStatusChanged, func(context.Context, *events.Event, events.OrderStatusChangedEvent) error {
+		return nil
+	})
+	c.handle(context.Background(), eventMessage("orders", 1, msg))
+
+	if typed != 0 || raw != 1 {
+		t.Errorf("typed handler called %d times and raw handler %d times, want 0 and 1", typed, raw)
+	}
+}
+
+func TestStrictDecodingIsPerConsumer(t *testing.T) {
+	msg := `{"id": "event-1", "type": "order.confirmed", "data": {"order_id": "order-1", "customer_id": "customer-1", "loyalty_tier": "gold"}}`
+	tests := []struct {
+		name    string
+		strict  bool
+		handled bool
+	}{
+		{name: "lenient", strict: false, handled: true},
+		{name: "strict", strict: true, handled: false},
+	}
+	// Both consumers live side by side; neither mode leaks into the other
+	consumers := make([]*Consumer, len(tests))
+	for i, tt := range tests {
+		consumers[i], _, _ = newTestConsumer(t, config.KafkaConfig{
+			Consumer: config.ConsumerConfig{StrictDecoding: tt.strict},
+		})
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := consumers[i]
+			handled := false
+			RegisterHandlerT(c, "orders", events.EventTypeOrderConfirmed, func(context.Context, *events.Event, events.OrderConfirmedEvent) error {
+				handled = true
+				return nil
+			})
+			var errs []error
+			c.OnError(func(err error) { errs = append(errs, err) })
+
+			c.handle(context.Background(), eventMessage("orders", 0, msg))
+
+			if handled != tt.handled {
+				t.Errorf("handled %v, want %v", handled, tt.handled)
+			}
+			if !tt.handled && (len(errs) == 0 || !errors.Is(errs[0], events.ErrInvalidPayload)) {
+				t.Errorf("reported errors %v, want events.ErrInvalidPayload", errs)
+			}
+		})
+	}
+}
+
+func TestDecoderFromContextDefaultsToLenient(t *testing.T) {
+	if DecoderFromContext(context.Background()).Strict {
+		t.Error("decoder outside a handler is strict, want lenient")
+	}
+}
@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"sync"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// defaultWorkerQueueSize bounds each worker's queue when no size is configured
+const defaultWorkerQueueSize = 100
+
+// workerPool handles messages on a fixed set of goroutines. All messages of a
+// partition go to the same worker, so they are handled in offset order and
+// the committed position of a partition never skips an unhandled message.
+// Worker queues are bounded: submitting to a full queue blocks the read loop,
+// which stops fetching until the worker catches up.
+type workerPool struct {
+	queues  []chan *kafka.Message
+	pending sync.WaitGroup // submitted messages not yet handled
+	running sync.WaitGroup // worker goroutines
+}
+
+// newWorkerPool starts workers calling handle for every submitted message
+func newWorkerPool(workers, queueSize int, handle func(msg *kafka.Message)) *workerPool {
+	if queueSize <= 0 {
+		queueSize = defaultWorkerQueueSize
+	}
+
+	p := &workerPool{queues: make([]chan *kafka.Message, workers)}
+	for i := range p.queues {
+		queue := make(chan *kafka.Message, queueSize)
+		p.queues[i] = queue
+
+		p.running.Add(1)
+		go func() {
+			defer p.running.Done()
+			for msg := range queue {
+				handle(msg)
+				p.pending.Done()
+			}
+		}()
+	}
+	return p
+}
+
+// submit queues msg on its partition's worker, blocking while that worker's
+// queue is full. The message is dropped if ctx is cancelled first.
+func (p *workerPool) submit(ctx context.Context, msg *kafka.Message) {
+	queue := p.queues[p.worker(msg.TopicPartition)]
+
+	p.pending.Add(1)
+	select {
+	case queue <- msg:
+	case <-ctx.Done():
+		p.pending.Done()
+	}
+}
+
+// drain waits until every submitted message has been handled. It must be
+// called from the goroutine that submits.
+func (p *workerPool) drain() {
+	p.pending.Wait()
+}
+
+// stop lets the workers finish their queues and waits for them to exit
+func (p *workerPool) stop() {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.running.Wait()
+}
+
+// worker returns the index of the worker handling the partition
+func (p *workerPool) worker(tp kafka.TopicPartition) int {
+	h := fnv.New32a()
+	if tp.Topic != nil {
+		h.Write([]byte(*tp.Topic))
+	}
+	h.Write([]byte(strconv.Itoa(int(tp.Partition))))
+	return int(h.Sum32() % uint32(len(p.queues)))
+}
@@ -0,0 +1,135 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/tanint/go-eda/internal/config"
+)
+
+func TestWorkerPoolHandlesPartitionsInParallelAndInOrder(t *testing.T) {
+	c, commits, _ := newTestConsumer(t, config.KafkaConfig{})
+
+	var (
+		mu          sync.Mutex
+		inFlight    int
+		maxInFlight int
+		handled     = make(map[int32][]kafka.Offset)
+	)
+	c.RegisterHandler("orders", func(ctx context.Context, msg *Message) error {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond) // a slow handler
+
+		mu.Lock()
+		inFlight--
+		tp := msg.TopicPartition
+		handled[tp.Partition] = append(handled[tp.Partition], tp.Offset)
+		mu.Unlock()
+		return nil
+	})
+
+	pool := newWorkerPool(4, 10, func(msg *kafka.Message) {
+		c.handle(context.Background(), msg)
+	})
+
+	// Two partitions on different workers, interleaved as a broker fetch would
+	partitions := []int32{0, 1}
+	topic := "orders"
+	for p := int32(2); pool.worker(kafka.TopicPartition{Topic: &topic, Partition: partitions[0]}) ==
+		pool.worker(kafka.TopicPartition{Topic: &topic, Partition: partitions[1]}); p++ {
+		partitions[1] = p
+	}
+
+	const perPartition = 5
+	for offset := kafka.Offset(0); offset < perPartition; offset++ {
+		for _, partition := range partitions {
+			msg := testMessage(topic, offset)
+			msg.TopicPartition.Partition = partition
+			pool.submit(context.Background(), msg)
+		}
+	}
+	pool.stop()
+
+	if maxInFlight < 2 {
+		t.Errorf("at most %d handler ran at once, want partitions handled in parallel", maxInFlight)
+	}
+
+	for _, partition := range partitions {
+		offsets := handled[partition]
+		if len(offsets) != perPartition {
+			t.Fatalf("partition %d: handled %v, want %d messages", partition, offsets, perPartition)
+		}
+		for i, offset := range offsets {
+			if offset != kafka.Offset(i) {
+				t.Errorf("partition %d: handled offsets %v, want them in order", partition, offsets)
+				break
+			}
+		}
+
+		var committed []kafka.Offset
+		for _, tp := range commits.messages {
+			if tp.Partition == partition {
+				committed = append(committed, tp.Offset)
+			}
+		}
+		for i, offset := range committed {
+			if offset != kafka.Offset(i) {
+				t.Errorf("partition %d: committed offsets %v, want them in order", partition, committed)
+				break
+			}
+		}
+		if len(committed) != perPartition {
+			t.Errorf("partition %d: committed %v, want %d messages", partition, committed, perPartition)
+		}
+	}
+}
+
+func TestReadLoopBlocksWhileWorkerQueuesAreFull(t *testing.T) {
+	c, _, _ := newTestConsumer(t, config.KafkaConfig{Consumer: config.ConsumerConfig{
+		Workers:         2,
+		WorkerQueueSize: 2,
+	}})
+	started, release := make(chan struct{}, 10), make(chan struct{})
+	var handled sync.WaitGroup
+	c.RegisterHandler("orders", func(ctx context.Context, msg *Message) error {
+		defer handled.Done()
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	feed, result := startTestConsumer(ctx, c)
+
+	// One partition, so one worker: it takes offset 0, its queue holds 1 and 2,
+	// and the read loop blocks submitting 3
+	handled.Add(5)
+	for offset := kafka.Offset(0); offset < 4; offset++ {
+		feed <- testMessage("orders", offset)
+	}
+	<-started
+
+	select {
+	case feed <- testMessage("orders", 4):
+		t.Fatal("read loop kept reading while the worker and its queue were full")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case feed <- testMessage("orders", 4):
+	case <-time.After(time.Second):
+		t.Fatal("read loop did not resume once the worker caught up")
+	}
+	handled.Wait()
+	cancel()
+	<-result
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type correlationIDKey struct{}
+
+type loggerKey struct{}
+
+// WithCorrelationID returns a copy of ctx carrying the correlation ID that
+// FromContext adds to log entries
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// WithLogger returns a copy of ctx whose FromContext logs to l instead of
+// the global logger, e.g. a component's injected logger
+func WithLogger(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger of ctx, or the global logger, with the
+// correlation ID of ctx, if any, as a correlation_id field
+func FromContext(ctx context.Context) *zap.Logger {
+	l, ok := ctx.Value(loggerKey{}).(*zap.Logger)
+	if !ok || l == nil {
+		l = Get()
+	}
+	id, ok := ctx.Value(correlationIDKey{}).(string)
+	if !ok || id == "" {
+		return l
+	}
+	return l.With(zap.String("correlation_id", id))
+}
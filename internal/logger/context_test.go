@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFromContextAddsTheCorrelationID(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	log = zap.New(core)
+	t.Cleanup(func() { log = nil })
+
+	ctx := WithCorrelationID(context.Background(), "order-chain-1")
+	FromContext(ctx).Info("Inventory reserved")
+	FromContext(context.Background()).Info("Consumer started")
+	FromContext(WithCorrelationID(context.Background(), "")).Info("Message without correlation ID")
+
+	entries := logs.All()
+	if len(entries) != 3 {
+		t.Fatalf("logged %d entries, want 3", len(entries))
+	}
+	if id := entries[0].ContextMap()["correlation_id"]; id != "order-chain-1" {
+		t.Errorf("correlation_id %v, want order-chain-1", id)
+	}
+	for _, entry := range entries[1:] {
+		if _, ok := entry.ContextMap()["correlation_id"]; ok {
+			t.Errorf("%q logged with a correlation ID, want none", entry.Message)
+		}
+	}
+}
+
+func TestFromContextUsesTheLoggerOfTheContext(t *testing.T) {
+	global, globalLogs := observer.New(zap.InfoLevel)
+	log = zap.New(global)
+	t.Cleanup(func() { log = nil })
+	injected, injectedLogs := observer.New(zap.InfoLevel)
+
+	ctx := WithCorrelationID(WithLogger(context.Background(), zap.New(injected)), "order-chain-1")
+	FromContext(ctx).Info("Inventory reserved")
+
+	if globalLogs.Len() != 0 || injectedLogs.Len() != 1 {
+		t.Fatalf("logged %d entries globally and %d to the context's logger, want only the latter", globalLogs.Len(), injectedLogs.Len())
+	}
+	if id := injectedLogs.All()[0].ContextMap()["correlation_id"]; id != "order-chain-1" {
+		t.Errorf("correlation_id %v, want order-chain-1", id)
+	}
+}
@@ -4,13 +4,18 @@ import (
 	"fmt"
 
 	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/version"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-var log *zap.Logger
+var (
+	log   *zap.Logger
+	level = zap.NewAtomicLevel() // shared by every logger Initialize builds
+)
 
-// Initialize creates a new logger based on the configuration
+// Initialize creates a new logger based on the configuration. Every entry
+// carries the service version (see version.Get).
 func Initialize(cfg config.LoggerConfig) error {
 	var zapCfg zap.Config
 
@@ -22,20 +27,27 @@ func Initialize(cfg config.LoggerConfig) error {
 	}
 
 	// Set log level
-	level, err := zapcore.ParseLevel(cfg.Level)
-	if err != nil {
-		return fmt.Errorf("invalid log level: %w", err)
+	if err := SetLevel(cfg.Level); err != nil {
+		return err
 	}
-	zapCfg.Level = zap.NewAtomicLevelAt(level)
+	zapCfg.Level = level
 
 	// Set output path
 	if cfg.OutputPath != "" {
 		zapCfg.OutputPaths = []string{cfg.OutputPath}
 	}
 
+	if cfg.Sampling.Initial > 0 {
+		zapCfg.Sampling = &zap.SamplingConfig{
+			Initial:    cfg.Sampling.Initial,
+			Thereafter: cfg.Sampling.Thereafter,
+		}
+	}
+
 	logger, err := zapCfg.Build(
 		zap.AddCaller(),
 		zap.AddStacktrace(zapcore.ErrorLevel),
+		zap.Fields(zap.String("version", version.Get())),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to initialize logger: %w", err)
@@ -45,6 +57,62 @@ func Initialize(cfg config.LoggerConfig) error {
 	return nil
 }
 
+// SetLevel changes the minimum level logged, also at runtime
+func SetLevel(name string) error {
+	l, err := zapcore.ParseLevel(name)
+	if err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+	level.SetLevel(l)
+	return nil
+}
+
+// Level returns the minimum level logged
+func Level() string {
+	return level.Level().String()
+}
+
+// FollowLevel applies the log level of every configuration reloaded from
+// cfg (see config.Config.OnChange)
+func FollowLevel(cfg *config.Config) {
+	cfg.OnChange(func(newCfg *config.Config, err error) {
+		if err != nil {
+			Warn("Ignoring invalid configuration change", zap.Error(err))
+			return
+		}
+		if newCfg.Logger.Level == Level() {
+			return
+		}
+		if err := SetLevel(newCfg.Logger.Level); err != nil {
+			Warn("Ignoring invalid log level", zap.Error(err))
+			return
+		}
+		Info("Log level changed", zap.String("level", newCfg.Logger.Level))
+	})
+}
+
+// Logger is the logging interface of components taking an injected logger,
+// such as kafka.Consumer; *zap.Logger implements it
+type Logger interface {
+	Debug(msg string, fields ...zap.Field)
+	Info(msg string, fields ...zap.Field)
+	Warn(msg string, fields ...zap.Field)
+	Error(msg string, fields ...zap.Field)
+}
+
+// Global returns a Logger writing to the global logger, including after
+// Initialize replaces it. It is the default of injectable loggers.
+func Global() Logger {
+	return globalLogger{}
+}
+
+type globalLogger struct{}
+
+func (globalLogger) Debug(msg string, fields ...zap.Field) { Debug(msg, fields...) }
+func (globalLogger) Info(msg string, fields ...zap.Field)  { Info(msg, fields...) }
+func (globalLogger) Warn(msg string, fields ...zap.Field)  { Warn(msg, fields...) }
+func (globalLogger) Error(msg string, fields ...zap.Field) { Error(msg, fields...) }
+
 // Get returns the global logger instance
 func Get() *zap.Logger {
 	if log == nil {
@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tanint/go-eda/internal/config"
+	"github.com/tanint/go-eda/internal/version"
+	"go.uber.org/zap"
+)
+
+func TestFollowLevelAppliesReloadedLevel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("reload: true\nlogger:\n  level: info\n")
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetLevel(cfg.Logger.Level); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetLevel("info") })
+	FollowLevel(cfg)
+
+	write("reload: true\nlogger:\n  level: debug\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for Level() != "debug" {
+		if time.Now().After(deadline) {
+			t.Fatalf("level %s, want the reloaded debug", Level())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestInitializeStampsTheServiceVersion(t *testing.T) {
+	version.Set("v1.2.3")
+	output := filepath.Join(t.TempDir(), "service.log")
+	if err := Initialize(config.LoggerConfig{Level: "info", OutputPath: output}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log = nil })
+
+	Info("Order created")
+	With(zap.String("order_id", "order-1")).Warn("Order rejected")
+	Sync()
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want 2", len(lines))
+	}
+	for _, line := range lines {
+		var entry struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.Version != "v1.2.3" {
+			t.Errorf("logged %s, want version v1.2.3", line)
+		}
+	}
+}
+
+func TestSetLevelChangesTheInitializedLogger(t *testing.T) {
+	if err := Initialize(config.LoggerConfig{Level: "info", OutputPath: filepath.Join(t.TempDir(), "service.log")}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		log = nil
+		SetLevel("info")
+	})
+	if Get().Core().Enabled(zap.DebugLevel) {
+		t.Fatal("debug enabled at level info")
+	}
+
+	if err := SetLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	if Level() != "debug" || !Get().Core().Enabled(zap.DebugLevel) {
+		t.Errorf("level %s, debug enabled %v; want the change applied to the running logger", Level(), Get().Core().Enabled(zap.DebugLevel))
+	}
+
+	if err := SetLevel("verbose"); err == nil || !strings.Contains(err.Error(), "invalid log level") {
+		t.Errorf("SetLevel(verbose) = %v, want an invalid log level error", err)
+	}
+	if Level() != "debug" {
+		t.Errorf("level %s after an invalid change, want debug kept", Level())
+	}
+}
+
+func TestSamplingDropsRepeatedLines(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "service.log")
+	err := Initialize(config.LoggerConfig{
+		Level:      "info",
+		OutputPath: output,
+		Sampling:   config.SamplingConfig{Initial: 3, Thereafter: 5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { log = nil })
+
+	for i := 0; i < 20; i++ {
+		Info("Message handled", zap.Int("i", i))
+	}
+	Info("Consumer stopped")
+	Sync()
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logged []int
+	stopped := false
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry struct {
+			Msg string `json:"msg"`
+			I   int    `json:"i"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.Msg == "Consumer stopped" {
+			stopped = true
+			continue
+		}
+		logged = append(logged, entry.I)
+	}
+	// The first 3, then every 5th of the rest
+	if want := []int{0, 1, 2, 7, 12, 17}; !slices.Equal(logged, want) {
+		t.Errorf("logged entries %v, want %v", logged, want)
+	}
+	if !stopped {
+		t.Error("dropped a different message sampled on its own")
+	}
+}
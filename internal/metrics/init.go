@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tanint/go-eda/internal/config"
+)
+
+// Metric sinks selectable through configuration
+const (
+	SinkStatsD     = "statsd"
+	SinkDogStatsD  = "dogstatsd"
+	SinkPrometheus = "prometheus"
+)
+
+var (
+	statsd   *StatsDSink
+	promSink *PrometheusSink
+)
+
+// Initialize registers the sinks selected by the configuration. Counters are
+// always kept in memory; an empty sink disables external emission.
+func Initialize(cfg config.MetricsConfig) error {
+	switch cfg.Sink {
+	case "":
+		return nil
+	case SinkStatsD, SinkDogStatsD:
+		sink, err := NewStatsDSink(cfg.StatsD.Address, cfg.StatsD.Prefix, cfg.Sink == SinkDogStatsD)
+		if err != nil {
+			return err
+		}
+		statsd = sink
+		AddSink(sink)
+		return nil
+	case SinkPrometheus:
+		promSink = NewPrometheusSink()
+		AddSink(promSink)
+		return nil
+	default:
+		return fmt.Errorf("invalid metrics sink %q: must be %s, %s or %s", cfg.Sink, SinkStatsD, SinkDogStatsD, SinkPrometheus)
+	}
+}
+
+// Handler serves the metrics for Prometheus to scrape, or is nil unless the
+// prometheus sink is configured
+func Handler() http.Handler {
+	if promSink == nil {
+		return nil
+	}
+	return promSink
+}
+
+// Close releases resources held by the configured sinks
+func Close() error {
+	if statsd == nil {
+		return nil
+	}
+	return statsd.Close()
+}
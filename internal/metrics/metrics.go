@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Counter names
+const (
+	HandlerErrors   = "handler_error"
+	HandlerTimeouts = "handler_timeout"
+	SlowHandlers    = "handler_slow"
+
+	MessagesPublished = "messages_published"
+	PublishErrors     = "publish_error"
+	MessagesConsumed  = "messages_consumed"
+
+	OrderingAnomalies  = "event_ordering_anomaly"
+	QuarantinedEvents  = "event_quarantined"
+	DeadLetteredEvents = "event_dead_lettered"
+	OrdersAbandoned    = "order_confirmation_abandoned"
+)
+
+// Gauge names
+const (
+	ConsumerLag = "consumer_lag"
+)
+
+// Timing names
+const (
+	PublishDuration = "publish_duration"
+	HandlerDuration = "handler_duration"
+
+	OrderConfirmationLatency = "order_confirmation_latency"
+)
+
+// Label is a name/value pair qualifying a metric
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Topic returns a label for the Kafka topic
+func Topic(topic string) Label {
+	return Label{Name: "topic", Value: topic}
+}
+
+// Partition returns a label for the Kafka partition
+func Partition(partition int32) Label {
+	return Label{Name: "partition", Value: strconv.Itoa(int(partition))}
+}
+
+// Sink receives every recorded metric, e.g. to forward it to an external
+// backend. Sinks must be safe for concurrent use and must not block.
+type Sink interface {
+	Count(name string, delta int64, labels []Label)
+	Gauge(name string, value int64, labels []Label)
+	Timing(name string, d time.Duration, labels []Label)
+}
+
+var (
+	mu       sync.Mutex
+	counters = make(map[string]int64)
+	gauges   = make(map[string]int64)
+
+	sinksMu sync.RWMutex
+	sinks   []Sink
+)
+
+// AddSink registers a sink receiving all subsequently recorded metrics.
+// Counters are always kept in memory as well.
+func AddSink(s Sink) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	sinks = append(sinks, s)
+}
+
+func forEachSink(fn func(Sink)) {
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	for _, s := range sinks {
+		fn(s)
+	}
+}
+
+// IncCounter increments the named counter by one
+func IncCounter(name string, labels ...Label) {
+	AddCounter(name, 1, labels...)
+}
+
+// AddCounter adds delta to the named counter
+func AddCounter(name string, delta int64, labels ...Label) {
+	key := seriesKey(name, labels)
+
+	mu.Lock()
+	counters[key] += delta
+	mu.Unlock()
+
+	forEachSink(func(s Sink) { s.Count(name, delta, labels) })
+}
+
+// SetGauge sets the named gauge to value
+func SetGauge(name string, value int64, labels ...Label) {
+	key := seriesKey(name, labels)
+
+	mu.Lock()
+	gauges[key] = value
+	mu.Unlock()
+
+	forEachSink(func(s Sink) { s.Gauge(name, value, labels) })
+}
+
+// GaugeValue returns the last value the named gauge was set to
+func GaugeValue(name string, labels ...Label) int64 {
+	key := seriesKey(name, labels)
+
+	mu.Lock()
+	defer mu.Unlock()
+	return gauges[key]
+}
+
+// RecordTiming records how long an operation took
+func RecordTiming(name string, d time.Duration, labels ...Label) {
+	forEachSink(func(s Sink) { s.Timing(name, d, labels) })
+}
+
+// CounterValue returns the current value of the named counter
+func CounterValue(name string, labels ...Label) int64 {
+	key := seriesKey(name, labels)
+
+	mu.Lock()
+	defer mu.Unlock()
+	return counters[key]
+}
+
+// Snapshot returns a copy of all counters keyed by series
+func Snapshot() map[string]int64 {
+	mu.Lock()
+	defer mu.Unlock()
+
+	snapshot := make(map[string]int64, len(counters))
+	for key, value := range counters {
+		snapshot[key] = value
+	}
+	return snapshot
+}
+
+// seriesKey renders a metric name and its labels as name{a="1",b="2"}
+func seriesKey(name string, labels []Label) string {
+	if len(labels) == 0 {
+		return name
+	}
+
+	sorted := make([]Label, len(labels))
+	copy(sorted, labels)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, l := range sorted {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l.Name)
+		b.WriteString(`="`)
+		b.WriteString(l.Value)
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
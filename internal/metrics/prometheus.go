@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// prometheusBuckets are the histogram upper bounds, in seconds, of timings
+var prometheusBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// PrometheusSink records metrics in Prometheus collectors for scraping:
+// counters as name_total, gauges as name and timings as name_seconds
+// histograms, next to the Go runtime and process collectors. Serve it with
+// Handler.
+//
+// A metric's label names are fixed by its first use; series recorded later
+// with other label names are dropped.
+type PrometheusSink struct {
+	registry *prometheus.Registry
+	handler  http.Handler
+
+	mu         sync.Mutex
+	counters   map[string]*prometheus.CounterVec
+	gauges     map[string]*prometheus.GaugeVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+// NewPrometheusSink creates a sink with a registry of its own
+func NewPrometheusSink() *PrometheusSink {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return &PrometheusSink{
+		registry:   registry,
+		handler:    promhttp.HandlerFor(registry, promhttp.HandlerOpts{}),
+		counters:   make(map[string]*prometheus.CounterVec),
+		gauges:     make(map[string]*prometheus.GaugeVec),
+		histograms: make(map[string]*prometheus.HistogramVec),
+	}
+}
+
+// Registry returns the registry holding the sink's collectors
+func (s *PrometheusSink) Registry() *prometheus.Registry {
+	return s.registry
+}
+
+// Count adds delta to the counter; negative deltas are dropped, since
+// Prometheus counters only go up
+func (s *PrometheusSink) Count(name string, delta int64, labels []Label) {
+	if delta < 0 {
+		return
+	}
+	s.mu.Lock()
+	vec, ok := s.counters[name]
+	if !ok {
+		vec = prometheus.NewCounterVec(prometheus.CounterOpts{Name: name + "_total"}, labelNames(labels))
+		vec = register(s.registry, vec)
+		s.counters[name] = vec
+	}
+	s.mu.Unlock()
+
+	if counter, err := vec.GetMetricWith(labelValues(labels)); err == nil {
+		counter.Add(float64(delta))
+	}
+}
+
+// Gauge sets the gauge to value
+func (s *PrometheusSink) Gauge(name string, value int64, labels []Label) {
+	s.mu.Lock()
+	vec, ok := s.gauges[name]
+	if !ok {
+		vec = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name}, labelNames(labels))
+		vec = register(s.registry, vec)
+		s.gauges[name] = vec
+	}
+	s.mu.Unlock()
+
+	if gauge, err := vec.GetMetricWith(labelValues(labels)); err == nil {
+		gauge.Set(float64(value))
+	}
+}
+
+// Timing observes d in the histogram
+func (s *PrometheusSink) Timing(name string, d time.Duration, labels []Label) {
+	s.mu.Lock()
+	vec, ok := s.histograms[name]
+	if !ok {
+		vec = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name + "_seconds", Buckets: prometheusBuckets}, labelNames(labels))
+		vec = register(s.registry, vec)
+		s.histograms[name] = vec
+	}
+	s.mu.Unlock()
+
+	if histogram, err := vec.GetMetricWith(labelValues(labels)); err == nil {
+		histogram.Observe(d.Seconds())
+	}
+}
+
+// register registers c and returns it, or the collector of the same type
+// registered in its place before. If registration fails otherwise, e.g. for
+// an invalid name, c is returned unregistered: it is recorded but not exposed.
+func register[C prometheus.Collector](registry *prometheus.Registry, c C) C {
+	err := registry.Register(c)
+	if already, ok := err.(prometheus.AlreadyRegisteredError); ok {
+		if existing, ok := already.ExistingCollector.(C); ok {
+			return existing
+		}
+	}
+	return c
+}
+
+// ServeHTTP writes all metrics in the Prometheus exposition format
+func (s *PrometheusSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// labelNames returns the names of labels in order
+func labelNames(labels []Label) []string {
+	names := make([]string, len(labels))
+	for i, l := range sortedLabels(labels) {
+		names[i] = l.Name
+	}
+	return names
+}
+
+// labelValues returns labels as a prometheus.Labels map
+func labelValues(labels []Label) prometheus.Labels {
+	values := make(prometheus.Labels, len(labels))
+	for _, l := range labels {
+		values[l.Name] = l.Value
+	}
+	return values
+}
+
+func sortedLabels(labels []Label) []Label {
+	sorted := make([]Label, len(labels))
+	copy(sorted, labels)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
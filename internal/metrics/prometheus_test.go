@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPrometheusSinkCounters(t *testing.T) {
+	s := NewPrometheusSink()
+	s.Count(MessagesPublished, 1, []Label{Topic("orders")})
+	s.Count(MessagesPublished, 2, []Label{Topic("orders")})
+	s.Count(MessagesPublished, 1, []Label{Topic("payments")})
+	s.Count(MessagesPublished, -1, []Label{Topic("orders")}) // counters never go down
+
+	if got := testutil.ToFloat64(s.counters[MessagesPublished].WithLabelValues("orders")); got != 3 {
+		t.Errorf("orders counter = %v, want 3", got)
+	}
+
+	expected := `
+# HELP messages_published_total
+# TYPE messages_published_total counter
+messages_published_total{topic="orders"} 3
+messages_published_total{topic="payments"} 1
+`
+	if err := testutil.GatherAndCompare(s.Registry(), strings.NewReader(expected), "messages_published_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPrometheusSinkGauges(t *testing.T) {
+	s := NewPrometheusSink()
+	s.Gauge(ConsumerLag, 10, []Label{Topic("orders"), Partition(0)})
+	s.Gauge(ConsumerLag, 4, []Label{Partition(0), Topic("orders")}) // label order does not matter
+	s.Gauge(ConsumerLag, 7, []Label{Topic("orders"), Partition(1)})
+
+	expected := `
+# HELP consumer_lag
+# TYPE consumer_lag gauge
+consumer_lag{partition="0",topic="orders"} 4
+consumer_lag{partition="1",topic="orders"} 7
+`
+	if err := testutil.GatherAndCompare(s.Registry(), strings.NewReader(expected), "consumer_lag"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPrometheusSinkTimings(t *testing.T) {
+	s := NewPrometheusSink()
+	s.Timing(HandlerDuration, 20*time.Millisecond, []Label{Topic("orders")})
+	s.Timing(HandlerDuration, 2*time.Second, []Label{Topic("orders")})
+	s.Timing(HandlerDuration, time.Minute, []Label{Topic("orders")})
+
+	expected := `
+# HELP handler_duration_seconds
+# TYPE handler_duration_seconds histogram
+handler_duration_seconds_bucket{topic="orders",le="0.005"} 0
+handler_duration_seconds_bucket{topic="orders",le="0.01"} 0
+handler_duration_seconds_bucket{topic="orders",le="0.025"} 1
+handler_duration_seconds_bucket{topic="orders",le="0.05"} 1
+handler_duration_seconds_bucket{topic="orders",le="0.1"} 1
+handler_duration_seconds_bucket{topic="orders",le="0.25"} 1
+handler_duration_seconds_bucket{topic="orders",le="0.5"} 1
+handler_duration_seconds_bucket{topic="orders",le="1"} 1
+handler_duration_seconds_bucket{topic="orders",le="2.5"} 2
+handler_duration_seconds_bucket{topic="orders",le="5"} 2
+handler_duration_seconds_bucket{topic="orders",le="10"} 2
+handler_duration_seconds_bucket{topic="orders",le="+Inf"} 3
+handler_duration_seconds_sum{topic="orders"} 62.02
+handler_duration_seconds_count{topic="orders"} 3
+`
+	if err := testutil.GatherAndCompare(s.Registry(), strings.NewReader(expected), "handler_duration_seconds"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPrometheusSinkDropsMismatchedLabelNames(t *testing.T) {
+	s := NewPrometheusSink()
+	s.Count(PublishErrors, 1, []Label{Topic("orders")})
+	s.Count(PublishErrors, 1, []Label{Topic("orders"), Partition(0)})
+	s.Count(PublishErrors, 1, nil)
+
+	if got := testutil.CollectAndCount(s.counters[PublishErrors]); got != 1 {
+		t.Errorf("got %d series, want only the one with the first use's label names", got)
+	}
+	if got := testutil.ToFloat64(s.counters[PublishErrors].WithLabelValues("orders")); got != 1 {
+		t.Errorf("counter = %v, want 1", got)
+	}
+}
+
+func TestPrometheusSinkLintsClean(t *testing.T) {
+	s := NewPrometheusSink()
+	s.Count(MessagesConsumed, 1, []Label{Topic("orders")})
+	s.Gauge(ConsumerLag, 1, []Label{Topic("orders")})
+	s.Timing(PublishDuration, time.Millisecond, []Label{Topic("orders")})
+
+	problems, err := testutil.GatherAndLint(s.Registry(), "messages_consumed_total", "consumer_lag", "publish_duration_seconds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range problems {
+		// The sink's metrics carry no help text
+		if !strings.Contains(p.Text, "no help text") {
+			t.Errorf("%s: %s", p.Metric, p.Text)
+		}
+	}
+}
+
+func TestPrometheusSinkServesMetrics(t *testing.T) {
+	s := NewPrometheusSink()
+	s.Count(MessagesConsumed, 5, []Label{Topic("orders")})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(w.Result().Body)
+
+	for _, want := range []string{
+		`messages_consumed_total{topic="orders"} 5`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("/metrics does not contain %q:\n%s", want, body)
+		}
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want the text format", ct)
+	}
+}
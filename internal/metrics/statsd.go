@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatsDSink emits metrics as StatsD lines over UDP. In DogStatsD mode labels
+// are sent as tags; plain StatsD has no tags, so label values are appended to
+// the metric name instead.
+type StatsDSink struct {
+	conn      net.Conn
+	prefix    string
+	dogStatsD bool
+}
+
+// NewStatsDSink creates a sink sending to the StatsD agent at addr (host:port).
+// A non-empty prefix is prepended to every metric name, separated by a dot.
+func NewStatsDSink(addr, prefix string, dogStatsD bool) (*StatsDSink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to statsd at %s: %w", addr, err)
+	}
+
+	return &StatsDSink{
+		conn:      conn,
+		prefix:    prefix,
+		dogStatsD: dogStatsD,
+	}, nil
+}
+
+// Count emits a counter increment
+func (s *StatsDSink) Count(name string, delta int64, labels []Label) {
+	s.send(name, strconv.FormatInt(delta, 10), "c", labels)
+}
+
+// Gauge emits a gauge value
+func (s *StatsDSink) Gauge(name string, value int64, labels []Label) {
+	s.send(name, strconv.FormatInt(value, 10), "g", labels)
+}
+
+// Timing emits a timing in milliseconds
+func (s *StatsDSink) Timing(name string, d time.Duration, labels []Label) {
+	ms := strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+	s.send(name, ms, "ms", labels)
+}
+
+// Close closes the UDP connection
+func (s *StatsDSink) Close() error {
+	return s.conn.Close()
+}
+
+// send writes a single line; UDP delivery is best effort so errors are dropped
+func (s *StatsDSink) send(name, value, kind string, labels []Label) {
+	_, _ = s.conn.Write([]byte(s.format(name, value, kind, labels)))
+}
+
+// format renders a StatsD line such as prefix.name:1|c|#topic:orders
+func (s *StatsDSink) format(name, value, kind string, labels []Label) string {
+	sorted := make([]Label, len(labels))
+	copy(sorted, labels)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var b strings.Builder
+	if s.prefix != "" {
+		b.WriteString(s.prefix)
+		b.WriteByte('.')
+	}
+	b.WriteString(name)
+	if !s.dogStatsD {
+		for _, l := range sorted {
+			b.WriteByte('.')
+			b.WriteString(sanitizeName(l.Value))
+		}
+	}
+
+	b.WriteByte(':')
+	b.WriteString(value)
+	b.WriteByte('|')
+	b.WriteString(kind)
+
+	if s.dogStatsD && len(sorted) > 0 {
+		b.WriteString("|#")
+		for i, l := range sorted {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(l.Name)
+			b.WriteByte(':')
+			b.WriteString(sanitizeTag(l.Value))
+		}
+	}
+	return b.String()
+}
+
+// Replace characters that are part of the StatsD line syntax
+var (
+	sanitizeName = strings.NewReplacer(":", "_", "|", "_", "@", "_", ".", "_").Replace
+	sanitizeTag  = strings.NewReplacer("|", "_", ",", "_", "#", "_").Replace
+)
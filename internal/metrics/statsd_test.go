@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeStatsD is a UDP server collecting the StatsD lines it receives
+type fakeStatsD struct {
+	conn  net.PacketConn
+	lines chan string
+}
+
+func newFakeStatsD(t *testing.T) *fakeStatsD {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeStatsD{conn: conn, lines: make(chan string, 100)}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			s.lines <- string(buf[:n])
+		}
+	}()
+	return s
+}
+
+// expect fails unless the next lines received are want, in order
+func (s *fakeStatsD) expect(t *testing.T, want ...string) {
+	t.Helper()
+	for _, line := range want {
+		select {
+		case got := <-s.lines:
+			if got != line {
+				t.Errorf("received %q, want %q", got, line)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("did not receive %q", line)
+		}
+	}
+}
+
+func TestStatsDSinkLines(t *testing.T) {
+	labels := []Label{Topic("orders"), Partition(2)}
+	tests := []struct {
+		name      string
+		prefix    string
+		dogStatsD bool
+		want      []string
+	}{
+		{
+			name:      "dogstatsd tags",
+			prefix:    "eda",
+			dogStatsD: true,
+			want: []string{
+				"eda.messages_published:3|c|#partition:2,topic:orders",
+				"eda.consumer_lag:42|g|#partition:2,topic:orders",
+				"eda.publish_duration:1.5|ms|#partition:2,topic:orders",
+			},
+		},
+		{
+			name: "statsd label values in the name",
+			want: []string{
+				"messages_published.2.orders:3|c",
+				"consumer_lag.2.orders:42|g",
+				"publish_duration.2.orders:1.5|ms",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeStatsD(t)
+			sink, err := NewStatsDSink(server.conn.LocalAddr().String(), tt.prefix, tt.dogStatsD)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sink.Close()
+
+			sink.Count(MessagesPublished, 3, labels)
+			sink.Gauge(ConsumerLag, 42, labels)
+			sink.Timing(PublishDuration, 1500*time.Microsecond, labels)
+			server.expect(t, tt.want...)
+		})
+	}
+}
+
+func TestStatsDSinkSanitizesLabelValues(t *testing.T) {
+	server := newFakeStatsD(t)
+	for _, dogStatsD := range []bool{true, false} {
+		sink, err := NewStatsDSink(server.conn.LocalAddr().String(), "", dogStatsD)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sink.Count(MessagesConsumed, 1, []Label{Topic("orders.v1|eu,#1")})
+		sink.Close()
+	}
+	server.expect(t,
+		"messages_consumed:1|c|#topic:orders.v1_eu__1",
+		"messages_consumed.orders_v1_eu,#1:1|c",
+	)
+}
+
+func TestStatsDSinkReceivesRecordedMetrics(t *testing.T) {
+	server := newFakeStatsD(t)
+	sink, err := NewStatsDSink(server.conn.LocalAddr().String(), "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sink.Close() })
+	AddSink(sink)
+
+	IncCounter(MessagesConsumed, Topic("statsd-orders"))
+	SetGauge(ConsumerLag, 5, Topic("statsd-orders"))
+	server.expect(t,
+		"messages_consumed:1|c|#topic:statsd-orders",
+		"consumer_lag:5|g|#topic:statsd-orders",
+	)
+	if got := CounterValue(MessagesConsumed, Topic("statsd-orders")); got != 1 {
+		t.Errorf("in-memory counter = %d, want 1 alongside the sink", got)
+	}
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// CurrencyConverter provides exchange rates between currencies (ISO 4217 codes)
+type CurrencyConverter interface {
+	// Rate returns how many units of to one unit of from is worth
+	Rate(from, to string) (float64, error)
+}
+
+// UnknownCurrencyPairError is returned when no rate is known for a currency pair
+type UnknownCurrencyPairError struct {
+	From string
+	To   string
+}
+
+func (e *UnknownCurrencyPairError) Error() string {
+	return fmt.Sprintf("unknown currency pair %s/%s", e.From, e.To)
+}
+
+// StaticRateConverter converts with a fixed, in-memory rate table
+type StaticRateConverter struct {
+	mu    sync.RWMutex
+	rates map[string]float64
+}
+
+// NewStaticRateConverter creates a converter without any rates
+func NewStaticRateConverter() *StaticRateConverter {
+	return &StaticRateConverter{
+		rates: make(map[string]float64),
+	}
+}
+
+// SetRate sets the rate from one currency to another; the inverse rate is
+// derived unless set explicitly
+func (c *StaticRateConverter) SetRate(from, to string, rate float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rates[pairKey(from, to)] = rate
+}
+
+// Rate returns the rate from one currency to another
+func (c *StaticRateConverter) Rate(from, to string) (float64, error) {
+	if strings.EqualFold(from, to) {
+		return 1, nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if rate, ok := c.rates[pairKey(from, to)]; ok {
+		return rate, nil
+	}
+	if rate, ok := c.rates[pairKey(to, from)]; ok && rate != 0 {
+		return 1 / rate, nil
+	}
+	return 0, &UnknownCurrencyPairError{From: from, To: to}
+}
+
+func pairKey(from, to string) string {
+	return strings.ToUpper(from) + "/" + strings.ToUpper(to)
+}
+
+// ConvertTotal stamps the order total expressed in the base currency, rounded
+// to cents. Orders without a currency are taken to be in the base currency.
+func (o *Order) ConvertTotal(c CurrencyConverter, base string) error {
+	if o.Currency == "" {
+		o.Currency = base
+	}
+
+	rate, err := c.Rate(o.Currency, base)
+	if err != nil {
+		return err
+	}
+
+	o.BaseCurrency = base
+	o.BaseTotalPrice = math.Round(o.TotalPrice*rate*100) / 100
+	return nil
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+// stubRates answers fixed rates keyed by "FROM/TO"
+type stubRates map[string]float64
+
+func (s stubRates) Rate(from, to string) (float64, error) {
+	if rate, ok := s[from+"/"+to]; ok {
+		return rate, nil
+	}
+	return 0, &UnknownCurrencyPairError{From: from, To: to}
+}
+
+func TestConvertTotal(t *testing.T) {
+	rates := stubRates{"EUR/USD": 1.0857, "USD/USD": 1}
+	tests := []struct {
+		name         string
+		currency     string
+		total        float64
+		wantCurrency string
+		wantBase     float64
+		wantErr      bool
+	}{
+		{name: "converted and rounded to cents", currency: "EUR", total: 19.99, wantCurrency: "EUR", wantBase: 21.7},
+		{name: "base currency", currency: "USD", total: 10, wantCurrency: "USD", wantBase: 10},
+		{name: "no currency means the base", total: 10, wantCurrency: "USD", wantBase: 10},
+		{name: "unknown pair", currency: "JPY", total: 1000, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := Order{Currency: tt.currency, TotalPrice: tt.total}
+			err := order.ConvertTotal(rates, "USD")
+			if tt.wantErr {
+				var unknown *UnknownCurrencyPairError
+				if !errors.As(err, &unknown) || unknown.From != tt.currency || unknown.To != "USD" {
+					t.Errorf("ConvertTotal() = %v, want an UnknownCurrencyPairError for %s/USD", err, tt.currency)
+				}
+				if order.BaseCurrency != "" || order.BaseTotalPrice != 0 {
+					t.Errorf("order stamped with %v %s despite the error", order.BaseTotalPrice, order.BaseCurrency)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order.Currency != tt.wantCurrency || order.BaseCurrency != "USD" || order.BaseTotalPrice != tt.wantBase {
+				t.Errorf("order %s %v in base %s %v, want %s %v in base USD %v",
+					order.Currency, order.TotalPrice, order.BaseCurrency, order.BaseTotalPrice, tt.wantCurrency, tt.total, tt.wantBase)
+			}
+			if order.TotalPrice != tt.total {
+				t.Errorf("original total changed to %v", order.TotalPrice)
+			}
+		})
+	}
+}
+
+func TestStaticRateConverter(t *testing.T) {
+	c := NewStaticRateConverter()
+	c.SetRate("EUR", "USD", 1.25)
+
+	tests := []struct {
+		from, to string
+		want     float64
+	}{
+		{"EUR", "USD", 1.25},
+		{"eur", "usd", 1.25},
+		{"USD", "EUR", 0.8}, // derived inverse
+		{"GBP", "gbp", 1},
+	}
+	for _, tt := range tests {
+		if got, err := c.Rate(tt.from, tt.to); err != nil || got != tt.want {
+			t.Errorf("Rate(%s, %s) = %v, %v, want %v", tt.from, tt.to, got, err, tt.want)
+		}
+	}
+	var unknown *UnknownCurrencyPairError
+	if _, err := c.Rate("EUR", "JPY"); !errors.As(err, &unknown) {
+		t.Errorf("Rate(EUR, JPY) = %v, want an UnknownCurrencyPairError", err)
+	}
+}
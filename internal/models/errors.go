@@ -7,8 +7,11 @@ var (
 	ErrInvalidProductID = errors.New("invalid product ID")
 	ErrInvalidQuantity  = errors.New("quantity must be greater than 0")
 	ErrInvalidPrice     = errors.New("price cannot be negative")
+	ErrPriceMismatch    = errors.New("item price does not match the current price")
 	ErrOrderNotFound    = errors.New("order not found")
 
+	ErrInvalidStatusTransition = errors.New("invalid order status transition")
+
 	// Inventory errors
 	ErrInsufficientStock = errors.New("insufficient stock")
 	ErrProductNotFound   = errors.New("product not found")
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,13 +19,17 @@ const (
 
 // Order represents an order in the system
 type Order struct {
-	ID         string      `json:"id"`
-	CustomerID string      `json:"customer_id"`
+	ID         string      `json:"id" validate:"required"`
+	CustomerID string      `json:"customer_id" validate:"required"`
 	Items      []OrderItem `json:"items"`
 	TotalPrice float64     `json:"total_price"`
-	Status     OrderStatus `json:"status"`
-	CreatedAt  time.Time   `json:"created_at"`
-	UpdatedAt  time.Time   `json:"updated_at"`
+	Currency   string      `json:"currency,omitempty"`
+	// BaseTotalPrice is TotalPrice converted to BaseCurrency at creation time
+	BaseCurrency   string      `json:"base_currency,omitempty"`
+	BaseTotalPrice float64     `json:"base_total_price,omitempty"`
+	Status         OrderStatus `json:"status"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
 }
 
 // OrderItem represents an item in an order
@@ -38,6 +43,12 @@ type OrderItem struct {
 type CreateOrderRequest struct {
 	CustomerID string      `json:"customer_id" binding:"required"`
 	Items      []OrderItem `json:"items" binding:"required,min=1,dive"`
+	Currency   string      `json:"currency" binding:"omitempty,len=3"`
+}
+
+// BulkCreateOrderRequest represents the request to import many orders at once
+type BulkCreateOrderRequest struct {
+	Orders []CreateOrderRequest `json:"orders" binding:"required,min=1,max=1000,dive"`
 }
 
 // Validate validates the order item
@@ -60,6 +71,7 @@ func NewOrder(req CreateOrderRequest) (*Order, error) {
 		ID:         uuid.New().String(),
 		CustomerID: req.CustomerID,
 		Items:      req.Items,
+		Currency:   strings.ToUpper(req.Currency),
 		Status:     OrderStatusPending,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
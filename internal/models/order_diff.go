@@ -0,0 +1,79 @@
+package models
+
+// OrderDiff describes what changed between two snapshots of an order
+type OrderDiff struct {
+	Status       *StatusChange     `json:"status,omitempty"`
+	ItemsAdded   []OrderItem       `json:"items_added,omitempty"`
+	ItemsRemoved []OrderItem       `json:"items_removed,omitempty"`
+	ItemsChanged []OrderItemChange `json:"items_changed,omitempty"`
+	TotalDelta   float64           `json:"total_delta"`
+}
+
+// StatusChange describes an order status transition
+type StatusChange struct {
+	From OrderStatus `json:"from"`
+	To   OrderStatus `json:"to"`
+}
+
+// OrderItemChange describes an item present in both snapshots whose quantity or price changed
+type OrderItemChange struct {
+	ProductID   string  `json:"product_id"`
+	OldQuantity int     `json:"old_quantity"`
+	NewQuantity int     `json:"new_quantity"`
+	OldPrice    float64 `json:"old_price"`
+	NewPrice    float64 `json:"new_price"`
+}
+
+// IsEmpty reports whether the diff contains no changes
+func (d OrderDiff) IsEmpty() bool {
+	return d.Status == nil &&
+		len(d.ItemsAdded) == 0 &&
+		len(d.ItemsRemoved) == 0 &&
+		len(d.ItemsChanged) == 0 &&
+		d.TotalDelta == 0
+}
+
+// DiffOrders computes the changes from old to new. Items are matched by product ID.
+func DiffOrders(old, new Order) OrderDiff {
+	diff := OrderDiff{
+		TotalDelta: new.TotalPrice - old.TotalPrice,
+	}
+
+	if old.Status != new.Status {
+		diff.Status = &StatusChange{From: old.Status, To: new.Status}
+	}
+
+	oldItems := make(map[string]OrderItem, len(old.Items))
+	for _, item := range old.Items {
+		oldItems[item.ProductID] = item
+	}
+	newItems := make(map[string]OrderItem, len(new.Items))
+	for _, item := range new.Items {
+		newItems[item.ProductID] = item
+	}
+
+	for _, item := range new.Items {
+		prev, ok := oldItems[item.ProductID]
+		if !ok {
+			diff.ItemsAdded = append(diff.ItemsAdded, item)
+			continue
+		}
+		if prev.Quantity != item.Quantity || prev.Price != item.Price {
+			diff.ItemsChanged = append(diff.ItemsChanged, OrderItemChange{
+				ProductID:   item.ProductID,
+				OldQuantity: prev.Quantity,
+				NewQuantity: item.Quantity,
+				OldPrice:    prev.Price,
+				NewPrice:    item.Price,
+			})
+		}
+	}
+
+	for _, item := range old.Items {
+		if _, ok := newItems[item.ProductID]; !ok {
+			diff.ItemsRemoved = append(diff.ItemsRemoved, item)
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffOrders(t *testing.T) {
+	base := Order{
+		ID:     "order-1",
+		Status: OrderStatusPending,
+		Items: []OrderItem{
+			{ProductID: "p1", Quantity: 1, Price: 10},
+			{ProductID: "p2", Quantity: 2, Price: 5},
+		},
+		TotalPrice: 20,
+	}
+
+	tests := []struct {
+		name   string
+		update func(o *Order)
+		want   OrderDiff
+	}{
+		{
+			name:   "unchanged",
+			update: func(o *Order) {},
+			want:   OrderDiff{},
+		},
+		{
+			name: "item added",
+			update: func(o *Order) {
+				o.Items = append(o.Items, OrderItem{ProductID: "p3", Quantity: 1, Price: 7})
+				o.TotalPrice = 27
+			},
+			want: OrderDiff{
+				ItemsAdded: []OrderItem{{ProductID: "p3", Quantity: 1, Price: 7}},
+				TotalDelta: 7,
+			},
+		},
+		{
+			name: "item removed",
+			update: func(o *Order) {
+				o.Items = o.Items[:1]
+				o.TotalPrice = 10
+			},
+			want: OrderDiff{
+				ItemsRemoved: []OrderItem{{ProductID: "p2", Quantity: 2, Price: 5}},
+				TotalDelta:   -10,
+			},
+		},
+		{
+			name: "quantity changed",
+			update: func(o *Order) {
+				o.Items = []OrderItem{o.Items[0], {ProductID: "p2", Quantity: 4, Price: 5}}
+				o.TotalPrice = 30
+			},
+			want: OrderDiff{
+				ItemsChanged: []OrderItemChange{{ProductID: "p2", OldQuantity: 2, NewQuantity: 4, OldPrice: 5, NewPrice: 5}},
+				TotalDelta:   10,
+			},
+		},
+		{
+			name: "price changed",
+			update: func(o *Order) {
+				o.Items = []OrderItem{{ProductID: "p1", Quantity: 1, Price: 12}, o.Items[1]}
+				o.TotalPrice = 22
+			},
+			want: OrderDiff{
+				ItemsChanged: []OrderItemChange{{ProductID: "p1", OldQuantity: 1, NewQuantity: 1, OldPrice: 10, NewPrice: 12}},
+				TotalDelta:   2,
+			},
+		},
+		{
+			name: "items reordered",
+			update: func(o *Order) {
+				o.Items = []OrderItem{o.Items[1], o.Items[0]}
+			},
+			want: OrderDiff{},
+		},
+		{
+			name: "status transition",
+			update: func(o *Order) {
+				o.Status = OrderStatusConfirmed
+			},
+			want: OrderDiff{
+				Status: &StatusChange{From: OrderStatusPending, To: OrderStatusConfirmed},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := base
+			updated.Items = append([]OrderItem(nil), base.Items...)
+			tt.update(&updated)
+
+			got := DiffOrders(base, updated)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffOrders() = %+v, want %+v", got, tt.want)
+			}
+			if got.IsEmpty() != reflect.DeepEqual(tt.want, OrderDiff{}) {
+				t.Errorf("IsEmpty() = %v for %+v", got.IsEmpty(), got)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// statusTransitions lists the statuses each status may move to
+var statusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusConfirmed, OrderStatusFailed, OrderStatusCancelled},
+	OrderStatusConfirmed: {OrderStatusCancelled},
+}
+
+// StatusTransition records one change of an order's status
+type StatusTransition struct {
+	OrderID string
+	From    OrderStatus
+	To      OrderStatus
+	At      time.Time
+	Actor   string // service or user that made the change
+}
+
+// IsValid reports whether s is a known order status
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusConfirmed, OrderStatusFailed, OrderStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// IsTerminal reports whether an order in this status can no longer change
+func (s OrderStatus) IsTerminal() bool {
+	return len(statusTransitions[s]) == 0
+}
+
+// CanTransitionTo reports whether the order may move to the given status
+func (o *Order) CanTransitionTo(to OrderStatus) bool {
+	for _, allowed := range statusTransitions[o.Status] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo moves the order to the given status on behalf of actor and
+// returns the transition, which callers publish as an order.status_changed
+// event for the audit trail. Disallowed transitions leave the order unchanged.
+func (o *Order) TransitionTo(to OrderStatus, actor string) (StatusTransition, error) {
+	if !o.CanTransitionTo(to) {
+		return StatusTransition{}, fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, o.Status, to)
+	}
+
+	now := time.Now()
+	transition := StatusTransition{
+		OrderID: o.ID,
+		From:    o.Status,
+		To:      to,
+		At:      now,
+		Actor:   actor,
+	}
+	o.Status = to
+	o.UpdatedAt = now
+	return transition, nil
+}
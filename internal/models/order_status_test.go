@@ -0,0 +1,88 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTransitionTo(t *testing.T) {
+	const (
+		pending   = OrderStatusPending
+		confirmed = OrderStatusConfirmed
+		failed    = OrderStatusFailed
+		cancelled = OrderStatusCancelled
+	)
+	tests := []struct {
+		from, to OrderStatus
+		allowed  bool
+	}{
+		{pending, pending, false},
+		{pending, confirmed, true},
+		{pending, failed, true},
+		{pending, cancelled, true},
+		{confirmed, pending, false},
+		{confirmed, confirmed, false},
+		{confirmed, failed, false},
+		{confirmed, cancelled, true},
+		{failed, pending, false},
+		{failed, confirmed, false},
+		{failed, failed, false},
+		{failed, cancelled, false},
+		{cancelled, pending, false},
+		{cancelled, confirmed, false},
+		{cancelled, failed, false},
+		{cancelled, cancelled, false},
+		{pending, "shipped", false},
+		{"shipped", confirmed, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			before := time.Now().Add(-time.Hour)
+			order := &Order{ID: "order-1", Status: tt.from, UpdatedAt: before}
+
+			transition, err := order.TransitionTo(tt.to, "order-service")
+			if !tt.allowed {
+				if !errors.Is(err, ErrInvalidStatusTransition) {
+					t.Fatalf("got %v, want ErrInvalidStatusTransition", err)
+				}
+				if order.Status != tt.from || !order.UpdatedAt.Equal(before) {
+					t.Errorf("rejected transition changed the order to %s at %s", order.Status, order.UpdatedAt)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if order.Status != tt.to || !order.UpdatedAt.After(before) {
+				t.Errorf("order is %s updated at %s, want %s updated now", order.Status, order.UpdatedAt, tt.to)
+			}
+			want := StatusTransition{OrderID: "order-1", From: tt.from, To: tt.to, At: order.UpdatedAt, Actor: "order-service"}
+			if transition != want {
+				t.Errorf("transition %+v, want %+v", transition, want)
+			}
+		})
+	}
+}
+
+func TestStatusProperties(t *testing.T) {
+	tests := []struct {
+		status   OrderStatus
+		valid    bool
+		terminal bool
+	}{
+		{OrderStatusPending, true, false},
+		{OrderStatusConfirmed, true, false},
+		{OrderStatusFailed, true, true},
+		{OrderStatusCancelled, true, true},
+		{"shipped", false, true},
+	}
+	for _, tt := range tests {
+		if got := tt.status.IsValid(); got != tt.valid {
+			t.Errorf("%s.IsValid() = %v, want %v", tt.status, got, tt.valid)
+		}
+		if got := tt.status.IsTerminal(); got != tt.terminal {
+			t.Errorf("%s.IsTerminal() = %v, want %v", tt.status, got, tt.terminal)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"fmt"
+	"math"
+)
+
+// PriceProvider returns the current price of a product, in the currency
+// orders are placed in
+type PriceProvider interface {
+	PriceFor(productID string) (float64, bool)
+}
+
+// NoopPriceProvider knows no prices, so every client-supplied price is accepted
+type NoopPriceProvider struct{}
+
+// PriceFor always reports the price as unknown
+func (NoopPriceProvider) PriceFor(string) (float64, bool) {
+	return 0, false
+}
+
+// priceTolerance absorbs float rounding below a cent
+const priceTolerance = 0.005
+
+// ValidatePrices checks that every item's price matches the provider's.
+// Products the provider has no price for are accepted; rejecting unknown
+// products is ProductValidator's job.
+func (o *Order) ValidatePrices(p PriceProvider) error {
+	for _, item := range o.Items {
+		price, ok := p.PriceFor(item.ProductID)
+		if !ok {
+			continue
+		}
+		if math.Abs(item.Price-price) > priceTolerance {
+			return fmt.Errorf("%w: %s costs %.2f, not %.2f", ErrPriceMismatch, item.ProductID, price, item.Price)
+		}
+	}
+	return nil
+}
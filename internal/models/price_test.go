@@ -0,0 +1,40 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+// staticPrices is a PriceProvider backed by a map
+type staticPrices map[string]float64
+
+func (p staticPrices) PriceFor(productID string) (float64, bool) {
+	price, ok := p[productID]
+	return price, ok
+}
+
+func TestValidatePrices(t *testing.T) {
+	prices := staticPrices{"p1": 10, "p2": 2.5}
+	tests := []struct {
+		name     string
+		provider PriceProvider
+		items    []OrderItem
+		wantErr  bool
+	}{
+		{name: "matching prices", provider: prices, items: []OrderItem{{ProductID: "p1", Price: 10}, {ProductID: "p2", Price: 2.5}}},
+		{name: "rounding below a cent", provider: prices, items: []OrderItem{{ProductID: "p2", Price: 2.504}}},
+		{name: "cheaper than listed", provider: prices, items: []OrderItem{{ProductID: "p1", Price: 10}, {ProductID: "p2", Price: 1}}, wantErr: true},
+		{name: "dearer than listed", provider: prices, items: []OrderItem{{ProductID: "p1", Price: 10.01}}, wantErr: true},
+		{name: "unknown product", provider: prices, items: []OrderItem{{ProductID: "p9", Price: 1}}},
+		{name: "no-op provider", provider: NoopPriceProvider{}, items: []OrderItem{{ProductID: "p1", Price: 0.01}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &Order{Items: tt.items}
+			err := order.ValidatePrices(tt.provider)
+			if tt.wantErr != errors.Is(err, ErrPriceMismatch) || (!tt.wantErr && err != nil) {
+				t.Errorf("got %v, want ErrPriceMismatch: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
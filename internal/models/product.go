@@ -0,0 +1,53 @@
+package models
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ProductValidator reports whether a product exists
+type ProductValidator interface {
+	Exists(productID string) bool
+}
+
+// InMemoryProductValidator validates products against an in-memory catalog
+type InMemoryProductValidator struct {
+	mu       sync.RWMutex
+	products map[string]struct{}
+}
+
+// NewInMemoryProductValidator creates a validator knowing the given products
+func NewInMemoryProductValidator(productIDs ...string) *InMemoryProductValidator {
+	v := &InMemoryProductValidator{
+		products: make(map[string]struct{}, len(productIDs)),
+	}
+	for _, id := range productIDs {
+		v.products[id] = struct{}{}
+	}
+	return v
+}
+
+// Add adds a product to the catalog
+func (v *InMemoryProductValidator) Add(productID string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.products[productID] = struct{}{}
+}
+
+// Exists reports whether the product is in the catalog
+func (v *InMemoryProductValidator) Exists(productID string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	_, ok := v.products[productID]
+	return ok
+}
+
+// ValidateProducts checks that every ordered product exists
+func (o *Order) ValidateProducts(v ProductValidator) error {
+	for _, item := range o.Items {
+		if !v.Exists(item.ProductID) {
+			return fmt.Errorf("%w: %s", ErrProductNotFound, item.ProductID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateProducts(t *testing.T) {
+	catalog := NewInMemoryProductValidator("p1")
+	catalog.Add("p2")
+
+	tests := []struct {
+		name     string
+		products []string
+		wantErr  bool
+	}{
+		{name: "all known", products: []string{"p1", "p2"}},
+		{name: "no items", products: nil},
+		{name: "one unknown", products: []string{"p1", "p3"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &Order{}
+			for _, id := range tt.products {
+				order.Items = append(order.Items, OrderItem{ProductID: id, Quantity: 1})
+			}
+			err := order.ValidateProducts(catalog)
+			if tt.wantErr != errors.Is(err, ErrProductNotFound) || (!tt.wantErr && err != nil) {
+				t.Errorf("got %v, want ErrProductNotFound: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// MemoryOutbox keeps messages in memory. Its transactions are atomic within
+// the process but nothing survives a restart, so it suits tests and
+// deployments where the state changed in the same transaction is in memory too.
+type MemoryOutbox struct {
+	mu      sync.Mutex
+	nextID  int64
+	records []Record // pending, in ID order
+}
+
+// NewMemoryOutbox creates an empty in-memory outbox
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{nextID: 1}
+}
+
+type memoryTx struct {
+	box      *MemoryOutbox
+	messages []Message
+	done     bool
+}
+
+// Begin starts a transaction buffering messages until it commits
+func (o *MemoryOutbox) Begin(ctx context.Context) (Tx, error) {
+	return &memoryTx{box: o}, nil
+}
+
+// Commit makes the transaction's messages pending
+func (tx *memoryTx) Commit() error {
+	if tx.done {
+		return errors.New("transaction already committed or rolled back")
+	}
+	tx.done = true
+
+	o := tx.box
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	now := time.Now()
+	for _, msg := range tx.messages {
+		o.records = append(o.records, Record{ID: o.nextID, Message: msg, CreatedAt: now})
+		o.nextID++
+	}
+	return nil
+}
+
+// Rollback discards the transaction's messages
+func (tx *memoryTx) Rollback() error {
+	if tx.done {
+		return errors.New("transaction already committed or rolled back")
+	}
+	tx.done = true
+	tx.messages = nil
+	return nil
+}
+
+// Enqueue buffers the message in tx
+func (o *MemoryOutbox) Enqueue(ctx context.Context, tx Tx, msg Message) error {
+	mtx, ok := tx.(*memoryTx)
+	if !ok || mtx.box != o {
+		return ErrForeignTx
+	}
+	if mtx.done {
+		return errors.New("transaction already committed or rolled back")
+	}
+	mtx.messages = append(mtx.messages, msg)
+	return nil
+}
+
+// Pending returns up to limit unsent messages, oldest first
+func (o *MemoryOutbox) Pending(ctx context.Context, limit int) ([]Record, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n := min(limit, len(o.records))
+	return append([]Record(nil), o.records[:n]...), nil
+}
+
+// MarkSent removes the message from the outbox
+func (o *MemoryOutbox) MarkSent(ctx context.Context, id int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i, r := range o.records {
+		if r.ID == id {
+			o.records = append(o.records[:i], o.records[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// Package outbox implements the transactional outbox pattern: events are
+// written in the same transaction as the state change they announce and
+// published by a relay afterwards, so a crash between the two loses nothing.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// ErrForeignTx is returned when enqueueing in a transaction of another store
+var ErrForeignTx = errors.New("transaction does not belong to this outbox")
+
+// Tx is a transaction of an outbox store; *sql.Tx satisfies it
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// Message is an event to publish to Kafka
+type Message struct {
+	Topic string
+	// PartitionKey picks the partition independently of Key, see
+	// kafka.Producer.PublishEventWithPartitionKey; empty partitions by Key
+	PartitionKey string
+	Key          []byte
+	Event        *events.Event
+}
+
+// Record is a message waiting in the outbox
+type Record struct {
+	ID int64 // increasing in enqueue order
+	Message
+	CreatedAt time.Time
+}
+
+// Outbox stores messages until the relay has published them
+type Outbox interface {
+	// Begin starts a transaction to write the state change and its messages in
+	Begin(ctx context.Context) (Tx, error)
+	// Enqueue adds a message within tx; it becomes pending once tx commits
+	Enqueue(ctx context.Context, tx Tx, msg Message) error
+	// Pending returns up to limit unsent messages, oldest first
+	Pending(ctx context.Context, limit int) ([]Record, error)
+	// MarkSent records that the message was published
+	MarkSent(ctx context.Context, id int64) error
+}
@@ -0,0 +1,90 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/tanint/go-eda/internal/kafka"
+	"github.com/tanint/go-eda/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Relay publishes the outbox's pending messages. Each message is marked sent
+// only after Kafka acknowledged it, so a relay stopped at any point (even
+// mid-batch) resends at most the messages whose marking was lost: delivery
+// is at-least-once and consumers deduplicate by event ID.
+type Relay struct {
+	outbox    Outbox
+	publisher kafka.Publisher
+	interval  time.Duration
+	batchSize int
+
+	newTicker func(d time.Duration) (<-chan time.Time, func())
+}
+
+// defaultBatchSize is the relay batch size when none is configured
+const defaultBatchSize = 100
+
+// NewRelay creates a relay polling the outbox every interval and publishing
+// up to batchSize messages per query; batchSize 0 means 100
+func NewRelay(outbox Outbox, publisher kafka.Publisher, interval time.Duration, batchSize int) *Relay {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	return &Relay{
+		outbox:    outbox,
+		publisher: publisher,
+		interval:  interval,
+		batchSize: batchSize,
+		newTicker: func(d time.Duration) (<-chan time.Time, func()) {
+			t := time.NewTicker(d)
+			return t.C, t.Stop
+		},
+	}
+}
+
+// Run relays pending messages every interval until ctx is done. Failures are
+// logged and retried on the next tick.
+func (r *Relay) Run(ctx context.Context) {
+	ticks, stop := r.newTicker(r.interval)
+	defer stop()
+
+	logger.Info("Outbox relay started",
+		zap.Duration("interval", r.interval),
+		zap.Int("batch_size", r.batchSize),
+	)
+
+	for {
+		if err := r.Flush(ctx); err != nil && ctx.Err() == nil {
+			logger.Warn("Failed to relay outbox messages", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			logger.Info("Outbox relay stopped")
+			return
+		case <-ticks:
+		}
+	}
+}
+
+// Flush publishes pending messages in enqueue order until none are left.
+// It stops at the first failure so messages are never published out of order.
+func (r *Relay) Flush(ctx context.Context) error {
+	for {
+		records, err := r.outbox.Pending(ctx, r.batchSize)
+		if err != nil {
+			return err
+		}
+		for _, rec := range records {
+			if err := r.publisher.PublishEventWithPartitionKey(ctx, rec.Topic, rec.PartitionKey, rec.Key, rec.Event); err != nil {
+				return err
+			}
+			if err := r.outbox.MarkSent(ctx, rec.ID); err != nil {
+				return err
+			}
+		}
+		if len(records) < r.batchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tanint/go-eda/internal/kafka"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// publisher records published event IDs, failing every publish after the
+// first failAfter ones when failAfter is not negative
+type publisher struct {
+	ids       []string
+	failAfter int
+}
+
+var _ kafka.Publisher = (*publisher)(nil)
+
+func (p *publisher) Publish(ctx context.Context, topic string, key, value []byte) error {
+	return errors.New("publisher: raw publish not supported")
+}
+
+func (p *publisher) PublishEvent(ctx context.Context, topic string, key []byte, event *events.Event) error {
+	return p.PublishEventWithPartitionKey(ctx, topic, "", key, event)
+}
+
+func (p *publisher) PublishEventWithPartitionKey(ctx context.Context, topic, partitionKey string, key []byte, event *events.Event) error {
+	if p.failAfter >= 0 && len(p.ids) >= p.failAfter {
+		return errors.New("broker unavailable")
+	}
+	p.ids = append(p.ids, event.ID)
+	return nil
+}
+
+// openSQLOutbox opens the SQLite outbox at path, as a restarted service would
+func openSQLOutbox(t *testing.T, path string) (*SQLOutbox, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := NewSQLOutbox(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o, db
+}
+
+// enqueue writes one message per ID in its own transaction, committing it
+// unless rollback is set
+func enqueue(t *testing.T, o Outbox, rollback bool, ids ...string) {
+	t.Helper()
+	ctx := context.Background()
+	for _, id := range ids {
+		tx, err := o.Begin(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		event := &events.Event{ID: id, Type: events.EventTypeOrderCreated, Data: map[string]interface{}{}}
+		if err := o.Enqueue(ctx, tx, Message{Topic: "orders", Key: []byte(id), Event: event}); err != nil {
+			t.Fatal(err)
+		}
+		if rollback {
+			err = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRelayResumesAfterRestartWithoutLossOrResending(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.db")
+	o, db := openSQLOutbox(t, path)
+	var ids []string
+	for i := 0; i < 10; i++ {
+		ids = append(ids, fmt.Sprintf("event-%d", i))
+	}
+	enqueue(t, o, false, ids...)
+	enqueue(t, o, true, "rolled-back")
+
+	// The first relay stops in its second batch, when Kafka becomes unavailable
+	first := &publisher{failAfter: 6}
+	if err := NewRelay(o, first, 0, 4).Flush(context.Background()); err == nil {
+		t.Fatal("relay reported no error for the failed publish")
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	o, db = openSQLOutbox(t, path)
+	defer db.Close()
+	second := &publisher{failAfter: -1}
+	if err := NewRelay(o, second, 0, 4).Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	published := append(first.ids, second.ids...)
+	if fmt.Sprint(published) != fmt.Sprint(ids) {
+		t.Errorf("published %v, want each committed event once, in order: %v", published, ids)
+	}
+	if pending, err := o.Pending(context.Background(), 100); err != nil || len(pending) != 0 {
+		t.Errorf("%d messages still pending (err %v), want none", len(pending), err)
+	}
+}
+
+func TestRelayResendsMessagesWhoseMarkingWasLost(t *testing.T) {
+	o := NewMemoryOutbox()
+	enqueue(t, o, false, "event-1", "event-2")
+
+	// Published but never marked sent, as when the relay stops in between
+	lost := &markFailingOutbox{Outbox: o, failID: 2}
+	first := &publisher{failAfter: -1}
+	if err := NewRelay(lost, first, 0, 10).Flush(context.Background()); err == nil {
+		t.Fatal("relay reported no error for the failed marking")
+	}
+
+	second := &publisher{failAfter: -1}
+	if err := NewRelay(o, second, 0, 10).Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(first.ids, second.ids) != "[event-1 event-2] [event-2]" {
+		t.Errorf("published %v then %v, want only event-2 resent", first.ids, second.ids)
+	}
+}
+
+// markFailingOutbox fails marking the message with ID failID sent
+type markFailingOutbox struct {
+	Outbox
+	failID int64
+}
+
+func (o *markFailingOutbox) MarkSent(ctx context.Context, id int64) error {
+	if id == o.failID {
+		return errors.New("database unavailable")
+	}
+	return o.Outbox.MarkSent(ctx, id)
+}
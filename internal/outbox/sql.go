@@ -0,0 +1,96 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS outbox (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	topic         TEXT NOT NULL,
+	partition_key TEXT NOT NULL,
+	message_key   BLOB,
+	event         BLOB NOT NULL,
+	created_at    TIMESTAMP NOT NULL,
+	sent_at       TIMESTAMP
+)`
+
+// SQLOutbox stores messages in an outbox table, written in the caller's
+// database transaction. Queries use SQLite syntax.
+type SQLOutbox struct {
+	db *sql.DB
+}
+
+// NewSQLOutbox creates the outbox, creating its table if needed. The caller
+// opens db with a SQLite driver.
+func NewSQLOutbox(ctx context.Context, db *sql.DB) (*SQLOutbox, error) {
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		return nil, fmt.Errorf("failed to create outbox table: %w", err)
+	}
+	return &SQLOutbox{db: db}, nil
+}
+
+// Begin starts a database transaction; write the state change with it
+// (tx.(*sql.Tx)) before committing
+func (o *SQLOutbox) Begin(ctx context.Context) (Tx, error) {
+	return o.db.BeginTx(ctx, nil)
+}
+
+// Enqueue inserts the message in tx, which must be a *sql.Tx of the outbox's database
+func (o *SQLOutbox) Enqueue(ctx context.Context, tx Tx, msg Message) error {
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return ErrForeignTx
+	}
+	event, err := msg.Event.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	_, err = sqlTx.ExecContext(ctx, `
+		INSERT INTO outbox (topic, partition_key, message_key, event, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		msg.Topic, msg.PartitionKey, msg.Key, event, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue outbox message: %w", err)
+	}
+	return nil
+}
+
+// Pending returns up to limit unsent messages, oldest first
+func (o *SQLOutbox) Pending(ctx context.Context, limit int) ([]Record, error) {
+	rows, err := o.db.QueryContext(ctx, `
+		SELECT id, topic, partition_key, message_key, event, created_at
+		FROM outbox WHERE sent_at IS NULL ORDER BY id LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var r Record
+		var event []byte
+		if err := rows.Scan(&r.ID, &r.Topic, &r.PartitionKey, &r.Key, &event, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		if r.Event, err = events.UnmarshalEvent(event); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox message %d: %w", r.ID, err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// MarkSent stamps the message's sent time; sent messages are kept for auditing
+func (o *SQLOutbox) MarkSent(ctx context.Context, id int64) error {
+	if _, err := o.db.ExecContext(ctx, `UPDATE outbox SET sent_at = ? WHERE id = ?`, time.Now().UTC(), id); err != nil {
+		return fmt.Errorf("failed to mark outbox message %d sent: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package projection
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+const auditSchema = `
+CREATE TABLE IF NOT EXISTS order_status_audit (
+	event_id    TEXT PRIMARY KEY,
+	order_id    TEXT NOT NULL,
+	from_status TEXT NOT NULL,
+	to_status   TEXT NOT NULL,
+	actor       TEXT NOT NULL,
+	changed_at  TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS order_status_audit_order ON order_status_audit (order_id, changed_at)`
+
+// AuditRow is one recorded order status transition
+type AuditRow struct {
+	EventID   string             `json:"event_id"`
+	OrderID   string             `json:"order_id"`
+	From      models.OrderStatus `json:"from"`
+	To        models.OrderStatus `json:"to"`
+	Actor     string             `json:"actor"`
+	ChangedAt time.Time          `json:"changed_at"`
+}
+
+// AuditProjection records order.status_changed events in an append-only
+// SQLite table. Rows are keyed by event ID, so redeliveries are recorded once
+// and existing rows are never updated.
+type AuditProjection struct {
+	db *sql.DB
+}
+
+// NewAuditProjection creates the projection, creating its table if needed
+func NewAuditProjection(ctx context.Context, db *sql.DB) (*AuditProjection, error) {
+	if _, err := db.ExecContext(ctx, auditSchema); err != nil {
+		return nil, fmt.Errorf("failed to create order status audit table: %w", err)
+	}
+	return &AuditProjection{db: db}, nil
+}
+
+// Handle records an order.status_changed event; register it with kafka.RegisterHandlerT
+func (p *AuditProjection) Handle(ctx context.Context, event *events.Event, changed events.OrderStatusChangedEvent) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO order_status_audit (event_id, order_id, from_status, to_status, actor, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(event_id) DO NOTHING`,
+		event.ID, changed.OrderID, string(changed.From), string(changed.To), changed.Actor, changed.ChangedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record status change of order %s: %w", changed.OrderID, err)
+	}
+	return nil
+}
+
+// History returns the recorded status transitions of an order, oldest first
+func (p *AuditProjection) History(ctx context.Context, orderID string) ([]AuditRow, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT event_id, order_id, from_status, to_status, actor, changed_at
+		FROM order_status_audit WHERE order_id = ?
+		ORDER BY changed_at`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status history of order %s: %w", orderID, err)
+	}
+	defer rows.Close()
+
+	history := []AuditRow{}
+	for rows.Next() {
+		var r AuditRow
+		if err := rows.Scan(&r.EventID, &r.OrderID, &r.From, &r.To, &r.Actor, &r.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status change: %w", err)
+		}
+		history = append(history, r)
+	}
+	return history, rows.Err()
+}
@@ -0,0 +1,63 @@
+package projection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+func TestAuditProjectionRecordsEachTransitionOnce(t *testing.T) {
+	ctx := context.Background()
+	p, err := NewAuditProjection(ctx, openTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	transitions := []models.StatusTransition{
+		{OrderID: "order-1", From: models.OrderStatusPending, To: models.OrderStatusConfirmed, At: base, Actor: "inventory-service"},
+		{OrderID: "order-2", From: models.OrderStatusPending, To: models.OrderStatusFailed, At: base.Add(time.Second), Actor: "inventory-service"},
+		{OrderID: "order-1", From: models.OrderStatusConfirmed, To: models.OrderStatusCancelled, At: base.Add(2 * time.Second), Actor: "order-service"},
+	}
+	var recorded []*events.Event
+	for _, transition := range transitions {
+		event := events.NewOrderStatusChangedEvent(nil, transition)
+		changed, err := events.DecodeData[events.OrderStatusChangedEvent](event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Redeliveries of the same event are recorded once
+		for i := 0; i < 2; i++ {
+			if err := p.Handle(ctx, event, changed); err != nil {
+				t.Fatal(err)
+			}
+		}
+		recorded = append(recorded, event)
+	}
+
+	history, err := p.History(ctx, "order-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AuditRow{
+		{EventID: recorded[0].ID, OrderID: "order-1", From: models.OrderStatusPending, To: models.OrderStatusConfirmed, Actor: "inventory-service", ChangedAt: base},
+		{EventID: recorded[2].ID, OrderID: "order-1", From: models.OrderStatusConfirmed, To: models.OrderStatusCancelled, Actor: "order-service", ChangedAt: base.Add(2 * time.Second)},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("history %+v, want %d transitions", history, len(want))
+	}
+	for i := range want {
+		got := history[i]
+		if got.EventID != want[i].EventID || got.From != want[i].From || got.To != want[i].To ||
+			got.Actor != want[i].Actor || !got.ChangedAt.Equal(want[i].ChangedAt) {
+			t.Errorf("transition %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	if history, err := p.History(ctx, "order-3"); err != nil || len(history) != 0 {
+		t.Errorf("History(order-3) = %v, %v, want an empty history", history, err)
+	}
+}
@@ -0,0 +1,212 @@
+package projection
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tanint/go-eda/internal/kafka"
+	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS orders (
+	order_id    TEXT PRIMARY KEY,
+	customer_id TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	total       REAL NOT NULL,
+	updated_at  TIMESTAMP NOT NULL
+)`
+
+// OrderRow is the projected state of an order
+type OrderRow struct {
+	OrderID    string             `json:"order_id"`
+	CustomerID string             `json:"customer_id"`
+	Status     models.OrderStatus `json:"status"`
+	Total      float64            `json:"total"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+// OrderProjection materializes order.* events into a SQLite orders table,
+// giving a queryable read model without a separate database server
+type OrderProjection struct {
+	db *sql.DB
+}
+
+// NewOrderProjection creates the projection, creating its table if needed.
+// The caller opens db with a SQLite driver.
+func NewOrderProjection(ctx context.Context, db *sql.DB) (*OrderProjection, error) {
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		return nil, fmt.Errorf("failed to create orders table: %w", err)
+	}
+	return &OrderProjection{db: db}, nil
+}
+
+// Handle is a message handler applying order events to the projection
+func (p *OrderProjection) Handle(ctx context.Context, msg *kafka.Message) error {
+	event, err := events.UnmarshalEvent(msg.Value)
+	if err != nil {
+		logger.Error("Failed to unmarshal event",
+			zap.Error(err),
+		)
+		return err
+	}
+	return p.Apply(ctx, event)
+}
+
+// Apply applies a single event. Events older than the projected row are ignored,
+// so replays and redeliveries don't move an order back in time.
+func (p *OrderProjection) Apply(ctx context.Context, event *events.Event) error {
+	switch event.Type {
+	case events.EventTypeOrderCreated:
+		var payload events.OrderCreatedEvent
+		if err := decodeData(event, &payload); err != nil {
+			return err
+		}
+		return p.upsert(ctx, payload.Order, event.Timestamp)
+
+	case events.EventTypeOrderCreatedChunk:
+		// Every chunk carries the full order header
+		var payload events.OrderCreatedChunkEvent
+		if err := decodeData(event, &payload); err != nil {
+			return err
+		}
+		return p.upsert(ctx, payload.Order, event.Timestamp)
+
+	case events.EventTypeOrderUpdated:
+		var payload events.OrderUpdatedEvent
+		if err := decodeData(event, &payload); err != nil {
+			return err
+		}
+		return p.upsert(ctx, payload.Order, event.Timestamp)
+
+	case events.EventTypeOrderConfirmed:
+		var payload events.OrderConfirmedEvent
+		if err := decodeData(event, &payload); err != nil {
+			return err
+		}
+		return p.updateStatus(ctx, payload.OrderID, models.OrderStatusConfirmed, event.Timestamp)
+
+	case events.EventTypeOrderRejected:
+		var payload events.OrderRejectedEvent
+		if err := decodeData(event, &payload); err != nil {
+			return err
+		}
+		return p.updateStatus(ctx, payload.OrderID, models.OrderStatusFailed, event.Timestamp)
+
+	default:
+		logger.Debug("Ignoring event not relevant to the order projection",
+			zap.String("type", string(event.Type)),
+		)
+		return nil
+	}
+}
+
+func (p *OrderProjection) upsert(ctx context.Context, order models.Order, at time.Time) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO orders (order_id, customer_id, status, total, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(order_id) DO UPDATE SET
+			customer_id = excluded.customer_id,
+			status      = excluded.status,
+			total       = excluded.total,
+			updated_at  = excluded.updated_at
+		WHERE excluded.updated_at >= orders.updated_at`,
+		order.ID, order.CustomerID, string(order.Status), order.TotalPrice, at.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to project order %s: %w", order.ID, err)
+	}
+	return nil
+}
+
+func (p *OrderProjection) updateStatus(ctx context.Context, orderID string, status models.OrderStatus, at time.Time) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE orders SET status = ?, updated_at = ?
+		WHERE order_id = ? AND updated_at <= ?`,
+		string(status), at.UTC(), orderID, at.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to project status of order %s: %w", orderID, err)
+	}
+	return nil
+}
+
+// Get returns the projected order, or models.ErrOrderNotFound
+func (p *OrderProjection) Get(ctx context.Context, orderID string) (*OrderRow, error) {
+	row := p.db.QueryRowContext(ctx, `
+		SELECT order_id, customer_id, status, total, updated_at
+		FROM orders WHERE order_id = ?`, orderID)
+
+	var o OrderRow
+	if err := row.Scan(&o.OrderID, &o.CustomerID, &o.Status, &o.Total, &o.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to query order %s: %w", orderID, err)
+	}
+	return &o, nil
+}
+
+// List returns projected orders, optionally filtered by customer, most recently updated first
+func (p *OrderProjection) List(ctx context.Context, customerID string) ([]OrderRow, error) {
+	query := `SELECT order_id, customer_id, status, total, updated_at FROM orders`
+	var args []interface{}
+	if customerID != "" {
+		query += ` WHERE customer_id = ?`
+		args = append(args, customerID)
+	}
+	query += ` ORDER BY updated_at DESC`
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []OrderRow{}
+	for rows.Next() {
+		var o OrderRow
+		if err := rows.Scan(&o.OrderID, &o.CustomerID, &o.Status, &o.Total, &o.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+// Snapshot returns the orders worth republishing as state: every order that
+// can still change, plus terminal orders updated since the given time
+func (p *OrderProjection) Snapshot(ctx context.Context, terminalSince time.Time) ([]OrderRow, error) {
+	all, err := p.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	orders := all[:0]
+	for _, o := range all {
+		if o.Status.IsTerminal() && o.UpdatedAt.Before(terminalSince) {
+			continue
+		}
+		orders = append(orders, o)
+	}
+	return orders, nil
+}
+
+// decodeData decodes the event payload into v
+func decodeData(event *events.Event, v interface{}) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event data: %w", event.Type, err)
+	}
+	if err := events.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal %s event data: %w", event.Type, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package projection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// openTestDB opens a SQLite database in a temporary file
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "projection.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// eventAt returns an event of type with data, timestamped at seconds past a fixed base
+func eventAt(eventType events.EventType, data interface{}, seconds int) *events.Event {
+	event := events.NewEvent(eventType, data)
+	event.Timestamp = time.Date(2026, 1, 1, 0, 0, seconds, 0, time.UTC)
+	return event
+}
+
+func TestOrderProjectionAppliesEvents(t *testing.T) {
+	ctx := context.Background()
+	p, err := NewOrderProjection(ctx, openTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order1 := models.Order{ID: "order-1", CustomerID: "customer-1", TotalPrice: 20, Status: models.OrderStatusPending}
+	order2 := models.Order{ID: "order-2", CustomerID: "customer-1", TotalPrice: 5, Status: models.OrderStatusPending}
+	order3 := models.Order{ID: "order-3", CustomerID: "customer-2", TotalPrice: 7, Status: models.OrderStatusPending}
+	updated1 := order1
+	updated1.TotalPrice = 30
+
+	for _, event := range []*events.Event{
+		eventAt(events.EventTypeOrderCreated, events.OrderCreatedEvent{Order: order1}, 0),
+		eventAt(events.EventTypeOrderCreated, events.OrderCreatedEvent{Order: order2}, 1),
+		eventAt(events.EventTypeOrderCreated, events.OrderCreatedEvent{Order: order3}, 2),
+		eventAt(events.EventTypeOrderUpdated, events.OrderUpdatedEvent{Order: updated1}, 3),
+		eventAt(events.EventTypeOrderConfirmed, events.OrderConfirmedEvent{OrderID: "order-1", CustomerID: "customer-1"}, 4),
+		eventAt(events.EventTypeOrderRejected, events.OrderRejectedEvent{OrderID: "order-2", CustomerID: "customer-1", Reason: "out of stock"}, 5),
+		// Redelivered out of order: older than the projected rows, so ignored
+		eventAt(events.EventTypeOrderCreated, events.OrderCreatedEvent{Order: order1}, 0),
+		eventAt(events.EventTypeOrderConfirmed, events.OrderConfirmedEvent{OrderID: "order-2", CustomerID: "customer-1"}, 4),
+		eventAt(events.EventTypeInventoryReserved, events.InventoryReservedEvent{OrderID: "order-3"}, 6),
+	} {
+		if err := p.Apply(ctx, event); err != nil {
+			t.Fatalf("applying %s: %v", event.Type, err)
+		}
+	}
+
+	at := func(seconds int) time.Time { return time.Date(2026, 1, 1, 0, 0, seconds, 0, time.UTC) }
+	want := []OrderRow{
+		{OrderID: "order-2", CustomerID: "customer-1", Status: models.OrderStatusFailed, Total: 5, UpdatedAt: at(5)},
+		{OrderID: "order-1", CustomerID: "customer-1", Status: models.OrderStatusConfirmed, Total: 30, UpdatedAt: at(4)},
+		{OrderID: "order-3", CustomerID: "customer-2", Status: models.OrderStatusPending, Total: 7, UpdatedAt: at(2)},
+	}
+	rows, err := p.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, want)
+
+	rows, err = p.List(ctx, "customer-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, rows, want[:2])
+
+	row, err := p.Get(ctx, "order-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRows(t, []OrderRow{*row}, want[2:])
+
+	if _, err := p.Get(ctx, "order-9"); !errors.Is(err, models.ErrOrderNotFound) {
+		t.Errorf("Get() of an unknown order = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func assertRows(t *testing.T, got, want []OrderRow) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows %+v, want %+v", len(got), got, want)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.OrderID != w.OrderID || g.CustomerID != w.CustomerID || g.Status != w.Status || g.Total != w.Total || !g.UpdatedAt.Equal(w.UpdatedAt) {
+			t.Errorf("row %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestOrderProjectionSnapshotSkipsOldTerminalOrders(t *testing.T) {
+	ctx := context.Background()
+	p, err := NewOrderProjection(ctx, openTestDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, event := range []*events.Event{
+		eventAt(events.EventTypeOrderCreated, events.OrderCreatedEvent{Order: models.Order{ID: "order-1", CustomerID: "customer-1", Status: models.OrderStatusPending}}, 0),
+		eventAt(events.EventTypeOrderCreated, events.OrderCreatedEvent{Order: models.Order{ID: "order-2", CustomerID: "customer-1", Status: models.OrderStatusPending}}, 1),
+		eventAt(events.EventTypeOrderCreated, events.OrderCreatedEvent{Order: models.Order{ID: "order-3", CustomerID: "customer-1", Status: models.OrderStatusPending}}, 2),
+		eventAt(events.EventTypeOrderRejected, events.OrderRejectedEvent{OrderID: "order-2", CustomerID: "customer-1", Reason: "out of stock"}, 3),
+		eventAt(events.EventTypeOrderRejected, events.OrderRejectedEvent{OrderID: "order-3", CustomerID: "customer-1", Reason: "out of stock"}, 10),
+	} {
+		if err := p.Apply(ctx, event); err != nil {
+			t.Fatalf("applying %s: %v", event.Type, err)
+		}
+	}
+
+	// order-1 is still pending however old; order-2 failed before the cutoff
+	rows, err := p.Snapshot(ctx, time.Date(2026, 1, 1, 0, 0, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, row := range rows {
+		ids = append(ids, row.OrderID)
+	}
+	if want := []string{"order-3", "order-1"}; !slices.Equal(ids, want) {
+		t.Errorf("snapshot of %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,104 @@
+package snapshot
+
+import (
+	"context"
+	"time"
+
+	"github.com/tanint/go-eda/internal/kafka"
+	"github.com/tanint/go-eda/internal/logger"
+	"github.com/tanint/go-eda/internal/projection"
+	"github.com/tanint/go-eda/pkg/events"
+	"go.uber.org/zap"
+)
+
+// OrderSource provides the orders to snapshot, see projection.OrderProjection.Snapshot
+type OrderSource interface {
+	Snapshot(ctx context.Context, terminalSince time.Time) ([]projection.OrderRow, error)
+}
+
+// Snapshotter periodically republishes the current state of orders to the
+// compacted order state topic, keyed by order ID, so consumers bootstrapping
+// from that topic also see orders that have been idle for a long time.
+// Terminal orders are only republished until they are older than the retention.
+type Snapshotter struct {
+	source    OrderSource
+	publisher kafka.Publisher
+	topic     string
+	interval  time.Duration
+	retention time.Duration
+
+	now       func() time.Time
+	newTicker func(d time.Duration) (<-chan time.Time, func())
+}
+
+// NewSnapshotter creates a snapshotter publishing every interval
+func NewSnapshotter(source OrderSource, publisher kafka.Publisher, topic string, interval, retention time.Duration) *Snapshotter {
+	return &Snapshotter{
+		source:    source,
+		publisher: publisher,
+		topic:     topic,
+		interval:  interval,
+		retention: retention,
+		now:       time.Now,
+		newTicker: func(d time.Duration) (<-chan time.Time, func()) {
+			t := time.NewTicker(d)
+			return t.C, t.Stop
+		},
+	}
+}
+
+// Run publishes a snapshot every interval until ctx is done. Failures are
+// logged and retried on the next tick.
+func (s *Snapshotter) Run(ctx context.Context) {
+	ticks, stop := s.newTicker(s.interval)
+	defer stop()
+
+	logger.Info("Order state snapshotter started",
+		zap.String("topic", s.topic),
+		zap.Duration("interval", s.interval),
+		zap.Duration("retention", s.retention),
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Order state snapshotter stopped")
+			return
+		case <-ticks:
+			s.snapshot(ctx)
+		}
+	}
+}
+
+func (s *Snapshotter) snapshot(ctx context.Context) {
+	orders, err := s.source.Snapshot(ctx, s.now().Add(-s.retention))
+	if err != nil {
+		logger.Warn("Failed to load orders to snapshot", zap.Error(err))
+		return
+	}
+
+	published := 0
+	for _, o := range orders {
+		event := events.NewEvent(events.EventTypeOrderState, events.OrderStateEvent{
+			OrderID:    o.OrderID,
+			CustomerID: o.CustomerID,
+			Status:     o.Status,
+			Total:      o.Total,
+			UpdatedAt:  o.UpdatedAt,
+		})
+		if err := s.publisher.PublishEvent(ctx, s.topic, []byte(o.OrderID), event); err != nil {
+			logger.Warn("Failed to publish order state",
+				zap.Error(err),
+				zap.String("topic", s.topic),
+				zap.String("order_id", o.OrderID),
+			)
+			continue
+		}
+		published++
+	}
+
+	logger.Debug("Published order state snapshot",
+		zap.Int("orders", published),
+		zap.Int("failed", len(orders)-published),
+	)
+}
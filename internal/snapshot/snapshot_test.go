@@ -0,0 +1,122 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/internal/projection"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// orderStub serves fixed orders, applying the terminal cutoff like the projection
+type orderStub struct {
+	orders []projection.OrderRow
+}
+
+func (s *orderStub) Snapshot(ctx context.Context, terminalSince time.Time) ([]projection.OrderRow, error) {
+	var orders []projection.OrderRow
+	for _, o := range s.orders {
+		if !o.Status.IsTerminal() || !o.UpdatedAt.Before(terminalSince) {
+			orders = append(orders, o)
+		}
+	}
+	return orders, nil
+}
+
+// statePublished is an event published by the snapshotter
+type statePublished struct {
+	topic, key string
+	state      events.OrderStateEvent
+}
+
+// stateRecorder passes every published order state to published
+type stateRecorder struct {
+	published chan statePublished
+}
+
+func (r *stateRecorder) Publish(ctx context.Context, topic string, key, value []byte) error {
+	return errors.New("stateRecorder: raw publish not supported")
+}
+
+func (r *stateRecorder) PublishEvent(ctx context.Context, topic string, key []byte, event *events.Event) error {
+	state, err := events.DecodeData[events.OrderStateEvent](event)
+	if err != nil {
+		return err
+	}
+	r.published <- statePublished{topic: topic, key: string(key), state: state}
+	return nil
+}
+
+func (r *stateRecorder) PublishEventWithPartitionKey(ctx context.Context, topic, partitionKey string, key []byte, event *events.Event) error {
+	return r.PublishEvent(ctx, topic, key, event)
+}
+
+func TestSnapshotterRepublishesActiveOrdersOnEveryTick(t *testing.T) {
+	clock := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	source := &orderStub{orders: []projection.OrderRow{
+		{OrderID: "order-pending", CustomerID: "customer-1", Status: models.OrderStatusPending, Total: 20, UpdatedAt: clock.Add(-30 * 24 * time.Hour)},
+		{OrderID: "order-confirmed", CustomerID: "customer-2", Status: models.OrderStatusConfirmed, Total: 5, UpdatedAt: clock.Add(-time.Hour)},
+		{OrderID: "order-failed-recently", CustomerID: "customer-1", Status: models.OrderStatusFailed, Total: 7, UpdatedAt: clock.Add(-time.Hour)},
+		{OrderID: "order-cancelled-long-ago", CustomerID: "customer-3", Status: models.OrderStatusCancelled, Total: 9, UpdatedAt: clock.Add(-48 * time.Hour)},
+	}}
+	publisher := &stateRecorder{published: make(chan statePublished, 10)}
+
+	s := NewSnapshotter(source, publisher, "order.state", time.Minute, 24*time.Hour)
+	s.now = func() time.Time { return clock }
+	ticks := make(chan time.Time)
+	var interval time.Duration
+	s.newTicker = func(d time.Duration) (<-chan time.Time, func()) {
+		interval = d
+		return ticks, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	want := map[string]models.OrderStatus{
+		"order-pending":         models.OrderStatusPending,
+		"order-confirmed":       models.OrderStatusConfirmed,
+		"order-failed-recently": models.OrderStatusFailed,
+	}
+	for tick := 0; tick < 2; tick++ {
+		clock = clock.Add(time.Minute)
+		ticks <- clock
+
+		got := make(map[string]models.OrderStatus)
+		for range want {
+			select {
+			case p := <-publisher.published:
+				if p.topic != "order.state" || p.key != p.state.OrderID {
+					t.Errorf("tick %d: published %s to %s keyed %s, want order.state keyed by order ID", tick, p.state.OrderID, p.topic, p.key)
+				}
+				got[p.state.OrderID] = p.state.Status
+			case <-time.After(time.Second):
+				t.Fatalf("tick %d: published %v, want %v", tick, got, want)
+			}
+		}
+		for id, status := range want {
+			if got[id] != status {
+				t.Errorf("tick %d: published %v, want %v", tick, got, want)
+				break
+			}
+		}
+	}
+	if interval != time.Minute {
+		t.Errorf("ticker interval %v, want the configured minute", interval)
+	}
+
+	cancel()
+	<-done
+	select {
+	case p := <-publisher.published:
+		t.Errorf("published %s beyond the snapshots, e.g. the order past its retention", p.state.OrderID)
+	default:
+	}
+}
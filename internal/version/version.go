@@ -0,0 +1,44 @@
+package version
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// Version is the build version, set at link time:
+//
+//	go build -ldflags "-X github.com/tanint/go-eda/internal/version.Version=v1.2.3"
+var Version = ""
+
+var (
+	mu       sync.RWMutex
+	override string
+)
+
+// Set overrides the build version, e.g. from configuration. Empty values are ignored.
+func Set(v string) {
+	if v == "" {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	override = v
+}
+
+// Get returns the service version: the configured override, the link-time
+// version, the main module version recorded by the Go toolchain, or "dev"
+func Get() string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	switch {
+	case override != "":
+		return override
+	case Version != "":
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
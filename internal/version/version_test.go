@@ -0,0 +1,36 @@
+package version
+
+import "testing"
+
+func TestGetPrecedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		linked   string
+		override string
+		want     string
+	}{
+		{name: "configured over link-time", linked: "v1.2.3", override: "v1.2.4-hotfix", want: "v1.2.4-hotfix"},
+		{name: "link-time", linked: "v1.2.3", want: "v1.2.3"},
+		{name: "neither", want: "dev"}, // test binaries record no module version
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Version, override = tt.linked, ""
+			t.Cleanup(func() { Version, override = "", "" })
+			Set(tt.override)
+
+			if got := Get(); got != tt.want {
+				t.Errorf("Get() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetIgnoresEmptyVersions(t *testing.T) {
+	t.Cleanup(func() { override = "" })
+	Set("v2.0.0")
+	Set("")
+	if got := Get(); got != "v2.0.0" {
+		t.Errorf("Get() = %q, want the earlier v2.0.0", got)
+	}
+}
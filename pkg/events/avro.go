@@ -0,0 +1,728 @@
+package events
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentTypeAvro identifies events in the Confluent Avro wire format
+const ContentTypeAvro = "avro/binary"
+
+// avroMagicByte starts every message of the Confluent wire format, followed
+// by the 4-byte big-endian schema ID and the Avro binary encoding
+const avroMagicByte = 0
+
+// AvroSerializer encodes events in Avro, with schemas registered in a
+// Confluent Schema Registry under the subject go_eda.<event type> (the
+// record name strategy, so one topic can carry several event types).
+//
+// Schemas are generated from the payload types of the event registry. On
+// decoding, the writer's schema is fetched by the ID in the message and its
+// data resolved against the local schema: fields the writer lacks get their
+// defaults and fields the reader lacks are dropped. Payloads written at an
+// older event version are left to the registered upcasters instead.
+// Timestamps are encoded with microsecond precision.
+type AvroSerializer struct {
+	client   *SchemaRegistryClient
+	registry *EventRegistry
+
+	mu      sync.Mutex
+	local   map[EventType]*avroSchema // generated schema per event type
+	texts   map[EventType]string      // its JSON form, as registered
+	writers map[int]*avroSchema       // parsed writer schemas by ID
+}
+
+// NewAvroSerializer creates an Avro serializer registering the schemas of
+// the registry's payload types with client. A nil registry means DefaultRegistry.
+func NewAvroSerializer(client *SchemaRegistryClient, registry *EventRegistry) *AvroSerializer {
+	if registry == nil {
+		registry = DefaultRegistry
+	}
+	return &AvroSerializer{
+		client:   client,
+		registry: registry,
+		local:    make(map[EventType]*avroSchema),
+		texts:    make(map[EventType]string),
+		writers:  make(map[int]*avroSchema),
+	}
+}
+
+// ContentType returns avro/binary
+func (s *AvroSerializer) ContentType() string {
+	return ContentTypeAvro
+}
+
+// AvroSchema returns the JSON form of the Avro schema of events of the type
+// at its current version; unregistered payloads are carried as JSON documents
+func (r *EventRegistry) AvroSchema(eventType EventType) (string, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[eventType]
+	r.mu.RUnlock()
+
+	var payload reflect.Type
+	if ok {
+		payload = reflect.TypeOf(factory())
+	}
+	return avroEventSchema(eventType, payload)
+}
+
+// Marshal registers the event type's schema, if not done yet, and encodes the event
+func (s *AvroSerializer) Marshal(event *Event) ([]byte, error) {
+	schema, text, err := s.schemaFor(event.Type)
+	if err != nil {
+		return nil, err
+	}
+	id, err := s.client.Register(avroEventName(event.Type), text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register %s avro schema: %w", event.Type, err)
+	}
+
+	data, err := event.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(avroMagicByte)
+	binary.Write(&buf, binary.BigEndian, uint32(id))
+	if err := avroEncode(&buf, schema, value, false); err != nil {
+		return nil, fmt.Errorf("failed to encode %s event as avro: %w", event.Type, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes an event with the writer's schema and resolves it
+// against the local schema of its type
+func (s *AvroSerializer) Unmarshal(data []byte) (*Event, error) {
+	if len(data) < 5 || data[0] != avroMagicByte {
+		return nil, fmt.Errorf("not an avro message: missing magic byte and schema ID")
+	}
+	id := int(binary.BigEndian.Uint32(data[1:5]))
+	writer, err := s.writerSchema(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &avroReader{data: data[5:]}
+	value, err := avroDecode(r, writer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode avro event with schema %d: %w", id, err)
+	}
+	if len(r.data) > 0 {
+		return nil, fmt.Errorf("failed to decode avro event with schema %d: %d trailing bytes", id, len(r.data))
+	}
+
+	envelope, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("avro schema %d does not describe an event", id)
+	}
+	eventType, _ := envelope["type"].(string)
+	reader, _, err := s.schemaFor(EventType(eventType))
+	if err != nil {
+		return nil, err
+	}
+	version, _ := envelope["version"].(int64)
+	if max(int(version), 1) < CurrentVersion(EventType(eventType)) {
+		// Upcasters transform the payload as written
+		reader = withJSONData(reader)
+	}
+	if value, err = avroConform(value, reader); err != nil {
+		return nil, fmt.Errorf("avro schema %d is incompatible with the %s schema: %w", id, eventType, err)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalEvent(encoded)
+}
+
+// schemaFor returns the generated schema of the event type and its JSON form
+func (s *AvroSerializer) schemaFor(eventType EventType) (*avroSchema, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if schema, ok := s.local[eventType]; ok {
+		return schema, s.texts[eventType], nil
+	}
+
+	text, err := s.registry.AvroSchema(eventType)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate %s avro schema: %w", eventType, err)
+	}
+	schema, err := parseAvroSchema(text)
+	if err != nil {
+		return nil, "", err
+	}
+	s.local[eventType] = schema
+	s.texts[eventType] = text
+	return schema, text, nil
+}
+
+// writerSchema returns the parsed schema with the registry ID
+func (s *AvroSerializer) writerSchema(id int) (*avroSchema, error) {
+	s.mu.Lock()
+	schema, ok := s.writers[id]
+	s.mu.Unlock()
+	if ok {
+		return schema, nil
+	}
+
+	text, err := s.client.Schema(id)
+	if err != nil {
+		return nil, err
+	}
+	if schema, err = parseAvroSchema(text); err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+
+	s.mu.Lock()
+	s.writers[id] = schema
+	s.mu.Unlock()
+	return schema, nil
+}
+
+// withJSONData returns a copy of an envelope schema reading data as a JSON document
+func withJSONData(envelope *avroSchema) *avroSchema {
+	copied := *envelope
+	copied.Fields = append([]avroField(nil), envelope.Fields...)
+	for i, f := range copied.Fields {
+		if f.Name == "data" {
+			copied.Fields[i].Schema = &avroSchema{Type: "string", Encoding: avroJSONEncoding}
+			copied.Fields[i].Default = "null"
+		}
+	}
+	return &copied
+}
+
+// avroEncode writes value, as decoded from an event's JSON with json.Number
+// numbers, in the Avro binary encoding of schema. Field defaults are encoded
+// with isDefault, since they hold JSON documents as text.
+func avroEncode(buf *bytes.Buffer, schema *avroSchema, value interface{}, isDefault bool) error {
+	switch schema.Type {
+	case "null":
+		if value != nil {
+			return fmt.Errorf("expected null, got %T", value)
+		}
+	case "boolean":
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected boolean, got %T", value)
+		}
+		if b {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case "int", "long":
+		n, err := avroLong(schema, value)
+		if err != nil {
+			return err
+		}
+		if schema.Type == "int" && (n < math.MinInt32 || n > math.MaxInt32) {
+			return fmt.Errorf("%d overflows int", n)
+		}
+		writeAvroLong(buf, n)
+	case "float", "double":
+		f, err := jsonFloat(value)
+		if err != nil {
+			return err
+		}
+		if schema.Type == "float" {
+			binary.Write(buf, binary.LittleEndian, math.Float32bits(float32(f)))
+		} else {
+			binary.Write(buf, binary.LittleEndian, math.Float64bits(f))
+		}
+	case "string":
+		var s string
+		if schema.Encoding == avroJSONEncoding && !isDefault {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			s = string(encoded)
+		} else if str, ok := value.(string); ok {
+			s = str
+		} else {
+			return fmt.Errorf("expected string, got %T", value)
+		}
+		writeAvroLong(buf, int64(len(s)))
+		buf.WriteString(s)
+	case "bytes", "fixed":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected base64 string, got %T", value)
+		}
+		b := []byte(str)
+		if !isDefault {
+			var err error
+			if b, err = base64.StdEncoding.DecodeString(str); err != nil {
+				return fmt.Errorf("invalid base64 bytes: %w", err)
+			}
+		}
+		if schema.Type == "fixed" {
+			if len(b) != schema.Size {
+				return fmt.Errorf("expected %d bytes for %s, got %d", schema.Size, schema.Name, len(b))
+			}
+		} else {
+			writeAvroLong(buf, int64(len(b)))
+		}
+		buf.Write(b)
+	case "enum":
+		str, _ := value.(string)
+		for i, symbol := range schema.Symbols {
+			if symbol == str {
+				writeAvroLong(buf, int64(i))
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not a symbol of %s", str, schema.Name)
+	case "array":
+		items, ok := value.([]interface{})
+		if value != nil && !ok {
+			return fmt.Errorf("expected array, got %T", value)
+		}
+		if len(items) > 0 {
+			writeAvroLong(buf, int64(len(items)))
+			for i, item := range items {
+				if err := avroEncode(buf, schema.Items, item, isDefault); err != nil {
+					return fmt.Errorf("[%d]: %w", i, err)
+				}
+			}
+		}
+		writeAvroLong(buf, 0)
+	case "map":
+		entries, ok := value.(map[string]interface{})
+		if value != nil && !ok {
+			return fmt.Errorf("expected object, got %T", value)
+		}
+		if len(entries) > 0 {
+			writeAvroLong(buf, int64(len(entries)))
+			for key, entry := range entries {
+				writeAvroLong(buf, int64(len(key)))
+				buf.WriteString(key)
+				if err := avroEncode(buf, schema.Values, entry, isDefault); err != nil {
+					return fmt.Errorf("[%q]: %w", key, err)
+				}
+			}
+		}
+		writeAvroLong(buf, 0)
+	case "record":
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected object for %s, got %T", schema.Name, value)
+		}
+		for _, f := range schema.Fields {
+			v, present := fields[f.Name]
+			fieldIsDefault := isDefault
+			if !present {
+				// Omitted by omitempty: the zero value is the default
+				if !f.HasDefault {
+					return fmt.Errorf("missing field %s.%s", schema.Name, f.Name)
+				}
+				v, fieldIsDefault = f.Default, true
+			}
+			if err := avroEncode(buf, f.Schema, v, fieldIsDefault); err != nil {
+				return fmt.Errorf("%s: %w", f.Name, err)
+			}
+		}
+	case "union":
+		for i, branch := range schema.Branches {
+			if (value == nil) != (branch.Type == "null") {
+				continue
+			}
+			writeAvroLong(buf, int64(i))
+			return avroEncode(buf, branch, value, isDefault)
+		}
+		return fmt.Errorf("no union branch for %T", value)
+	default:
+		return fmt.Errorf("unsupported avro type %s", schema.Type)
+	}
+	return nil
+}
+
+// avroLong converts a JSON number, or an RFC3339 time for timestamps, to a long
+func avroLong(schema *avroSchema, value interface{}) (int64, error) {
+	if s, ok := value.(string); ok && strings.HasPrefix(schema.Logical, "timestamp-") {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q: %w", s, err)
+		}
+		if schema.Logical == "timestamp-millis" {
+			return t.UnixMilli(), nil
+		}
+		return t.UnixMicro(), nil
+	}
+	switch n := value.(type) {
+	case json.Number:
+		return n.Int64()
+	case int64:
+		return n, nil
+	default:
+		return 0, fmt.Errorf("expected integer, got %T", value)
+	}
+}
+
+func jsonFloat(value interface{}) (float64, error) {
+	switch n := value.(type) {
+	case json.Number:
+		return n.Float64()
+	case float64:
+		return n, nil
+	default:
+		return 0, fmt.Errorf("expected number, got %T", value)
+	}
+}
+
+// writeAvroLong writes a zig-zag varint
+func writeAvroLong(buf *bytes.Buffer, n int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutVarint(tmp[:], n)])
+}
+
+// avroReader consumes the Avro binary encoding
+type avroReader struct {
+	data []byte
+}
+
+var errAvroShort = errors.New("unexpected end of avro data")
+
+func (r *avroReader) long() (int64, error) {
+	n, size := binary.Varint(r.data)
+	if size <= 0 {
+		return 0, errAvroShort
+	}
+	r.data = r.data[size:]
+	return n, nil
+}
+
+func (r *avroReader) bytes(n int) ([]byte, error) {
+	if n < 0 || n > len(r.data) {
+		return nil, errAvroShort
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b, nil
+}
+
+// avroDecode reads a value written with schema. Records and maps decode to
+// map[string]interface{}, integers to int64, floats to float64, timestamps
+// to time.Time and JSON documents to json.RawMessage, so that the value
+// renders as the event's JSON.
+func avroDecode(r *avroReader, schema *avroSchema) (interface{}, error) {
+	switch schema.Type {
+	case "null":
+		return nil, nil
+	case "boolean":
+		b, err := r.bytes(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0] != 0, nil
+	case "int", "long":
+		n, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		switch schema.Logical {
+		case "timestamp-micros":
+			return time.UnixMicro(n).UTC(), nil
+		case "timestamp-millis":
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return n, nil
+	case "float":
+		b, err := r.bytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), nil
+	case "double":
+		b, err := r.bytes(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case "bytes", "string":
+		n, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.bytes(int(n))
+		if err != nil {
+			return nil, err
+		}
+		if schema.Type == "bytes" {
+			return append([]byte(nil), b...), nil
+		}
+		if schema.Encoding == avroJSONEncoding {
+			if !json.Valid(b) {
+				return nil, fmt.Errorf("invalid JSON document %q", b)
+			}
+			return json.RawMessage(append([]byte(nil), b...)), nil
+		}
+		return string(b), nil
+	case "fixed":
+		b, err := r.bytes(schema.Size)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case "enum":
+		i, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || int(i) >= len(schema.Symbols) {
+			return nil, fmt.Errorf("enum index %d out of range for %s", i, schema.Name)
+		}
+		return schema.Symbols[i], nil
+	case "array":
+		items := []interface{}{}
+		err := readAvroBlocks(r, func() error {
+			item, err := avroDecode(r, schema.Items)
+			items = append(items, item)
+			return err
+		})
+		return items, err
+	case "map":
+		entries := map[string]interface{}{}
+		err := readAvroBlocks(r, func() error {
+			n, err := r.long()
+			if err != nil {
+				return err
+			}
+			key, err := r.bytes(int(n))
+			if err != nil {
+				return err
+			}
+			entries[string(key)], err = avroDecode(r, schema.Values)
+			return err
+		})
+		return entries, err
+	case "record":
+		fields := make(map[string]interface{}, len(schema.Fields))
+		for _, f := range schema.Fields {
+			v, err := avroDecode(r, f.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name, err)
+			}
+			fields[f.Name] = v
+		}
+		return fields, nil
+	case "union":
+		i, err := r.long()
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || int(i) >= len(schema.Branches) {
+			return nil, fmt.Errorf("union index %d out of range", i)
+		}
+		return avroDecode(r, schema.Branches[i])
+	default:
+		return nil, fmt.Errorf("unsupported avro type %s", schema.Type)
+	}
+}
+
+// readAvroBlocks reads the blocks of an array or map, calling item per element
+func readAvroBlocks(r *avroReader, item func() error) error {
+	for {
+		count, err := r.long()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if count < 0 {
+			// A negative count is followed by the block's size in bytes
+			count = -count
+			if _, err := r.long(); err != nil {
+				return err
+			}
+		}
+		for ; count > 0; count-- {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// avroConform resolves a value decoded with the writer's schema against the
+// reader's schema, following the Avro schema resolution rules: record fields
+// are matched by name, missing ones take the reader's default and extra ones
+// are dropped; numbers are promoted; unions pick the first matching branch.
+func avroConform(value interface{}, schema *avroSchema) (interface{}, error) {
+	if raw, ok := value.(json.RawMessage); ok && schema.Encoding != avroJSONEncoding && schema.Type != "union" {
+		// Written as a JSON document: bring it into the reader's shape
+		return avroFromJSON(raw, schema, false)
+	}
+
+	switch schema.Type {
+	case "null":
+		if value != nil {
+			return nil, fmt.Errorf("expected null, got %T", value)
+		}
+		return nil, nil
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return nil, fmt.Errorf("expected boolean, got %T", value)
+		}
+		return value, nil
+	case "int", "long":
+		switch v := value.(type) {
+		case int64:
+			if schema.Logical == "timestamp-micros" {
+				return time.UnixMicro(v).UTC(), nil
+			}
+			if schema.Logical == "timestamp-millis" {
+				return time.UnixMilli(v).UTC(), nil
+			}
+			if schema.Type == "int" && (v < math.MinInt32 || v > math.MaxInt32) {
+				return nil, fmt.Errorf("%d overflows int", v)
+			}
+			return v, nil
+		case time.Time:
+			if schema.Logical == "" {
+				return v.UnixMicro(), nil
+			}
+			return v, nil
+		}
+		return nil, fmt.Errorf("expected integer, got %T", value)
+	case "float", "double":
+		switch v := value.(type) {
+		case int64:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		}
+		return nil, fmt.Errorf("expected number, got %T", value)
+	case "string":
+		if schema.Encoding == avroJSONEncoding {
+			if raw, ok := value.(json.RawMessage); ok {
+				return raw, nil
+			}
+			encoded, err := json.Marshal(value)
+			return json.RawMessage(encoded), err
+		}
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case []byte:
+			return string(v), nil
+		}
+		return nil, fmt.Errorf("expected string, got %T", value)
+	case "bytes", "fixed":
+		switch v := value.(type) {
+		case []byte:
+			return v, nil
+		case string:
+			return []byte(v), nil
+		}
+		return nil, fmt.Errorf("expected bytes, got %T", value)
+	case "enum":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected enum symbol, got %T", value)
+		}
+		for _, symbol := range schema.Symbols {
+			if symbol == s {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a symbol of %s", s, schema.Name)
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array, got %T", value)
+		}
+		conformed := make([]interface{}, len(items))
+		for i, item := range items {
+			var err error
+			if conformed[i], err = avroConform(item, schema.Items); err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		return conformed, nil
+	case "map":
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected map, got %T", value)
+		}
+		conformed := make(map[string]interface{}, len(entries))
+		for key, entry := range entries {
+			var err error
+			if conformed[key], err = avroConform(entry, schema.Values); err != nil {
+				return nil, fmt.Errorf("[%q]: %w", key, err)
+			}
+		}
+		return conformed, nil
+	case "record":
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected record %s, got %T", schema.Name, value)
+		}
+		conformed := make(map[string]interface{}, len(schema.Fields))
+		for _, f := range schema.Fields {
+			v, present := fields[f.Name]
+			var err error
+			switch {
+			case present:
+				conformed[f.Name], err = avroConform(v, f.Schema)
+			case f.HasDefault:
+				conformed[f.Name], err = avroFromJSON(f.Default, f.Schema, true)
+			default:
+				err = fmt.Errorf("writer has no field %s and the reader no default for it", f.Name)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name, err)
+			}
+		}
+		return conformed, nil
+	case "union":
+		var errs []error
+		for _, branch := range schema.Branches {
+			conformed, err := avroConform(value, branch)
+			if err == nil {
+				return conformed, nil
+			}
+			errs = append(errs, err)
+		}
+		return nil, fmt.Errorf("no union branch matches: %w", errors.Join(errs...))
+	default:
+		return nil, fmt.Errorf("unsupported avro type %s", schema.Type)
+	}
+}
+
+// avroFromJSON converts a JSON value (a document, or a field default) to the
+// decoded form of schema by encoding and decoding it
+func avroFromJSON(value interface{}, schema *avroSchema, isDefault bool) (interface{}, error) {
+	if raw, ok := value.(json.RawMessage); ok {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := avroEncode(&buf, schema, value, isDefault); err != nil {
+		return nil, err
+	}
+	return avroDecode(&avroReader{data: buf.Bytes()}, schema)
+}
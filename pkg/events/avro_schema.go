@@ -0,0 +1,395 @@
+package events
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// avroNamespace prefixes the names of the generated Avro records
+const avroNamespace = "go_eda"
+
+// avroJSONEncoding marks string schemas carrying a JSON document: payloads
+// without a registered type and values with custom JSON encodings
+const avroJSONEncoding = "json"
+
+var (
+	avroNameRe        = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// avroSchema is a parsed Avro schema
+type avroSchema struct {
+	Type     string        // primitive name, record, enum, array, map, fixed or union
+	Name     string        // full name of records, enums and fixed
+	Fields   []avroField   // record
+	Items    *avroSchema   // array
+	Values   *avroSchema   // map
+	Branches []*avroSchema // union
+	Symbols  []string      // enum
+	Size     int           // fixed
+	Logical  string        // logicalType
+	Encoding string        // "json" for strings carrying JSON documents
+}
+
+type avroField struct {
+	Name       string
+	Schema     *avroSchema
+	Default    interface{} // JSON-decoded, with json.Number numbers
+	HasDefault bool
+}
+
+// parseAvroSchema parses the JSON form of an Avro schema
+func parseAvroSchema(text string) (*avroSchema, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid avro schema: %w", err)
+	}
+	return (&avroParser{names: map[string]*avroSchema{}}).parse(raw, "")
+}
+
+type avroParser struct {
+	names map[string]*avroSchema // named types defined so far
+}
+
+func (p *avroParser) parse(raw interface{}, namespace string) (*avroSchema, error) {
+	switch v := raw.(type) {
+	case string:
+		switch v {
+		case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+			return &avroSchema{Type: v}, nil
+		}
+		name := fullAvroName(v, namespace)
+		if named, ok := p.names[name]; ok {
+			return named, nil
+		}
+		if named, ok := p.names[v]; ok {
+			return named, nil
+		}
+		return nil, fmt.Errorf("invalid avro schema: unknown type %q", v)
+	case []interface{}:
+		union := &avroSchema{Type: "union"}
+		for _, branch := range v {
+			s, err := p.parse(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+			union.Branches = append(union.Branches, s)
+		}
+		return union, nil
+	case map[string]interface{}:
+		return p.parseComplex(v, namespace)
+	default:
+		return nil, fmt.Errorf("invalid avro schema: unexpected %T", raw)
+	}
+}
+
+func (p *avroParser) parseComplex(v map[string]interface{}, namespace string) (*avroSchema, error) {
+	typ, _ := v["type"].(string)
+	logical, _ := v["logicalType"].(string)
+	enc, _ := v["encoding"].(string)
+
+	switch typ {
+	case "record", "error", "enum", "fixed":
+		name, _ := v["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("invalid avro schema: %s without a name", typ)
+		}
+		if ns, ok := v["namespace"].(string); ok && !strings.Contains(name, ".") {
+			namespace = ns
+		}
+		s := &avroSchema{Type: typ, Name: fullAvroName(name, namespace), Logical: logical}
+		if typ == "error" {
+			s.Type = "record"
+		}
+		// Registered before the fields are parsed so records can refer to themselves
+		p.names[s.Name] = s
+		namespace = s.Name[:max(strings.LastIndex(s.Name, "."), 0)]
+
+		switch s.Type {
+		case "record":
+			fields, _ := v["fields"].([]interface{})
+			for _, f := range fields {
+				fm, ok := f.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("invalid avro schema: field of %s is not an object", s.Name)
+				}
+				fieldName, _ := fm["name"].(string)
+				fieldSchema, err := p.parse(fm["type"], namespace)
+				if err != nil {
+					return nil, fmt.Errorf("field %s.%s: %w", s.Name, fieldName, err)
+				}
+				def, hasDefault := fm["default"]
+				s.Fields = append(s.Fields, avroField{Name: fieldName, Schema: fieldSchema, Default: def, HasDefault: hasDefault})
+			}
+		case "enum":
+			symbols, _ := v["symbols"].([]interface{})
+			for _, sym := range symbols {
+				name, _ := sym.(string)
+				s.Symbols = append(s.Symbols, name)
+			}
+		case "fixed":
+			size, _ := v["size"].(json.Number)
+			n, err := size.Int64()
+			if err != nil {
+				return nil, fmt.Errorf("invalid avro schema: fixed %s without a size", s.Name)
+			}
+			s.Size = int(n)
+		}
+		return s, nil
+	case "array":
+		items, err := p.parse(v["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &avroSchema{Type: "array", Items: items}, nil
+	case "map":
+		values, err := p.parse(v["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &avroSchema{Type: "map", Values: values}, nil
+	default:
+		s, err := p.parse(typ, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if s.Name == "" {
+			// A primitive with attributes, e.g. a logical type
+			s = &avroSchema{Type: s.Type, Logical: logical, Encoding: enc}
+		}
+		return s, nil
+	}
+}
+
+func fullAvroName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// avroEventName is the full record name of the envelope of an event type,
+// e.g. go_eda.order.created; it is also its registry subject
+func avroEventName(eventType EventType) string {
+	parts := strings.Split(string(eventType), ".")
+	for i, part := range parts {
+		parts[i] = avroIdentifier(part)
+	}
+	return avroNamespace + "." + strings.Join(parts, ".")
+}
+
+// avroIdentifier replaces the characters Avro names can't contain
+func avroIdentifier(s string) string {
+	id := []byte(s)
+	for i, c := range id {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			id[i] = '_'
+		}
+	}
+	if len(id) == 0 {
+		return "_"
+	}
+	return string(id)
+}
+
+// avroEventSchema returns the JSON form of the Avro schema of events of the
+// type: the envelope with a payload of type payload, or a JSON document when
+// payload is nil. Record names follow the Go types; every field has a
+// default (its zero value, null for pointers), so fields can be added and
+// removed under the registry's backward and forward compatibility rules.
+func avroEventSchema(eventType EventType, payload reflect.Type) (string, error) {
+	g := &avroGenerator{defined: map[string]bool{}}
+	envelope, err := g.record(reflect.TypeOf(Event{}), avroEventName(eventType))
+	if err != nil {
+		return "", err
+	}
+
+	if payload != nil {
+		data, err := g.schema(indirect(payload), avroEventName(eventType)+"_data")
+		if err != nil {
+			return "", fmt.Errorf("%s payload %s: %w", eventType, payload, err)
+		}
+		for _, f := range envelope["fields"].([]interface{}) {
+			if field := f.(map[string]interface{}); field["name"] == "data" {
+				field["type"] = data
+				field["default"] = avroZero(indirect(payload))
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(envelope); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// avroGenerator derives Avro schemas from Go types as encoding/json renders them
+type avroGenerator struct {
+	defined map[string]bool // records already defined, referred to by name afterwards
+}
+
+// schema returns the Avro schema of t; hint names anonymous structs
+func (g *avroGenerator) schema(t reflect.Type, hint string) (interface{}, error) {
+	if t.Kind() == reflect.Pointer {
+		inner, err := g.schema(t.Elem(), hint)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"null", inner}, nil
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "long", "logicalType": "timestamp-micros"}, nil
+	case t == rawJSONType, t.Implements(marshalerType), reflect.PointerTo(t).Implements(marshalerType):
+		return map[string]interface{}{"type": "string", "encoding": avroJSONEncoding}, nil
+	case t.Implements(textMarshalerType), reflect.PointerTo(t).Implements(textMarshalerType):
+		return "string", nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string", nil
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return "int", nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return "long", nil
+	case reflect.Float32:
+		return "float", nil
+	case reflect.Float64:
+		return "double", nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "bytes", nil
+		}
+		items, err := g.schema(t.Elem(), hint+"_item")
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		values, err := g.schema(t.Elem(), hint+"_value")
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "map", "values": values}, nil
+	case reflect.Struct:
+		name := hint
+		if t.Name() != "" {
+			pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+			name = avroNamespace + "." + avroIdentifier(pkg) + "." + avroIdentifier(t.Name())
+		}
+		if g.defined[name] {
+			return name, nil
+		}
+		return g.record(t, name)
+	case reflect.Interface:
+		return map[string]interface{}{"type": "string", "encoding": avroJSONEncoding}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// record defines the record named name for struct t
+func (g *avroGenerator) record(t reflect.Type, name string) (map[string]interface{}, error) {
+	g.defined[name] = true
+	fields := []interface{}{}
+	err := eachJSONField(t, func(jsonName string, field reflect.StructField) error {
+		if !avroNameRe.MatchString(jsonName) {
+			return fmt.Errorf("field %s of %s: %q is not a valid avro name", field.Name, t, jsonName)
+		}
+		schema, err := g.schema(field.Type, name+"_"+avroIdentifier(jsonName))
+		if err != nil {
+			return fmt.Errorf("field %s of %s: %w", field.Name, t, err)
+		}
+		fields = append(fields, map[string]interface{}{"name": jsonName, "type": schema, "default": avroZero(field.Type)})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"type": "record", "name": name, "fields": fields}, nil
+}
+
+// avroZero is the Avro default of fields of type t: the zero value as
+// encoding/json renders it, null for pointers
+func avroZero(t reflect.Type) interface{} {
+	if t.Kind() == reflect.Pointer {
+		return nil
+	}
+	switch {
+	case t == timeType:
+		return 0
+	case t == rawJSONType, t.Implements(marshalerType), reflect.PointerTo(t).Implements(marshalerType):
+		return "null"
+	case t.Implements(textMarshalerType), reflect.PointerTo(t).Implements(textMarshalerType):
+		return ""
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return ""
+	case reflect.Bool:
+		return false
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return ""
+		}
+		return []interface{}{}
+	case reflect.Map:
+		return map[string]interface{}{}
+	case reflect.Struct:
+		def := map[string]interface{}{}
+		_ = eachJSONField(t, func(jsonName string, field reflect.StructField) error {
+			def[jsonName] = avroZero(field.Type)
+			return nil
+		})
+		return def
+	case reflect.Interface:
+		return "null"
+	default:
+		return 0
+	}
+}
+
+// eachJSONField calls fn with the fields encoding/json renders for struct t,
+// promoting the fields of untagged embedded structs
+func eachJSONField(t reflect.Type, fn func(jsonName string, field reflect.StructField) error) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && indirect(field.Type).Kind() == reflect.Struct {
+			if err := eachJSONField(indirect(field.Type), fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if err := fn(name, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,528 @@
+package events
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSchemaRegistry serves the registry endpoints the Avro serializer uses,
+// assigning IDs in registration order without checking compatibility
+type fakeSchemaRegistry struct {
+	mu       sync.Mutex
+	schemas  []string       // by ID - 1
+	subjects map[string]int // subject of each registered ID
+}
+
+func newFakeSchemaRegistry(t *testing.T) (*fakeSchemaRegistry, *SchemaRegistryClient) {
+	t.Helper()
+	registry := &fakeSchemaRegistry{subjects: map[string]int{}}
+	server := httptest.NewServer(registry)
+	t.Cleanup(server.Close)
+
+	client, err := NewSchemaRegistryClient(server.URL, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry, client
+}
+
+func (r *fakeSchemaRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case req.Method == http.MethodPost && strings.HasPrefix(req.URL.Path, "/subjects/"):
+		subject := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/subjects/"), "/versions")
+		var body struct {
+			Schema string `json:"schema"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		id := len(r.schemas) + 1
+		for i, schema := range r.schemas {
+			if schema == body.Schema {
+				id = i + 1
+			}
+		}
+		if id > len(r.schemas) {
+			r.schemas = append(r.schemas, body.Schema)
+		}
+		r.subjects[subject] = id
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	case req.Method == http.MethodGet && strings.HasPrefix(req.URL.Path, "/schemas/ids/"):
+		id, _ := strconv.Atoi(strings.TrimPrefix(req.URL.Path, "/schemas/ids/"))
+		if id < 1 || id > len(r.schemas) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error_code": 40403, "message": "Schema not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"schema": r.schemas[id-1]})
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// avroKitchenSink exercises every kind of field the schema generator maps
+type avroKitchenSink struct {
+	Name     string             `json:"name"`
+	Active   bool               `json:"active"`
+	Small    int32              `json:"small"`
+	Large    int64              `json:"large"`
+	Ratio    float32            `json:"ratio"`
+	Amount   float64            `json:"amount"`
+	Raw      []byte             `json:"raw"`
+	Tags     []string           `json:"tags"`
+	Counts   map[string]int     `json:"counts"`
+	Nickname *string            `json:"nickname"`
+	Missing  *string            `json:"missing"`
+	At       time.Time          `json:"at"`
+	Child    avroKitchenChild   `json:"child"`
+	Children []avroKitchenChild `json:"children"`
+	Document json.RawMessage    `json:"document"`
+	Anything interface{}        `json:"anything"`
+	Omitted  string             `json:"omitted,omitempty"`
+}
+
+type avroKitchenChild struct {
+	ID    string  `json:"id"`
+	Price float64 `json:"price"`
+}
+
+func TestAvroRoundTrip(t *testing.T) {
+	_, client := newFakeSchemaRegistry(t)
+	registry := NewEventRegistry()
+	registry.Register("test.kitchen_sink", func() interface{} { return &avroKitchenSink{} })
+	s := NewAvroSerializer(client, registry)
+
+	nickname := "sinky"
+	payload := avroKitchenSink{
+		Name:     "sink",
+		Active:   true,
+		Small:    -42,
+		Large:    1 << 40,
+		Ratio:    0.5,
+		Amount:   -1234.5678,
+		Raw:      []byte{0, 1, 2, 255},
+		Tags:     []string{"a", "b"},
+		Counts:   map[string]int{"x": 1, "y": -2},
+		Nickname: &nickname,
+		At:       time.Date(2024, 5, 6, 7, 8, 9, 123456000, time.UTC),
+		Child:    avroKitchenChild{ID: "c1", Price: 9.99},
+		Children: []avroKitchenChild{{ID: "c2", Price: 1}, {ID: "c3", Price: 2.5}},
+		Document: json.RawMessage(`{"nested":[1,"two",null]}`),
+		Anything: map[string]interface{}{"free": "form"},
+	}
+	event := NewEvent("test.kitchen_sink", payload)
+	event.Timestamp = time.Date(2024, 1, 2, 3, 4, 5, 678901000, time.UTC)
+	event.SetMetadata("tenant", "acme")
+	event.CausationID = "cause-1"
+
+	data, err := s.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := s.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.ID != event.ID || decoded.Type != event.Type || decoded.Version != event.Version ||
+		!decoded.Timestamp.Equal(event.Timestamp) || decoded.CorrelationID != event.CorrelationID ||
+		decoded.CausationID != event.CausationID || !reflect.DeepEqual(decoded.Metadata, event.Metadata) {
+		t.Errorf("envelope = %+v, want %+v", decoded, event)
+	}
+
+	got, err := DecodeData[avroKitchenSink](decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Documents are compared as JSON, the rest exactly
+	if !jsonEqual(t, got.Document, payload.Document) || !jsonEqual(t, got.Anything, payload.Anything) {
+		t.Errorf("documents = %s, %v; want %s, %v", got.Document, got.Anything, payload.Document, payload.Anything)
+	}
+	got.Document, got.Anything = payload.Document, payload.Anything
+	if !reflect.DeepEqual(got, payload) {
+		t.Errorf("payload = %+v\nwant      %+v", got, payload)
+	}
+}
+
+func TestAvroRoundTripOfUnregisteredPayload(t *testing.T) {
+	_, client := newFakeSchemaRegistry(t)
+	s := NewAvroSerializer(client, NewEventRegistry())
+
+	event := NewEvent("test.unregistered", map[string]interface{}{"id": "x", "items": []interface{}{1.5, "two"}})
+	data, err := s.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := s.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !jsonEqual(t, decoded.Data, event.Data) {
+		t.Errorf("data = %v, want %v", decoded.Data, event.Data)
+	}
+}
+
+func TestAvroTimestampsHaveMicrosecondPrecision(t *testing.T) {
+	_, client := newFakeSchemaRegistry(t)
+	s := NewAvroSerializer(client, NewEventRegistry())
+
+	event := NewEvent("test.unregistered", nil)
+	event.Timestamp = time.Date(2024, 1, 1, 0, 0, 0, 123456789, time.UTC)
+	data, err := s.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := s.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := event.Timestamp.Truncate(time.Microsecond); !decoded.Timestamp.Equal(want) {
+		t.Errorf("timestamp = %v, want %v", decoded.Timestamp, want)
+	}
+}
+
+func TestAvroWireFormat(t *testing.T) {
+	fake, client := newFakeSchemaRegistry(t)
+	s := NewAvroSerializer(client, nil)
+
+	// Registered first, so it does not get ID 1
+	if _, err := s.Marshal(NewEvent(EventTypeServiceHeartbeat, ServiceHeartbeatEvent{Service: "order-service"})); err != nil {
+		t.Fatal(err)
+	}
+	data, err := s.Marshal(NewEvent(EventTypeOrderConfirmed, OrderConfirmedEvent{OrderID: "order-1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject := "go_eda.order.confirmed"
+	id, ok := fake.subjects[subject]
+	if !ok {
+		t.Fatalf("schema registered under %v, want subject %s", fake.subjects, subject)
+	}
+	if data[0] != 0 {
+		t.Errorf("first byte = %#x, want the magic byte 0", data[0])
+	}
+	if got := binary.BigEndian.Uint32(data[1:5]); got != uint32(id) || id != 2 {
+		t.Errorf("schema ID in message = %d, want the registered ID %d (2)", got, id)
+	}
+
+	// The body is the plain Avro binary encoding of the registered schema
+	schema, err := parseAvroSchema(fake.schemas[id-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &avroReader{data: data[5:]}
+	value, err := avroDecode(r, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.data) != 0 {
+		t.Errorf("%d bytes left after decoding the body", len(r.data))
+	}
+	if fields := value.(map[string]interface{}); fields["type"] != "order.confirmed" {
+		t.Errorf("decoded envelope type = %v, want order.confirmed", fields["type"])
+	}
+}
+
+func TestAvroBinaryEncoding(t *testing.T) {
+	// Expected bytes from the Avro specification's encoding rules
+	tests := []struct {
+		name   string
+		schema string
+		value  interface{}
+		want   []byte
+	}{
+		{name: "long 0", schema: `"long"`, value: json.Number("0"), want: []byte{0x00}},
+		{name: "long -1", schema: `"long"`, value: json.Number("-1"), want: []byte{0x01}},
+		{name: "long 1", schema: `"long"`, value: json.Number("1"), want: []byte{0x02}},
+		{name: "long -64", schema: `"long"`, value: json.Number("-64"), want: []byte{0x7f}},
+		{name: "long 64", schema: `"long"`, value: json.Number("64"), want: []byte{0x80, 0x01}},
+		{name: "string", schema: `"string"`, value: "foo", want: []byte{0x06, 'f', 'o', 'o'}},
+		{name: "boolean", schema: `"boolean"`, value: true, want: []byte{0x01}},
+		{name: "double", schema: `"double"`, value: json.Number("1.5"), want: []byte{0, 0, 0, 0, 0, 0, 0xf8, 0x3f}},
+		{name: "float", schema: `"float"`, value: json.Number("1.5"), want: []byte{0, 0, 0xc0, 0x3f}},
+		{name: "bytes", schema: `"bytes"`, value: "AAH/", want: []byte{0x06, 0x00, 0x01, 0xff}},
+		{name: "array", schema: `{"type":"array","items":"long"}`, value: []interface{}{json.Number("3"), json.Number("27")}, want: []byte{0x04, 0x06, 0x36, 0x00}},
+		{name: "empty array", schema: `{"type":"array","items":"long"}`, value: []interface{}{}, want: []byte{0x00}},
+		{name: "map", schema: `{"type":"map","values":"long"}`, value: map[string]interface{}{"a": json.Number("1")}, want: []byte{0x02, 0x02, 'a', 0x02, 0x00}},
+		{name: "union null", schema: `["null","string"]`, value: nil, want: []byte{0x00}},
+		{name: "union string", schema: `["null","string"]`, value: "a", want: []byte{0x02, 0x02, 'a'}},
+		{name: "enum", schema: `{"type":"enum","name":"E","symbols":["A","B"]}`, value: "B", want: []byte{0x02}},
+		{name: "fixed", schema: `{"type":"fixed","name":"F","size":2}`, value: "AQI=", want: []byte{0x01, 0x02}},
+		{
+			name:   "record",
+			schema: `{"type":"record","name":"R","fields":[{"name":"a","type":"long"},{"name":"b","type":"string"}]}`,
+			value:  map[string]interface{}{"a": json.Number("27"), "b": "foo"},
+			want:   []byte{0x36, 0x06, 'f', 'o', 'o'},
+		},
+		{
+			name:   "record field default",
+			schema: `{"type":"record","name":"R","fields":[{"name":"a","type":"long","default":2}]}`,
+			value:  map[string]interface{}{},
+			want:   []byte{0x04},
+		},
+		{
+			name:   "timestamp-micros",
+			schema: `{"type":"long","logicalType":"timestamp-micros"}`,
+			value:  "1970-01-01T00:00:00.000001Z",
+			want:   []byte{0x02},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := parseAvroSchema(tt.schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := avroEncode(&buf, schema, tt.value, false); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), tt.want) {
+				t.Errorf("encoded % x, want % x", buf.Bytes(), tt.want)
+			}
+
+			r := &avroReader{data: tt.want}
+			if _, err := avroDecode(r, schema); err != nil {
+				t.Errorf("decoding % x: %v", tt.want, err)
+			}
+			if len(r.data) != 0 {
+				t.Errorf("%d bytes left after decoding", len(r.data))
+			}
+		})
+	}
+}
+
+func TestAvroDecodesNegativeBlockCounts(t *testing.T) {
+	schema, err := parseAvroSchema(`{"type":"array","items":"long"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One block of -2 items (count 2 followed by its byte size 2), then the end
+	value, err := avroDecode(&avroReader{data: []byte{0x03, 0x04, 0x06, 0x36, 0x00}}, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(value, []interface{}{int64(3), int64(27)}) {
+		t.Errorf("decoded %v, want [3 27]", value)
+	}
+}
+
+func TestAvroUnmarshalRejectsMalformedMessages(t *testing.T) {
+	_, client := newFakeSchemaRegistry(t)
+	s := NewAvroSerializer(client, NewEventRegistry())
+	valid, err := s.Marshal(NewEvent("test.unregistered", "payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{name: "empty", data: nil, want: "magic byte"},
+		{name: "no schema ID", data: []byte{0, 0, 0}, want: "magic byte"},
+		{name: "wrong magic byte", data: append([]byte{1}, valid[1:]...), want: "magic byte"},
+		{name: "unknown schema ID", data: append([]byte{0, 0, 0, 0, 99}, valid[5:]...), want: "schema 99"},
+		{name: "truncated", data: valid[:len(valid)-3], want: "unexpected end"},
+		{name: "trailing bytes", data: append(append([]byte(nil), valid...), 0), want: "trailing bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Unmarshal(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Unmarshal error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// Two versions of one payload, as published by an old and a new service
+type avroItemV1 struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+	Note     string `json:"note"`
+}
+
+type avroItemV2 struct {
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity"` // promoted from long
+	Price    float64 `json:"price"`    // added
+	Currency string  `json:"currency"` // added
+	// note removed
+}
+
+// avroEvolution returns serializers for the old and the new payload sharing a registry
+func avroEvolution(t *testing.T, eventType EventType) (v1, v2 *AvroSerializer) {
+	t.Helper()
+	_, client := newFakeSchemaRegistry(t)
+	old := NewEventRegistry()
+	old.Register(eventType, func() interface{} { return &avroItemV1{} })
+	current := NewEventRegistry()
+	current.Register(eventType, func() interface{} { return &avroItemV2{} })
+	return NewAvroSerializer(client, old), NewAvroSerializer(client, current)
+}
+
+func TestAvroSchemaEvolution(t *testing.T) {
+	t.Run("new reader of old data", func(t *testing.T) {
+		v1, v2 := avroEvolution(t, "test.item")
+		data, err := v1.Marshal(NewEvent("test.item", avroItemV1{Name: "widget", Quantity: 3, Note: "fragile"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := v2.Unmarshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := DecodeData[avroItemV2](decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Added fields take their defaults, the removed one is dropped
+		if want := (avroItemV2{Name: "widget", Quantity: 3}); got != want {
+			t.Errorf("payload = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("old reader of new data", func(t *testing.T) {
+		v1, v2 := avroEvolution(t, "test.item")
+		data, err := v2.Marshal(NewEvent("test.item", avroItemV2{Name: "widget", Quantity: 3, Price: 9.5, Currency: "EUR"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = v1.Unmarshal(data)
+		// A double can't be narrowed back to a long
+		if err == nil || !strings.Contains(err.Error(), "incompatible") {
+			t.Fatalf("Unmarshal error = %v, want an incompatible schema", err)
+		}
+	})
+
+	t.Run("old reader of new data without narrowing", func(t *testing.T) {
+		_, client := newFakeSchemaRegistry(t)
+		type itemV3 struct {
+			Name     string `json:"name"`
+			Quantity int    `json:"quantity"`
+			Note     string `json:"note"`
+			Color    string `json:"color"` // added
+		}
+		old := NewEventRegistry()
+		old.Register("test.item", func() interface{} { return &avroItemV1{} })
+		current := NewEventRegistry()
+		current.Register("test.item", func() interface{} { return &itemV3{} })
+
+		data, err := NewAvroSerializer(client, current).Marshal(NewEvent("test.item", itemV3{Name: "widget", Quantity: 3, Note: "fragile", Color: "red"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := NewAvroSerializer(client, old).Unmarshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := DecodeData[avroItemV1](decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The field the old reader does not know is dropped
+		if want := (avroItemV1{Name: "widget", Quantity: 3, Note: "fragile"}); got != want {
+			t.Errorf("payload = %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestAvroOlderEventVersionsAreUpcast(t *testing.T) {
+	const eventType = EventType("test.avro_upcast")
+	v1, v2 := avroEvolution(t, eventType)
+	event := NewEvent(eventType, avroItemV1{Name: "widget", Quantity: 3, Note: "fragile"})
+	data, err := v1.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Version 2 moved the note into the currency, which the schema alone can't express
+	RegisterUpcaster(eventType, 1, func(data json.RawMessage) (json.RawMessage, error) {
+		var item map[string]interface{}
+		if err := json.Unmarshal(data, &item); err != nil {
+			return nil, err
+		}
+		item["currency"] = item["note"]
+		delete(item, "note")
+		return json.Marshal(item)
+	})
+
+	decoded, err := v2.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Version != 2 {
+		t.Errorf("version = %d, want 2", decoded.Version)
+	}
+	got, err := DecodeData[avroItemV2](decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (avroItemV2{Name: "widget", Quantity: 3, Currency: "fragile"}); got != want {
+		t.Errorf("payload = %+v, want %+v", got, want)
+	}
+}
+
+func TestAvroEventSchemaDefaultsEveryField(t *testing.T) {
+	text, err := avroEventSchema("test.kitchen_sink", reflect.TypeOf(avroKitchenSink{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := parseAvroSchema(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.Name != "go_eda.test.kitchen_sink" {
+		t.Errorf("record name = %s, want go_eda.test.kitchen_sink", schema.Name)
+	}
+
+	var check func(s *avroSchema, path string)
+	check = func(s *avroSchema, path string) {
+		for _, f := range s.Fields {
+			if !f.HasDefault {
+				t.Errorf("field %s.%s has no default", path, f.Name)
+			}
+			// Every default must be encodable with its own schema
+			if _, err := avroFromJSON(f.Default, f.Schema, true); err != nil {
+				t.Errorf("default of %s.%s: %v", path, f.Name, err)
+			}
+			if f.Schema.Type == "record" {
+				check(f.Schema, fmt.Sprintf("%s.%s", path, f.Name))
+			}
+		}
+	}
+	check(schema, schema.Name)
+}
+
+func jsonEqual(t *testing.T, a, b interface{}) bool {
+	t.Helper()
+	normalize := func(v interface{}) interface{} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out interface{}
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
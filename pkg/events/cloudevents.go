@@ -0,0 +1,272 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CloudEvents 1.0 structured mode, see https://github.com/cloudevents/spec
+const (
+	CloudEventsSpecVersion = "1.0"
+	ContentTypeCloudEvents = "application/cloudevents+json"
+)
+
+// Extension attributes carrying Event fields without a CloudEvents counterpart
+const (
+	extensionVersion     = "eventversion"
+	extensionCorrelation = "correlationid"
+	extensionCausation   = "causationid"
+)
+
+// cloudEventAttributes are the context attributes that are not extensions
+var cloudEventAttributes = map[string]bool{
+	"specversion": true, "id": true, "source": true, "type": true, "time": true,
+	"datacontenttype": true, "dataschema": true, "subject": true, "data": true, "data_base64": true,
+}
+
+// extensionName matches valid CloudEvents attribute names
+var extensionName = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// CloudEvent is an event in the CloudEvents 1.0 JSON format. Extension
+// attributes are serialized at the top level next to the context attributes.
+type CloudEvent struct {
+	SpecVersion     string
+	ID              string
+	Source          string
+	Type            string
+	Time            time.Time
+	DataContentType string
+	Data            json.RawMessage
+	Extensions      map[string]interface{}
+}
+
+// ToCloudEvent maps the event onto a CloudEvent emitted by source, a URI
+// reference identifying the publishing service (e.g. /go-eda/order-service).
+// Metadata becomes extension attributes. CloudEvents only allows lowercase
+// letters and digits in attribute names, so other characters are dropped
+// (service_version becomes serviceversion); FromCloudEvent restores the
+// metadata keys defined in this package.
+func (e *Event) ToCloudEvent(source string) (*CloudEvent, error) {
+	if source == "" {
+		return nil, errors.New("cloudevents source must not be empty")
+	}
+
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	ce := &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              e.ID,
+		Source:          source,
+		Type:            string(e.Type),
+		Time:            e.Timestamp,
+		DataContentType: ContentTypeJSON,
+		Data:            data,
+		Extensions:      make(map[string]interface{}, len(e.Metadata)+1),
+	}
+	for key, value := range e.Metadata {
+		name := extensionAttribute(key)
+		if name == "" || cloudEventAttributes[name] || name == extensionVersion || name == extensionCorrelation || name == extensionCausation {
+			return nil, fmt.Errorf("metadata key %q cannot be a cloudevents extension", key)
+		}
+		ce.Extensions[name] = value
+	}
+	if e.Version != 0 {
+		ce.Extensions[extensionVersion] = e.Version
+	}
+	if e.CorrelationID != "" {
+		ce.Extensions[extensionCorrelation] = e.CorrelationID
+	}
+	if e.CausationID != "" {
+		ce.Extensions[extensionCausation] = e.CausationID
+	}
+	return ce, nil
+}
+
+// FromCloudEvent converts a CloudEvent back to an Event. Extension attributes
+// become metadata and the payload is upcast like in UnmarshalEvent.
+func FromCloudEvent(ce *CloudEvent) (*Event, error) {
+	if ce.SpecVersion != CloudEventsSpecVersion {
+		return nil, fmt.Errorf("unsupported cloudevents specversion %q", ce.SpecVersion)
+	}
+	if ce.DataContentType != "" && ce.DataContentType != ContentTypeJSON {
+		return nil, fmt.Errorf("unsupported cloudevents datacontenttype %q", ce.DataContentType)
+	}
+
+	event := &Event{
+		ID:        ce.ID,
+		Type:      EventType(ce.Type),
+		Timestamp: ce.Time,
+	}
+	for name, value := range ce.Extensions {
+		switch name {
+		case extensionVersion:
+			version, err := strconv.Atoi(fmt.Sprint(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s extension %v: %w", extensionVersion, value, err)
+			}
+			event.Version = version
+		case extensionCorrelation:
+			event.CorrelationID = fmt.Sprint(value)
+		case extensionCausation:
+			event.CausationID = fmt.Sprint(value)
+		default:
+			event.SetMetadata(metadataKey(name), fmt.Sprint(value))
+		}
+	}
+
+	payload, version, err := upcast(event.Type, event.Version, ce.Data)
+	if err != nil {
+		return nil, err
+	}
+	event.Version = version
+	if err := unmarshalData(event, payload); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// MarshalJSON renders the CloudEvents JSON format
+func (ce *CloudEvent) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]interface{}, len(ce.Extensions)+7)
+	for name, value := range ce.Extensions {
+		if cloudEventAttributes[name] || !extensionName.MatchString(name) {
+			return nil, fmt.Errorf("invalid cloudevents extension name %q", name)
+		}
+		fields[name] = value
+	}
+	fields["specversion"] = ce.SpecVersion
+	fields["id"] = ce.ID
+	fields["source"] = ce.Source
+	fields["type"] = ce.Type
+	if !ce.Time.IsZero() {
+		fields["time"] = ce.Time.Format(time.RFC3339Nano)
+	}
+	if ce.DataContentType != "" {
+		fields["datacontenttype"] = ce.DataContentType
+	}
+	if len(ce.Data) > 0 {
+		fields["data"] = ce.Data
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON parses the CloudEvents JSON format, checking the required attributes
+func (ce *CloudEvent) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	var parsed CloudEvent
+	for name, target := range map[string]*string{
+		"specversion":     &parsed.SpecVersion,
+		"id":              &parsed.ID,
+		"source":          &parsed.Source,
+		"type":            &parsed.Type,
+		"datacontenttype": &parsed.DataContentType,
+	} {
+		raw, ok := fields[name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, target); err != nil {
+			return fmt.Errorf("invalid cloudevents attribute %s: %w", name, err)
+		}
+	}
+	for _, name := range []string{"specversion", "id", "source", "type"} {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("missing required cloudevents attribute %s", name)
+		}
+	}
+
+	if raw, ok := fields["time"]; ok {
+		if err := json.Unmarshal(raw, &parsed.Time); err != nil {
+			return fmt.Errorf("invalid cloudevents attribute time: %w", err)
+		}
+	}
+	if _, ok := fields["data_base64"]; ok {
+		return errors.New("binary cloudevents data (data_base64) is not supported")
+	}
+	if raw, ok := fields["data"]; ok && !bytes.Equal(raw, []byte("null")) {
+		parsed.Data = raw
+	}
+
+	for name, raw := range fields {
+		if cloudEventAttributes[name] {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return fmt.Errorf("invalid cloudevents extension %s: %w", name, err)
+		}
+		if parsed.Extensions == nil {
+			parsed.Extensions = make(map[string]interface{})
+		}
+		parsed.Extensions[name] = value
+	}
+
+	*ce = parsed
+	return nil
+}
+
+// extensionAttribute derives a valid extension attribute name from a metadata key
+func extensionAttribute(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return -1
+		}
+	}, key)
+}
+
+// metadataKey reverses extensionAttribute for the metadata keys defined here
+func metadataKey(name string) string {
+	for _, key := range []string{MetadataSequence, MetadataServiceVersion} {
+		if extensionAttribute(key) == name {
+			return key
+		}
+	}
+	return name
+}
+
+// CloudEventsSerializer writes events as structured-mode CloudEvents
+type CloudEventsSerializer struct {
+	// Source is the CloudEvents source of marshaled events
+	Source string
+}
+
+// Marshal serializes the event as a CloudEvents JSON document
+func (s CloudEventsSerializer) Marshal(event *Event) ([]byte, error) {
+	ce, err := event.ToCloudEvent(s.Source)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ce)
+}
+
+// Unmarshal deserializes a CloudEvents JSON document
+func (CloudEventsSerializer) Unmarshal(data []byte) (*Event, error) {
+	var ce CloudEvent
+	if err := json.Unmarshal(data, &ce); err != nil {
+		return nil, err
+	}
+	return FromCloudEvent(&ce)
+}
+
+// ContentType returns application/cloudevents+json
+func (CloudEventsSerializer) ContentType() string {
+	return ContentTypeCloudEvents
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/tanint/go-eda/internal/models"
+)
+
+// checkCloudEventsSchema fails unless doc satisfies the CloudEvents 1.0 JSON
+// schema (https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/formats/cloudevents.json)
+func checkCloudEventsSchema(t *testing.T, doc map[string]interface{}) {
+	t.Helper()
+	nonEmptyString := func(name string) string {
+		t.Helper()
+		s, ok := doc[name].(string)
+		if !ok || s == "" {
+			t.Errorf("%s = %v, want a non-empty string", name, doc[name])
+		}
+		return s
+	}
+
+	for _, name := range []string{"specversion", "id", "source", "type"} {
+		if _, ok := doc[name]; !ok {
+			t.Errorf("required attribute %s missing", name)
+		}
+	}
+	if v := nonEmptyString("specversion"); v != "1.0" {
+		t.Errorf("specversion %q, want 1.0", v)
+	}
+	nonEmptyString("id")
+	nonEmptyString("type")
+	if _, err := url.Parse(nonEmptyString("source")); err != nil {
+		t.Errorf("source is not a URI-reference: %v", err)
+	}
+	if v, ok := doc["time"]; ok {
+		if s, _ := v.(string); s == "" {
+			t.Errorf("time = %v, want an RFC 3339 string", v)
+		} else if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			t.Errorf("time is not RFC 3339: %v", err)
+		}
+	}
+	if _, ok := doc["datacontenttype"]; ok {
+		nonEmptyString("datacontenttype")
+	}
+
+	attributeName := regexp.MustCompile(`^[a-z0-9]{1,20}$`)
+	for name, value := range doc {
+		if name == "data" {
+			continue
+		}
+		if !attributeName.MatchString(name) {
+			t.Errorf("attribute name %q does not match %s", name, attributeName)
+		}
+		switch value.(type) {
+		case string, float64, bool:
+		default:
+			t.Errorf("attribute %s = %v (%T), want a string, number or boolean", name, value, value)
+		}
+	}
+}
+
+// cloudEventsTestEvent returns an order.created event using every mapped field
+func cloudEventsTestEvent() *Event {
+	parent := NewEvent(EventTypeOrderCreated, nil)
+	event := NewChildEvent(parent, EventTypeOrderCreated, OrderCreatedEvent{
+		Order: models.Order{ID: "order-1", CustomerID: "customer-1", Currency: "EUR", TotalPrice: 20},
+	})
+	event.Timestamp = time.Date(2024, 3, 1, 12, 0, 0, 123_000_000, time.UTC)
+	event.SetMetadata(MetadataServiceVersion, "v1.2.3")
+	event.SetMetadata(MetadataSequence, "7")
+	return event
+}
+
+func TestToCloudEventMatchesTheSchema(t *testing.T) {
+	event := cloudEventsTestEvent()
+	data, err := CloudEventsSerializer{Source: "/go-eda/order-service"}.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	checkCloudEventsSchema(t, doc)
+
+	want := map[string]interface{}{
+		"specversion":     "1.0",
+		"id":              event.ID,
+		"source":          "/go-eda/order-service",
+		"type":            "order.created",
+		"time":            "2024-03-01T12:00:00.123Z",
+		"datacontenttype": "application/json",
+		"serviceversion":  "v1.2.3",
+		"sequence":        "7",
+		"correlationid":   event.CorrelationID,
+		"causationid":     event.CausationID,
+	}
+	for name, value := range want {
+		if doc[name] != value {
+			t.Errorf("%s = %v, want %v", name, doc[name], value)
+		}
+	}
+	if order, _ := doc["data"].(map[string]interface{})["order"].(map[string]interface{}); order["id"] != "order-1" {
+		t.Errorf("data = %v, want the order payload", doc["data"])
+	}
+}
+
+func TestCloudEventRoundTrip(t *testing.T) {
+	event := cloudEventsTestEvent()
+	serializer := CloudEventsSerializer{Source: "/go-eda/order-service"}
+	data, err := serializer.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := serializer.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ID != event.ID || decoded.Type != event.Type || !decoded.Timestamp.Equal(event.Timestamp) {
+		t.Errorf("decoded %s %s at %s, want %s %s at %s",
+			decoded.ID, decoded.Type, decoded.Timestamp, event.ID, event.Type, event.Timestamp)
+	}
+	if decoded.CorrelationID != event.CorrelationID || decoded.CausationID != event.CausationID {
+		t.Errorf("decoded correlation %s / causation %s, want %s / %s",
+			decoded.CorrelationID, decoded.CausationID, event.CorrelationID, event.CausationID)
+	}
+	if decoded.Metadata[MetadataServiceVersion] != "v1.2.3" || decoded.Metadata[MetadataSequence] != "7" {
+		t.Errorf("decoded metadata %v, want the original keys restored", decoded.Metadata)
+	}
+	created, err := DecodeData[OrderCreatedEvent](decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Order.ID != "order-1" || created.Order.TotalPrice != 20 {
+		t.Errorf("decoded order %+v, want order-1 totalling 20", created.Order)
+	}
+}
+
+func TestCloudEventsSourceIsPerService(t *testing.T) {
+	event := cloudEventsTestEvent()
+	for _, source := range []string{"/go-eda/order-service", "/go-eda/inventory-service"} {
+		ce, err := event.ToCloudEvent(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ce.Source != source {
+			t.Errorf("source %q, want %q", ce.Source, source)
+		}
+	}
+	if _, err := event.ToCloudEvent(""); err == nil {
+		t.Error("converted with an empty source")
+	}
+	if _, err := NewSerializer("cloudevents", ""); err == nil {
+		t.Error("created a cloudevents serializer without a source")
+	}
+}
+
+func TestCloudEventsSerializerRejectsInvalidDocuments(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{name: "missing id", doc: `{"specversion": "1.0", "source": "/s", "type": "order.created"}`},
+		{name: "missing source", doc: `{"specversion": "1.0", "id": "1", "type": "order.created"}`},
+		{name: "other specversion", doc: `{"specversion": "0.3", "id": "1", "source": "/s", "type": "order.created"}`},
+		{name: "non-JSON data", doc: `{"specversion": "1.0", "id": "1", "source": "/s", "type": "order.created", "datacontenttype": "application/xml", "data": "<order/>"}`},
+		{name: "binary data", doc: `{"specversion": "1.0", "id": "1", "source": "/s", "type": "order.created", "data_base64": "e30="}`},
+		{name: "malformed time", doc: `{"specversion": "1.0", "id": "1", "source": "/s", "type": "order.created", "time": "yesterday"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (CloudEventsSerializer{}).Unmarshal([]byte(tt.doc)); err == nil {
+				t.Error("decoded an invalid CloudEvents document")
+			}
+		})
+	}
+}
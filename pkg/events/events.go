@@ -1,9 +1,15 @@
 package events
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
 	"time"
 
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/tanint/go-eda/internal/models"
 )
 
@@ -12,18 +18,38 @@ type EventType string
 
 const (
 	EventTypeOrderCreated       EventType = "order.created"
+	EventTypeOrderCreatedChunk  EventType = "order.created.chunk"
+	EventTypeOrderUpdated       EventType = "order.updated"
 	EventTypeOrderConfirmed     EventType = "order.confirmed"
+	EventTypeOrderRejected      EventType = "order.rejected"
+	EventTypeOrderStatusChanged EventType = "order.status_changed"
+	EventTypeOrderState         EventType = "order.state"
 	EventTypeInventoryReserved  EventType = "inventory.reserved"
 	EventTypeInventoryReleased  EventType = "inventory.released"
 	EventTypeNotificationSent   EventType = "notification.sent"
+	EventTypeServiceHeartbeat   EventType = "service.heartbeat"
+)
+
+// Metadata keys
+const (
+	// MetadataSequence carries a per-aggregate sequence number
+	MetadataSequence = "sequence"
+	// MetadataServiceVersion carries the version of the service that published the event
+	MetadataServiceVersion = "service_version"
 )
 
 // Event represents a base event structure
 type Event struct {
-	ID        string      `json:"id"`
-	Type      EventType   `json:"type"`
-	Timestamp time.Time   `json:"timestamp"`
-	Data      interface{} `json:"data"`
+	ID        string            `json:"id"`
+	Type      EventType         `json:"type"`
+	Version   int               `json:"version,omitempty"` // payload schema version, see RegisterUpcaster
+	Timestamp time.Time         `json:"timestamp"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Data      interface{}       `json:"data"`
+	// CorrelationID is shared by all events of a causal chain, the ID of its first event
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// CausationID is the ID of the event this event was published in response to
+	CausationID string `json:"causation_id,omitempty"`
 }
 
 // OrderCreatedEvent represents an order creation event
@@ -31,34 +57,161 @@ type OrderCreatedEvent struct {
 	Order models.Order `json:"order"`
 }
 
+// OrderCreatedChunkEvent carries one slice of a large order's items. All
+// chunks share the order header (ID, customer, total); Order.Items holds only
+// this chunk's items. The chunk with ChunkIndex ChunkTotal-1 is the last one.
+type OrderCreatedChunkEvent struct {
+	Order      models.Order `json:"order"`
+	ChunkIndex int          `json:"chunk_index" validate:"gte=0,ltfield=ChunkTotal"`
+	ChunkTotal int          `json:"chunk_total" validate:"gt=0"`
+}
+
+// NewOrderCreatedChunks splits the order's items into order.created.chunk
+// events of at most size items each
+func NewOrderCreatedChunks(order models.Order, size int) []*Event {
+	total := (len(order.Items) + size - 1) / size
+	chunks := make([]*Event, 0, total)
+	for i := 0; i < total; i++ {
+		end := min((i+1)*size, len(order.Items))
+
+		chunk := order
+		chunk.Items = order.Items[i*size : end]
+		chunks = append(chunks, NewEvent(EventTypeOrderCreatedChunk, OrderCreatedChunkEvent{
+			Order:      chunk,
+			ChunkIndex: i,
+			ChunkTotal: total,
+		}))
+	}
+	return chunks
+}
+
+// OrderUpdatedEvent represents an order update event carrying the new state and what changed
+type OrderUpdatedEvent struct {
+	Order models.Order     `json:"order"`
+	Diff  models.OrderDiff `json:"diff"`
+}
+
+// NewOrderUpdatedEvent creates an order.updated event from the previous and current order state
+func NewOrderUpdatedEvent(old, new models.Order) *Event {
+	return NewEvent(EventTypeOrderUpdated, OrderUpdatedEvent{
+		Order: new,
+		Diff:  models.DiffOrders(old, new),
+	})
+}
+
 // OrderConfirmedEvent represents an order confirmation event
 type OrderConfirmedEvent struct {
-	OrderID    string    `json:"order_id"`
-	CustomerID string    `json:"customer_id"`
+	OrderID     string    `json:"order_id" validate:"required"`
+	CustomerID  string    `json:"customer_id" validate:"required"`
 	ConfirmedAt time.Time `json:"confirmed_at"`
 }
 
+// OrderRejectedEvent represents an order rejected after it was accepted
+type OrderRejectedEvent struct {
+	OrderID    string    `json:"order_id,omitempty"`
+	CustomerID string    `json:"customer_id" validate:"required"`
+	Reason     string    `json:"reason" validate:"required"`
+	RejectedAt time.Time `json:"rejected_at"`
+}
+
+// OrderStatusChangedEvent is the audit record of an order status transition
+type OrderStatusChangedEvent struct {
+	OrderID   string             `json:"order_id" validate:"required"`
+	From      models.OrderStatus `json:"from" validate:"required"`
+	To        models.OrderStatus `json:"to" validate:"required"`
+	ChangedAt time.Time          `json:"changed_at"`
+	Actor     string             `json:"actor" validate:"required"`
+}
+
+// NewOrderStatusChangedEvent creates an order.status_changed event from a
+// transition caused by parent, which may be nil (see NewChildEvent)
+func NewOrderStatusChangedEvent(parent *Event, t models.StatusTransition) *Event {
+	return NewChildEvent(parent, EventTypeOrderStatusChanged, OrderStatusChangedEvent{
+		OrderID:   t.OrderID,
+		From:      t.From,
+		To:        t.To,
+		ChangedAt: t.At,
+		Actor:     t.Actor,
+	})
+}
+
+// OrderStateEvent is a snapshot of an order's current state, published keyed
+// by order ID to the compacted order state topic
+type OrderStateEvent struct {
+	OrderID    string             `json:"order_id" validate:"required"`
+	CustomerID string             `json:"customer_id"`
+	Status     models.OrderStatus `json:"status" validate:"required"`
+	Total      float64            `json:"total"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
 // InventoryReservedEvent represents an inventory reservation event
 type InventoryReservedEvent struct {
-	OrderID    string                  `json:"order_id"`
-	Items      []InventoryReservation  `json:"items"`
-	ReservedAt time.Time               `json:"reserved_at"`
+	OrderID    string                 `json:"order_id" validate:"required"`
+	Items      []InventoryReservation `json:"items" validate:"required,dive"`
+	ReservedAt time.Time              `json:"reserved_at"`
 }
 
 // InventoryReservation represents a single item reservation
 type InventoryReservation struct {
-	ProductID string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
+	ProductID string `json:"product_id" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"gt=0"`
+}
+
+// InventoryReleasedEvent reverses an inventory reservation once its order was
+// cancelled or failed, the compensation of InventoryReservedEvent
+type InventoryReleasedEvent struct {
+	OrderID    string                 `json:"order_id" validate:"required"`
+	Items      []InventoryReservation `json:"items" validate:"required,dive"`
+	Reason     models.OrderStatus     `json:"reason" validate:"required"` // status the order moved to
+	ReleasedAt time.Time              `json:"released_at"`
+}
+
+// ServiceHeartbeatEvent is published periodically by a running service instance
+type ServiceHeartbeatEvent struct {
+	Service   string    `json:"service" validate:"required"`
+	Instance  string    `json:"instance"`
+	Version   string    `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // NewEvent creates a new event with the given type and data
 func NewEvent(eventType EventType, data interface{}) *Event {
+	id := generateEventID()
 	return &Event{
-		ID:        generateEventID(),
-		Type:      eventType,
-		Timestamp: time.Now(),
-		Data:      data,
+		ID:            id,
+		Type:          eventType,
+		Version:       CurrentVersion(eventType),
+		Timestamp:     time.Now(),
+		Data:          data,
+		CorrelationID: id,
+	}
+}
+
+// NewChildEvent creates an event published in response to parent: it joins
+// the parent's correlation chain and records the parent as its cause. A nil
+// parent starts a new chain, like NewEvent.
+func NewChildEvent(parent *Event, eventType EventType, data interface{}) *Event {
+	event := NewEvent(eventType, data)
+	if parent == nil {
+		return event
+	}
+
+	event.CorrelationID = parent.CorrelationID
+	if event.CorrelationID == "" {
+		// The parent predates correlation IDs and starts the chain
+		event.CorrelationID = parent.ID
 	}
+	event.CausationID = parent.ID
+	return event
+}
+
+// SetMetadata sets a metadata value on the event
+func (e *Event) SetMetadata(key, value string) {
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]string)
+	}
+	e.Metadata[key] = value
 }
 
 // Marshal serializes the event to JSON
@@ -66,24 +219,162 @@ func (e *Event) Marshal() ([]byte, error) {
 	return json.Marshal(e)
 }
 
-// UnmarshalEvent deserializes JSON to an Event
+// UnmarshalEvent deserializes JSON to an Event, upcasting payloads written
+// with an older schema version to the current one. Unknown fields are
+// ignored; see Decoder for strict decoding.
 func UnmarshalEvent(data []byte) (*Event, error) {
-	var event Event
-	if err := json.Unmarshal(data, &event); err != nil {
+	return Decoder{}.UnmarshalEvent(data)
+}
+
+// unmarshalData decodes the payload into event.Data without a target type
+func unmarshalData(event *Event, payload json.RawMessage) error {
+	if len(payload) == 0 {
+		return nil
+	}
+	return json.Unmarshal(payload, &event.Data)
+}
+
+// decodeEnvelope decodes everything but the payload, which is returned
+// upcast to the current version but still encoded
+func (d Decoder) decodeEnvelope(data []byte) (*Event, json.RawMessage, error) {
+	type rawEvent Event
+	var raw struct {
+		rawEvent
+		Data json.RawMessage `json:"data"`
+	}
+	if err := d.Unmarshal(data, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	payload, version, err := upcast(raw.Type, raw.Version, raw.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	event := Event(raw.rawEvent)
+	event.Version = version
+	return &event, payload, nil
+}
+
+// DecodeData decodes the payload of e into T, ignoring unknown fields; see
+// DecodeDataWith
+func DecodeData[T any](e *Event) (T, error) {
+	return DecodeDataWith[T](Decoder{}, e)
+}
+
+// DecodeDataWith decodes the payload of e into T with d. Data decoded from
+// JSON (a map[string]interface{}) goes through d.Unmarshal, honoring strict
+// decoding; a payload that already is a T or *T, as on locally built events,
+// is used as is. Both paths validate the result.
+func DecodeDataWith[T any](d Decoder, e *Event) (T, error) {
+	var v T
+	switch data := e.Data.(type) {
+	case T:
+		v = data
+	case *T:
+		if data == nil {
+			return v, fmt.Errorf("%s event has nil %T data", e.Type, data)
+		}
+		v = *data
+	case map[string]interface{}, []interface{}:
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return v, fmt.Errorf("failed to encode %s event data: %w", e.Type, err)
+		}
+		if err := d.Unmarshal(raw, &v); err != nil {
+			return v, fmt.Errorf("failed to decode %s event data into %T: %w", e.Type, v, err)
+		}
+		return v, nil
+	default:
+		return v, fmt.Errorf("%s event data is %T, not %T", e.Type, e.Data, v)
+	}
+
+	if err := Validate(&v); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// Decoder decodes events and their payloads. Its zero value ignores JSON
+// fields that the target type does not declare, so older consumers keep
+// working as event schemas gain fields.
+type Decoder struct {
+	// Strict rejects JSON fields that the target type does not declare,
+	// e.g. in contract tests
+	Strict bool
+}
+
+// UnmarshalEvent is like the package's UnmarshalEvent, honoring d.Strict
+func (d Decoder) UnmarshalEvent(data []byte) (*Event, error) {
+	event, payload, err := d.decodeEnvelope(data)
+	if err != nil {
 		return nil, err
 	}
-	return &event, nil
+	if err := unmarshalData(event, payload); err != nil {
+		return nil, err
+	}
+	return event, nil
 }
 
-func generateEventID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(8)
+// UnmarshalEnvelope decodes the event's envelope, honoring d.Strict, and
+// leaves its Data nil, e.g. to read the event's metadata when its payload
+// may not decode
+func (d Decoder) UnmarshalEnvelope(data []byte) (*Event, error) {
+	event, _, err := d.decodeEnvelope(data)
+	return event, err
 }
 
-func randomString(n int) string {
-	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, n)
-	for i := range b {
-		b[i] = letters[time.Now().UnixNano()%int64(len(letters))]
+// Unmarshal decodes JSON event data into v, honoring d.Strict, then checks
+// the `validate` struct tags of v
+func (d Decoder) Unmarshal(data []byte, v interface{}) error {
+	if !d.Strict {
+		if err := json.Unmarshal(data, v); err != nil {
+			return err
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(v); err != nil {
+			return err
+		}
+	}
+	return Validate(v)
+}
+
+// ErrInvalidPayload is wrapped by Unmarshal when decoded data fails validation
+var ErrInvalidPayload = errors.New("invalid event payload")
+
+var validate = validator.New(validator.WithRequiredStructEnabled())
+
+// Unmarshal decodes JSON event data into v, ignoring unknown fields, then
+// checks the `validate` struct tags of v; see Decoder
+func Unmarshal(data []byte, v interface{}) error {
+	return Decoder{}.Unmarshal(data, v)
+}
+
+// Validate checks the `validate` struct tags of a payload. Values that are
+// not structs (or pointers to structs) are always valid.
+func Validate(v interface{}) error {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	if err := validate.Struct(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	return nil
+}
+
+// generateEventID returns a UUIDv7: random enough to never collide and
+// time-ordered, so IDs sort roughly by creation time
+func generateEventID() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
 	}
-	return string(b)
+	return id.String()
 }
@@ -0,0 +1,319 @@
+package events
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tanint/go-eda/internal/models"
+)
+
+func TestGenerateEventIDIsUniqueUnderConcurrency(t *testing.T) {
+	const (
+		goroutines = 8
+		perRoutine = 100_000 / goroutines
+	)
+
+	ids := make([][]string, goroutines)
+	var wg sync.WaitGroup
+	for g := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids[g] = make([]string, perRoutine)
+			for i := range ids[g] {
+				ids[g][i] = generateEventID()
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[string]struct{}, goroutines*perRoutine)
+	for _, batch := range ids {
+		for _, id := range batch {
+			if _, dup := seen[id]; dup {
+				t.Fatalf("event ID %s generated twice", id)
+			}
+			seen[id] = struct{}{}
+		}
+	}
+}
+
+func TestGenerateEventIDIsTimeOrdered(t *testing.T) {
+	prev := generateEventID()
+	for i := 0; i < 10_000; i++ {
+		id := generateEventID()
+		if id <= prev {
+			t.Fatalf("event ID %s sorts before the earlier %s", id, prev)
+		}
+		prev = id
+	}
+
+	parsed, err := uuid.Parse(prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Version() != 7 {
+		t.Errorf("event ID %s is a version %d UUID, want version 7", prev, parsed.Version())
+	}
+}
+
+func TestDecoderUnknownFields(t *testing.T) {
+	data := []byte(`{"id": "event-1", "type": "order.confirmed", "partition_hint": 3, "data": {"order_id": "order-1", "customer_id": "customer-1", "loyalty_tier": "gold"}}`)
+	tests := []struct {
+		name    string
+		decoder Decoder
+		wantErr bool
+	}{
+		{name: "lenient", decoder: Decoder{}},
+		{name: "strict", decoder: Decoder{Strict: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := tt.decoder.UnmarshalEvent(data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("decoded an envelope with an unknown field")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			confirmed, err := DecodeDataWith[OrderConfirmedEvent](tt.decoder, event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if confirmed.OrderID != "order-1" {
+				t.Errorf("order ID %q, want order-1", confirmed.OrderID)
+			}
+		})
+	}
+}
+
+func TestStrictDecoderRejectsUnknownPayloadFields(t *testing.T) {
+	event, err := UnmarshalEvent([]byte(`{"id": "event-1", "type": "order.confirmed", "data": {"order_id": "order-1", "customer_id": "customer-1", "loyalty_tier": "gold"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeDataWith[OrderConfirmedEvent](Decoder{Strict: true}, event); err == nil {
+		t.Error("strict decoder accepted a payload with an unknown field")
+	}
+	if _, err := DecodeData[OrderConfirmedEvent](event); err != nil {
+		t.Errorf("lenient decoder rejected a payload with an unknown field: %v", err)
+	}
+}
+
+func TestUnmarshalEnvelopeSkipsThePayload(t *testing.T) {
+	data := []byte(`{"id": "event-1", "type": "order.created", "correlation_id": "chain-1", "data": {"order": {"id": "order-1"}}}`)
+	event, err := Decoder{}.UnmarshalEnvelope(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.ID != "event-1" || event.CorrelationID != "chain-1" || event.Data != nil {
+		t.Errorf("envelope %+v, want event-1 of chain-1 without data", event)
+	}
+	if _, err := (Decoder{Strict: true}).UnmarshalEnvelope([]byte(`{"id": "event-1", "extra": true}`)); err == nil {
+		t.Error("strict decoder accepted an unknown envelope field")
+	}
+}
+
+func TestOrderUpdatedEventCarriesDiff(t *testing.T) {
+	old := models.Order{ID: "order-1", CustomerID: "customer-1", Status: models.OrderStatusPending, TotalPrice: 10}
+	updated := old
+	updated.Status = models.OrderStatusConfirmed
+
+	data, err := NewOrderUpdatedEvent(old, updated).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := UnmarshalEvent(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := DecodeData[OrderUpdatedEvent](event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Order.Status != models.OrderStatusConfirmed {
+		t.Errorf("order status = %q, want %q", payload.Order.Status, models.OrderStatusConfirmed)
+	}
+	want := models.StatusChange{From: models.OrderStatusPending, To: models.OrderStatusConfirmed}
+	if payload.Diff.Status == nil || *payload.Diff.Status != want {
+		t.Errorf("diff status = %+v, want %+v", payload.Diff.Status, want)
+	}
+}
+
+func TestPayloadValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		decode  func(*Event) error
+		wantErr bool
+	}{
+		{
+			name:   "valid reservation",
+			data:   `{"order_id": "order-1", "items": [{"product_id": "p1", "quantity": 2}]}`,
+			decode: decodeAs[InventoryReservedEvent],
+		},
+		{
+			name:    "reservation without order",
+			data:    `{"items": [{"product_id": "p1", "quantity": 2}]}`,
+			decode:  decodeAs[InventoryReservedEvent],
+			wantErr: true,
+		},
+		{
+			name:    "negative reserved quantity",
+			data:    `{"order_id": "order-1", "items": [{"product_id": "p1", "quantity": -1}]}`,
+			decode:  decodeAs[InventoryReservedEvent],
+			wantErr: true,
+		},
+		{
+			name:   "valid confirmation",
+			data:   `{"order_id": "order-1", "customer_id": "customer-1"}`,
+			decode: decodeAs[OrderConfirmedEvent],
+		},
+		{
+			name:    "confirmation without customer",
+			data:    `{"order_id": "order-1"}`,
+			decode:  decodeAs[OrderConfirmedEvent],
+			wantErr: true,
+		},
+		{
+			name:    "chunk index past the total",
+			data:    `{"order": {"customer_id": "customer-1"}, "chunk_index": 2, "chunk_total": 2}`,
+			decode:  decodeAs[OrderCreatedChunkEvent],
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := UnmarshalEvent([]byte(`{"id": "event-1", "type": "test", "data": ` + tt.data + `}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = tt.decode(event)
+			if tt.wantErr != errors.Is(err, ErrInvalidPayload) {
+				t.Errorf("decoding = %v, want ErrInvalidPayload %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("decoding a valid payload: %v", err)
+			}
+		})
+	}
+}
+
+func TestLocallyBuiltPayloadsAreValidated(t *testing.T) {
+	event := NewEvent(EventTypeInventoryReserved, InventoryReservedEvent{
+		OrderID: "order-1",
+		Items:   []InventoryReservation{{ProductID: "p1", Quantity: 0}},
+	})
+	if _, err := DecodeData[InventoryReservedEvent](event); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("DecodeData() = %v, want ErrInvalidPayload for a zero quantity", err)
+	}
+}
+
+func TestDecodeData(t *testing.T) {
+	confirmed := OrderConfirmedEvent{OrderID: "order-1", CustomerID: "customer-1"}
+	tests := []struct {
+		name    string
+		decoder Decoder
+		data    interface{}
+		want    OrderConfirmedEvent
+		wantErr string
+	}{
+		{
+			name: "decoded JSON",
+			data: map[string]interface{}{"order_id": "order-1", "customer_id": "customer-1"},
+			want: confirmed,
+		},
+		{
+			name: "decoded JSON with an unknown field",
+			data: map[string]interface{}{"order_id": "order-1", "customer_id": "customer-1", "channel": "web"},
+			want: confirmed,
+		},
+		{
+			name:    "decoded JSON with an unknown field, strictly",
+			decoder: Decoder{Strict: true},
+			data:    map[string]interface{}{"order_id": "order-1", "customer_id": "customer-1", "channel": "web"},
+			wantErr: "failed to decode order.confirmed event data",
+		},
+		{name: "locally built value", data: confirmed, want: confirmed},
+		{name: "locally built pointer", data: &confirmed, want: confirmed},
+		{
+			name:    "nil pointer",
+			data:    (*OrderConfirmedEvent)(nil),
+			wantErr: "order.confirmed event has nil *events.OrderConfirmedEvent data",
+		},
+		{
+			name:    "another payload type",
+			data:    OrderRejectedEvent{CustomerID: "customer-1", Reason: "out of stock"},
+			wantErr: "order.confirmed event data is events.OrderRejectedEvent, not events.OrderConfirmedEvent",
+		},
+		{
+			name:    "invalid locally built value",
+			data:    OrderConfirmedEvent{OrderID: "order-1"},
+			wantErr: ErrInvalidPayload.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &Event{Type: EventTypeOrderConfirmed, Data: tt.data}
+			got, err := DecodeDataWith[OrderConfirmedEvent](tt.decoder, event)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("DecodeDataWith() = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeDataWith() = %+v, want %+v", got, tt.want)
+			}
+			if tt.decoder == (Decoder{}) {
+				if got, err := DecodeData[OrderConfirmedEvent](event); err != nil || got != tt.want {
+					t.Errorf("DecodeData() = %+v, %v, want %+v", got, err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// decodeAs decodes the event's payload into T, discarding it
+func decodeAs[T any](e *Event) error {
+	_, err := DecodeData[T](e)
+	return err
+}
+
+func TestNewChildEventLinksToItsParent(t *testing.T) {
+	root := NewEvent(EventTypeOrderCreated, nil)
+	child := NewChildEvent(root, EventTypeInventoryReserved, nil)
+	grandchild := NewChildEvent(child, EventTypeOrderConfirmed, nil)
+	legacy := &Event{ID: "legacy-1", Type: EventTypeOrderCreated} // published before correlation IDs
+	orphan := NewChildEvent(nil, EventTypeOrderCreated, nil)
+
+	tests := []struct {
+		name            string
+		event           *Event
+		wantCorrelation string
+		wantCausation   string
+	}{
+		{name: "root", event: root, wantCorrelation: root.ID},
+		{name: "child", event: child, wantCorrelation: root.ID, wantCausation: root.ID},
+		{name: "grandchild", event: grandchild, wantCorrelation: root.ID, wantCausation: child.ID},
+		{name: "child of a legacy event", event: NewChildEvent(legacy, EventTypeInventoryReserved, nil), wantCorrelation: "legacy-1", wantCausation: "legacy-1"},
+		{name: "without a parent", event: orphan, wantCorrelation: orphan.ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.event.CorrelationID != tt.wantCorrelation || tt.event.CausationID != tt.wantCausation {
+				t.Errorf("correlation %q, causation %q; want %q, %q",
+					tt.event.CorrelationID, tt.event.CausationID, tt.wantCorrelation, tt.wantCausation)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+// Package eventspb holds the Protobuf messages of the event contracts,
+// generated from events.proto.
+//
+// The compiler is buf (pinned below) and the plugin protoc-gen-go at the
+// google.golang.org/protobuf version of go.mod, so regenerating needs
+// neither protoc nor protoc-gen-go installed. buf compiles in process, which
+// is why the generated header reports the protoc version as unknown.
+package eventspb
+
+//go:generate go run github.com/bufbuild/buf/cmd/buf@v1.47.2 generate ../../.. --template buf.gen.yaml --path ../../../pkg/events/eventspb/events.proto
@@ -0,0 +1,300 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events/eventspb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ProtobufSerializer encodes events with the messages in eventspb. Payload
+// types without a protobuf message are carried as JSON inside the envelope.
+// Decoded events hold their payload as a struct value (e.g.
+// InventoryReservedEvent) rather than a generic map; DecodeData handles both.
+type ProtobufSerializer struct{}
+
+// Marshal serializes the event to protobuf
+func (ProtobufSerializer) Marshal(event *Event) ([]byte, error) {
+	pb := &eventspb.Event{
+		Id:            event.ID,
+		Type:          string(event.Type),
+		Version:       int32(event.Version),
+		Timestamp:     toTimestamp(event.Timestamp),
+		Metadata:      event.Metadata,
+		CorrelationId: event.CorrelationID,
+		CausationId:   event.CausationID,
+	}
+	if err := setProtoData(pb, event.Data); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(pb)
+}
+
+// Unmarshal deserializes a protobuf event. JSON-carried payloads written with
+// an older schema version are upcast like in UnmarshalEvent.
+func (ProtobufSerializer) Unmarshal(data []byte) (*Event, error) {
+	var pb eventspb.Event
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return nil, err
+	}
+
+	event := &Event{
+		ID:            pb.Id,
+		Type:          EventType(pb.Type),
+		Version:       int(pb.Version),
+		Timestamp:     fromTimestamp(pb.Timestamp),
+		Metadata:      pb.Metadata,
+		CorrelationID: pb.CorrelationId,
+		CausationID:   pb.CausationId,
+	}
+
+	switch d := pb.Data.(type) {
+	case nil:
+	case *eventspb.Event_JsonData:
+		payload, version, err := upcast(event.Type, event.Version, d.JsonData)
+		if err != nil {
+			return nil, err
+		}
+		event.Version = version
+		if err := unmarshalData(event, payload); err != nil {
+			return nil, err
+		}
+	case *eventspb.Event_OrderCreated:
+		event.Data = OrderCreatedEvent{Order: fromProtoOrder(d.OrderCreated.Order)}
+	case *eventspb.Event_OrderCreatedChunk:
+		event.Data = OrderCreatedChunkEvent{
+			Order:      fromProtoOrder(d.OrderCreatedChunk.Order),
+			ChunkIndex: int(d.OrderCreatedChunk.ChunkIndex),
+			ChunkTotal: int(d.OrderCreatedChunk.ChunkTotal),
+		}
+	case *eventspb.Event_OrderUpdated:
+		event.Data = OrderUpdatedEvent{
+			Order: fromProtoOrder(d.OrderUpdated.Order),
+			Diff:  fromProtoDiff(d.OrderUpdated.Diff),
+		}
+	case *eventspb.Event_OrderConfirmed:
+		event.Data = OrderConfirmedEvent{
+			OrderID:     d.OrderConfirmed.OrderId,
+			CustomerID:  d.OrderConfirmed.CustomerId,
+			ConfirmedAt: fromTimestamp(d.OrderConfirmed.ConfirmedAt),
+		}
+	case *eventspb.Event_OrderRejected:
+		event.Data = OrderRejectedEvent{
+			OrderID:    d.OrderRejected.OrderId,
+			CustomerID: d.OrderRejected.CustomerId,
+			Reason:     d.OrderRejected.Reason,
+			RejectedAt: fromTimestamp(d.OrderRejected.RejectedAt),
+		}
+	case *eventspb.Event_InventoryReserved:
+		reserved := InventoryReservedEvent{
+			OrderID:    d.InventoryReserved.OrderId,
+			ReservedAt: fromTimestamp(d.InventoryReserved.ReservedAt),
+		}
+		for _, item := range d.InventoryReserved.Items {
+			reserved.Items = append(reserved.Items, InventoryReservation{
+				ProductID: item.ProductId,
+				Quantity:  int(item.Quantity),
+			})
+		}
+		event.Data = reserved
+	case *eventspb.Event_ServiceHeartbeat:
+		event.Data = ServiceHeartbeatEvent{
+			Service:   d.ServiceHeartbeat.Service,
+			Instance:  d.ServiceHeartbeat.Instance,
+			Version:   d.ServiceHeartbeat.Version,
+			Timestamp: fromTimestamp(d.ServiceHeartbeat.Timestamp),
+		}
+	default:
+		return nil, fmt.Errorf("unsupported protobuf payload %T", d)
+	}
+	return event, nil
+}
+
+// ContentType returns application/x-protobuf
+func (ProtobufSerializer) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+// setProtoData sets the payload oneof from the event data, a payload struct
+// or a pointer to one. Anything else is carried as JSON.
+func setProtoData(pb *eventspb.Event, data interface{}) error {
+	if data == nil {
+		return nil
+	}
+	if v := reflect.ValueOf(data); v.Kind() == reflect.Ptr && !v.IsNil() {
+		data = v.Elem().Interface()
+	}
+
+	switch d := data.(type) {
+	case OrderCreatedEvent:
+		pb.Data = &eventspb.Event_OrderCreated{OrderCreated: &eventspb.OrderCreated{
+			Order: toProtoOrder(d.Order),
+		}}
+	case OrderCreatedChunkEvent:
+		pb.Data = &eventspb.Event_OrderCreatedChunk{OrderCreatedChunk: &eventspb.OrderCreatedChunk{
+			Order:      toProtoOrder(d.Order),
+			ChunkIndex: int32(d.ChunkIndex),
+			ChunkTotal: int32(d.ChunkTotal),
+		}}
+	case OrderUpdatedEvent:
+		pb.Data = &eventspb.Event_OrderUpdated{OrderUpdated: &eventspb.OrderUpdated{
+			Order: toProtoOrder(d.Order),
+			Diff:  toProtoDiff(d.Diff),
+		}}
+	case OrderConfirmedEvent:
+		pb.Data = &eventspb.Event_OrderConfirmed{OrderConfirmed: &eventspb.OrderConfirmed{
+			OrderId:     d.OrderID,
+			CustomerId:  d.CustomerID,
+			ConfirmedAt: toTimestamp(d.ConfirmedAt),
+		}}
+	case OrderRejectedEvent:
+		pb.Data = &eventspb.Event_OrderRejected{OrderRejected: &eventspb.OrderRejected{
+			OrderId:    d.OrderID,
+			CustomerId: d.CustomerID,
+			Reason:     d.Reason,
+			RejectedAt: toTimestamp(d.RejectedAt),
+		}}
+	case InventoryReservedEvent:
+		reserved := &eventspb.InventoryReserved{
+			OrderId:    d.OrderID,
+			ReservedAt: toTimestamp(d.ReservedAt),
+		}
+		for _, item := range d.Items {
+			reserved.Items = append(reserved.Items, &eventspb.InventoryReservation{
+				ProductId: item.ProductID,
+				Quantity:  int64(item.Quantity),
+			})
+		}
+		pb.Data = &eventspb.Event_InventoryReserved{InventoryReserved: reserved}
+	case ServiceHeartbeatEvent:
+		pb.Data = &eventspb.Event_ServiceHeartbeat{ServiceHeartbeat: &eventspb.ServiceHeartbeat{
+			Service:   d.Service,
+			Instance:  d.Instance,
+			Version:   d.Version,
+			Timestamp: toTimestamp(d.Timestamp),
+		}}
+	default:
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event data: %w", err)
+		}
+		pb.Data = &eventspb.Event_JsonData{JsonData: raw}
+	}
+	return nil
+}
+
+func toProtoOrder(o models.Order) *eventspb.Order {
+	return &eventspb.Order{
+		Id:             o.ID,
+		CustomerId:     o.CustomerID,
+		Items:          toProtoItems(o.Items),
+		TotalPrice:     o.TotalPrice,
+		Currency:       o.Currency,
+		BaseCurrency:   o.BaseCurrency,
+		BaseTotalPrice: o.BaseTotalPrice,
+		Status:         string(o.Status),
+		CreatedAt:      toTimestamp(o.CreatedAt),
+		UpdatedAt:      toTimestamp(o.UpdatedAt),
+	}
+}
+
+func fromProtoOrder(o *eventspb.Order) models.Order {
+	return models.Order{
+		ID:             o.GetId(),
+		CustomerID:     o.GetCustomerId(),
+		Items:          fromProtoItems(o.GetItems()),
+		TotalPrice:     o.GetTotalPrice(),
+		Currency:       o.GetCurrency(),
+		BaseCurrency:   o.GetBaseCurrency(),
+		BaseTotalPrice: o.GetBaseTotalPrice(),
+		Status:         models.OrderStatus(o.GetStatus()),
+		CreatedAt:      fromTimestamp(o.GetCreatedAt()),
+		UpdatedAt:      fromTimestamp(o.GetUpdatedAt()),
+	}
+}
+
+func toProtoItems(items []models.OrderItem) []*eventspb.OrderItem {
+	var pb []*eventspb.OrderItem
+	for _, item := range items {
+		pb = append(pb, &eventspb.OrderItem{
+			ProductId: item.ProductID,
+			Quantity:  int64(item.Quantity),
+			Price:     item.Price,
+		})
+	}
+	return pb
+}
+
+func fromProtoItems(pb []*eventspb.OrderItem) []models.OrderItem {
+	var items []models.OrderItem
+	for _, item := range pb {
+		items = append(items, models.OrderItem{
+			ProductID: item.ProductId,
+			Quantity:  int(item.Quantity),
+			Price:     item.Price,
+		})
+	}
+	return items
+}
+
+func toProtoDiff(d models.OrderDiff) *eventspb.OrderDiff {
+	pb := &eventspb.OrderDiff{
+		ItemsAdded:   toProtoItems(d.ItemsAdded),
+		ItemsRemoved: toProtoItems(d.ItemsRemoved),
+		TotalDelta:   d.TotalDelta,
+	}
+	if d.Status != nil {
+		pb.Status = &eventspb.StatusChange{From: string(d.Status.From), To: string(d.Status.To)}
+	}
+	for _, c := range d.ItemsChanged {
+		pb.ItemsChanged = append(pb.ItemsChanged, &eventspb.OrderItemChange{
+			ProductId:   c.ProductID,
+			OldQuantity: int64(c.OldQuantity),
+			NewQuantity: int64(c.NewQuantity),
+			OldPrice:    c.OldPrice,
+			NewPrice:    c.NewPrice,
+		})
+	}
+	return pb
+}
+
+func fromProtoDiff(pb *eventspb.OrderDiff) models.OrderDiff {
+	d := models.OrderDiff{
+		ItemsAdded:   fromProtoItems(pb.GetItemsAdded()),
+		ItemsRemoved: fromProtoItems(pb.GetItemsRemoved()),
+		TotalDelta:   pb.GetTotalDelta(),
+	}
+	if s := pb.GetStatus(); s != nil {
+		d.Status = &models.StatusChange{From: models.OrderStatus(s.From), To: models.OrderStatus(s.To)}
+	}
+	for _, c := range pb.GetItemsChanged() {
+		d.ItemsChanged = append(d.ItemsChanged, models.OrderItemChange{
+			ProductID:   c.ProductId,
+			OldQuantity: int(c.OldQuantity),
+			NewQuantity: int(c.NewQuantity),
+			OldPrice:    c.OldPrice,
+			NewPrice:    c.NewPrice,
+		})
+	}
+	return d
+}
+
+// toTimestamp leaves zero times unset
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownEventType is returned by EventRegistry.Unmarshal for event types
+// without a registered payload factory
+var ErrUnknownEventType = errors.New("unknown event type")
+
+// EventRegistry maps event types to their concrete payload types, so a
+// consumer can decode any event and switch on the payload's Go type
+type EventRegistry struct {
+	mu        sync.RWMutex
+	factories map[EventType]func() interface{}
+}
+
+// NewEventRegistry creates an empty registry
+func NewEventRegistry() *EventRegistry {
+	return &EventRegistry{
+		factories: make(map[EventType]func() interface{}),
+	}
+}
+
+// DefaultRegistry knows the payload types of the events published by this
+// repository's services
+var DefaultRegistry = NewEventRegistry()
+
+func init() {
+	DefaultRegistry.Register(EventTypeOrderCreated, func() interface{} { return &OrderCreatedEvent{} })
+	DefaultRegistry.Register(EventTypeOrderCreatedChunk, func() interface{} { return &OrderCreatedChunkEvent{} })
+	DefaultRegistry.Register(EventTypeOrderUpdated, func() interface{} { return &OrderUpdatedEvent{} })
+	DefaultRegistry.Register(EventTypeOrderConfirmed, func() interface{} { return &OrderConfirmedEvent{} })
+	DefaultRegistry.Register(EventTypeOrderRejected, func() interface{} { return &OrderRejectedEvent{} })
+	DefaultRegistry.Register(EventTypeOrderStatusChanged, func() interface{} { return &OrderStatusChangedEvent{} })
+	DefaultRegistry.Register(EventTypeOrderState, func() interface{} { return &OrderStateEvent{} })
+	DefaultRegistry.Register(EventTypeInventoryReserved, func() interface{} { return &InventoryReservedEvent{} })
+	DefaultRegistry.Register(EventTypeInventoryReleased, func() interface{} { return &InventoryReleasedEvent{} })
+	DefaultRegistry.Register(EventTypeServiceHeartbeat, func() interface{} { return &ServiceHeartbeatEvent{} })
+	// notification.sent has no payload contract yet
+}
+
+// Register sets the factory returning a new, empty payload for the event
+// type. The factory should return a pointer so the payload can be decoded into it.
+func (r *EventRegistry) Register(eventType EventType, factory func() interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[eventType] = factory
+}
+
+// Unmarshal decodes an event and its payload into the type registered for
+// the event's type. The payload is returned and also set as the event's
+// Data. Events of unregistered types are returned with their generically
+// decoded payload, together with an error wrapping ErrUnknownEventType.
+func (r *EventRegistry) Unmarshal(data []byte) (*Event, interface{}, error) {
+	event, payload, err := Decoder{}.decodeEnvelope(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.RLock()
+	factory, ok := r.factories[event.Type]
+	r.mu.RUnlock()
+	if !ok {
+		if err := unmarshalData(event, payload); err != nil {
+			return nil, nil, err
+		}
+		return event, event.Data, fmt.Errorf("%w: %s", ErrUnknownEventType, event.Type)
+	}
+
+	v := factory()
+	if len(payload) > 0 {
+		if err := Unmarshal(payload, v); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode %s event data into %T: %w", event.Type, v, err)
+		}
+	}
+	event.Data = v
+	return event, v, nil
+}
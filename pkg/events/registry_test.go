@@ -0,0 +1,97 @@
+package events
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tanint/go-eda/internal/models"
+)
+
+// registrySamples holds a valid payload of every type in DefaultRegistry
+var registrySamples = map[EventType]interface{}{
+	EventTypeOrderCreated: OrderCreatedEvent{Order: models.Order{ID: "order-1", CustomerID: "customer-1"}},
+	EventTypeOrderCreatedChunk: OrderCreatedChunkEvent{
+		Order:      models.Order{ID: "order-1", CustomerID: "customer-1"},
+		ChunkIndex: 1,
+		ChunkTotal: 2,
+	},
+	EventTypeOrderUpdated:   OrderUpdatedEvent{Order: models.Order{ID: "order-1", CustomerID: "customer-1"}},
+	EventTypeOrderConfirmed: OrderConfirmedEvent{OrderID: "order-1", CustomerID: "customer-1"},
+	EventTypeOrderRejected:  OrderRejectedEvent{CustomerID: "customer-1", Reason: "out of stock"},
+	EventTypeOrderStatusChanged: OrderStatusChangedEvent{
+		OrderID: "order-1",
+		From:    models.OrderStatusPending,
+		To:      models.OrderStatusConfirmed,
+		Actor:   "inventory-service",
+	},
+	EventTypeOrderState: OrderStateEvent{OrderID: "order-1", Status: models.OrderStatusPending},
+	EventTypeInventoryReserved: InventoryReservedEvent{
+		OrderID: "order-1",
+		Items:   []InventoryReservation{{ProductID: "p1", Quantity: 2}},
+	},
+	EventTypeInventoryReleased: InventoryReleasedEvent{
+		OrderID: "order-1",
+		Items:   []InventoryReservation{{ProductID: "p1", Quantity: 2}},
+		Reason:  models.OrderStatusCancelled,
+	},
+	EventTypeServiceHeartbeat: ServiceHeartbeatEvent{Service: "order-service"},
+}
+
+func TestRegistryUnmarshalsEveryRegisteredType(t *testing.T) {
+	for _, eventType := range DefaultRegistry.Types() {
+		t.Run(string(eventType), func(t *testing.T) {
+			sample, ok := registrySamples[eventType]
+			if !ok {
+				t.Fatalf("no sample payload for %s", eventType)
+			}
+			data, err := NewEvent(eventType, sample).Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			event, payload, err := DefaultRegistry.Unmarshal(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := reflect.PointerTo(reflect.TypeOf(sample))
+			if reflect.TypeOf(payload) != want {
+				t.Fatalf("payload is %T, want %v", payload, want)
+			}
+			if event.Data != payload {
+				t.Errorf("event data %T is not the returned payload", event.Data)
+			}
+			if got := reflect.ValueOf(payload).Elem().Interface(); !reflect.DeepEqual(got, sample) {
+				t.Errorf("payload = %+v, want %+v", got, sample)
+			}
+		})
+	}
+}
+
+func TestRegistryUnmarshalOfAnUnknownType(t *testing.T) {
+	data, err := NewEvent("payment.captured", map[string]interface{}{"amount": 42.0}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event, payload, err := DefaultRegistry.Unmarshal(data)
+	if !errors.Is(err, ErrUnknownEventType) {
+		t.Fatalf("got %v, want ErrUnknownEventType", err)
+	}
+	if event == nil || event.Type != "payment.captured" {
+		t.Fatalf("event = %+v, want the decoded envelope", event)
+	}
+	if m, ok := payload.(map[string]interface{}); !ok || m["amount"] != 42.0 {
+		t.Errorf("payload = %#v, want the generically decoded data", payload)
+	}
+}
+
+func TestRegistryUnmarshalRejectsInvalidPayloads(t *testing.T) {
+	data, err := NewEvent(EventTypeOrderConfirmed, map[string]interface{}{"order_id": "order-1"}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := DefaultRegistry.Unmarshal(data); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("got %v, want ErrInvalidPayload for a confirmation without customer", err)
+	}
+}
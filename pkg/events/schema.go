@@ -0,0 +1,180 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// jsonSchemaDialect is the JSON Schema version of the exported documents
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// Types returns the registered event types in lexical order
+func (r *EventRegistry) Types() []EventType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]EventType, 0, len(r.factories))
+	for t := range r.factories {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// JSONSchema returns the JSON Schema of events of the type at its current
+// version: the envelope with the registered payload type as its data.
+// Property names are the JSON names; properties without omitempty are required.
+func (r *EventRegistry) JSONSchema(eventType EventType) (map[string]interface{}, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[eventType]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+
+	version := CurrentVersion(eventType)
+	schema := typeSchema(reflect.TypeOf(Event{}), map[reflect.Type]bool{})
+	properties := schema["properties"].(map[string]interface{})
+	properties["type"] = map[string]interface{}{"const": string(eventType)}
+	properties["version"] = map[string]interface{}{"const": version}
+	properties["data"] = typeSchema(reflect.TypeOf(factory()), map[reflect.Type]bool{})
+
+	schema["$schema"] = jsonSchemaDialect
+	schema["$id"] = schemaFileName(eventType, version)
+	schema["title"] = fmt.Sprintf("%s v%d", eventType, version)
+	return schema, nil
+}
+
+// WriteJSONSchemas writes the schema of every registered event type to dir
+// as <type>.v<version>.schema.json and returns the written paths
+func (r *EventRegistry) WriteJSONSchemas(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create schema directory: %w", err)
+	}
+
+	var paths []string
+	for _, eventType := range r.Types() {
+		schema, err := r.JSONSchema(eventType)
+		if err != nil {
+			return paths, err
+		}
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return paths, fmt.Errorf("failed to encode %s schema: %w", eventType, err)
+		}
+
+		path := filepath.Join(dir, schemaFileName(eventType, CurrentVersion(eventType)))
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			return paths, fmt.Errorf("failed to write %s schema: %w", eventType, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func schemaFileName(eventType EventType, version int) string {
+	return fmt.Sprintf("%s.v%d.schema.json", eventType, version)
+}
+
+// typeSchema describes how encoding/json renders values of type t. Types
+// with custom JSON encodings other than time.Time accept any value.
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	t = indirect(t)
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawJSONType, t.Implements(marshalerType), reflect.PointerTo(t).Implements(marshalerType):
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			// Recursive types are left open rather than expanded forever
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := map[string]interface{}{}
+		required := []string{}
+		structSchema(t, properties, &required, seen)
+		sort.Strings(required)
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		}
+	default:
+		// interface{} and anything encoding/json can't type statically
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema adds the JSON properties of t's fields, promoting the fields
+// of untagged embedded structs like encoding/json does
+func structSchema(t reflect.Type, properties map[string]interface{}, required *[]string, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && indirect(field.Type).Kind() == reflect.Struct {
+			structSchema(indirect(field.Type), properties, required, seen)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = typeSchema(field.Type, seen)
+		if !hasOption(opts, "omitempty") && !hasOption(opts, "omitzero") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func hasOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
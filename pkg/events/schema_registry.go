@@ -0,0 +1,164 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrIncompatibleSchema is matched by registration errors for schemas that
+// break the subject's compatibility rules
+var ErrIncompatibleSchema = errors.New("schema is incompatible with an earlier version")
+
+// schemaRegistryTimeout bounds each request to the registry
+const schemaRegistryTimeout = 10 * time.Second
+
+// schemaRegistryContentType is the media type of the registry's REST API
+const schemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+
+// SchemaRegistryError is an error response of the schema registry
+type SchemaRegistryError struct {
+	StatusCode int    // HTTP status
+	ErrorCode  int    // registry error code, e.g. 409 or 42201
+	Message    string // registry error message
+}
+
+func (e *SchemaRegistryError) Error() string {
+	return fmt.Sprintf("schema registry error %d (HTTP %d): %s", e.ErrorCode, e.StatusCode, e.Message)
+}
+
+// Is matches ErrIncompatibleSchema for compatibility violations
+func (e *SchemaRegistryError) Is(target error) bool {
+	return target == ErrIncompatibleSchema && e.StatusCode == http.StatusConflict
+}
+
+// SchemaRegistryClient registers and fetches schemas in a Confluent Schema
+// Registry. Schema IDs and schemas are immutable, so both are cached.
+type SchemaRegistryClient struct {
+	url      string
+	username string
+	password string
+	http     *http.Client
+
+	mu      sync.RWMutex
+	ids     map[string]int // subject + "\x00" + schema -> ID
+	schemas map[int]string // ID -> schema
+}
+
+// NewSchemaRegistryClient creates a client of the registry at baseURL.
+// username and password, if set, are sent as basic auth (Confluent Cloud API key and secret).
+func NewSchemaRegistryClient(baseURL, username, password string) (*SchemaRegistryClient, error) {
+	if _, err := url.ParseRequestURI(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid schema registry URL %q: %w", baseURL, err)
+	}
+	return &SchemaRegistryClient{
+		url:      strings.TrimRight(baseURL, "/"),
+		username: username,
+		password: password,
+		http:     &http.Client{Timeout: schemaRegistryTimeout},
+		ids:      make(map[string]int),
+		schemas:  make(map[int]string),
+	}, nil
+}
+
+// Register registers an Avro schema under subject and returns its ID. The
+// registry returns the existing ID of a schema registered before; a schema
+// incompatible with the subject's earlier versions fails with an error
+// matching ErrIncompatibleSchema.
+func (c *SchemaRegistryClient) Register(subject, schema string) (int, error) {
+	key := subject + "\x00" + schema
+	c.mu.RLock()
+	id, ok := c.ids[key]
+	c.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	body, err := json.Marshal(map[string]string{"schema": schema})
+	if err != nil {
+		return 0, err
+	}
+	var resp struct {
+		ID int `json:"id"`
+	}
+	if err := c.do(http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", body, &resp); err != nil {
+		return 0, fmt.Errorf("failed to register schema for subject %s: %w", subject, err)
+	}
+
+	c.mu.Lock()
+	c.ids[key] = resp.ID
+	c.schemas[resp.ID] = schema
+	c.mu.Unlock()
+	return resp.ID, nil
+}
+
+// Schema returns the schema registered with the ID
+func (c *SchemaRegistryClient) Schema(id int) (string, error) {
+	c.mu.RLock()
+	schema, ok := c.schemas[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	var resp struct {
+		Schema string `json:"schema"`
+	}
+	if err := c.do(http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to fetch schema %d: %w", id, err)
+	}
+
+	c.mu.Lock()
+	c.schemas[id] = resp.Schema
+	c.mu.Unlock()
+	return resp.Schema, nil
+}
+
+// do sends a request and decodes the JSON response into out, or returns
+// the registry's error response as a *SchemaRegistryError
+func (c *SchemaRegistryClient) do(method, path string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, c.url+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", schemaRegistryContentType)
+	if body != nil {
+		req.Header.Set("Content-Type", schemaRegistryContentType)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var errBody struct {
+			ErrorCode int    `json:"error_code"`
+			Message   string `json:"message"`
+		}
+		if json.Unmarshal(data, &errBody) != nil || errBody.Message == "" {
+			errBody.Message = strings.TrimSpace(string(data))
+		}
+		return &SchemaRegistryError{StatusCode: resp.StatusCode, ErrorCode: errBody.ErrorCode, Message: errBody.Message}
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,166 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+// schemaObject returns the properties and required names of an object schema
+func schemaObject(t *testing.T, schema interface{}) (map[string]interface{}, []string) {
+	t.Helper()
+	object, _ := schema.(map[string]interface{})
+	properties, ok := object["properties"].(map[string]interface{})
+	if !ok || object["type"] != "object" {
+		t.Fatalf("schema %v is not an object schema", schema)
+	}
+	var required []string
+	switch names := object["required"].(type) {
+	case []string:
+		required = names
+	case []interface{}:
+		for _, name := range names {
+			required = append(required, name.(string))
+		}
+	}
+	return properties, required
+}
+
+func TestOrderCreatedJSONSchema(t *testing.T) {
+	schema, err := DefaultRegistry.JSONSchema(EventTypeOrderCreated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := schemaFileName(EventTypeOrderCreated, CurrentVersion(EventTypeOrderCreated))
+	if schema["$schema"] != jsonSchemaDialect || schema["$id"] != id {
+		t.Errorf("$schema %v, $id %v, want the 2020-12 dialect and %s", schema["$schema"], schema["$id"], id)
+	}
+
+	envelope, required := schemaObject(t, schema)
+	if want := []string{"data", "id", "timestamp", "type"}; !slices.Equal(required, want) {
+		t.Errorf("envelope requires %v, want %v", required, want)
+	}
+	for _, name := range []string{"version", "metadata", "correlation_id", "causation_id"} {
+		if _, ok := envelope[name]; !ok {
+			t.Errorf("envelope has no %s property", name)
+		}
+	}
+	if c := envelope["type"].(map[string]interface{})["const"]; c != "order.created" {
+		t.Errorf("type const %v, want order.created", c)
+	}
+	if c := envelope["version"].(map[string]interface{})["const"]; c != CurrentVersion(EventTypeOrderCreated) {
+		t.Errorf("version const %v, want the current version %d", c, CurrentVersion(EventTypeOrderCreated))
+	}
+
+	data, required := schemaObject(t, envelope["data"])
+	if !slices.Equal(required, []string{"order"}) {
+		t.Errorf("data requires %v, want [order]", required)
+	}
+	order, required := schemaObject(t, data["order"])
+	if want := []string{"created_at", "customer_id", "id", "items", "status", "total_price", "updated_at"}; !slices.Equal(required, want) {
+		t.Errorf("order requires %v, want %v", required, want)
+	}
+	wantTypes := map[string]string{
+		"id":               "string",
+		"customer_id":      "string",
+		"items":            "array",
+		"total_price":      "number",
+		"currency":         "string",
+		"base_currency":    "string",
+		"base_total_price": "number",
+		"status":           "string",
+		"created_at":       "string",
+		"updated_at":       "string",
+	}
+	if len(order) != len(wantTypes) {
+		t.Errorf("order properties %v, want exactly %v", order, wantTypes)
+	}
+	for name, typ := range wantTypes {
+		if got := order[name].(map[string]interface{})["type"]; got != typ {
+			t.Errorf("order.%s type %v, want %s", name, got, typ)
+		}
+	}
+	if format := order["created_at"].(map[string]interface{})["format"]; format != "date-time" {
+		t.Errorf("created_at format %v, want date-time", format)
+	}
+	item, required := schemaObject(t, order["items"].(map[string]interface{})["items"])
+	if want := []string{"price", "product_id", "quantity"}; !slices.Equal(required, want) || item["quantity"].(map[string]interface{})["type"] != "integer" {
+		t.Errorf("item requires %v with quantity %v, want %v and an integer quantity", required, item["quantity"], want)
+	}
+}
+
+func TestJSONSchemaOfAnUnregisteredType(t *testing.T) {
+	if _, err := NewEventRegistry().JSONSchema(EventTypeOrderCreated); !errors.Is(err, ErrUnknownEventType) {
+		t.Errorf("JSONSchema() = %v, want ErrUnknownEventType", err)
+	}
+}
+
+func TestTypeSchemaFollowsEncodingJSON(t *testing.T) {
+	type Audit struct {
+		By string `json:"by"`
+	}
+	type node struct {
+		Audit
+		Name     string          `json:"name,omitempty"`
+		Skipped  string          `json:"-"`
+		internal string          // unexported, so not encoded
+		Untagged int             // encoded under the Go name
+		Raw      json.RawMessage `json:"raw"`
+		At       *time.Time      `json:"at,omitzero"`
+		Children []*node         `json:"children"`
+	}
+
+	properties, required := schemaObject(t, typeSchema(reflect.TypeOf(node{}), map[reflect.Type]bool{}))
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if want := []string{"Untagged", "at", "by", "children", "name", "raw"}; !slices.Equal(names, want) {
+		t.Errorf("properties %v, want %v", names, want)
+	}
+	if want := []string{"Untagged", "by", "children", "raw"}; !slices.Equal(required, want) {
+		t.Errorf("required %v, want %v", required, want)
+	}
+	if raw := properties["raw"].(map[string]interface{}); len(raw) != 0 {
+		t.Errorf("raw schema %v, want any value", raw)
+	}
+	child := properties["children"].(map[string]interface{})["items"].(map[string]interface{})
+	if _, expanded := child["properties"]; expanded || child["type"] != "object" {
+		t.Errorf("recursive child schema %v, want an open object", child)
+	}
+}
+
+func TestWriteJSONSchemasWritesOneDocumentPerType(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "schemas")
+	paths, err := DefaultRegistry.WriteJSONSchemas(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != len(DefaultRegistry.Types()) {
+		t.Errorf("wrote %d schemas, want one for each of the %d registered types", len(paths), len(DefaultRegistry.Types()))
+	}
+
+	version := CurrentVersion(EventTypeOrderCreated)
+	path := filepath.Join(dir, schemaFileName(EventTypeOrderCreated, version))
+	if !slices.Contains(paths, path) {
+		t.Fatalf("wrote %v, want %s among them", paths, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema file is not JSON: %v", err)
+	}
+	if want := fmt.Sprintf("order.created v%d", version); schema["title"] != want {
+		t.Errorf("title %v, want %s", schema["title"], want)
+	}
+}
@@ -0,0 +1,68 @@
+package events
+
+import "fmt"
+
+// Content types identifying the serialization of an event message
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// Serializer converts events to and from their wire format
+type Serializer interface {
+	Marshal(event *Event) ([]byte, error)
+	Unmarshal(data []byte) (*Event, error)
+	// ContentType identifies the format, e.g. in a message header
+	ContentType() string
+}
+
+// JSONSerializer is the default, human-readable serialization
+type JSONSerializer struct{}
+
+// Marshal serializes the event to JSON
+func (JSONSerializer) Marshal(event *Event) ([]byte, error) {
+	return event.Marshal()
+}
+
+// Unmarshal deserializes a JSON event, see UnmarshalEvent
+func (JSONSerializer) Unmarshal(data []byte) (*Event, error) {
+	return UnmarshalEvent(data)
+}
+
+// ContentType returns application/json
+func (JSONSerializer) ContentType() string {
+	return ContentTypeJSON
+}
+
+// NewSerializer returns the serializer for a configured format name: "json",
+// "protobuf" or "cloudevents". An empty name selects JSON. source is the
+// CloudEvents source of published events and is only used by "cloudevents".
+func NewSerializer(format, source string) (Serializer, error) {
+	switch format {
+	case "", "json":
+		return JSONSerializer{}, nil
+	case "protobuf":
+		return ProtobufSerializer{}, nil
+	case "cloudevents":
+		if source == "" {
+			return nil, fmt.Errorf("the cloudevents serializer requires a source")
+		}
+		return CloudEventsSerializer{Source: source}, nil
+	default:
+		return nil, fmt.Errorf("invalid serializer %q: must be json, protobuf or cloudevents", format)
+	}
+}
+
+// SerializerForContentType returns the serializer producing content type ct
+func SerializerForContentType(ct string) (Serializer, bool) {
+	switch ct {
+	case ContentTypeJSON:
+		return JSONSerializer{}, true
+	case ContentTypeProtobuf:
+		return ProtobufSerializer{}, true
+	case ContentTypeCloudEvents:
+		return CloudEventsSerializer{}, true
+	default:
+		return nil, false
+	}
+}
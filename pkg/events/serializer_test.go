@@ -0,0 +1,85 @@
+package events
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tanint/go-eda/internal/models"
+)
+
+// benchmarkOrder returns an order.created event of an order with items items
+func benchmarkOrder(items int) *Event {
+	order := models.Order{
+		ID:         "9b2f6a4e-1c3d-4e5f-8a7b-0c1d2e3f4a5b",
+		CustomerID: "customer-123",
+		Currency:   "EUR",
+		Status:     models.OrderStatusPending,
+		CreatedAt:  time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		UpdatedAt:  time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	for i := 0; i < items; i++ {
+		item := models.OrderItem{ProductID: fmt.Sprintf("product-%03d", i), Quantity: i%5 + 1, Price: 9.99 + float64(i)}
+		order.Items = append(order.Items, item)
+		order.TotalPrice += float64(item.Quantity) * item.Price
+	}
+	event := NewEvent(EventTypeOrderCreated, OrderCreatedEvent{Order: order})
+	event.SetMetadata("source", "order-service")
+	return event
+}
+
+var benchmarkSizes = []int{1, 10, 100}
+
+func benchmarkSerialize(b *testing.B, s Serializer) {
+	for _, items := range benchmarkSizes {
+		event := benchmarkOrder(items)
+		b.Run(fmt.Sprintf("items=%d", items), func(b *testing.B) {
+			data, err := s.Marshal(event)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.Marshal(event); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(len(data)), "bytes/event")
+		})
+	}
+}
+
+func benchmarkDeserialize(b *testing.B, s Serializer) {
+	for _, items := range benchmarkSizes {
+		data, err := s.Marshal(benchmarkOrder(items))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("items=%d", items), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.Unmarshal(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(len(data)), "bytes/event")
+		})
+	}
+}
+
+func BenchmarkSerializeJSON(b *testing.B) {
+	benchmarkSerialize(b, JSONSerializer{})
+}
+
+func BenchmarkSerializeProtobuf(b *testing.B) {
+	benchmarkSerialize(b, ProtobufSerializer{})
+}
+
+func BenchmarkDeserializeJSON(b *testing.B) {
+	benchmarkDeserialize(b, JSONSerializer{})
+}
+
+func BenchmarkDeserializeProtobuf(b *testing.B) {
+	benchmarkDeserialize(b, ProtobufSerializer{})
+}
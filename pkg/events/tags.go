@@ -0,0 +1,112 @@
+package events
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// snakeCase matches JSON names following the event contract convention
+var snakeCase = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// TagViolation describes a payload field whose JSON name breaks the naming convention
+type TagViolation struct {
+	Type  string // payload type, e.g. events.OrderRejectedEvent
+	Field string // Go field path, e.g. Order.CustomerID
+	Name  string // effective JSON name
+}
+
+func (v TagViolation) String() string {
+	return fmt.Sprintf("%s.%s: JSON name %q is not snake_case", v.Type, v.Field, v.Name)
+}
+
+// ValidateTags checks the payload types of DefaultRegistry; see
+// EventRegistry.ValidateTags. It is meant to be run from CI so contract drift
+// is caught before an event ships.
+func ValidateTags() []TagViolation {
+	return DefaultRegistry.ValidateTags()
+}
+
+// ValidateTags checks that every exported field of the registered payload
+// types, including nested structs, has a snake_case JSON name
+func (r *EventRegistry) ValidateTags() []TagViolation {
+	r.mu.RLock()
+	unique := make(map[reflect.Type]struct{}, len(r.factories))
+	for _, factory := range r.factories {
+		unique[indirect(reflect.TypeOf(factory()))] = struct{}{}
+	}
+	r.mu.RUnlock()
+
+	types := make([]reflect.Type, 0, len(unique))
+	for t := range unique {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].String() < types[j].String() })
+
+	var violations []TagViolation
+	for _, t := range types {
+		violations = append(violations, checkTags(t, t.String(), "", map[reflect.Type]bool{})...)
+	}
+	return violations
+}
+
+func checkTags(t reflect.Type, root, prefix string, seen map[reflect.Type]bool) []TagViolation {
+	t = indirect(t)
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	var violations []TagViolation
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs have their fields promoted
+		if field.Anonymous && name == "" {
+			violations = append(violations, checkTags(field.Type, root, prefix, seen)...)
+			continue
+		}
+
+		path := prefix + field.Name
+		if name == "" {
+			name = field.Name
+		}
+		if !snakeCase.MatchString(name) {
+			violations = append(violations, TagViolation{Type: root, Field: path, Name: name})
+		}
+
+		violations = append(violations, checkTags(elem(field.Type), root, path+".", seen)...)
+	}
+	return violations
+}
+
+// elem unwraps pointers, slices, arrays and maps down to the element type
+func elem(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
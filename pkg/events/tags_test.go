@@ -0,0 +1,67 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEventPayloadsUseSnakeCaseJSONNames(t *testing.T) {
+	for _, v := range ValidateTags() {
+		t.Error(v)
+	}
+}
+
+type compliantPayload struct {
+	OrderID  string            `json:"order_id"`
+	Lines    []compliantLine   `json:"lines"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+type compliantLine struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+type nonCompliantPayload struct {
+	OrderID    string             `json:"orderId"`
+	CustomerID string             // untagged, encoded as CustomerID
+	Lines      []nonCompliantLine `json:"lines"`
+}
+
+type nonCompliantLine struct {
+	ProductID string `json:"Product-ID"`
+}
+
+func TestValidateTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload func() interface{}
+		want    []TagViolation
+	}{
+		{
+			name:    "compliant",
+			payload: func() interface{} { return &compliantPayload{} },
+		},
+		{
+			name:    "non-compliant",
+			payload: func() interface{} { return &nonCompliantPayload{} },
+			want: []TagViolation{
+				{Type: "events.nonCompliantPayload", Field: "OrderID", Name: "orderId"},
+				{Type: "events.nonCompliantPayload", Field: "CustomerID", Name: "CustomerID"},
+				{Type: "events.nonCompliantPayload", Field: "Lines.ProductID", Name: "Product-ID"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewEventRegistry()
+			registry.Register("test.event", tt.payload)
+
+			if got := registry.ValidateTags(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Upcaster transforms an event payload from one schema version to the next
+type Upcaster func(data json.RawMessage) (json.RawMessage, error)
+
+type upcasterKey struct {
+	eventType   EventType
+	fromVersion int
+}
+
+var (
+	upcastersMu sync.RWMutex
+	upcasters   = map[upcasterKey]Upcaster{}
+	versions    = map[EventType]int{}
+)
+
+// RegisterUpcaster registers the transform from fromVersion to fromVersion+1
+// of the event type's payload. The type's current version becomes the highest
+// version reachable through registered upcasters; UnmarshalEvent chains them
+// (v1→v2→v3) so handlers always see the current shape.
+func RegisterUpcaster(eventType EventType, fromVersion int, fn Upcaster) {
+	upcastersMu.Lock()
+	defer upcastersMu.Unlock()
+
+	upcasters[upcasterKey{eventType, fromVersion}] = fn
+	if fromVersion+1 > versions[eventType] {
+		versions[eventType] = fromVersion + 1
+	}
+}
+
+// CurrentVersion returns the current payload schema version of the event type
+func CurrentVersion(eventType EventType) int {
+	upcastersMu.RLock()
+	defer upcastersMu.RUnlock()
+
+	if v, ok := versions[eventType]; ok {
+		return v
+	}
+	return 1
+}
+
+// upcast brings data from version up to the event type's current version.
+// Events without a version predate versioning and are treated as version 1.
+func upcast(eventType EventType, version int, data json.RawMessage) (json.RawMessage, int, error) {
+	if version == 0 {
+		version = 1
+	}
+
+	current := CurrentVersion(eventType)
+	for version < current {
+		upcastersMu.RLock()
+		fn, ok := upcasters[upcasterKey{eventType, version}]
+		upcastersMu.RUnlock()
+		if !ok {
+			return nil, version, fmt.Errorf("no upcaster for %s from version %d", eventType, version)
+		}
+
+		var err error
+		if data, err = fn(data); err != nil {
+			return nil, version, fmt.Errorf("upcasting %s from version %d: %w", eventType, version, err)
+		}
+		version++
+	}
+	return data, version, nil
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// flatOrderCreated is an order.created-like event type whose version 1
+// payload held the order's fields at the top level, and version 2 nests them
+// under "order"
+const flatOrderCreated EventType = "test.flat_order_created"
+
+func init() {
+	RegisterUpcaster(flatOrderCreated, 1, func(data json.RawMessage) (json.RawMessage, error) {
+		return json.Marshal(map[string]json.RawMessage{"order": data})
+	})
+}
+
+func TestOlderPayloadsAreUpcastToTheCurrentVersion(t *testing.T) {
+	payload := `{"id": "order-1", "customer_id": "customer-1", "items": [{"product_id": "p1", "quantity": 2, "price": 10}], "total_price": 20, "status": "pending"}`
+	data := `{"id": "event-1", "type": "test.flat_order_created", "version": 1, "timestamp": "2024-01-01T00:00:00Z", "data": ` + payload + `}`
+
+	event, err := UnmarshalEvent([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Version != 2 || CurrentVersion(flatOrderCreated) != 2 {
+		t.Errorf("version = %d, current %d, want both 2", event.Version, CurrentVersion(flatOrderCreated))
+	}
+
+	created, err := DecodeData[OrderCreatedEvent](event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := created.Order
+	if order.ID != "order-1" || order.CustomerID != "customer-1" || order.TotalPrice != 20 ||
+		len(order.Items) != 1 || order.Items[0].ProductID != "p1" {
+		t.Errorf("decoded order %+v, want the version 1 order", order)
+	}
+}
+
+func TestEventTypesWithoutUpcastersStayAtVersion1(t *testing.T) {
+	if v := CurrentVersion(EventTypeOrderCreated); v != 1 {
+		t.Errorf("order.created is at version %d, want 1: its payload shape never changed", v)
+	}
+
+	data := `{"id": "event-1", "type": "order.created", "timestamp": "2024-01-01T00:00:00Z", "data": {"order": {"id": "order-1"}}}`
+	event, err := UnmarshalEvent([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Version != 1 {
+		t.Errorf("unversioned order.created decoded at version %d, want 1", event.Version)
+	}
+}
+
+func TestOrderCreatedRoundTripsAtTheCurrentVersion(t *testing.T) {
+	event := benchmarkOrder(2)
+	if event.Version != CurrentVersion(EventTypeOrderCreated) {
+		t.Fatalf("new event has version %d, want %d", event.Version, CurrentVersion(EventTypeOrderCreated))
+	}
+
+	data, err := event.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := UnmarshalEvent(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := DecodeData[OrderCreatedEvent](decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Order.ID != event.Data.(OrderCreatedEvent).Order.ID || len(created.Order.Items) != 2 {
+		t.Errorf("decoded order %+v, want the published one", created.Order)
+	}
+}
+
+func TestUpcastersChainAndSkipCurrentVersions(t *testing.T) {
+	const eventType EventType = "test.upcast_chain"
+	calls := 0
+	// v1 {"name"} → v2 {"full_name"} → v3 {"full_name", "source"}
+	RegisterUpcaster(eventType, 1, func(data json.RawMessage) (json.RawMessage, error) {
+		calls++
+		var v1 struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(data, &v1); err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]string{"full_name": v1.Name})
+	})
+	RegisterUpcaster(eventType, 2, func(data json.RawMessage) (json.RawMessage, error) {
+		calls++
+		var v2 map[string]string
+		if err := json.Unmarshal(data, &v2); err != nil {
+			return nil, err
+		}
+		v2["source"] = "upcast"
+		return json.Marshal(v2)
+	})
+
+	type payload struct {
+		FullName string `json:"full_name"`
+		Source   string `json:"source"`
+	}
+	tests := []struct {
+		name      string
+		data      string
+		want      payload
+		wantCalls int
+	}{
+		{name: "v1", data: `{"type": "test.upcast_chain", "version": 1, "data": {"name": "Ada"}}`, want: payload{"Ada", "upcast"}, wantCalls: 2},
+		{name: "unversioned", data: `{"type": "test.upcast_chain", "data": {"name": "Ada"}}`, want: payload{"Ada", "upcast"}, wantCalls: 2},
+		{name: "v2", data: `{"type": "test.upcast_chain", "version": 2, "data": {"full_name": "Ada"}}`, want: payload{"Ada", "upcast"}, wantCalls: 1},
+		{name: "current", data: `{"type": "test.upcast_chain", "version": 3, "data": {"full_name": "Ada", "source": "api"}}`, want: payload{"Ada", "api"}, wantCalls: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			event, err := UnmarshalEvent([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := DecodeData[payload](event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want || event.Version != 3 || calls != tt.wantCalls {
+				t.Errorf("decoded %+v at version %d after %d upcasts, want %+v at version 3 after %d",
+					got, event.Version, calls, tt.want, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Package eventstore persists events in per-aggregate streams so state can
+// be rebuilt by replaying them. Appends use optimistic concurrency: writers
+// pass the stream version they last read and fail with ErrVersionConflict if
+// another writer appended in between.
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// ErrVersionConflict is matched (via errors.Is) by VersionConflictError
+var ErrVersionConflict = errors.New("stream version conflict")
+
+// AnyVersion appends regardless of the stream's current version
+const AnyVersion = -1
+
+// VersionConflictError is returned when appending at an expected version
+// that is not the stream's current version
+type VersionConflictError struct {
+	StreamID string
+	Expected int
+	Actual   int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("stream %s is at version %d, expected %d", e.StreamID, e.Actual, e.Expected)
+}
+
+// Is matches ErrVersionConflict
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// Event is an event stored in a stream
+type Event struct {
+	StreamID string
+	// Version is the event's position in its stream, starting at 1; the
+	// stream's version is the version of its last event (0 when empty)
+	Version    int
+	RecordedAt time.Time
+	Event      *events.Event
+}
+
+// EventStore appends events to streams and loads them back in order
+type EventStore interface {
+	// Append adds the events to the stream, which must be at
+	// expectedVersion (0 for a new stream, or AnyVersion), and returns the
+	// stream's new version
+	Append(ctx context.Context, streamID string, expectedVersion int, evts ...*events.Event) (int, error)
+	// Load returns all events of the stream, oldest first; none for unknown streams
+	Load(ctx context.Context, streamID string) ([]Event, error)
+	// LoadFrom returns the stream's events from version on, for replaying
+	// on top of a snapshot taken at version-1
+	LoadFrom(ctx context.Context, streamID string, version int) ([]Event, error)
+}
+
+// streams holds stream contents in memory; callers synchronize access
+type streams map[string][]Event
+
+// prepare checks the expected version and returns the events to append
+func (s streams) prepare(streamID string, expectedVersion int, evts []*events.Event) ([]Event, error) {
+	current := len(s[streamID])
+	if expectedVersion != AnyVersion && expectedVersion != current {
+		return nil, &VersionConflictError{StreamID: streamID, Expected: expectedVersion, Actual: current}
+	}
+
+	now := time.Now().UTC()
+	stored := make([]Event, len(evts))
+	for i, event := range evts {
+		stored[i] = Event{StreamID: streamID, Version: current + i + 1, RecordedAt: now, Event: event}
+	}
+	return stored, nil
+}
+
+func (s streams) add(stored []Event) {
+	for _, e := range stored {
+		s[e.StreamID] = append(s[e.StreamID], e)
+	}
+}
+
+func (s streams) loadFrom(streamID string, version int) []Event {
+	stream := s[streamID]
+	if version < 1 {
+		version = 1
+	}
+	if version > len(stream) {
+		return nil
+	}
+	return append([]Event(nil), stream[version-1:]...)
+}
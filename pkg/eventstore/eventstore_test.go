@@ -0,0 +1,184 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// stores returns a constructor for each implementation
+func stores(t *testing.T) map[string]func() EventStore {
+	return map[string]func() EventStore{
+		"memory": func() EventStore { return NewMemoryStore() },
+		"file": func() EventStore {
+			s, err := OpenFileStore(filepath.Join(t.TempDir(), "events.jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return s
+		},
+	}
+}
+
+func orderEvents(ids ...string) []*events.Event {
+	var evts []*events.Event
+	for _, id := range ids {
+		event := events.NewEvent(events.EventTypeOrderUpdated, map[string]interface{}{"order_id": "order-1"})
+		event.ID = id
+		evts = append(evts, event)
+	}
+	return evts
+}
+
+// ids returns the event IDs and versions of the stored events
+func ids(stored []Event) ([]string, []int) {
+	var ids []string
+	var versions []int
+	for _, e := range stored {
+		ids = append(ids, e.Event.ID)
+		versions = append(versions, e.Version)
+	}
+	return ids, versions
+}
+
+func TestAppendAndLoad(t *testing.T) {
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			ctx := context.Background()
+
+			if version, err := s.Append(ctx, "order-1", 0, orderEvents("e1", "e2")...); err != nil || version != 2 {
+				t.Fatalf("Append() = %d, %v, want version 2", version, err)
+			}
+			if version, err := s.Append(ctx, "order-1", 2, orderEvents("e3")...); err != nil || version != 3 {
+				t.Fatalf("Append() = %d, %v, want version 3", version, err)
+			}
+			if _, err := s.Append(ctx, "order-2", 0, orderEvents("other")...); err != nil {
+				t.Fatal(err)
+			}
+
+			stored, err := s.Load(ctx, "order-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotIDs, versions := ids(stored)
+			if !slices.Equal(gotIDs, []string{"e1", "e2", "e3"}) || !slices.Equal(versions, []int{1, 2, 3}) {
+				t.Errorf("loaded %v at versions %v, want e1, e2, e3 at 1, 2, 3", gotIDs, versions)
+			}
+			if stored[0].StreamID != "order-1" || stored[0].Event.Type != events.EventTypeOrderUpdated || stored[0].RecordedAt.IsZero() {
+				t.Errorf("stored %+v, want the order.updated event of order-1 with its record time", stored[0])
+			}
+
+			if stored, err := s.Load(ctx, "order-3"); err != nil || len(stored) != 0 {
+				t.Errorf("Load(unknown) = %v, %v, want no events", stored, err)
+			}
+		})
+	}
+}
+
+func TestAppendAtAStaleVersionConflicts(t *testing.T) {
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			ctx := context.Background()
+			if _, err := s.Append(ctx, "order-1", 0, orderEvents("e1", "e2")...); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := s.Append(ctx, "order-1", 1, orderEvents("stale")...)
+			var conflict *VersionConflictError
+			if !errors.Is(err, ErrVersionConflict) || !errors.As(err, &conflict) {
+				t.Fatalf("Append() = %v, want a version conflict", err)
+			}
+			if conflict.StreamID != "order-1" || conflict.Expected != 1 || conflict.Actual != 2 {
+				t.Errorf("conflict %+v, want order-1 expected at 1 but at 2", conflict)
+			}
+			if _, err := s.Append(ctx, "order-2", 3, orderEvents("new")...); !errors.Is(err, ErrVersionConflict) {
+				t.Errorf("Append(new stream at 3) = %v, want a version conflict", err)
+			}
+
+			if version, err := s.Append(ctx, "order-1", AnyVersion, orderEvents("e3")...); err != nil || version != 3 {
+				t.Errorf("Append(AnyVersion) = %d, %v, want version 3", version, err)
+			}
+			stored, _ := s.Load(ctx, "order-1")
+			if gotIDs, _ := ids(stored); !slices.Equal(gotIDs, []string{"e1", "e2", "e3"}) {
+				t.Errorf("loaded %v, want the conflicting append left out", gotIDs)
+			}
+		})
+	}
+}
+
+func TestLoadFromReplaysTheRestOfTheStream(t *testing.T) {
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			ctx := context.Background()
+			if _, err := s.Append(ctx, "order-1", 0, orderEvents("e1", "e2", "e3", "e4")...); err != nil {
+				t.Fatal(err)
+			}
+
+			tests := []struct {
+				from int
+				want []string
+			}{
+				{from: 0, want: []string{"e1", "e2", "e3", "e4"}},
+				{from: 1, want: []string{"e1", "e2", "e3", "e4"}},
+				{from: 3, want: []string{"e3", "e4"}},
+				{from: 4, want: []string{"e4"}},
+				{from: 5, want: nil},
+			}
+			for _, tt := range tests {
+				stored, err := s.LoadFrom(ctx, "order-1", tt.from)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if gotIDs, _ := ids(stored); !slices.Equal(gotIDs, tt.want) {
+					t.Errorf("LoadFrom(%d) = %v, want %v", tt.from, gotIDs, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFileStoreReloadsItsStreams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	ctx := context.Background()
+	s, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Append(ctx, "order-1", 0, orderEvents("e1", "e2")...); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// A crash during an append leaves an incomplete last line
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"stream_id": "order-1", "version": 3, "ev`)
+	f.Close()
+
+	s, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if version, err := s.Append(ctx, "order-1", 2, orderEvents("e3")...); err != nil || version != 3 {
+		t.Fatalf("Append() after reopening = %d, %v, want version 3", version, err)
+	}
+	stored, err := s.Load(ctx, "order-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotIDs, versions := ids(stored); !slices.Equal(gotIDs, []string{"e1", "e2", "e3"}) || !slices.Equal(versions, []int{1, 2, 3}) {
+		t.Errorf("loaded %v at versions %v, want e1, e2, e3 at 1, 2, 3", gotIDs, versions)
+	}
+}
@@ -0,0 +1,153 @@
+package eventstore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// fileRecord is one line of the store's file
+type fileRecord struct {
+	StreamID   string          `json:"stream_id"`
+	Version    int             `json:"version"`
+	RecordedAt time.Time       `json:"recorded_at"`
+	Event      json.RawMessage `json:"event"`
+}
+
+// FileStore appends events of all streams to one JSON-lines file, synced
+// on every append, and serves reads from memory
+type FileStore struct {
+	mu      sync.RWMutex
+	file    *os.File
+	streams streams
+}
+
+var _ EventStore = (*FileStore)(nil)
+
+// OpenFileStore opens or creates the store's file and loads its streams. A
+// final line left incomplete by a crash during an append is discarded.
+func OpenFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event store: %w", err)
+	}
+
+	s := &FileStore{file: file, streams: make(streams)}
+	if err := s.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the file and truncates an incomplete last line
+func (s *FileStore) load() error {
+	reader := bufio.NewReader(s.file)
+	var offset int64
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				if err := s.file.Truncate(offset); err != nil {
+					return fmt.Errorf("failed to discard incomplete event: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read event store: %w", err)
+		}
+		offset += int64(len(line))
+
+		var rec fileRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("failed to decode event store line %d: %w", lineNo, err)
+		}
+		event, err := events.UnmarshalEvent(rec.Event)
+		if err != nil {
+			return fmt.Errorf("failed to decode event store line %d: %w", lineNo, err)
+		}
+		s.streams.add([]Event{{StreamID: rec.StreamID, Version: rec.Version, RecordedAt: rec.RecordedAt, Event: event}})
+	}
+
+	if _, err := s.file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek event store: %w", err)
+	}
+	return nil
+}
+
+// Append writes the events to the stream if it is at expectedVersion. The
+// events are synced to disk before Append returns.
+func (s *FileStore) Append(ctx context.Context, streamID string, expectedVersion int, evts ...*events.Event) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.streams.prepare(streamID, expectedVersion, evts)
+	if err != nil {
+		return 0, err
+	}
+
+	var buf bytes.Buffer
+	for _, e := range stored {
+		data, err := e.Event.Marshal()
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal event: %w", err)
+		}
+		line, err := json.Marshal(fileRecord{StreamID: e.StreamID, Version: e.Version, RecordedAt: e.RecordedAt, Event: data})
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal event: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	offset, err := s.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, fmt.Errorf("failed to seek event store: %w", err)
+	}
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		s.rewind(offset)
+		return 0, fmt.Errorf("failed to append to stream %s: %w", streamID, err)
+	}
+	if err := s.file.Sync(); err != nil {
+		s.rewind(offset)
+		return 0, fmt.Errorf("failed to sync event store: %w", err)
+	}
+
+	s.streams.add(stored)
+	return len(s.streams[streamID]), nil
+}
+
+// rewind drops a partially written append so later appends start on a clean line
+func (s *FileStore) rewind(offset int64) {
+	if s.file.Truncate(offset) == nil {
+		s.file.Seek(offset, io.SeekStart)
+	}
+}
+
+// Load returns all events of the stream
+func (s *FileStore) Load(ctx context.Context, streamID string) ([]Event, error) {
+	return s.LoadFrom(ctx, streamID, 1)
+}
+
+// LoadFrom returns the stream's events from version on
+func (s *FileStore) LoadFrom(ctx context.Context, streamID string, version int) ([]Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.streams.loadFrom(streamID, version), nil
+}
+
+// Close closes the store's file
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
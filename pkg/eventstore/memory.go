@@ -0,0 +1,46 @@
+package eventstore
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// MemoryStore keeps streams in memory; they are lost on restart
+type MemoryStore struct {
+	mu      sync.RWMutex
+	streams streams
+}
+
+var _ EventStore = (*MemoryStore)(nil)
+
+// NewMemoryStore creates an empty in-memory event store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{streams: make(streams)}
+}
+
+// Append adds the events to the stream if it is at expectedVersion
+func (m *MemoryStore) Append(ctx context.Context, streamID string, expectedVersion int, evts ...*events.Event) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.streams.prepare(streamID, expectedVersion, evts)
+	if err != nil {
+		return 0, err
+	}
+	m.streams.add(stored)
+	return len(m.streams[streamID]), nil
+}
+
+// Load returns all events of the stream
+func (m *MemoryStore) Load(ctx context.Context, streamID string) ([]Event, error) {
+	return m.LoadFrom(ctx, streamID, 1)
+}
+
+// LoadFrom returns the stream's events from version on
+func (m *MemoryStore) LoadFrom(ctx context.Context, streamID string, version int) ([]Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.streams.loadFrom(streamID, version), nil
+}
@@ -0,0 +1,98 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// Steps of the order lifecycle
+const (
+	StepReserveInventory StepName = "reserve_inventory"
+	StepConfirmOrder     StepName = "confirm_order"
+	StepNotifyCustomer   StepName = "notify_customer"
+)
+
+// OrderCompensations undo the completed steps of a failed order
+type OrderCompensations struct {
+	// ReleaseInventory undoes ReserveInventory, e.g. by publishing the
+	// order.status_changed event that makes the inventory service release it
+	ReleaseInventory Compensation
+	// CancelOrder undoes ConfirmOrder
+	CancelOrder Compensation
+}
+
+// OrderSaga orchestrates the order lifecycle: reserve inventory, confirm the
+// order, notify the customer. Instances are keyed by order ID.
+type OrderSaga struct {
+	*Saga
+}
+
+// NewOrderSaga creates the order lifecycle saga. Reserving inventory and
+// confirming the order each fail the saga when they take longer than
+// stepTimeout (0 waits forever). Notifying the customer never times out:
+// it happens after the order is confirmed and must not roll it back.
+func NewOrderSaga(store Store, stepTimeout time.Duration, compensations OrderCompensations) *OrderSaga {
+	return &OrderSaga{Saga: New(store,
+		Step{Name: StepReserveInventory, Timeout: stepTimeout, Compensate: compensations.ReleaseInventory},
+		Step{Name: StepConfirmOrder, Timeout: stepTimeout, Compensate: compensations.CancelOrder},
+		Step{Name: StepNotifyCustomer},
+	)}
+}
+
+// HandleEvent drives the saga of the event's order: order.created starts it,
+// inventory.reserved, order.confirmed and notification.sent complete its
+// steps, and order.rejected fails it. Other events, and events of orders
+// whose saga was never started, are ignored.
+func (s *OrderSaga) HandleEvent(ctx context.Context, event *events.Event) error {
+	var err error
+	switch event.Type {
+	case events.EventTypeOrderCreated:
+		created, decodeErr := events.DecodeData[events.OrderCreatedEvent](event)
+		if decodeErr != nil {
+			return decodeErr
+		}
+		return s.Start(ctx, created.Order.ID)
+	case events.EventTypeOrderCreatedChunk:
+		chunk, decodeErr := events.DecodeData[events.OrderCreatedChunkEvent](event)
+		if decodeErr != nil {
+			return decodeErr
+		}
+		return s.Start(ctx, chunk.Order.ID)
+	case events.EventTypeInventoryReserved:
+		reserved, decodeErr := events.DecodeData[events.InventoryReservedEvent](event)
+		if decodeErr != nil {
+			return decodeErr
+		}
+		err = s.Complete(ctx, reserved.OrderID, StepReserveInventory)
+	case events.EventTypeOrderConfirmed:
+		confirmed, decodeErr := events.DecodeData[events.OrderConfirmedEvent](event)
+		if decodeErr != nil {
+			return decodeErr
+		}
+		err = s.Complete(ctx, confirmed.OrderID, StepConfirmOrder)
+	case events.EventTypeNotificationSent:
+		// notification.sent has no payload contract yet; it carries the order ID
+		data, _ := event.Data.(map[string]interface{})
+		orderID, _ := data["order_id"].(string)
+		if orderID == "" {
+			return nil
+		}
+		err = s.Complete(ctx, orderID, StepNotifyCustomer)
+	case events.EventTypeOrderRejected:
+		rejected, decodeErr := events.DecodeData[events.OrderRejectedEvent](event)
+		if decodeErr != nil {
+			return decodeErr
+		}
+		err = s.Fail(ctx, rejected.OrderID, rejected.Reason)
+	default:
+		return nil
+	}
+
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
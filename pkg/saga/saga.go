@@ -0,0 +1,273 @@
+// Package saga orchestrates multi-step workflows across services. A saga
+// runs its steps in order, advancing as their completion events are
+// consumed, and undoes the completed steps in reverse order through their
+// compensations when a step fails or times out.
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned for sagas that were never started
+	ErrNotFound = errors.New("saga not found")
+	// ErrUnknownStep is returned for steps the saga does not define
+	ErrUnknownStep = errors.New("unknown saga step")
+)
+
+// StepName identifies a step of a saga
+type StepName string
+
+// Status is the state of a saga as a whole
+type Status string
+
+const (
+	StatusRunning      Status = "running"
+	StatusCompleted    Status = "completed"
+	StatusCompensating Status = "compensating" // a step failed; compensations are pending
+	StatusCompensated  Status = "compensated"  // a step failed and every completed step was undone
+)
+
+// StepStatus is the state of one step of a saga
+type StepStatus string
+
+const (
+	StepPending     StepStatus = "pending"
+	StepCompleted   StepStatus = "completed"
+	StepCompensated StepStatus = "compensated"
+)
+
+// Compensation undoes a completed step, e.g. by publishing the event
+// reversing it. It must be idempotent: it is retried until it succeeds.
+type Compensation func(ctx context.Context, state *State) error
+
+// Step is one step of a saga
+type Step struct {
+	Name StepName
+	// Timeout fails the saga when the step has not completed this long after
+	// the previous one; 0 waits forever
+	Timeout time.Duration
+	// Compensate undoes the step once completed; nil if there is nothing to undo
+	Compensate Compensation
+}
+
+// StepState is the persisted state of one step
+type StepState struct {
+	Name        StepName   `json:"name"`
+	Status      StepStatus `json:"status"`
+	CompletedAt time.Time  `json:"completed_at"`
+}
+
+// State is the persisted state of one saga, keyed by its ID (the order ID
+// for the order lifecycle)
+type State struct {
+	ID     string      `json:"id"`
+	Status Status      `json:"status"`
+	Steps  []StepState `json:"steps"`
+	// Deadline is when the current step times out; zero if it never does
+	Deadline time.Time `json:"deadline"`
+	// Reason is why the saga is compensating
+	Reason    string    `json:"reason,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// current returns the index of the first pending step, or -1
+func (s *State) current() int {
+	for i, step := range s.Steps {
+		if step.Status == StepPending {
+			return i
+		}
+	}
+	return -1
+}
+
+// Store persists saga state
+type Store interface {
+	// Load returns the saga's state, or ErrNotFound
+	Load(ctx context.Context, id string) (*State, error)
+	Save(ctx context.Context, state *State) error
+	// Active returns the running and compensating sagas
+	Active(ctx context.Context) ([]*State, error)
+}
+
+// Saga orchestrates instances of one workflow, each identified by an ID
+type Saga struct {
+	steps []Step
+	store Store
+	now   func() time.Time
+
+	mu sync.Mutex // serializes state changes
+}
+
+// New creates a saga running steps in order and persisting its instances in store
+func New(store Store, steps ...Step) *Saga {
+	return &Saga{steps: steps, store: store, now: time.Now}
+}
+
+// Start begins a saga instance with its first step pending. Starting an
+// instance that already exists is a no-op, so redelivered events are harmless.
+func (s *Saga) Start(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.store.Load(ctx, id); !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	now := s.now()
+	state := &State{ID: id, Status: StatusRunning, StartedAt: now, UpdatedAt: now}
+	for _, step := range s.steps {
+		state.Steps = append(state.Steps, StepState{Name: step.Name, Status: StepPending})
+	}
+	s.advance(state, now)
+	return s.store.Save(ctx, state)
+}
+
+// Complete records that the step succeeded and moves on to the next pending
+// step. Steps may complete out of order, e.g. when their events are consumed
+// from different topics; completing a step twice or after the saga failed is
+// a no-op.
+func (s *Saga) Complete(ctx context.Context, id string, name StepName) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.store.Load(ctx, id)
+	if err != nil {
+		return err
+	}
+	if state.Status != StatusRunning {
+		return nil
+	}
+
+	i, err := s.stepIndex(name)
+	if err != nil {
+		return err
+	}
+	if state.Steps[i].Status != StepPending {
+		return nil
+	}
+
+	now := s.now()
+	state.Steps[i].Status = StepCompleted
+	state.Steps[i].CompletedAt = now
+	s.advance(state, now)
+	return s.store.Save(ctx, state)
+}
+
+// Fail aborts the saga and compensates its completed steps, latest first.
+// If a compensation fails the saga stays compensating and the error is
+// returned; calling Fail again (or CheckTimeouts) resumes compensating.
+func (s *Saga) Fail(ctx context.Context, id string, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.store.Load(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.fail(ctx, state, reason)
+}
+
+// CheckTimeouts fails the running sagas whose current step is overdue and
+// resumes compensating the sagas whose compensations failed earlier
+func (s *Saga) CheckTimeouts(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active, err := s.store.Active(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	var errs []error
+	for _, state := range active {
+		switch {
+		case state.Status == StatusCompensating:
+			errs = append(errs, s.fail(ctx, state, state.Reason))
+		case !state.Deadline.IsZero() && now.After(state.Deadline):
+			step := state.Steps[state.current()].Name
+			errs = append(errs, s.fail(ctx, state, fmt.Sprintf("step %s timed out", step)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run checks for timed out steps every interval until ctx is done
+func (s *Saga) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.CheckTimeouts(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// fail moves the saga to compensating and runs the pending compensations
+func (s *Saga) fail(ctx context.Context, state *State, reason string) error {
+	if state.Status != StatusRunning && state.Status != StatusCompensating {
+		return nil
+	}
+	if state.Status == StatusRunning {
+		state.Status = StatusCompensating
+		state.Reason = reason
+		state.Deadline = time.Time{}
+	}
+
+	for i := len(state.Steps) - 1; i >= 0; i-- {
+		if state.Steps[i].Status != StepCompleted {
+			continue
+		}
+		if compensate := s.steps[i].Compensate; compensate != nil {
+			if err := compensate(ctx, state); err != nil {
+				state.UpdatedAt = s.now()
+				if saveErr := s.store.Save(ctx, state); saveErr != nil {
+					return errors.Join(err, saveErr)
+				}
+				return fmt.Errorf("failed to compensate step %s of saga %s: %w", state.Steps[i].Name, state.ID, err)
+			}
+		}
+		state.Steps[i].Status = StepCompensated
+	}
+
+	state.Status = StatusCompensated
+	state.UpdatedAt = s.now()
+	return s.store.Save(ctx, state)
+}
+
+// advance sets the deadline of the current step, or completes the saga when
+// no step is pending
+func (s *Saga) advance(state *State, now time.Time) {
+	state.UpdatedAt = now
+	state.Deadline = time.Time{}
+
+	i := state.current()
+	if i < 0 {
+		state.Status = StatusCompleted
+		return
+	}
+	if timeout := s.steps[i].Timeout; timeout > 0 {
+		state.Deadline = now.Add(timeout)
+	}
+}
+
+func (s *Saga) stepIndex(name StepName) (int, error) {
+	for i, step := range s.steps {
+		if step.Name == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnknownStep, name)
+}
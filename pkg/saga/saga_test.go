@@ -0,0 +1,221 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tanint/go-eda/internal/models"
+	"github.com/tanint/go-eda/pkg/events"
+)
+
+// compensationRecorder records the compensations run, in order
+type compensationRecorder struct {
+	ran  []StepName
+	fail map[StepName]error // returned by the step's compensation when set
+}
+
+func (r *compensationRecorder) compensation(step StepName) Compensation {
+	return func(ctx context.Context, state *State) error {
+		if err := r.fail[step]; err != nil {
+			return err
+		}
+		r.ran = append(r.ran, step)
+		return nil
+	}
+}
+
+// newTestOrderSaga returns an order saga with a 1m step timeout whose clock
+// is *clock and whose compensations are recorded
+func newTestOrderSaga(clock *time.Time) (*OrderSaga, *MemoryStore, *compensationRecorder) {
+	store := NewMemoryStore()
+	compensations := &compensationRecorder{}
+	s := NewOrderSaga(store, time.Minute, OrderCompensations{
+		ReleaseInventory: compensations.compensation(StepReserveInventory),
+		CancelOrder:      compensations.compensation(StepConfirmOrder),
+	})
+	s.now = func() time.Time { return *clock }
+	return s, store, compensations
+}
+
+func orderCreated(orderID string) *events.Event {
+	return events.NewEvent(events.EventTypeOrderCreated, events.OrderCreatedEvent{
+		Order: models.Order{ID: orderID, CustomerID: "customer-1"},
+	})
+}
+
+func inventoryReserved(orderID string) *events.Event {
+	return events.NewEvent(events.EventTypeInventoryReserved, events.InventoryReservedEvent{
+		OrderID: orderID,
+		Items:   []events.InventoryReservation{{ProductID: "p1", Quantity: 1}},
+	})
+}
+
+func orderRejected(orderID, reason string) *events.Event {
+	return events.NewEvent(events.EventTypeOrderRejected, events.OrderRejectedEvent{
+		OrderID: orderID, CustomerID: "customer-1", Reason: reason,
+	})
+}
+
+// handle passes every event to the saga
+func handle(t *testing.T, s *OrderSaga, evts ...*events.Event) {
+	t.Helper()
+	for _, event := range evts {
+		if err := s.HandleEvent(context.Background(), event); err != nil {
+			t.Fatalf("%s: %v", event.Type, err)
+		}
+	}
+}
+
+// stepStatuses returns the status of each step of the saga
+func stepStatuses(t *testing.T, store *MemoryStore, id string) (Status, []StepStatus) {
+	t.Helper()
+	state, err := store.Load(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var steps []StepStatus
+	for _, step := range state.Steps {
+		steps = append(steps, step.Status)
+	}
+	return state.Status, steps
+}
+
+func TestOrderSagaCompletesThroughItsEvents(t *testing.T) {
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, store, compensations := newTestOrderSaga(&clock)
+
+	handle(t, s,
+		orderCreated("order-1"),
+		inventoryReserved("order-1"),
+		events.NewEvent(events.EventTypeOrderConfirmed, events.OrderConfirmedEvent{OrderID: "order-1", CustomerID: "customer-1"}),
+		events.NewEvent(events.EventTypeNotificationSent, map[string]interface{}{"order_id": "order-1"}),
+	)
+
+	status, steps := stepStatuses(t, store, "order-1")
+	if want := []StepStatus{StepCompleted, StepCompleted, StepCompleted}; status != StatusCompleted || !slices.Equal(steps, want) {
+		t.Errorf("saga %s with steps %v, want completed with %v", status, steps, want)
+	}
+	if len(compensations.ran) != 0 {
+		t.Errorf("compensated %v, want nothing", compensations.ran)
+	}
+}
+
+func TestFailedReservationCompensatesNothing(t *testing.T) {
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, store, compensations := newTestOrderSaga(&clock)
+
+	handle(t, s, orderCreated("order-1"), orderRejected("order-1", "out of stock"))
+
+	status, steps := stepStatuses(t, store, "order-1")
+	if want := []StepStatus{StepPending, StepPending, StepPending}; status != StatusCompensated || !slices.Equal(steps, want) {
+		t.Errorf("saga %s with steps %v, want compensated with %v", status, steps, want)
+	}
+	if len(compensations.ran) != 0 {
+		t.Errorf("compensated %v, want nothing: no step completed", compensations.ran)
+	}
+}
+
+func TestFailureAfterTheReservationReleasesTheInventory(t *testing.T) {
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, store, compensations := newTestOrderSaga(&clock)
+
+	handle(t, s, orderCreated("order-1"), inventoryReserved("order-1"), orderRejected("order-1", "payment declined"))
+
+	if !slices.Equal(compensations.ran, []StepName{StepReserveInventory}) {
+		t.Errorf("compensated %v, want the reservation released", compensations.ran)
+	}
+	status, steps := stepStatuses(t, store, "order-1")
+	if want := []StepStatus{StepCompensated, StepPending, StepPending}; status != StatusCompensated || !slices.Equal(steps, want) {
+		t.Errorf("saga %s with steps %v, want compensated with %v", status, steps, want)
+	}
+
+	// Late and redelivered events leave the failed saga alone
+	handle(t, s, orderCreated("order-1"), inventoryReserved("order-1"), orderRejected("order-1", "payment declined"))
+	if len(compensations.ran) != 1 {
+		t.Errorf("compensated %v, want the reservation released once", compensations.ran)
+	}
+}
+
+func TestStepTimeoutCompensatesInReverseOrder(t *testing.T) {
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	compensations := &compensationRecorder{}
+	store := NewMemoryStore()
+	s := New(store,
+		Step{Name: "a", Compensate: compensations.compensation("a")},
+		Step{Name: "b"}, // nothing to undo
+		Step{Name: "c", Compensate: compensations.compensation("c")},
+		Step{Name: "d", Timeout: time.Minute, Compensate: compensations.compensation("d")},
+	)
+	s.now = func() time.Time { return clock }
+	ctx := context.Background()
+
+	if err := s.Start(ctx, "saga-1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range []StepName{"c", "a", "b"} {
+		if err := s.Complete(ctx, "saga-1", step); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clock = clock.Add(time.Minute)
+	if err := s.CheckTimeouts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(compensations.ran) != 0 {
+		t.Fatalf("compensated %v at the deadline, want to wait until it passed", compensations.ran)
+	}
+
+	clock = clock.Add(time.Second)
+	if err := s.CheckTimeouts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want := []StepName{"c", "a"}; !slices.Equal(compensations.ran, want) {
+		t.Errorf("compensated %v, want %v", compensations.ran, want)
+	}
+	state, err := store.Load(ctx, "saga-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status != StatusCompensated || state.Reason != "step d timed out" {
+		t.Errorf("saga %s because %q, want compensated because step d timed out", state.Status, state.Reason)
+	}
+}
+
+func TestFailedCompensationIsResumed(t *testing.T) {
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, store, compensations := newTestOrderSaga(&clock)
+	compensations.fail = map[StepName]error{StepReserveInventory: errors.New("broker unavailable")}
+	handle(t, s, orderCreated("order-1"), inventoryReserved("order-1"))
+
+	if err := s.HandleEvent(context.Background(), orderRejected("order-1", "payment declined")); err == nil {
+		t.Fatal("failed the saga although releasing the inventory failed")
+	}
+	if status, _ := stepStatuses(t, store, "order-1"); status != StatusCompensating {
+		t.Fatalf("saga %s, want compensating until the release succeeds", status)
+	}
+
+	compensations.fail = nil
+	if err := s.CheckTimeouts(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := stepStatuses(t, store, "order-1"); status != StatusCompensated || !slices.Equal(compensations.ran, []StepName{StepReserveInventory}) {
+		t.Errorf("saga %s after compensating %v, want the reservation released", status, compensations.ran)
+	}
+}
+
+func TestEventsOfUnknownSagasAreIgnored(t *testing.T) {
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, store, _ := newTestOrderSaga(&clock)
+
+	handle(t, s, inventoryReserved("order-1"), orderRejected("order-1", "out of stock"))
+	if _, err := store.Load(context.Background(), "order-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load() = %v, want ErrNotFound", err)
+	}
+	if err := s.Complete(context.Background(), "order-1", "unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Complete() = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,55 @@
+package saga
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore keeps saga state in memory; sagas in flight are lost on restart
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]*State
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]*State)}
+}
+
+// Load returns a copy of the saga's state
+func (m *MemoryStore) Load(ctx context.Context, id string) (*State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state, ok := m.states[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyState(state), nil
+}
+
+// Save stores a copy of the state
+func (m *MemoryStore) Save(ctx context.Context, state *State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[state.ID] = copyState(state)
+	return nil
+}
+
+// Active returns copies of the running and compensating sagas
+func (m *MemoryStore) Active(ctx context.Context) ([]*State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var active []*State
+	for _, state := range m.states {
+		if state.Status == StatusRunning || state.Status == StatusCompensating {
+			active = append(active, copyState(state))
+		}
+	}
+	return active, nil
+}
+
+func copyState(state *State) *State {
+	c := *state
+	c.Steps = append([]StepState(nil), state.Steps...)
+	return &c
+}